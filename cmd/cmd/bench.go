@@ -0,0 +1,278 @@
+// Copyright (c) 2025 Stefano Scafiti
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"runtime"
+	"slices"
+	"sync/atomic"
+	"text/tabwriter"
+	"time"
+
+	"github.com/ostafen/digler/internal/disk"
+	fileformat "github.com/ostafen/digler/internal/format"
+	"github.com/ostafen/digler/internal/fs"
+	"github.com/ostafen/digler/pkg/carve"
+	"github.com/ostafen/digler/pkg/util/format"
+	"github.com/spf13/cobra"
+)
+
+func DefineBenchCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "bench <image_or_device>",
+		Short: "Measure the scan throughput on an image file or disk",
+		Long: `The 'bench' command scans an image file or disk without writing any report, log or file, and reports the throughput,
+the time spent by the scanner of each format and the memory allocations. The whole source is scanned as a single partition.
+It is meant to compare the performance of the scan engine across versions and options on the same data.`,
+		Args:         cobra.ExactArgs(1),
+		SilenceUsage: true,
+		RunE:         RunBench,
+	}
+
+	cmd.Flags().StringSlice("ext", nil, "file extensions to scan for (default: all)")
+	cmd.Flags().Int("block-size", disk.DefaultBlocksize, "block size in bytes")
+	cmd.Flags().String("scan-buffer-size", "4MiB", "size of the scan buffer")
+	cmd.Flags().String("max-scan-size", "", "maximum number of bytes to scan (default: the whole source)")
+	cmd.Flags().Int("runs", 1, "number of times the scan is repeated; the results of the fastest run are reported")
+	cmd.Flags().Bool("mmap", false, "memory map the image file instead of reading it (ignored for raw devices)")
+	return cmd
+}
+
+// benchResult holds the measurements of a benchmark run.
+type benchResult struct {
+	duration   time.Duration
+	files      int
+	allocs     uint64
+	allocBytes uint64
+	scanners   []*timedScanner
+}
+
+func RunBench(cmd *cobra.Command, args []string) error {
+	path := volumePath(cmd, args[0])
+
+	fileExt, _ := cmd.Flags().GetStringSlice("ext")
+	blockSize, _ := cmd.Flags().GetInt("block-size")
+	runs, _ := cmd.Flags().GetInt("runs")
+	useMmap, _ := cmd.Flags().GetBool("mmap")
+
+	if blockSize <= 0 {
+		return fmt.Errorf("invalid value %d for flag --block-size: must be positive", blockSize)
+	}
+	if runs < 1 {
+		return fmt.Errorf("invalid value %d for flag --runs: must be at least 1", runs)
+	}
+
+	bufferSize, err := getBytes(cmd, "scan-buffer-size", false)
+	if err != nil {
+		return err
+	}
+
+	maxScanSize, err := getBytes(cmd, "max-scan-size", false)
+	if err != nil {
+		return err
+	}
+
+	open := fs.Open
+	if useMmap {
+		open = fs.OpenMmap
+	}
+
+	f, err := open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	finfo, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	size := min(uint64(finfo.Size()), maxScanSize)
+
+	var best *benchResult
+	for range runs {
+		res, err := runBench(f, size, fileExt, blockSize, int(bufferSize))
+		if err != nil {
+			return err
+		}
+		if best == nil || res.duration < best.duration {
+			best = res
+		}
+	}
+
+	printBenchResult(best, size, runs)
+	return nil
+}
+
+// runBench scans the first size bytes of f once, timing the scanners of the given extensions.
+func runBench(f fs.File, size uint64, fileExt []string, blockSize, bufferSize int) (*benchResult, error) {
+	scanners, err := carve.Scanners(fileExt...)
+	if err != nil {
+		return nil, err
+	}
+
+	timed := make([]*timedScanner, len(scanners))
+	wrapped := make([]carve.FileScanner, len(scanners))
+	for i, sc := range scanners {
+		timed[i] = &timedScanner{FileScanner: sc}
+		wrapped[i] = timed[i]
+		if f, ok := sc.(fileformat.FooterScanner); ok {
+			wrapped[i] = &timedFooterScanner{timedScanner: timed[i], footer: f}
+		}
+	}
+
+	var scanErr error
+	opts := carve.Options{
+		BlockSize:  blockSize,
+		BufferSize: bufferSize,
+		Scanners:   wrapped,
+		OnError:    func(err error) { scanErr = err },
+	}
+
+	// Collect the garbage of the previous runs, so that it is not accounted to this one.
+	runtime.GC()
+
+	var before, after runtime.MemStats
+	runtime.ReadMemStats(&before)
+
+	start := time.Now()
+
+	files := 0
+	for range carve.Scan(context.Background(), f, size, opts) {
+		files++
+	}
+
+	duration := time.Since(start)
+	runtime.ReadMemStats(&after)
+
+	if scanErr != nil {
+		return nil, scanErr
+	}
+
+	return &benchResult{
+		duration:   duration,
+		files:      files,
+		allocs:     after.Mallocs - before.Mallocs,
+		allocBytes: after.TotalAlloc - before.TotalAlloc,
+		scanners:   timed,
+	}, nil
+}
+
+func printBenchResult(res *benchResult, size uint64, runs int) {
+	seconds := res.duration.Seconds()
+
+	throughput := 0.0
+	if seconds > 0 {
+		throughput = float64(size) / seconds / 1e6
+	}
+
+	fmt.Printf("Scanned: \t%s\n", format.FormatBytes(int64(size)))
+	fmt.Printf("Duration: \t%s (fastest of %d run(s))\n", res.duration.Round(time.Millisecond), runs)
+	fmt.Printf("Throughput: \t%.2f MB/s\n", throughput)
+	fmt.Printf("Files found: \t%d\n", res.files)
+	fmt.Printf("Allocations: \t%d (%s)\n", res.allocs, format.FormatBytes(int64(res.allocBytes)))
+	fmt.Println()
+
+	scanners := slices.Clone(res.scanners)
+	slices.SortStableFunc(scanners, func(a, b *timedScanner) int {
+		return int(b.elapsed.Load() - a.elapsed.Load())
+	})
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "EXT\tCALLS\tFOUND\tTIME\tSHARE")
+
+	for _, sc := range scanners {
+		elapsed := time.Duration(sc.elapsed.Load())
+
+		share := 0.0
+		if res.duration > 0 {
+			share = 100 * float64(elapsed) / float64(res.duration)
+		}
+
+		fmt.Fprintf(w, "%s\t%d\t%d\t%s\t%.1f%%\n",
+			sc.Ext(),
+			sc.calls.Load(),
+			sc.found.Load(),
+			elapsed.Round(time.Microsecond),
+			share,
+		)
+	}
+	w.Flush()
+}
+
+// timedScanner wraps a scanner, measuring the time spent in ScanFile.
+// The time spent searching for signatures is not accounted to any scanner.
+// The optional methods of the wrapped scanner are forwarded, so that it is
+// run as it would be by the scan command.
+type timedScanner struct {
+	carve.FileScanner
+
+	calls   atomic.Int64
+	found   atomic.Int64
+	elapsed atomic.Int64 // nanoseconds
+}
+
+func (s *timedScanner) ScanFile(r *carve.Reader) (*carve.ScanResult, error) {
+	return s.time(func() (*carve.ScanResult, error) { return s.FileScanner.ScanFile(r) })
+}
+
+func (s *timedScanner) time(scan func() (*carve.ScanResult, error)) (*carve.ScanResult, error) {
+	start := time.Now()
+	res, err := scan()
+	s.elapsed.Add(int64(time.Since(start)))
+
+	s.calls.Add(1)
+	if err == nil && res != nil {
+		s.found.Add(1)
+	}
+	return res, err
+}
+
+// SignatureOffset forwards the optional method of the wrapped scanner.
+func (s *timedScanner) SignatureOffset() int {
+	return fileformat.ScannerSignatureOffset(s.FileScanner)
+}
+
+// Entropy forwards the optional method of the wrapped scanner.
+func (s *timedScanner) Entropy() fileformat.EntropyClass {
+	return fileformat.ScannerEntropy(s.FileScanner)
+}
+
+// timedFooterScanner wraps a scanner of files recognized by their footer,
+// accounting the time spent in ScanFooter as well.
+type timedFooterScanner struct {
+	*timedScanner
+	footer fileformat.FooterScanner
+}
+
+func (s *timedFooterScanner) Footers() [][]byte {
+	return s.footer.Footers()
+}
+
+func (s *timedFooterScanner) FooterSize() int {
+	return s.footer.FooterSize()
+}
+
+func (s *timedFooterScanner) ScanFooter(footer []byte) (*carve.ScanResult, error) {
+	return s.time(func() (*carve.ScanResult, error) { return s.footer.ScanFooter(footer) })
+}
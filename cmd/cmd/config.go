@@ -0,0 +1,152 @@
+// Copyright (c) 2025 Stefano Scafiti
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+// configFlag is the flag holding the path of the configuration file, which cannot be set by the file itself.
+const configFlag = "config"
+
+// scanConfig holds the options of a scan set by a configuration file, keyed by the names of
+// the flags of the scan command, as the fields of scan.Options are. Options not listed in
+// the file are left nil.
+type scanConfig struct {
+	Dump                   *string  `yaml:"dump"`
+	BlockSize              *string  `yaml:"block-size"`
+	ScanBufferSize         *string  `yaml:"scan-buffer-size"`
+	BufferOverlap          *string  `yaml:"buffer-overlap"`
+	MaxScanSize            *string  `yaml:"max-scan-size"`
+	Offset                 *string  `yaml:"offset"`
+	Length                 *string  `yaml:"length"`
+	SkipRegion             []string `yaml:"skip-region"`
+	MaxFileSize            *string  `yaml:"max-file-size"`
+	MaxCarveBytes          *string  `yaml:"max-carve-bytes"`
+	MinConfidence          *string  `yaml:"min-confidence"`
+	NoLog                  *bool    `yaml:"no-log"`
+	SkipErrors             *bool    `yaml:"skip-errors"`
+	FatalPanics            *bool    `yaml:"fatal-panics"`
+	DryRun                 *bool    `yaml:"dry-run"`
+	EntropyFilter          *bool    `yaml:"entropy-filter"`
+	HeaderOnly             *bool    `yaml:"header-only"`
+	FATMetadata            *bool    `yaml:"fat-metadata"`
+	Recursive              *bool    `yaml:"recursive"`
+	RecursionDepth         *int     `yaml:"recursion-depth"`
+	JPEGFollowConcatenated *bool    `yaml:"jpeg-follow-concatenated"`
+	JPEGIncludeTrailing    *bool    `yaml:"jpeg-include-trailing"`
+	GIFStrict              *bool    `yaml:"gif-strict"`
+	ZIPVerifyCentralDir    *bool    `yaml:"zip-verify-central-dir"`
+	SQLiteWAL              *bool    `yaml:"sqlite-wal"`
+	SkipPartitionMetadata  *bool    `yaml:"skip-partition-metadata"`
+	IncludeGaps            *bool    `yaml:"include-gaps"`
+	SkipInvalidPartitions  *bool    `yaml:"skip-invalid-partitions"`
+	Mmap                   *bool    `yaml:"mmap"`
+	Partition              []int    `yaml:"partition"`
+	ListPartitions         *bool    `yaml:"list-partitions"`
+	NameTemplate           *string  `yaml:"name-template"`
+	IgnoreHashes           *string  `yaml:"ignore-hashes"`
+	Dedupe                 *bool    `yaml:"dedupe"`
+	Ext                    []string `yaml:"ext"`
+	ExcludeExt             []string `yaml:"exclude-ext"`
+	Output                 *string  `yaml:"output"`
+	SingleReport           *bool    `yaml:"single-report"`
+	ReportDir              *string  `yaml:"report-dir"`
+	Plugins                []string `yaml:"plugins"`
+	JSONSummary            *bool    `yaml:"json-summary"`
+}
+
+// applyConfigFile sets the flags of cmd listed in the configuration file at path,
+// unless they were given on the command line, which takes precedence.
+func applyConfigFile(cmd *cobra.Command, path string) error {
+	flags := cmd.Flags()
+
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("unable to open config file: %w", err)
+	}
+	defer f.Close()
+
+	config, err := parseConfig(f)
+	if err != nil {
+		return fmt.Errorf("invalid config file %q: %w", path, err)
+	}
+
+	v := reflect.ValueOf(config).Elem()
+	for i := 0; i < v.NumField(); i++ {
+		field := v.Field(i)
+		if field.IsNil() {
+			continue
+		}
+
+		name := v.Type().Field(i).Tag.Get("yaml")
+		if flags.Changed(name) {
+			continue
+		}
+
+		if err := flags.Set(name, configFlagValue(field)); err != nil {
+			return fmt.Errorf("invalid config file %q: option %q: %w", path, name, err)
+		}
+	}
+	return nil
+}
+
+// parseConfig decodes a configuration file: a YAML mapping of option names to scalars,
+// or to lists of scalars. Unknown and duplicate options are rejected.
+func parseConfig(r io.Reader) (*scanConfig, error) {
+	dec := yaml.NewDecoder(r)
+	dec.KnownFields(true)
+
+	var config scanConfig
+	if err := dec.Decode(&config); err != nil && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+	return &config, nil
+}
+
+// configFlagValue formats the value of a field of scanConfig as the value of its flag,
+// joining lists with commas.
+func configFlagValue(v reflect.Value) string {
+	switch v.Kind() {
+	case reflect.Pointer:
+		return configFlagValue(v.Elem())
+	case reflect.Slice:
+		items := make([]string, v.Len())
+		for i := range items {
+			items[i] = configFlagValue(v.Index(i))
+		}
+		return strings.Join(items, ",")
+	case reflect.Bool:
+		return strconv.FormatBool(v.Bool())
+	case reflect.Int:
+		return strconv.FormatInt(v.Int(), 10)
+	default:
+		return v.String()
+	}
+}
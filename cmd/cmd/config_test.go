@@ -0,0 +1,132 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/spf13/pflag"
+)
+
+func TestParseConfig(t *testing.T) {
+	config := `# carving profile
+ext: [jpeg, png]   # images only
+max-file-size: "64MiB"
+block-size: 4096
+skip-errors: true
+exclude-ext:
+  - wav
+  - 'mp3'
+partition: [1, 2]
+name-template: '{offset:x}.{ext}'
+ignore-hashes: known#1.txt
+plugins:
+`
+
+	cfg, err := parseConfig(strings.NewReader(config))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(cfg.Ext, []string{"jpeg", "png"}) {
+		t.Errorf("ext = %q", cfg.Ext)
+	}
+	if cfg.MaxFileSize == nil || *cfg.MaxFileSize != "64MiB" {
+		t.Errorf("max-file-size = %v", cfg.MaxFileSize)
+	}
+	if cfg.BlockSize == nil || *cfg.BlockSize != "4096" {
+		t.Errorf("block-size = %v", cfg.BlockSize)
+	}
+	if cfg.SkipErrors == nil || !*cfg.SkipErrors {
+		t.Errorf("skip-errors = %v", cfg.SkipErrors)
+	}
+	if !reflect.DeepEqual(cfg.ExcludeExt, []string{"wav", "mp3"}) {
+		t.Errorf("exclude-ext = %q", cfg.ExcludeExt)
+	}
+	if !reflect.DeepEqual(cfg.Partition, []int{1, 2}) {
+		t.Errorf("partition = %v", cfg.Partition)
+	}
+	if cfg.NameTemplate == nil || *cfg.NameTemplate != "{offset:x}.{ext}" {
+		t.Errorf("name-template = %v", cfg.NameTemplate)
+	}
+	if cfg.IgnoreHashes == nil || *cfg.IgnoreHashes != "known#1.txt" {
+		t.Errorf("ignore-hashes = %v", cfg.IgnoreHashes)
+	}
+	if cfg.Plugins != nil || cfg.DryRun != nil {
+		t.Errorf("expected unset options to be nil")
+	}
+}
+
+func TestParseConfigInvalid(t *testing.T) {
+	tests := []struct {
+		name   string
+		config string
+	}{
+		{"missing colon", "skip-errors\n"},
+		{"nested mapping", "jpeg:\n  follow: true\n"},
+		{"orphan list item", "  - png\n"},
+		{"duplicate option", "ext: png\next: jpeg\n"},
+		{"unterminated list", "ext: [png, jpeg\n"},
+		{"unknown option", "skip-erors: true\n"},
+		{"invalid boolean", "skip-errors: maybe\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := parseConfig(strings.NewReader(tt.config)); err == nil {
+				t.Errorf("expected an error")
+			}
+		})
+	}
+}
+
+func TestScanConfigFields(t *testing.T) {
+	flags := DefineScanCommand().Flags()
+
+	typ := reflect.TypeOf(scanConfig{})
+	fields := make(map[string]bool, typ.NumField())
+	for i := 0; i < typ.NumField(); i++ {
+		name := typ.Field(i).Tag.Get("yaml")
+		if flags.Lookup(name) == nil {
+			t.Errorf("option %q is not a flag of the scan command", name)
+		}
+		fields[name] = true
+	}
+
+	flags.VisitAll(func(f *pflag.Flag) {
+		if f.Name != configFlag && !fields[f.Name] {
+			t.Errorf("flag %q cannot be set by configuration files", f.Name)
+		}
+	})
+}
+
+func TestApplyConfigFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	config := "ext: [jpeg, png]\nmax-file-size: 64MiB\nskip-errors: true\nrecursion-depth: 3\n"
+	if err := os.WriteFile(path, []byte(config), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cmd := DefineScanCommand()
+	if err := cmd.Flags().Parse([]string{"--max-file-size", "1GiB"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := applyConfigFile(cmd, path); err != nil {
+		t.Fatal(err)
+	}
+
+	ext, _ := cmd.Flags().GetStringSlice("ext")
+	maxFileSize, _ := cmd.Flags().GetString("max-file-size")
+	skipErrors, _ := cmd.Flags().GetBool("skip-errors")
+	depth, _ := cmd.Flags().GetInt("recursion-depth")
+
+	if !reflect.DeepEqual(ext, []string{"jpeg", "png"}) || !skipErrors || depth != 3 {
+		t.Errorf("unexpected values: ext=%q skip-errors=%v recursion-depth=%d", ext, skipErrors, depth)
+	}
+	if maxFileSize != "1GiB" {
+		t.Errorf("expected the command line to take precedence, got max-file-size=%q", maxFileSize)
+	}
+}
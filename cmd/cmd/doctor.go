@@ -0,0 +1,226 @@
+// Copyright (c) 2025 Stefano Scafiti
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/ostafen/digler/internal/env"
+	"github.com/ostafen/digler/internal/format"
+	"github.com/ostafen/digler/internal/fuse"
+	"github.com/ostafen/digler/pkg/sysinfo"
+	utilformat "github.com/ostafen/digler/pkg/util/format"
+	"github.com/spf13/cobra"
+)
+
+func DefineDoctorCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "doctor",
+		Short: "Check the environment for problems affecting the other commands",
+		Long: `The 'doctor' command checks that the environment supports the features of digler: mounting scan results with FUSE,
+loading plugins, writing reports and recovered files to the current directory, and that the signatures of the supported
+formats (and of the given plugins) do not shadow each other. It exits with an error if any check fails.`,
+		Args:         cobra.NoArgs,
+		SilenceUsage: true,
+		RunE:         RunDoctor,
+	}
+
+	cmd.Flags().StringSlice("plugins", nil, "paths to plugin .so/.wasm files or directories containing plugins to check")
+	return cmd
+}
+
+// checkStatus is the outcome of a check of the doctor command.
+type checkStatus string
+
+const (
+	checkOK   checkStatus = "ok"
+	checkWarn checkStatus = "warn" // The check found a problem affecting some uses only.
+	checkFail checkStatus = "fail"
+)
+
+// checkResult is the result of a check of the doctor command.
+type checkResult struct {
+	name    string
+	status  checkStatus
+	details string
+}
+
+func RunDoctor(cmd *cobra.Command, args []string) error {
+	plugins, _ := cmd.Flags().GetStringSlice("plugins")
+	pluginScanners, pluginErr := loadPluginScanners(plugins)
+	defer format.ClosePlugins(pluginScanners)
+
+	results := []checkResult{
+		checkSystem(),
+		checkWriteAccess("."),
+		checkMount(),
+		checkPlugins(plugins, pluginErr),
+		checkSignatures(pluginScanners, pluginErr),
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "CHECK\tSTATUS\tDETAILS")
+
+	failed := 0
+	for _, res := range results {
+		if res.status == checkFail {
+			failed++
+		}
+
+		// Additional lines of the details are aligned under the first one.
+		lines := strings.Split(res.details, "\n")
+		fmt.Fprintf(w, "%s\t%s\t%s\n", res.name, res.status, lines[0])
+		for _, line := range lines[1:] {
+			fmt.Fprintf(w, "\t\t%s\n", line)
+		}
+	}
+	w.Flush()
+
+	if failed > 0 {
+		return fmt.Errorf("%d of %d checks failed", failed, len(results))
+	}
+	return nil
+}
+
+// checkSystem reports the version of digler and the details of the system.
+func checkSystem() checkResult {
+	res := checkResult{
+		name:    "system",
+		status:  checkOK,
+		details: fmt.Sprintf("%s %s (%s/%s)", env.AppName, env.Version, runtime.GOOS, runtime.GOARCH),
+	}
+
+	sinfo, err := sysinfo.Stat()
+	if err != nil {
+		res.status = checkWarn
+		res.details += fmt.Sprintf(", cannot read system information: %v", err)
+		return res
+	}
+
+	res.details += fmt.Sprintf(", %s %s, %d CPUs", sinfo.Release, sinfo.Version, sinfo.NumCPU)
+	if sinfo.TotalRAM > 0 {
+		res.details += ", " + utilformat.FormatBytes(int64(sinfo.TotalRAM)) + " of RAM"
+	}
+	return res
+}
+
+// checkWriteAccess checks that files can be created in dir, where the scan command writes
+// its reports by default.
+func checkWriteAccess(dir string) checkResult {
+	res := checkResult{name: "write access", status: checkOK, details: dir}
+	if abs, err := filepath.Abs(dir); err == nil {
+		res.details = abs
+	}
+
+	f, err := os.CreateTemp(dir, ".digler-doctor-*")
+	if err != nil {
+		res.status = checkFail
+		res.details = fmt.Sprintf("cannot write reports to %s: %v", res.details, err)
+		return res
+	}
+	f.Close()
+	os.Remove(f.Name())
+	return res
+}
+
+// checkMount checks that the mount command is supported.
+func checkMount() checkResult {
+	if err := fuse.Available(); err != nil {
+		return checkResult{name: "mount", status: checkWarn, details: err.Error()}
+	}
+	return checkResult{name: "mount", status: checkOK, details: "FUSE available"}
+}
+
+// checkPlugins checks that plugins can be loaded on this platform, and reports whether the
+// given ones were loaded, or the error loading them.
+func checkPlugins(plugins []string, loadErr error) checkResult {
+	res := checkResult{name: "plugins", status: checkOK, details: "native (.so) and WebAssembly (.wasm) plugins supported"}
+	if err := format.NativePluginsSupported(); err != nil {
+		res.status = checkWarn
+		res.details = err.Error() + ": only WebAssembly (.wasm) plugins can be loaded"
+	}
+
+	if len(plugins) == 0 {
+		return res
+	}
+
+	if loadErr != nil {
+		res.status = checkFail
+		res.details = loadErr.Error()
+		return res
+	}
+	res.details += fmt.Sprintf("\nloaded %s", strings.Join(plugins, ", "))
+	return res
+}
+
+// checkSignatures checks that no signature of the built-in formats and of the scanners of
+// the plugins is a prefix of another, which would prevent it from being detected whenever
+// the scanner of the shorter one accepts a file.
+func checkSignatures(pluginScanners []format.FileScanner, loadErr error) checkResult {
+	res := checkResult{name: "signatures", status: checkOK}
+
+	scanners := format.GetAllFileScanners()
+	if loadErr != nil {
+		res.status = checkWarn
+		res.details = "plugins not checked, as they cannot be loaded\n"
+	}
+	scanners = append(scanners, pluginScanners...)
+
+	signatures := 0
+	for _, sc := range scanners {
+		signatures += len(sc.Signatures()) + len(format.ScannerFooters(sc))
+	}
+
+	conflicts := format.FindSignatureConflicts(scanners...)
+	if len(conflicts) == 0 {
+		res.details += fmt.Sprintf("%d formats, %d signatures, no conflicts", len(scanners), signatures)
+		return res
+	}
+
+	res.status = checkWarn
+	res.details += fmt.Sprintf("%d formats, %d signatures, %d conflicts:", len(scanners), signatures, len(conflicts))
+	for _, c := range conflicts {
+		res.details += fmt.Sprintf("\n%s signature %x at offset %d is shadowed by %s signature %x",
+			c.Ext, c.Signature, c.Offset, c.PrefixExt, c.Prefix)
+	}
+	return res
+}
+
+// loadPluginScanners loads the scanners of the given plugin files or directories.
+func loadPluginScanners(plugins []string) ([]format.FileScanner, error) {
+	if len(plugins) == 0 {
+		return nil, nil
+	}
+
+	pluginPaths, err := listPlugins(plugins)
+	if err != nil {
+		return nil, err
+	}
+	if len(pluginPaths) == 0 {
+		return nil, errors.New("no plugins found")
+	}
+	return format.LoadPlugins(pluginPaths...)
+}
@@ -0,0 +1,27 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCheckWriteAccess(t *testing.T) {
+	dir := t.TempDir()
+
+	if res := checkWriteAccess(dir); res.status != checkOK {
+		t.Errorf("expected %s for a writable directory, got %+v", checkOK, res)
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 0 {
+		t.Errorf("expected the test file to be removed, found %d files", len(entries))
+	}
+
+	if res := checkWriteAccess(filepath.Join(dir, "missing")); res.status != checkFail {
+		t.Errorf("expected %s for a missing directory, got %+v", checkFail, res)
+	}
+}
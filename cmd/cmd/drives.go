@@ -0,0 +1,57 @@
+// Copyright (c) 2025 Stefano Scafiti
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/ostafen/digler/internal/fs"
+	"github.com/ostafen/digler/pkg/util/format"
+	"github.com/spf13/cobra"
+)
+
+func DefineDrivesCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "drives",
+		Short: "List the physical drives attached to the system (Windows only)",
+		Long: `The 'drives' command lists the physical drives attached to the system, with their size and logical sector size.
+The listed paths can be passed to 'partitions' and 'scan'. Listing drives usually requires administrator privileges.`,
+		Args:         cobra.NoArgs,
+		SilenceUsage: true,
+		RunE:         RunDrives,
+	}
+}
+
+func RunDrives(cmd *cobra.Command, args []string) error {
+	drives, err := fs.PhysicalDrives()
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "PATH\tSIZE\tSECTOR SIZE")
+
+	for _, d := range drives {
+		fmt.Fprintf(w, "%s\t%s\t%d\n", d.Path, format.FormatBytes(int64(d.Size)), d.SectorSize)
+	}
+	return w.Flush()
+}
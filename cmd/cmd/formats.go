@@ -41,16 +41,14 @@ Each format includes its name, associated file extensions, category (e.g., image
 		RunE:         RunFormats,
 	}
 
-	cmd.Flags().StringSlice("plugins", nil, "paths to plugin .so files or directories containing plugins")
+	cmd.Flags().StringSlice("plugins", nil, "paths to plugin .so/.wasm files or directories containing plugins")
+	cmd.Flags().String("category", "", "only list formats of the given category (audio, image, video, document, database, memory, other)")
 	return cmd
 }
 
 func RunFormats(cmd *cobra.Command, args []string) error {
-	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
-	fmt.Fprintln(w, "NAME\tDESC\tSIGNATURES")
-
-	scanners, err := format.GetFileScanners()
-	if err != nil {
+	category, _ := cmd.Flags().GetString("category")
+	if err := validateCategory(category); err != nil {
 		return err
 	}
 
@@ -64,19 +62,58 @@ func RunFormats(cmd *cobra.Command, args []string) error {
 	if err != nil {
 		return fmt.Errorf("failed to load plugins: %w", err)
 	}
-	scanners = append(scanners, pluginScanners...)
+	defer format.ClosePlugins(pluginScanners)
+	for _, sc := range pluginScanners {
+		if err := format.RegisterScanner(sc); err != nil {
+			return fmt.Errorf("failed to load plugins: %w", err)
+		}
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tCATEGORY\tDESC\tSIGNATURES")
+
+	for _, ext := range format.SupportedExtensions() {
+		hdr, _ := format.Lookup(ext)
+		if category != "" && hdr.Category != format.Category(category) {
+			continue
+		}
 
-	for _, sc := range scanners {
-		signatures := make([]string, len(sc.Signatures()))
-		for i, sig := range sc.Signatures() {
+		signatures := make([]string, len(hdr.Signatures))
+		for i, sig := range hdr.Signatures {
 			signatures[i] = hex.EncodeToString(sig)
+			if hdr.SignatureOffset > 0 {
+				signatures[i] += fmt.Sprintf("@%d", hdr.SignatureOffset)
+			}
 		}
 
-		fmt.Fprintf(w, "%s\t%s\t%s\n",
-			sc.Ext(),
-			sc.Description(),
+		// Footers end the file, rather than starting it.
+		for _, footer := range hdr.Footers {
+			signatures = append(signatures, hex.EncodeToString(footer)+"@end")
+		}
+
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n",
+			hdr.Ext,
+			hdr.Category,
+			hdr.Description,
 			strings.Join(signatures, ","),
 		)
 	}
 	return w.Flush()
 }
+
+func validateCategory(category string) error {
+	if category == "" {
+		return nil
+	}
+
+	categories := append(format.Categories(), format.CategoryOther)
+
+	names := make([]string, len(categories))
+	for i, c := range categories {
+		if format.Category(category) == c {
+			return nil
+		}
+		names[i] = string(c)
+	}
+	return fmt.Errorf("unknown category %q (supported categories: %s)", category, strings.Join(names, ", "))
+}
@@ -88,7 +88,7 @@ func RunMerge(cmd *cobra.Command, args []string) error {
 	}
 	defer f.Close()
 
-	logger := logger.New(os.Stdout, logger.InfoLevel)
+	logger := logger.New(os.Stdout, consoleLogLevel(cmd))
 
 	logger.Infof("Merging %d files into %s", len(filePaths), out)
 
@@ -29,17 +29,18 @@ import (
 	"github.com/ostafen/digler/internal/format"
 	"github.com/ostafen/digler/internal/fs"
 	"github.com/ostafen/digler/internal/fuse"
+	"github.com/ostafen/digler/internal/logger"
 	"github.com/ostafen/digler/pkg/dfxml"
 	"github.com/spf13/cobra"
 )
 
 func DefineMountCommand() *cobra.Command {
 	cmd := &cobra.Command{
-		Use:   "mount <image_path> <report_file>",
+		Use:   "mount <image_path> <report_file|->",
 		Short: "Mount a disk image to a specified mountpoint",
 		Long: `The 'mount' command mounts a disk image or device based on the information provided in a report file.
 The report file typically contains details about the image's structure and any required offsets.
-You must provide the full path to the image file and the report file.`,
+You must provide the full path to the image file and the report file, or "-" to read the report from stdin.`,
 		Args:         cobra.ExactArgs(2),
 		SilenceUsage: true,
 		RunE:         RunMount,
@@ -50,16 +51,17 @@ You must provide the full path to the image file and the report file.`,
 }
 
 func RunMount(cmd *cobra.Command, args []string) error {
-	f, err := fs.Open(args[0])
+	f, err := fs.Open(volumePath(cmd, args[0]))
 	if err != nil {
 		return err
 	}
 	defer f.Close()
 
-	reportFile, err := os.Open(args[1])
+	reportFile, err := openReport(args[1])
 	if err != nil {
 		return err
 	}
+	defer reportFile.Close()
 
 	mountpoint, _ := cmd.Flags().GetString("mountpoint")
 	if mountpoint == "" {
@@ -75,9 +77,24 @@ func RunMount(cmd *cobra.Command, args []string) error {
 	if err != nil {
 		return err
 	}
+
+	if n := countTruncated(finfos); n > 0 {
+		logger.New(os.Stdout, consoleLogLevel(cmd)).Warnf("%d of %d files are truncated: their content is incomplete", n, len(finfos))
+	}
 	return fuse.Mount(mountpoint, f, finfos)
 }
 
+// countTruncated returns the number of the given files which are truncated.
+func countTruncated(finfos []format.FileInfo) int {
+	n := 0
+	for _, finfo := range finfos {
+		if finfo.Truncated {
+			n++
+		}
+	}
+	return n
+}
+
 // getMountpoint generates a mountpoint name from a report file name by stripping the extension.
 // If the extension is empty, "_mnt" is added.
 func getMountpoint(reportFileName string) string {
@@ -91,18 +108,33 @@ func getMountpoint(reportFileName string) string {
 	return mountpoint
 }
 
+// fileObjectsToFileInfo returns the information of the files listed in a report. The byte
+// runs of fragmented files, such as those recovered from FAT directories, are all kept.
 func fileObjectsToFileInfo(objs []dfxml.FileObject) ([]format.FileInfo, error) {
 	finfos := make([]format.FileInfo, len(objs))
 	for i, o := range objs {
 		runs := o.ByteRuns.Runs
-		if len(runs) < 1 {
-			return nil, fmt.Errorf("invalid report file")
+		if len(runs) < 1 && o.FileSize > 0 {
+			return nil, fmt.Errorf("invalid report file: file %s has no byte runs", o.Filename)
+		}
+
+		finfos[i] = format.FileInfo{Name: o.Filename, Truncated: o.Truncated}
+		for _, run := range runs {
+			finfos[i].Size += run.Length
+		}
+
+		if len(runs) > 0 {
+			finfos[i].Offset = runs[0].ImgOffset
+		}
+		if len(runs) > 1 {
+			finfos[i].Runs = make([]format.ByteRun, len(runs))
+			for j, run := range runs {
+				finfos[i].Runs[j] = format.ByteRun{Offset: run.ImgOffset, Size: run.Length}
+			}
 		}
 
-		finfos[i] = format.FileInfo{
-			Name:   o.Filename,
-			Offset: runs[0].Offset,
-			Size:   runs[0].Length,
+		if o.ModTime != nil {
+			finfos[i].ModTime = *o.ModTime
 		}
 	}
 	return finfos, nil
@@ -0,0 +1,52 @@
+package cmd
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/ostafen/digler/internal/format"
+	"github.com/ostafen/digler/pkg/dfxml"
+)
+
+func TestFileObjectsToFileInfo(t *testing.T) {
+	objs := []dfxml.FileObject{
+		{
+			Filename:  "a.jpg",
+			FileSize:  100,
+			Truncated: true,
+			ByteRuns:  dfxml.ByteRuns{Runs: []dfxml.ByteRun{{ImgOffset: 512, Length: 100}}},
+		},
+		{
+			Filename: "DOCS/REPORT.DOC",
+			FileSize: 6144,
+			ByteRuns: dfxml.ByteRuns{Runs: []dfxml.ByteRun{
+				{Offset: 0, ImgOffset: 8192, Length: 4096},
+				{Offset: 4096, ImgOffset: 2048, Length: 2048},
+			}},
+		},
+		{Filename: "EMPTY.TXT"},
+	}
+
+	finfos, err := fileObjectsToFileInfo(objs)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []format.FileInfo{
+		{Name: "a.jpg", Offset: 512, Size: 100, Truncated: true},
+		{
+			Name:   "DOCS/REPORT.DOC",
+			Offset: 8192,
+			Size:   6144,
+			Runs:   []format.ByteRun{{Offset: 8192, Size: 4096}, {Offset: 2048, Size: 2048}},
+		},
+		{Name: "EMPTY.TXT"},
+	}
+	if !reflect.DeepEqual(finfos, want) {
+		t.Errorf("got %+v, want %+v", finfos, want)
+	}
+
+	if _, err := fileObjectsToFileInfo([]dfxml.FileObject{{Filename: "x", FileSize: 1}}); err == nil {
+		t.Error("expected an error for a non-empty file without byte runs")
+	}
+}
@@ -0,0 +1,132 @@
+// Copyright (c) 2025 Stefano Scafiti
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/ostafen/digler/internal/disk"
+	"github.com/ostafen/digler/internal/fs"
+	"github.com/ostafen/digler/internal/scan"
+	"github.com/ostafen/digler/pkg/util/format"
+	"github.com/spf13/cobra"
+)
+
+func DefinePartitionsCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "partitions <image_or_device>",
+		Short: "List the partitions of an image file or disk",
+		Long: `The 'partitions' command discovers the partitions of an image file or disk and displays their number, type, offset, size, block size and filesystem.
+The partition numbers can be passed to 'scan --partition' to only scan the selected partitions.`,
+		Args:         cobra.ExactArgs(1),
+		SilenceUsage: true,
+		RunE:         RunPartitions,
+	}
+
+	cmd.Flags().Bool("mbr", false, "also print the raw Master Boot Record")
+	cmd.Flags().Bool("include-gaps", false, "also list the unallocated space before, between and after the partitions")
+	return cmd
+}
+
+func RunPartitions(cmd *cobra.Command, args []string) error {
+	path := volumePath(cmd, args[0])
+
+	if printMBR, _ := cmd.Flags().GetBool("mbr"); printMBR {
+		if err := printMasterBootRecord(path); err != nil {
+			return err
+		}
+		fmt.Println()
+	}
+
+	includeGaps, _ := cmd.Flags().GetBool("include-gaps")
+	return printPartitions(path, includeGaps)
+}
+
+// printPartitions prints a table of the partitions discovered on the given device,
+// followed by its unallocated gaps if includeGaps is true.
+func printPartitions(path string, includeGaps bool) error {
+	partitions, err := scan.DiscoverPartitions(path)
+	if err != nil {
+		return err
+	}
+
+	if includeGaps {
+		gaps, err := scan.DiscoverGaps(path, partitions)
+		if err != nil {
+			return err
+		}
+		partitions = append(partitions, gaps...)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NUM\tTYPE\tOFFSET\tSIZE\tBLOCK SIZE\tFILESYSTEM")
+
+	for _, p := range partitions {
+		partitionType := "-" // not partitioned
+		if p.Unallocated {
+			partitionType = "unallocated"
+		} else if p.Type != disk.PartitionTypeEmpty {
+			partitionType = p.Type.String()
+		}
+
+		fmt.Fprintf(w, "%d\t%s\t%d\t%s\t%d\t%v\n",
+			p.Num,
+			partitionType,
+			p.Offset,
+			format.FormatBytes(int64(p.Size)),
+			p.BlockSize,
+			p.FSType,
+		)
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	for _, p := range partitions {
+		if p.Err != nil {
+			fmt.Printf("warning: partition %d is invalid: %s\n", p.Num, p.Err)
+		}
+	}
+	return nil
+}
+
+// printMasterBootRecord prints the Master Boot Record of the given device.
+func printMasterBootRecord(path string) error {
+	f, err := fs.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	var sector [512]byte
+	if _, err := f.ReadAt(sector[:], 0); err != nil {
+		return err
+	}
+
+	mbr, err := disk.ParseMBR(sector[:])
+	if err != nil {
+		return err
+	}
+
+	fmt.Println(mbr.String())
+	return nil
+}
@@ -0,0 +1,195 @@
+// Copyright (c) 2025 Stefano Scafiti
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package cmd
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"go/format"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"text/template"
+
+	"github.com/spf13/cobra"
+)
+
+func DefinePluginCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "plugin",
+		Short: "Manage file scanner plugins",
+	}
+
+	cmd.AddCommand(DefinePluginNewCommand())
+	return cmd
+}
+
+func DefinePluginNewCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "new <ext>",
+		Short: "Generate the skeleton of a new file scanner plugin",
+		Long: `The 'plugin new' command generates a Go source file implementing the FileScanner interface for the given file extension.
+The generated plugin exports the GetScanner function required by the plugin loader, and contains a stub of the ScanFile method to be completed.`,
+		Args:         cobra.ExactArgs(1),
+		SilenceUsage: true,
+		RunE:         RunPluginNew,
+	}
+
+	cmd.Flags().StringP("output", "o", "", "path of the generated source file (default \"plugins/<ext>_scanner.go\")")
+	cmd.Flags().String("signature", "", "hex-encoded signature of the file format (e.g. \"deadbeef\")")
+	cmd.Flags().String("description", "", "short description of the file format")
+	return cmd
+}
+
+var pluginExtRegexp = regexp.MustCompile(`^[a-z][a-z0-9]*$`)
+
+type pluginTemplateData struct {
+	Ext         string
+	TypeName    string
+	Description string
+	Signature   string
+}
+
+var pluginTemplate = template.Must(template.New("plugin").Parse(`package main
+
+import (
+	"bytes"
+	"errors"
+
+	"github.com/ostafen/digler/internal/format"
+)
+
+// {{.TypeName}} implements FileScanner
+type {{.TypeName}} struct{}
+
+// Ext returns file extension supported
+func (s *{{.TypeName}}) Ext() string {
+	return "{{.Ext}}"
+}
+
+// Description returns a short description of the format
+func (s *{{.TypeName}}) Description() string {
+	return {{printf "%q" .Description}}
+}
+
+// Category returns the category of the format (e.g., format.CategoryImage)
+func (s *{{.TypeName}}) Category() format.Category {
+	return format.CategoryOther
+}
+
+// Signatures returns file signature byte slices to identify the file
+func (s *{{.TypeName}}) Signatures() [][]byte {
+	return [][]byte{
+		{ {{- .Signature -}} },
+	}
+}
+
+// ScanFile validates the file at the start of the Reader and returns its size
+func (s *{{.TypeName}}) ScanFile(r *format.Reader) (*format.ScanResult, error) {
+	sig := s.Signatures()[0]
+
+	buf := make([]byte, len(sig))
+	if _, err := r.Read(buf); err != nil {
+		return nil, err
+	}
+
+	if !bytes.Equal(buf, sig) {
+		return nil, errors.New("signature mismatch")
+	}
+
+	// TODO: parse the rest of the file to validate it and determine its size.
+	return nil, errors.New("not implemented")
+}
+
+// Exported constructor function for plugin
+func GetScanner() (format.FileScanner, error) {
+	return &{{.TypeName}}{}, nil
+}
+`))
+
+func RunPluginNew(cmd *cobra.Command, args []string) error {
+	ext := strings.ToLower(args[0])
+	if !pluginExtRegexp.MatchString(ext) {
+		return fmt.Errorf("invalid extension %q: only letters and digits are allowed, starting with a letter", args[0])
+	}
+
+	sigHex, _ := cmd.Flags().GetString("signature")
+	sig, err := hex.DecodeString(sigHex)
+	if err != nil {
+		return fmt.Errorf("invalid signature %q: %w", sigHex, err)
+	}
+
+	sigBytes := make([]string, len(sig))
+	for i, b := range sig {
+		sigBytes[i] = fmt.Sprintf("0x%02X", b)
+	}
+
+	signature := strings.Join(sigBytes, ", ")
+	if len(sig) == 0 {
+		signature = "/* TODO: signature bytes */"
+	}
+
+	description, _ := cmd.Flags().GetString("description")
+	if description == "" {
+		description = strings.ToUpper(ext) + " file format"
+	}
+
+	out, _ := cmd.Flags().GetString("output")
+	if out == "" {
+		out = filepath.Join("plugins", ext+"_scanner.go")
+	}
+
+	if _, err := os.Stat(out); err == nil {
+		return fmt.Errorf("file %s already exists", out)
+	}
+
+	var buf bytes.Buffer
+	err = pluginTemplate.Execute(&buf, pluginTemplateData{
+		Ext:         ext,
+		TypeName:    ext + "Scanner",
+		Description: description,
+		Signature:   signature,
+	})
+	if err != nil {
+		return err
+	}
+
+	src, err := format.Source(buf.Bytes())
+	if err != nil {
+		return fmt.Errorf("failed to format plugin source: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(out), 0755); err != nil {
+		return err
+	}
+
+	if err := os.WriteFile(out, src, 0644); err != nil {
+		return err
+	}
+
+	name := strings.TrimSuffix(filepath.Base(out), filepath.Ext(out))
+
+	fmt.Printf("Plugin source written to %s\n", out)
+	fmt.Println("Build it with:")
+	fmt.Printf("  go build -buildmode=plugin -o bin/plugins/%s.so %s\n", name, out)
+	return nil
+}
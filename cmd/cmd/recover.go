@@ -35,11 +35,11 @@ import (
 
 func DefineRecoverCommand() *cobra.Command {
 	cmd := &cobra.Command{
-		Use:   "recover <image_path> <report_file>",
+		Use:   "recover <image_path> <report_file|->",
 		Short: "Recover files from a disk image using a scan report",
 		Long: `The 'recover' command extracts files from a disk image or device based on the information provided in a scan report.
 The scan report contains metadata and file information needed for recovery.
-You must provide the full path to the image file and the report file.
+You must provide the full path to the image file and the report file, or "-" to read the report from stdin.
 Recovered files will be saved to the specified output directory.`,
 		Args:         cobra.ExactArgs(2),
 		SilenceUsage: true,
@@ -50,16 +50,17 @@ Recovered files will be saved to the specified output directory.`,
 }
 
 func RunRecover(cmd *cobra.Command, args []string) error {
-	f, err := fs.Open(args[0])
+	f, err := fs.Open(volumePath(cmd, args[0]))
 	if err != nil {
 		return err
 	}
 	defer f.Close()
 
-	reportFile, err := os.Open(args[1])
+	reportFile, err := openReport(args[1])
 	if err != nil {
 		return err
 	}
+	defer reportFile.Close()
 
 	objects, err := dfxml.ReadFileObjects(bufio.NewReader(reportFile))
 	if err != nil {
@@ -88,14 +89,29 @@ func RunRecover(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
-	logger := logger.New(os.Stdout, logger.InfoLevel)
+	logger := logger.New(os.Stdout, consoleLogLevel(cmd))
 
 	for _, finfo := range finfos {
 		logger.Infof("recovering file %s", filepath.Join(outDir, finfo.Name))
 
+		if finfo.Truncated {
+			logger.Warnf("file %s is truncated: its content is incomplete", finfo.Name)
+		}
+
 		if err := scan.DumpFile(f, outDir, &finfo); err != nil {
 			logger.Errorf("unable to dump file %s: %s", finfo.Name, err)
 		}
 	}
 	return nil
 }
+
+// stdinReport is the report argument standing for the standard input.
+const stdinReport = "-"
+
+// openReport opens the report file at path, or returns the standard input if path is "-".
+func openReport(path string) (*os.File, error) {
+	if path == stdinReport {
+		return os.Stdin, nil
+	}
+	return os.Open(path)
+}
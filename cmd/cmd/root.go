@@ -20,21 +20,136 @@
 package cmd
 
 import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"runtime"
+
+	"github.com/ostafen/digler/internal/disk"
+	"github.com/ostafen/digler/internal/fs"
+	"github.com/ostafen/digler/internal/logger"
 	"github.com/spf13/cobra"
 )
 
 const AppName = "diglet"
 
-func Execute() error {
+const (
+	quietFlag   = "quiet"
+	noColorFlag = "no-color"
+)
+
+// Exit codes of the program.
+const (
+	ExitOK      = 0 // The command succeeded.
+	ExitError   = 1 // The command failed.
+	ExitNoFiles = 2 // The scan completed, but no file was found.
+)
+
+// errNoFilesFound is returned by the scan command when no file was found.
+var errNoFilesFound = errors.New("no files found")
+
+// Execute runs the command given on the command line. Unless --quiet is given,
+// printLogo is called before running it, with the writer of the console output.
+func Execute(printLogo func(w io.Writer)) error {
+	defer fs.RemoveDecompressed()
+
 	rootCmd := &cobra.Command{
 		Use: AppName,
+		PersistentPreRun: func(cmd *cobra.Command, args []string) {
+			if noColor, _ := cmd.Flags().GetBool(noColorFlag); noColor {
+				logger.SetColors(false)
+			}
+
+			if !isQuiet(cmd) {
+				printLogo(consoleOutput(cmd))
+			}
+		},
 	}
+	rootCmd.PersistentFlags().BoolP(quietFlag, "q", false, "do not print the logo and the progress bar, and print only warnings and errors")
+	rootCmd.PersistentFlags().Bool(noColorFlag, false, "do not colorize log levels on terminals (also disabled by the NO_COLOR environment variable)")
 
 	rootCmd.AddCommand(DefineScanCommand())
 	rootCmd.AddCommand(DefineRecoverCommand())
 	rootCmd.AddCommand(DefineMountCommand())
 	rootCmd.AddCommand(DefineFormatsCommand())
+	rootCmd.AddCommand(DefinePartitionsCommand())
+	rootCmd.AddCommand(DefineDrivesCommand())
 	rootCmd.AddCommand(DefineMergeCommand())
+	rootCmd.AddCommand(DefineBenchCommand())
+	rootCmd.AddCommand(DefineSignaturesCommand())
+	rootCmd.AddCommand(DefinePluginCommand())
+	rootCmd.AddCommand(DefineDoctorCommand())
+
+	err := rootCmd.Execute()
+	if hint := errorHint(err); hint != "" {
+		fmt.Fprintf(rootCmd.ErrOrStderr(), "hint: %s\n", hint)
+	}
+	return err
+}
+
+// errorHint returns a suggestion to fix the given error, printed after it,
+// or an empty string if there is none.
+func errorHint(err error) string {
+	switch {
+	case err == nil:
+		return ""
+	case errors.Is(err, fs.ErrPermission):
+		if runtime.GOOS == "windows" {
+			return "reading a drive requires administrator privileges: run digler from an elevated prompt"
+		}
+		return "reading a device requires root privileges: run digler with sudo, or as a user allowed to read it (e.g., a member of the disk group)"
+	case errors.Is(err, fs.ErrDeviceBusy):
+		return "the device is in use: unmount it, or close the programs using it, and retry"
+	case errors.Is(err, fs.ErrNoMedium):
+		return "the drive holds no medium: insert the card or disk, and check that the drive detects it"
+	case errors.Is(err, fs.ErrNotADevice):
+		return "pass the path of a disk image or of a device, such as /dev/sdb or \\\\.\\PhysicalDrive1"
+	}
+	return ""
+}
+
+// ExitCode returns the exit code of the program for the error returned by Execute.
+func ExitCode(err error) int {
+	switch {
+	case err == nil:
+		return ExitOK
+	case errors.Is(err, errNoFilesFound):
+		return ExitNoFiles
+	default:
+		return ExitError
+	}
+}
 
-	return rootCmd.Execute()
+// isQuiet reports whether --quiet was given.
+func isQuiet(cmd *cobra.Command) bool {
+	quiet, _ := cmd.Flags().GetBool(quietFlag)
+	return quiet
+}
+
+// consoleOutput returns the writer of the messages meant for the user. It is stdout,
+// unless --json-summary is given, which reserves stdout to the summaries.
+func consoleOutput(cmd *cobra.Command) io.Writer {
+	if jsonSummary, _ := cmd.Flags().GetBool("json-summary"); jsonSummary {
+		return os.Stderr
+	}
+	return os.Stdout
+}
+
+// consoleLogLevel returns the minimum level of the messages printed to stdout.
+func consoleLogLevel(cmd *cobra.Command) logger.Level {
+	if isQuiet(cmd) {
+		return logger.WarnLevel
+	}
+	return logger.InfoLevel
+}
+
+// volumePath normalizes the path of the disk image or device given on the command line,
+// and prints a warning to stderr if it looks like a mistake.
+func volumePath(cmd *cobra.Command, arg string) string {
+	path := disk.NormalizeVolumePath(arg)
+	if warning := disk.CheckVolumePath(path); warning != "" {
+		fmt.Fprintf(cmd.ErrOrStderr(), "warning: %s\n", warning)
+	}
+	return path
 }
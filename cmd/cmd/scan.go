@@ -20,16 +20,21 @@
 package cmd
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
 	"io/fs"
 	"math"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
 
 	"github.com/ostafen/digler/internal/disk"
+	fileformat "github.com/ostafen/digler/internal/format"
 	"github.com/ostafen/digler/internal/logger"
 	"github.com/ostafen/digler/internal/scan"
+	"github.com/ostafen/digler/pkg/pbar"
 	"github.com/ostafen/digler/pkg/util/format"
 	"github.com/spf13/cobra"
 )
@@ -37,81 +42,389 @@ import (
 func DefineScanCommand() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:          "scan <device>",
-		Short:        "Scan an image file or disk",
+		Short:        "Scan an image file or disk, or \"-\" for the standard input",
 		Args:         cobra.ExactArgs(1),
 		SilenceUsage: true,
 		RunE:         RunScan,
 	}
 
 	cmd.Flags().StringP("dump", "d", "", "dump the found files to the specified directory")
-	cmd.Flags().String("block-size", "0", "use the specified block size during scanning")
-	cmd.Flags().String("scan-buffer-size", "4MB", "the size of the scan buffer")
+	cmd.Flags().String("block-size", "0", "use the specified block size during scanning, either for all the partitions or per partition (e.g., 512,2=4096)")
+	cmd.Flags().String("scan-buffer-size", "4MiB", "the size of the scan buffer, or \"auto\" to size it based on the available memory")
+	cmd.Flags().String("buffer-overlap", "auto", "number of bytes after the scan buffer searched for signatures with it, so that headers near its end are not missed, or \"auto\" for the length of the longest signature")
 	cmd.Flags().String("max-scan-size", "", "max number of bytes to scan")
-	cmd.Flags().String("max-file-size", "4GB", "maximum size of a carved file")
+	cmd.Flags().String("offset", "0", "offset within the partition where the scan starts (a multiple of the block size)")
+	cmd.Flags().String("length", "", "number of bytes to scan from --offset (default: up to the end of the partition)")
+	cmd.Flags().StringSlice("skip-region", nil, "byte ranges of the partition not to search for files, as offset:length (e.g., 10GiB:2GiB), repeatable")
+	cmd.Flags().String("max-file-size", "4GiB", "maximum size of a carved file")
+	cmd.Flags().String("max-carve-bytes", "", "max number of bytes a scanner may read to validate a single file, which is abandoned beyond it (default: no limit)")
+	cmd.Flags().String("min-confidence", "header", "minimum validation level of carved files: header, structural or validated")
 	cmd.Flags().Bool("no-log", false, "disable logging")
+	cmd.Flags().Bool("skip-errors", false, "zero-fill and skip unreadable blocks instead of stopping the scan")
+	cmd.Flags().Bool("fatal-panics", false, "stop with a stack trace when a file scanner panics, instead of logging the panic and going on (for debugging scanners)")
+	cmd.Flags().Bool("dry-run", false, "scan and write the report without dumping any file")
+	cmd.Flags().Bool("entropy-filter", false, "reject carved files whose entropy does not match their format, e.g. ZIP or JPEG files holding uncompressed data")
+	cmd.Flags().Bool("header-only", false, "fast indexing: report each signature match as a one-block file, without validating nor sizing it, and dump no file")
+	cmd.Flags().Bool("fat-metadata", false, "recover the files listed in FAT directories, including deleted ones, with their original names before carving")
+	cmd.Flags().Bool("recursive", false, "also carve files embedded in carved files, such as thumbnails or images inside archives")
+	cmd.Flags().Int("recursion-depth", 2, "maximum nesting depth of embedded files carved with --recursive")
+	cmd.Flags().Bool("jpeg-follow-concatenated", false, "carve JPEG images immediately followed by another one (e.g., MPO files) as a single file")
+	cmd.Flags().Bool("jpeg-include-trailing", false, "include the data following the end of a JPEG image, up to the next recognized file header")
+	cmd.Flags().Bool("gif-strict", false, "reject GIF images followed by unexpected data instead of carving them up to that point")
+	cmd.Flags().Bool("zip-verify-central-dir", false, "reject ZIP archives (and OOXML documents) whose central directory does not match their local file headers")
+	cmd.Flags().Bool("sqlite-wal", false, "also carve the write-ahead log stored right after a SQLite database, named after it with the -wal suffix")
+	cmd.Flags().Bool("skip-partition-metadata", false, "do not carve files from boot sectors and partition tables")
+	cmd.Flags().Bool("include-gaps", false, "also scan the unallocated space before, between and after the partitions, as additional partitions")
+	cmd.Flags().Bool("skip-invalid-partitions", false, "do not scan partitions whose table entry overlaps another one or extends past the end of the disk")
+	cmd.Flags().Bool("mmap", false, "memory map the image file instead of reading it (ignored for raw devices)")
+	cmd.Flags().IntSlice("partition", nil, "numbers of the partitions to scan (default: all)")
+	cmd.Flags().Bool("list-partitions", false, "list the partitions of the device and exit")
+	cmd.Flags().String("name-template", fileformat.DefaultNameTemplate, "template of the names of carved files, using the tokens {offset}, {block}, {index}, {size} and {ext} (e.g., {offset:x}.{ext})")
+	cmd.Flags().String("ignore-hashes", "", "file listing the SHA-1 digests of known files (one per line, or NSRL CSV), which are neither dumped nor reported")
+	cmd.Flags().Bool("dedupe", false, "dump only the first of the files with identical contents, reporting the others as its duplicates")
 	cmd.Flags().StringSliceP("ext", "", nil, "file extensions to parse")
+	cmd.Flags().StringSlice("exclude-ext", nil, "file extensions to skip")
 	cmd.Flags().StringP("output", "o", "", "The path of the scan index file")
-	cmd.Flags().StringSlice("plugins", nil, "paths to plugin .so files or directories containing plugins")
+	cmd.Flags().Bool("single-report", false, "write one report for all the scanned partitions, instead of one per partition")
+	cmd.Flags().String("report-dir", "", "the directory of the report, when --output is not given (default: the dump directory, or the current one)")
+	cmd.Flags().StringSlice("plugins", nil, "paths to plugin .so/.wasm files or directories containing plugins")
+	cmd.Flags().Bool("json-summary", false, "print a JSON summary of the scan of each partition to stdout, one object per line, after the scan, and print the logs to stderr")
+	cmd.Flags().String(configFlag, "", "YAML file setting the options of the scan, by their flag names; flags given on the command line take precedence")
 
 	return cmd
 }
 
 func RunScan(cmd *cobra.Command, args []string) error {
-	path := disk.NormalizeVolumePath(args[0])
+	path := volumePath(cmd, args[0])
+
+	if configPath, _ := cmd.Flags().GetString(configFlag); configPath != "" {
+		if err := applyConfigFile(cmd, configPath); err != nil {
+			return err
+		}
+	}
+
+	if listPartitions, _ := cmd.Flags().GetBool("list-partitions"); listPartitions {
+		includeGaps, _ := cmd.Flags().GetBool("include-gaps")
+		return printPartitions(path, includeGaps)
+	}
 
 	opts, err := parseOptions(cmd)
 	if err != nil {
 		return err
 	}
-	return scan.Scan(path, opts)
+
+	progress := pbar.NewReporterTo(consoleOutput(cmd))
+	defer progress.Finish()
+	if !opts.Quiet {
+		opts.Progress = progress.Update
+	}
+
+	var summaries []scan.Summary
+	opts.OnSummary = func(s scan.Summary) {
+		summaries = append(summaries, s)
+	}
+
+	err = scan.Scan(path, opts)
+
+	progress.Finish()
+
+	// The logs and the progress bar are printed to stderr, so that stdout only holds the summaries.
+	if jsonSummary, _ := cmd.Flags().GetBool("json-summary"); jsonSummary {
+		enc := json.NewEncoder(os.Stdout)
+		for _, s := range summaries {
+			if err := enc.Encode(s); err != nil {
+				return err
+			}
+		}
+	}
+
+	if errors.Is(err, fileformat.ErrUnknownExtension) {
+		return fmt.Errorf("%w (supported extensions: %s)", err, strings.Join(fileformat.SupportedExtensions(), ", "))
+	}
+	if err != nil {
+		return err
+	}
+
+	for _, s := range summaries {
+		if s.FilesFound > 0 || s.FATFiles > 0 {
+			return nil
+		}
+	}
+	return errNoFilesFound
 }
 
 func parseOptions(cmd *cobra.Command) (scan.Options, error) {
 	dumpDir := cmd.Flag("dump").Value.String()
 	disableLog, _ := cmd.Flags().GetBool("no-log")
+	dryRun, _ := cmd.Flags().GetBool("dry-run")
+	skipErrors, _ := cmd.Flags().GetBool("skip-errors")
+	fatalPanics, _ := cmd.Flags().GetBool("fatal-panics")
+	fatMetadata, _ := cmd.Flags().GetBool("fat-metadata")
+	useMmap, _ := cmd.Flags().GetBool("mmap")
+	skipPartitionMetadata, _ := cmd.Flags().GetBool("skip-partition-metadata")
+	skipInvalidPartitions, _ := cmd.Flags().GetBool("skip-invalid-partitions")
+	includeGaps, _ := cmd.Flags().GetBool("include-gaps")
+	jpegFollowConcatenated, _ := cmd.Flags().GetBool("jpeg-follow-concatenated")
+	jpegIncludeTrailing, _ := cmd.Flags().GetBool("jpeg-include-trailing")
+	gifStrict, _ := cmd.Flags().GetBool("gif-strict")
+	zipVerifyCentralDir, _ := cmd.Flags().GetBool("zip-verify-central-dir")
+	sqliteWAL, _ := cmd.Flags().GetBool("sqlite-wal")
 	outputFile, _ := cmd.Flags().GetString("output")
+	reportDir, _ := cmd.Flags().GetString("report-dir")
+	singleReport, _ := cmd.Flags().GetBool("single-report")
+	ignoreHashes, _ := cmd.Flags().GetString("ignore-hashes")
+	dedupe, _ := cmd.Flags().GetBool("dedupe")
+	headerOnly, _ := cmd.Flags().GetBool("header-only")
+	entropyFilter, _ := cmd.Flags().GetBool("entropy-filter")
+
+	// Only a template given by the user replaces the names chosen by file scanners.
+	var nameTemplate string
+	if cmd.Flags().Changed("name-template") {
+		nameTemplate, _ = cmd.Flags().GetString("name-template")
+	}
+
+	scanBufferSize, err := getBytes(cmd, "scan-buffer-size", true)
+	if err != nil {
+		return scan.Options{}, err
+	}
+	if scanBufferSize == format.AutoSize {
+		scanBufferSize = 0 // let the scanner pick the buffer size
+	}
+
+	bufferOverlap, err := getBytes(cmd, "buffer-overlap", true)
+	if err != nil {
+		return scan.Options{}, err
+	}
+	if bufferOverlap == format.AutoSize {
+		bufferOverlap = 0 // let the scanner pick the overlap
+	}
+
+	blockSizeValue, _ := cmd.Flags().GetString("block-size")
+	blockSize, partitionBlockSizes, err := parseBlockSizes(blockSizeValue)
+	if err != nil {
+		return scan.Options{}, fmt.Errorf("invalid value %q for flag --block-size: %w", blockSizeValue, err)
+	}
+
+	maxScanSize, err := getLimit(cmd, "max-scan-size")
+	if err != nil {
+		return scan.Options{}, err
+	}
 
-	scanBufferSize := getBytes(cmd, "scan-buffer-size")
-	blockSize := getBytes(cmd, "block-size")
-	maxScanSize := getBytes(cmd, "max-scan-size")
-	maxFileSize := getBytes(cmd, "max-file-size")
+	offset, err := getBytes(cmd, "offset", false)
+	if err != nil {
+		return scan.Options{}, err
+	}
+
+	length, err := getLimit(cmd, "length")
+	if err != nil {
+		return scan.Options{}, err
+	}
+
+	maxFileSize, err := getLimit(cmd, "max-file-size")
+	if err != nil {
+		return scan.Options{}, err
+	}
+
+	maxCarveBytes, err := getLimit(cmd, "max-carve-bytes")
+	if err != nil {
+		return scan.Options{}, err
+	}
+
+	skipRegionValues, _ := cmd.Flags().GetStringSlice("skip-region")
+	skipRegions, err := parseRegions(skipRegionValues)
+	if err != nil {
+		return scan.Options{}, fmt.Errorf("invalid value for flag --skip-region: %w", err)
+	}
+
+	minConfidenceValue, _ := cmd.Flags().GetString("min-confidence")
+	minConfidence, err := fileformat.ParseConfidence(minConfidenceValue)
+	if err != nil {
+		return scan.Options{}, fmt.Errorf("invalid value for flag --min-confidence: %w", err)
+	}
 
 	fileExt, _ := cmd.Flags().GetStringSlice("ext")
+	excludeExt, _ := cmd.Flags().GetStringSlice("exclude-ext")
+	partitions, _ := cmd.Flags().GetIntSlice("partition")
+
+	var maxDepth int
+	if recursive, _ := cmd.Flags().GetBool("recursive"); recursive {
+		maxDepth, _ = cmd.Flags().GetInt("recursion-depth")
+		if maxDepth < 1 {
+			return scan.Options{}, fmt.Errorf("invalid value %d for flag --recursion-depth: must be at least 1", maxDepth)
+		}
+	}
 	logLevel, _ := cmd.Flags().GetString("log-level")
 
 	plugins, _ := cmd.Flags().GetStringSlice("plugins")
 
 	pluginPaths, err := listPlugins(plugins)
 	if err != nil {
-		return scan.Options{}, nil
+		return scan.Options{}, fmt.Errorf("invalid value for flag --plugins: %w", err)
 	}
 
 	return scan.Options{
+		LogOutput:      consoleOutput(cmd),
 		DumpDir:        dumpDir,
 		ReportFile:     outputFile,
+		ReportDir:      reportDir,
+		SingleReport:   singleReport,
 		BlockSize:      blockSize,
 		MaxScanSize:    maxScanSize,
+		Offset:         offset,
+		Length:         length,
 		ScanBufferSize: scanBufferSize,
+		BufferOverlap:  bufferOverlap,
 		MaxFileSize:    maxFileSize,
+		MaxCarveBytes:  maxCarveBytes,
+		MinConfidence:  minConfidence,
 		DisableLog:     disableLog,
+		Quiet:          isQuiet(cmd),
+		Mmap:           useMmap,
+		SkipErrors:     skipErrors,
+		FatalPanics:    fatalPanics,
+		DryRun:         dryRun,
+		HeaderOnly:     headerOnly,
+		EntropyFilter:  entropyFilter,
+		FATMetadata:    fatMetadata,
+		NameTemplate:   nameTemplate,
+		IgnoreHashes:   ignoreHashes,
+		Dedupe:         dedupe,
+		MaxDepth:       maxDepth,
+		Partitions:     partitions,
 		FileExt:        fileExt,
+		ExcludeExt:     excludeExt,
 		Plugins:        pluginPaths,
 		LogLevel:       logger.ParseLevel(logLevel),
+
+		SkipPartitionMetadata: skipPartitionMetadata,
+		SkipRegions:           skipRegions,
+		SkipInvalidPartitions: skipInvalidPartitions,
+		IncludeGaps:           includeGaps,
+		PartitionBlockSizes:   partitionBlockSizes,
+
+		JPEG: fileformat.JPEGOptions{
+			FollowConcatenated:  jpegFollowConcatenated,
+			IncludeTrailingData: jpegIncludeTrailing,
+		},
+		GIF: fileformat.GIFOptions{
+			Strict: gifStrict,
+		},
+		ZIP: fileformat.ZIPOptions{
+			VerifyCentralDir: zipVerifyCentralDir,
+		},
+		SQLite: fileformat.SQLiteOptions{
+			IncludeWAL: sqliteWAL,
+		},
 	}, nil
 }
 
-func getBytes(cmd *cobra.Command, name string) uint64 {
+// getBytes parses the byte size value of the flag with the given name.
+// An empty value means no limit, and is returned as math.MaxUint64.
+func getBytes(cmd *cobra.Command, name string, allowAuto bool) (uint64, error) {
 	s, _ := cmd.Flags().GetString(name)
+	if s == "" {
+		return math.MaxUint64, nil
+	}
 
 	v, err := format.ParseBytes(s)
 	if err != nil {
-		return math.MaxUint64
+		return 0, fmt.Errorf("invalid value %q for flag --%s: %w", s, name, err)
+	}
+
+	if v == format.AutoSize && !allowAuto {
+		return 0, fmt.Errorf("invalid value %q for flag --%s: \"auto\" is not supported", s, name)
+	}
+	return v, nil
+}
+
+// getLimit parses the byte size value of the flag with the given name, which sets a limit.
+// An empty value means no limit, and is returned as 0, following scan.Options.
+func getLimit(cmd *cobra.Command, name string) (uint64, error) {
+	v, err := getBytes(cmd, name, false)
+	if v == math.MaxUint64 {
+		return 0, err
 	}
-	return v
+	return v, err
+}
+
+// parseRegions parses byte ranges given as offset:length, e.g. "10GiB:2GiB".
+func parseRegions(values []string) ([]disk.Region, error) {
+	regions := make([]disk.Region, 0, len(values))
+	for _, value := range values {
+		offsetValue, lengthValue, ok := strings.Cut(value, ":")
+		if !ok {
+			return nil, fmt.Errorf("%q is not in the offset:length format", value)
+		}
+
+		offset, err := format.ParseBytes(offsetValue)
+		if err != nil {
+			return nil, fmt.Errorf("invalid offset in %q: %w", value, err)
+		}
+
+		length, err := format.ParseBytes(lengthValue)
+		if err != nil {
+			return nil, fmt.Errorf("invalid length in %q: %w", value, err)
+		}
+
+		if offset == format.AutoSize || length == format.AutoSize || length == 0 {
+			return nil, fmt.Errorf("invalid region %q", value)
+		}
+		regions = append(regions, disk.Region{Offset: offset, Size: length})
+	}
+	return regions, nil
+}
+
+// parseBlockSizes parses the value of the --block-size flag: a comma-separated list of
+// block sizes, either for a given partition ("2=4096") or for all the other ones ("512").
+func parseBlockSizes(s string) (uint64, map[int]uint64, error) {
+	var (
+		blockSize      uint64
+		partitionSizes map[int]uint64
+		hasDefault     bool
+	)
+
+	for _, entry := range strings.Split(s, ",") {
+		num, size, isPartition := strings.Cut(strings.TrimSpace(entry), "=")
+		if !isPartition {
+			size = num
+		}
+
+		v, err := format.ParseBytes(size)
+		if err != nil {
+			return 0, nil, err
+		}
+
+		if v == format.AutoSize {
+			return 0, nil, fmt.Errorf("\"auto\" is not supported")
+		}
+
+		if !isPartition {
+			if hasDefault {
+				return 0, nil, fmt.Errorf("more than one block size given for all the partitions")
+			}
+			blockSize, hasDefault = v, true
+			continue
+		}
+
+		n, err := strconv.Atoi(num)
+		if err != nil || n < 0 {
+			return 0, nil, fmt.Errorf("invalid partition number %q", num)
+		}
+
+		if _, ok := partitionSizes[n]; ok {
+			return 0, nil, fmt.Errorf("more than one block size given for partition %d", n)
+		}
+
+		if partitionSizes == nil {
+			partitionSizes = make(map[int]uint64)
+		}
+		partitionSizes[n] = v
+	}
+	return blockSize, partitionSizes, nil
 }
 
 // listPlugins expands plugin paths: if path is a file, add it directly;
-// if path is a directory, scan it recursively for .so files.
+// if path is a directory, scan it recursively for .so and .wasm files.
 func listPlugins(plugins []string) ([]string, error) {
 	var pluginPaths []string
 
@@ -122,8 +435,8 @@ func listPlugins(plugins []string) ([]string, error) {
 		}
 
 		if !info.IsDir() {
-			if !strings.HasSuffix(info.Name(), ".so") {
-				return nil, fmt.Errorf("plugin file %s does not have .so extension", info.Name())
+			if !isPluginFile(info.Name()) {
+				return nil, fmt.Errorf("plugin file %s does not have .so or .wasm extension", info.Name())
 			}
 			pluginPaths = append(pluginPaths, p)
 			continue
@@ -133,7 +446,7 @@ func listPlugins(plugins []string) ([]string, error) {
 			if err != nil {
 				return err
 			}
-			if !d.IsDir() && strings.HasSuffix(d.Name(), ".so") {
+			if !d.IsDir() && isPluginFile(d.Name()) {
 				pluginPaths = append(pluginPaths, path)
 			}
 			return nil
@@ -144,3 +457,7 @@ func listPlugins(plugins []string) ([]string, error) {
 	}
 	return pluginPaths, nil
 }
+
+func isPluginFile(name string) bool {
+	return strings.HasSuffix(name, ".so") || strings.HasSuffix(name, ".wasm")
+}
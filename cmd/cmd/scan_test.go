@@ -0,0 +1,33 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/ostafen/digler/internal/disk"
+)
+
+func TestParseRegions(t *testing.T) {
+	regions, err := parseRegions([]string{"0:512", "10GiB:2GiB"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []disk.Region{
+		{Offset: 0, Size: 512},
+		{Offset: 10 << 30, Size: 2 << 30},
+	}
+	if len(regions) != len(want) {
+		t.Fatalf("got %d regions, want %d: %+v", len(regions), len(want), regions)
+	}
+	for i := range want {
+		if regions[i] != want[i] {
+			t.Errorf("region %d = %+v, want %+v", i, regions[i], want[i])
+		}
+	}
+
+	for _, value := range []string{"512", "x:512", "0:y", "0:0", "auto:512"} {
+		if _, err := parseRegions([]string{value}); err == nil {
+			t.Errorf("expected an error for %q", value)
+		}
+	}
+}
@@ -0,0 +1,171 @@
+// Copyright (c) 2025 Stefano Scafiti
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"text/tabwriter"
+
+	"github.com/ostafen/digler/internal/disk"
+	fileformat "github.com/ostafen/digler/internal/format"
+	"github.com/ostafen/digler/internal/fs"
+	"github.com/ostafen/digler/pkg/carve"
+	"github.com/spf13/cobra"
+)
+
+func DefineSignaturesCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "signatures <image_or_device>",
+		Short: "List the offsets of known file signatures in an image file or disk",
+		Long: `The 'signatures' command searches each block of an image file or disk for the signatures of the supported formats,
+and prints every match with its offset, without validating nor sizing the files. The whole source is searched as a single partition.
+It is meant as a fast reconnaissance of the contents of a disk before a full scan.`,
+		Args:         cobra.ExactArgs(1),
+		SilenceUsage: true,
+		RunE:         RunSignatures,
+	}
+
+	cmd.Flags().StringSlice("ext", nil, "file extensions to search for (default: all)")
+	cmd.Flags().Int("block-size", disk.DefaultBlocksize, "block size in bytes")
+	cmd.Flags().String("scan-buffer-size", "4MiB", "size of the scan buffer")
+	cmd.Flags().String("max-scan-size", "", "maximum number of bytes to search (default: the whole source)")
+	cmd.Flags().Bool("json", false, "print one JSON object per match instead of a table")
+	return cmd
+}
+
+// signatureMatch is a block starting with the signature of a file format.
+type signatureMatch struct {
+	Offset uint64 `json:"offset"`
+	Ext    string `json:"ext"`
+}
+
+func RunSignatures(cmd *cobra.Command, args []string) error {
+	path := volumePath(cmd, args[0])
+
+	fileExt, _ := cmd.Flags().GetStringSlice("ext")
+	blockSize, _ := cmd.Flags().GetInt("block-size")
+	printJSON, _ := cmd.Flags().GetBool("json")
+
+	if blockSize <= 0 {
+		return fmt.Errorf("invalid value %d for flag --block-size: must be positive", blockSize)
+	}
+
+	bufferSize, err := getBytes(cmd, "scan-buffer-size", false)
+	if err != nil {
+		return err
+	}
+
+	maxScanSize, err := getBytes(cmd, "max-scan-size", false)
+	if err != nil {
+		return err
+	}
+
+	scanners, err := carve.Scanners(fileExt...)
+	if err != nil {
+		return err
+	}
+
+	f, err := fs.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	finfo, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	size := min(uint64(finfo.Size()), maxScanSize)
+
+	registry := fileformat.BuildFileRegistry(scanners...)
+
+	if printJSON {
+		enc := json.NewEncoder(os.Stdout)
+		return searchSignatures(f, size, registry, blockSize, int(bufferSize), func(m signatureMatch) error {
+			return enc.Encode(m)
+		})
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "OFFSET\tBLOCK\tEXT")
+
+	matches := 0
+	err = searchSignatures(f, size, registry, blockSize, int(bufferSize), func(m signatureMatch) error {
+		matches++
+		_, err := fmt.Fprintf(w, "%d\t%d\t%s\n", m.Offset, m.Offset/uint64(blockSize), m.Ext)
+		return err
+	})
+	w.Flush()
+
+	fmt.Printf("\n%d signature(s) found\n", matches)
+	return err
+}
+
+// searchSignatures calls fn for each block of the first size bytes of r starting with a
+// signature of the registry, once per matching format. Signatures are only searched for at
+// the start of blocks, as during a scan, but no block is skipped: the matches of a file
+// embedded in another one are listed too.
+func searchSignatures(
+	r io.ReaderAt,
+	size uint64,
+	registry *fileformat.FileRegistry,
+	blockSize, bufferSize int,
+	fn func(signatureMatch) error,
+) error {
+	buf := make([]byte, max(bufferSize/blockSize, 1)*blockSize)
+
+	// Formats matched at the current block, as a format may have several signatures.
+	var exts []string
+
+	for offset := uint64(0); offset < size; offset += uint64(len(buf)) {
+		n, err := r.ReadAt(buf, int64(offset))
+		if err != nil && err != io.EOF {
+			return fmt.Errorf("read error at offset %d: %w", offset, err)
+		}
+		n = int(min(uint64(n), size-offset))
+
+		for i := 0; i < n; i += blockSize {
+			exts = exts[:0]
+
+			var fnErr error
+			registry.Search(buf[i:n], func(sc fileformat.FileScanner) bool {
+				if slices.Contains(exts, sc.Ext()) {
+					return false
+				}
+				exts = append(exts, sc.Ext())
+
+				fnErr = fn(signatureMatch{Offset: offset + uint64(i), Ext: sc.Ext()})
+				return fnErr != nil
+			})
+			if fnErr != nil {
+				return fnErr
+			}
+		}
+
+		if err == io.EOF {
+			break
+		}
+	}
+	return nil
+}
@@ -0,0 +1,42 @@
+package cmd
+
+import (
+	"bytes"
+	"testing"
+
+	fileformat "github.com/ostafen/digler/internal/format"
+	"github.com/ostafen/digler/pkg/carve"
+)
+
+func TestSearchSignatures(t *testing.T) {
+	scanners, err := carve.Scanners("png", "gif")
+	if err != nil {
+		t.Fatal(err)
+	}
+	registry := fileformat.BuildFileRegistry(scanners...)
+
+	data := make([]byte, 8192)
+	copy(data[512:], "\x89PNG\r\n\x1a\n")
+	copy(data[4096:], "GIF89a")
+	copy(data[4200:], "GIF89a") // Not at the start of a block.
+	copy(data[7680:], "\x89PNG\r\n\x1a\n")
+
+	var matches []signatureMatch
+	err = searchSignatures(bytes.NewReader(data), 7680, registry, 512, 2048, func(m signatureMatch) error {
+		matches = append(matches, m)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []signatureMatch{{Offset: 512, Ext: "png"}, {Offset: 4096, Ext: "gif"}}
+	if len(matches) != len(want) {
+		t.Fatalf("got %+v, want %+v", matches, want)
+	}
+	for i := range want {
+		if matches[i] != want[i] {
+			t.Errorf("match %d = %+v, want %+v", i, matches[i], want[i])
+		}
+	}
+}
@@ -21,31 +21,33 @@ package main
 
 import (
 	"fmt"
+	"io"
+	"os"
 
 	"github.com/ostafen/digler/cmd/cmd"
 	"github.com/ostafen/digler/internal/env"
 )
 
 func main() {
-	PrintLogo()
-
-	_ = cmd.Execute()
+	if err := cmd.Execute(PrintLogo); err != nil {
+		os.Exit(cmd.ExitCode(err))
+	}
 }
 
-func PrintLogo() {
-	fmt.Println("    _ _        _          ")
-	fmt.Println("  __| (_) __ _| | ___ _ __")
-	fmt.Println(" / _` | |/ _` | |/ _ \\ '__|")
-	fmt.Println("| (_| | | (_| | |  __/ |   ")
-	fmt.Println(" \\__,_|_|\\__, |_|\\___|_|   ")
-	fmt.Println("          |___/           ")
-	fmt.Println()
-	fmt.Println("Disk analysis and recovery tool")
-	fmt.Println()
-	fmt.Printf("Version:   %s\n", env.Version)
-	fmt.Printf("Commit:    %s\n", env.CommitHash)
-	fmt.Printf("Build Time: %s\n", env.BuildTime)
-	fmt.Println(" ")
-	fmt.Println("© 2025 Stefano Scafiti. Licensed under MIT License.")
-	fmt.Println(" ")
+func PrintLogo(w io.Writer) {
+	fmt.Fprintln(w, "    _ _        _          ")
+	fmt.Fprintln(w, "  __| (_) __ _| | ___ _ __")
+	fmt.Fprintln(w, " / _` | |/ _` | |/ _ \\ '__|")
+	fmt.Fprintln(w, "| (_| | | (_| | |  __/ |   ")
+	fmt.Fprintln(w, " \\__,_|_|\\__, |_|\\___|_|   ")
+	fmt.Fprintln(w, "          |___/           ")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Disk analysis and recovery tool")
+	fmt.Fprintln(w)
+	fmt.Fprintf(w, "Version:   %s\n", env.Version)
+	fmt.Fprintf(w, "Commit:    %s\n", env.CommitHash)
+	fmt.Fprintf(w, "Build Time: %s\n", env.BuildTime)
+	fmt.Fprintln(w, " ")
+	fmt.Fprintln(w, "© 2025 Stefano Scafiti. Licensed under MIT License.")
+	fmt.Fprintln(w, " ")
 }
@@ -23,6 +23,7 @@ import (
 	"bytes"
 	"encoding/binary"
 	"fmt"
+	"io"
 )
 
 // Partition type indicators
@@ -147,14 +148,17 @@ func (b *FatBootSector) String() string {
 }
 */
 
+// ReadFatBootSectorFrom parses the boot sector at the start of data. Media with
+// larger logical sectors (e.g. 4Kn drives) have boot sectors longer than 512 bytes:
+// only the first 512 bytes, which hold the BPB and the 0xAA55 marker, are read.
 func ReadFatBootSectorFrom(data []byte) (*FatBootSector, error) {
-	if len(data) != Fat1xBootSectorSize {
-		return nil, fmt.Errorf("input data slice size mismatch: expected %d bytes, got %d bytes",
+	if len(data) < Fat1xBootSectorSize {
+		return nil, fmt.Errorf("input data slice too short: expected at least %d bytes, got %d bytes",
 			Fat1xBootSectorSize, len(data))
 	}
 
 	var bs FatBootSector
-	r := bytes.NewReader(data)
+	r := bytes.NewReader(data[:Fat1xBootSectorSize])
 
 	err := binary.Read(r, binary.LittleEndian, &bs)
 	if err != nil {
@@ -164,5 +168,46 @@ func ReadFatBootSectorFrom(data []byte) (*FatBootSector, error) {
 	if bs.Marker != 0xAA55 {
 		return nil, fmt.Errorf("invalid boot sector marker: expected 0xAA55, got 0x%04X", bs.Marker)
 	}
+
+	if !validFatSectorSize(bs.SectorSize) {
+		return nil, fmt.Errorf("invalid sector size: %d", bs.SectorSize)
+	}
 	return &bs, nil
 }
+
+// validFatSectorSize reports whether size is a power of two between 512 and 4096.
+func validFatSectorSize(size uint16) bool {
+	return size >= Fat1xBootSectorSize && size <= 4096 && size&(size-1) == 0
+}
+
+// fatSectorSizes are the logical sector sizes probed by ReadFatBootSector.
+var fatSectorSizes = []uint32{DefaultBlocksize, 4096}
+
+// ReadFatBootSector reads the boot sector of the FAT volume starting at the given LBA
+// of an MBR disk. Since the MBR doesn't record the logical sector size of the disk,
+// the LBA is tried in units of 512 and 4096 bytes (as on 4Kn drives): the first
+// valid boot sector declaring a compatible sector size is returned, together with
+// the sector size of the disk.
+func ReadFatBootSector(r io.ReaderAt, lba uint32) (*FatBootSector, uint32, error) {
+	for _, sectorSize := range fatSectorSizes {
+		offset := uint64(lba) * uint64(sectorSize)
+
+		buf := make([]byte, sectorSize)
+		if _, err := r.ReadAt(buf, int64(offset)); err != nil && err != io.EOF {
+			continue
+		}
+
+		bs, err := ReadFatBootSectorFrom(buf)
+		if err != nil {
+			continue
+		}
+
+		// A volume on a 512-byte sector disk may still use larger logical sectors,
+		// but on a 4Kn disk the volume sectors can't be smaller than the disk ones.
+		if sectorSize != DefaultBlocksize && uint32(bs.SectorSize) != sectorSize {
+			continue
+		}
+		return bs, sectorSize, nil
+	}
+	return nil, 0, fmt.Errorf("no valid FAT boot sector found at LBA %d", lba)
+}
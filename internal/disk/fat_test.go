@@ -0,0 +1,87 @@
+package disk
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+)
+
+func fatBootSector(size int, sectorSize uint16) []byte {
+	bs := make([]byte, size)
+	copy(bs[0x03:], "MSDOS5.0")
+	binary.LittleEndian.PutUint16(bs[0x0B:], sectorSize)
+	bs[0x0D] = 1
+	binary.LittleEndian.PutUint16(bs[0x0E:], 1)
+	bs[0x10] = 2
+	binary.LittleEndian.PutUint16(bs[0x1FE:], 0xAA55)
+	return bs
+}
+
+func TestReadFatBootSectorFrom(t *testing.T) {
+	tests := []struct {
+		name       string
+		data       []byte
+		wantErr    bool
+		sectorSize uint16
+	}{
+		{name: "512-byte sector", data: fatBootSector(512, 512), sectorSize: 512},
+		{name: "4Kn sector", data: fatBootSector(4096, 4096), sectorSize: 4096},
+		{name: "2048-byte sector", data: fatBootSector(2048, 2048), sectorSize: 2048},
+		{name: "short buffer", data: fatBootSector(512, 512)[:511], wantErr: true},
+		{name: "zero sector size", data: fatBootSector(512, 0), wantErr: true},
+		{name: "sector size below 512", data: fatBootSector(512, 256), wantErr: true},
+		{name: "sector size above 4096", data: fatBootSector(512, 8192), wantErr: true},
+		{name: "sector size not a power of two", data: fatBootSector(512, 1000), wantErr: true},
+		{name: "missing marker", data: make([]byte, 512), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bs, err := ReadFatBootSectorFrom(tt.data)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ReadFatBootSectorFrom() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && bs.SectorSize != tt.sectorSize {
+				t.Errorf("SectorSize = %d, want %d", bs.SectorSize, tt.sectorSize)
+			}
+		})
+	}
+}
+
+func TestReadFatBootSector(t *testing.T) {
+	const lba = 8
+
+	tests := []struct {
+		name           string
+		diskSectorSize int
+		fatSectorSize  uint16
+		wantErr        bool
+	}{
+		{name: "512-byte sector disk", diskSectorSize: 512, fatSectorSize: 512},
+		{name: "512-byte sector disk with 4K FAT sectors", diskSectorSize: 512, fatSectorSize: 4096},
+		{name: "4Kn disk", diskSectorSize: 4096, fatSectorSize: 4096},
+		{name: "4Kn disk with 512-byte FAT sectors", diskSectorSize: 4096, fatSectorSize: 512, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			img := make([]byte, (lba+2)*4096)
+			copy(img[lba*tt.diskSectorSize:], fatBootSector(int(tt.fatSectorSize), tt.fatSectorSize))
+
+			bs, sectorSize, err := ReadFatBootSector(bytes.NewReader(img), lba)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ReadFatBootSector() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+
+			if sectorSize != uint32(tt.diskSectorSize) {
+				t.Errorf("sector size = %d, want %d", sectorSize, tt.diskSectorSize)
+			}
+			if bs.SectorSize != tt.fatSectorSize {
+				t.Errorf("SectorSize = %d, want %d", bs.SectorSize, tt.fatSectorSize)
+			}
+		})
+	}
+}
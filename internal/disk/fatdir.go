@@ -0,0 +1,454 @@
+// Copyright (c) 2025 Stefano Scafiti
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package disk
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+	"time"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// FatDirEntrySize is the size of a FAT directory entry.
+const FatDirEntrySize = 32
+
+// ATTR_LFN marks the directory entries holding a part of a long file name (VFAT).
+const ATTR_LFN = ATTR_RO | ATTR_HIDDEN | ATTR_SYS | ATTR_VOLUME
+
+const (
+	// maxFATDirDepth bounds the depth of the walked directory tree, protecting against corrupted filesystems.
+	maxFATDirDepth = 64
+	// maxFATDirSize is the maximum size of a FAT directory (65536 entries).
+	maxFATDirSize = 65536 * FatDirEntrySize
+)
+
+// FATFile is a file listed in a directory of a FAT volume.
+type FATFile struct {
+	Path    string    // Path of the file, relative to the root directory and separated by slashes
+	Size    uint64    // Size of the file, as recorded in the directory entry
+	Attr    uint8     // Attributes of the file
+	Deleted bool      // Whether the file, or one of its parent directories, is marked as deleted
+	Runs    []Region  // Byte ranges of the volume holding the contents of the file, in order
+	ModTime time.Time // Last modification time, or the zero time if not set
+}
+
+// FATVolume provides access to the directory tree of a FAT12, FAT16 or FAT32 volume.
+type FATVolume struct {
+	r            io.ReaderAt
+	fsType       FSType
+	clusterSize  uint64
+	clusterCount uint32
+	fatOffset    uint64 // offset of the first FAT
+	rootOffset   uint64 // offset of the root directory (FAT12/16 only)
+	rootSize     uint64 // size of the root directory (FAT12/16 only)
+	rootCluster  uint32 // first cluster of the root directory (FAT32 only)
+	dataOffset   uint64 // offset of cluster 2
+}
+
+// OpenFATVolume reads the boot sector of the FAT volume starting at offset 0 of r.
+func OpenFATVolume(r io.ReaderAt) (*FATVolume, error) {
+	var buf [Fat1xBootSectorSize]byte
+	if _, err := r.ReadAt(buf[:], 0); err != nil {
+		return nil, fmt.Errorf("unable to read boot sector: %w", err)
+	}
+
+	bs, err := ReadFatBootSectorFrom(buf[:])
+	if err != nil {
+		return nil, err
+	}
+
+	if bs.SectorSize == 0 || bs.SectorsPerCluster == 0 || bs.Fats == 0 {
+		return nil, fmt.Errorf("invalid FAT geometry")
+	}
+
+	sectorSize := uint64(bs.SectorSize)
+
+	fatLength := uint64(bs.FatLength)
+	if fatLength == 0 {
+		fatLength = uint64(bs.Fat32Length)
+	}
+
+	totalSectors := uint64(bs.Sectors)
+	if totalSectors == 0 {
+		totalSectors = uint64(bs.TotalSect)
+	}
+
+	rootSectors := (uint64(bs.DirEntries)*FatDirEntrySize + sectorSize - 1) / sectorSize
+
+	v := &FATVolume{
+		r:           r,
+		clusterSize: uint64(bs.SectorsPerCluster) * sectorSize,
+		fatOffset:   uint64(bs.Reserved) * sectorSize,
+	}
+	v.rootOffset = v.fatOffset + uint64(bs.Fats)*fatLength*sectorSize
+	v.rootSize = rootSectors * sectorSize
+	v.dataOffset = v.rootOffset + v.rootSize
+
+	dataSectors := totalSectors - min(totalSectors, v.dataOffset/sectorSize)
+	if dataSectors == 0 {
+		return nil, fmt.Errorf("invalid FAT geometry: no data region")
+	}
+	v.clusterCount = uint32(dataSectors / uint64(bs.SectorsPerCluster))
+
+	// The FAT type is determined by the number of clusters only.
+	switch {
+	case v.clusterCount < 4085:
+		v.fsType = FSTypeFAT12
+	case v.clusterCount < 65525:
+		v.fsType = FSTypeFAT16
+	default:
+		v.fsType = FSTypeFAT32
+		v.rootCluster = bs.ReadRootCluster()
+	}
+	return v, nil
+}
+
+// FSType returns the FAT type of the volume.
+func (v *FATVolume) FSType() FSType {
+	return v.fsType
+}
+
+// ClusterSize returns the size of a cluster in bytes.
+func (v *FATVolume) ClusterSize() uint64 {
+	return v.clusterSize
+}
+
+// Walk calls fn for each file listed in the directory tree of the volume, including
+// deleted ones whose contents were not reused. The contents of deleted files are assumed
+// to be contiguous, since their cluster chains are cleared. Walk stops when fn returns false.
+func (v *FATVolume) Walk(fn func(FATFile) bool) error {
+	var root []Region
+	if v.fsType == FSTypeFAT32 {
+		root = v.chain(v.rootCluster, maxFATDirSize)
+	} else {
+		root = []Region{{Offset: v.rootOffset, Size: v.rootSize}}
+	}
+
+	visited := map[uint32]bool{v.rootCluster: true}
+
+	_, err := v.walkDir(root, "", false, 0, visited, fn)
+	return err
+}
+
+func (v *FATVolume) walkDir(
+	runs []Region,
+	dir string,
+	deleted bool,
+	depth int,
+	visited map[uint32]bool,
+	fn func(FATFile) bool,
+) (bool, error) {
+	data, err := v.readRuns(runs)
+	if err != nil {
+		return false, err
+	}
+
+	var lfn [][]byte
+	for i := 0; i+FatDirEntrySize <= len(data); i += FatDirEntrySize {
+		e := data[i : i+FatDirEntrySize]
+		if e[0] == 0x00 {
+			// No entry is used past this one.
+			break
+		}
+
+		attr := e[11]
+		if attr&ATTR_EXT_MASK == ATTR_LFN {
+			lfn = append(lfn, e)
+			continue
+		}
+
+		name := fatEntryName(e, lfn)
+		lfn = lfn[:0]
+
+		if attr&ATTR_VOLUME != 0 || attr&^ATTR_EXT_MASK != 0 || e[0] == '.' {
+			continue
+		}
+
+		entryDeleted := deleted || e[0] == DELETED_FLAG
+		cluster := uint32(binary.LittleEndian.Uint16(e[26:28]))
+		if v.fsType == FSTypeFAT32 {
+			cluster |= uint32(binary.LittleEndian.Uint16(e[20:22])) << 16
+		}
+		filePath := path.Join(dir, name)
+
+		if attr&ATTR_DIR != 0 {
+			if depth >= maxFATDirDepth || !v.validCluster(cluster) || visited[cluster] {
+				continue
+			}
+			visited[cluster] = true
+
+			// Only the first cluster of a deleted directory is known to be part of it.
+			dirSize := uint64(maxFATDirSize)
+			if entryDeleted {
+				dirSize = v.clusterSize
+			}
+
+			dirRuns, ok := v.fileRuns(cluster, dirSize, entryDeleted)
+			if !ok {
+				continue
+			}
+
+			cont, err := v.walkDir(dirRuns, filePath, entryDeleted, depth+1, visited, fn)
+			if err != nil || !cont {
+				return cont, err
+			}
+			continue
+		}
+
+		size := uint64(binary.LittleEndian.Uint32(e[28:32]))
+
+		var fileRuns []Region
+		if size > 0 {
+			var ok bool
+			if fileRuns, ok = v.fileRuns(cluster, size, entryDeleted); !ok {
+				continue
+			}
+		}
+
+		if !fn(FATFile{
+			Path:    filePath,
+			Size:    size,
+			Attr:    attr,
+			Deleted: entryDeleted,
+			Runs:    fileRuns,
+			ModTime: dosTime(binary.LittleEndian.Uint16(e[24:26]), binary.LittleEndian.Uint16(e[22:24])),
+		}) {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// fileRuns returns the byte ranges holding the first size bytes of the file starting at
+// the given cluster. For deleted files, the clusters are assumed to be contiguous, and
+// false is returned if the first one was reused.
+func (v *FATVolume) fileRuns(cluster uint32, size uint64, deleted bool) ([]Region, bool) {
+	if !v.validCluster(cluster) {
+		return nil, false
+	}
+
+	if !deleted {
+		runs := v.chain(cluster, size)
+		return runs, len(runs) > 0
+	}
+
+	next, err := v.next(cluster)
+	if err != nil || next != 0 {
+		return nil, false
+	}
+
+	offset := v.clusterOffset(cluster)
+	end := v.clusterOffset(v.clusterCount + 2)
+	return []Region{{Offset: offset, Size: min(size, end-offset)}}, true
+}
+
+// chain follows the cluster chain starting at the given cluster, returning the byte ranges
+// holding its first size bytes. Contiguous clusters are merged into a single range.
+func (v *FATVolume) chain(cluster uint32, size uint64) []Region {
+	var runs []Region
+
+	maxClusters := (size + v.clusterSize - 1) / v.clusterSize
+	for n := uint64(0); n < maxClusters && v.validCluster(cluster); n++ {
+		offset := v.clusterOffset(cluster)
+		length := min(v.clusterSize, size-n*v.clusterSize)
+
+		if last := len(runs) - 1; last >= 0 && runs[last].Offset+runs[last].Size == offset {
+			runs[last].Size += length
+		} else {
+			runs = append(runs, Region{Offset: offset, Size: length})
+		}
+
+		next, err := v.next(cluster)
+		if err != nil {
+			break
+		}
+		cluster = next
+	}
+	return runs
+}
+
+// next returns the FAT entry of the given cluster, i.e. the next cluster of its chain.
+func (v *FATVolume) next(cluster uint32) (uint32, error) {
+	var buf [4]byte
+
+	switch v.fsType {
+	case FSTypeFAT12:
+		if _, err := v.r.ReadAt(buf[:2], int64(v.fatOffset+uint64(cluster)+uint64(cluster)/2)); err != nil {
+			return 0, err
+		}
+		entry := uint32(binary.LittleEndian.Uint16(buf[:2]))
+		if cluster&1 == 1 {
+			return entry >> 4, nil
+		}
+		return entry & 0x0FFF, nil
+	case FSTypeFAT16:
+		if _, err := v.r.ReadAt(buf[:2], int64(v.fatOffset+uint64(cluster)*2)); err != nil {
+			return 0, err
+		}
+		return uint32(binary.LittleEndian.Uint16(buf[:2])), nil
+	default:
+		if _, err := v.r.ReadAt(buf[:], int64(v.fatOffset+uint64(cluster)*4)); err != nil {
+			return 0, err
+		}
+		return binary.LittleEndian.Uint32(buf[:]) & 0x0FFFFFFF, nil
+	}
+}
+
+// validCluster reports whether the given cluster is a data cluster of the volume.
+// End-of-chain and bad cluster markers are always above the last data cluster.
+func (v *FATVolume) validCluster(cluster uint32) bool {
+	return cluster >= 2 && cluster < v.clusterCount+2
+}
+
+func (v *FATVolume) clusterOffset(cluster uint32) uint64 {
+	return v.dataOffset + uint64(cluster-2)*v.clusterSize
+}
+
+func (v *FATVolume) readRuns(runs []Region) ([]byte, error) {
+	var size uint64
+	for _, run := range runs {
+		size += run.Size
+	}
+
+	data := make([]byte, size)
+
+	var pos uint64
+	for _, run := range runs {
+		n, err := v.r.ReadAt(data[pos:pos+run.Size], int64(run.Offset))
+		if err != nil && err != io.EOF {
+			return nil, err
+		}
+		pos += uint64(n)
+		if uint64(n) < run.Size {
+			break
+		}
+	}
+	return data[:pos], nil
+}
+
+// dosTime converts a DOS date and time, with a resolution of two seconds, to a time.
+// DOS timestamps carry no time zone, so they are interpreted in the local one.
+// It returns the zero time if the date is not set or invalid.
+func dosTime(date, t uint16) time.Time {
+	year := int(date>>9) + 1980
+	month := time.Month(date >> 5 & 0x0F)
+	day := int(date & 0x1F)
+	if month < time.January || month > time.December || day == 0 {
+		return time.Time{}
+	}
+
+	hour := int(t >> 11)
+	minute := int(t >> 5 & 0x3F)
+	sec := int(t&0x1F) * 2
+	if hour > 23 || minute > 59 || sec > 59 {
+		return time.Time{}
+	}
+	return time.Date(year, month, day, hour, minute, sec, 0, time.Local)
+}
+
+// fatEntryName returns the name of a directory entry, using its long file name if the
+// preceding LFN entries belong to it. The first character of the short name of a deleted
+// entry is lost, and is replaced with an underscore.
+func fatEntryName(e []byte, lfn [][]byte) string {
+	if name := longFileName(e, lfn); name != "" {
+		return sanitizeFATName(name)
+	}
+
+	base := []byte(strings.TrimRight(string(e[0:8]), " "))
+	ext := strings.TrimRight(string(e[8:11]), " ")
+	if len(base) == 0 {
+		return "_"
+	}
+
+	switch base[0] {
+	case DELETED_FLAG:
+		base[0] = '_'
+	case 0x05:
+		// 0xE5 is stored as 0x05 when it is the actual first character.
+		base[0] = DELETED_FLAG
+	}
+
+	// Windows NT stores the case of 8.3 names in the reserved byte.
+	name := string(base)
+	if e[12]&0x08 != 0 {
+		name = strings.ToLower(name)
+	}
+	if e[12]&0x10 != 0 {
+		ext = strings.ToLower(ext)
+	}
+
+	if ext != "" {
+		name += "." + ext
+	}
+	return sanitizeFATName(name)
+}
+
+// longFileName assembles the long file name stored in the given LFN entries, which precede
+// the short entry in reverse order. It returns an empty string if they do not belong to the
+// short entry. Since the first character of a deleted short name is lost, the checksum of
+// deleted entries cannot be verified, and the LFN entries need only agree with each other.
+func longFileName(e []byte, lfn [][]byte) string {
+	if len(lfn) == 0 {
+		return ""
+	}
+
+	checksum := lfnChecksum(e[0:11])
+	deleted := e[0] == DELETED_FLAG
+
+	var chars []uint16
+	for i := len(lfn) - 1; i >= 0; i-- {
+		part := lfn[i]
+		if part[13] != checksum && (!deleted || part[13] != lfn[0][13]) {
+			return ""
+		}
+
+		for _, off := range [13]int{1, 3, 5, 7, 9, 14, 16, 18, 20, 22, 24, 28, 30} {
+			c := binary.LittleEndian.Uint16(part[off : off+2])
+			if c == 0x0000 || c == 0xFFFF {
+				break
+			}
+			chars = append(chars, c)
+		}
+	}
+	return string(utf16.Decode(chars))
+}
+
+func lfnChecksum(shortName []byte) byte {
+	var sum byte
+	for _, c := range shortName {
+		sum = (sum&1)<<7 + sum>>1 + c
+	}
+	return sum
+}
+
+// sanitizeFATName replaces the characters of a file name which cannot be
+// part of a path component, such as separators and control characters.
+func sanitizeFATName(name string) string {
+	return strings.Map(func(r rune) rune {
+		if r < 0x20 || r == '/' || r == '\\' || r == 0x7F || r == utf8.RuneError {
+			return '_'
+		}
+		return r
+	}, name)
+}
@@ -0,0 +1,259 @@
+package disk
+
+import (
+	"bytes"
+	"encoding/binary"
+	"reflect"
+	"testing"
+	"time"
+	"unicode/utf16"
+)
+
+const (
+	testSectorSize  = 512
+	testDataSector  = 4 // boot sector, two FATs and the root directory
+	testFATSectors  = 200
+	testRootEntries = 16
+)
+
+// fatImage builds a FAT12 volume with one sector per cluster.
+type fatImage struct {
+	data []byte
+}
+
+func newFATImage() *fatImage {
+	img := &fatImage{data: make([]byte, testFATSectors*testSectorSize)}
+
+	bs := img.data[:testSectorSize]
+	copy(bs[0x03:], "MSDOS5.0")
+	binary.LittleEndian.PutUint16(bs[0x0B:], testSectorSize)
+	bs[0x0D] = 1 // sectors per cluster
+	binary.LittleEndian.PutUint16(bs[0x0E:], 1)
+	bs[0x10] = 2 // FATs
+	binary.LittleEndian.PutUint16(bs[0x11:], testRootEntries)
+	binary.LittleEndian.PutUint16(bs[0x13:], testFATSectors)
+	binary.LittleEndian.PutUint16(bs[0x16:], 1)
+	copy(bs[0x36:], "FAT12   ")
+	binary.LittleEndian.PutUint16(bs[0x1FE:], 0xAA55)
+
+	img.setFAT(0, 0xFF8)
+	img.setFAT(1, 0xFFF)
+	return img
+}
+
+func (img *fatImage) setFAT(cluster, value uint32) {
+	fat := img.data[testSectorSize : 2*testSectorSize]
+
+	off := cluster + cluster/2
+	entry := binary.LittleEndian.Uint16(fat[off:])
+	if cluster&1 == 1 {
+		entry = entry&0x000F | uint16(value)<<4
+	} else {
+		entry = entry&0xF000 | uint16(value)
+	}
+	binary.LittleEndian.PutUint16(fat[off:], entry)
+}
+
+func (img *fatImage) clusterOffset(cluster uint32) int {
+	return (testDataSector + int(cluster) - 2) * testSectorSize
+}
+
+func (img *fatImage) writeCluster(cluster uint32, data []byte) {
+	copy(img.data[img.clusterOffset(cluster):], data)
+}
+
+func shortEntry(name string, attr byte, cluster uint32, size uint32) []byte {
+	e := make([]byte, FatDirEntrySize)
+	copy(e[0:11], name)
+	e[11] = attr
+	binary.LittleEndian.PutUint16(e[26:], uint16(cluster))
+	binary.LittleEndian.PutUint32(e[28:], size)
+	return e
+}
+
+func lfnEntries(name string, short []byte) []byte {
+	chars := utf16.Encode([]rune(name))
+	chars = append(chars, 0)
+	for len(chars)%13 != 0 {
+		chars = append(chars, 0xFFFF)
+	}
+
+	n := len(chars) / 13
+
+	var entries []byte
+	for seq := n; seq >= 1; seq-- {
+		e := make([]byte, FatDirEntrySize)
+		e[0] = byte(seq)
+		if seq == n {
+			e[0] |= 0x40
+		}
+		e[11] = ATTR_LFN
+		e[13] = lfnChecksum(short[0:11])
+
+		part := chars[(seq-1)*13 : seq*13]
+		for i, off := range [13]int{1, 3, 5, 7, 9, 14, 16, 18, 20, 22, 24, 28, 30} {
+			binary.LittleEndian.PutUint16(e[off:], part[i])
+		}
+		entries = append(entries, e...)
+	}
+	return entries
+}
+
+func TestFATVolumeWalk(t *testing.T) {
+	img := newFATImage()
+
+	// HELLO.TXT: 700 bytes in the fragmented clusters 2 and 5.
+	img.setFAT(2, 5)
+	img.setFAT(5, 0xFFF)
+
+	// SUB: a directory in cluster 3, holding a file with a long name in cluster 4.
+	img.setFAT(3, 0xFFF)
+	img.setFAT(4, 0xFFF)
+
+	long := shortEntry("LONGNA~1TXT", ATTR_ARCH, 4, 10)
+
+	var sub []byte
+	sub = append(sub, shortEntry(".          ", ATTR_DIR, 3, 0)...)
+	sub = append(sub, shortEntry("..         ", ATTR_DIR, 0, 0)...)
+	sub = append(sub, lfnEntries("Long name.txt", long)...)
+	sub = append(sub, long...)
+	img.writeCluster(3, sub)
+
+	// A deleted file, whose free clusters 6 and 7 are assumed to be contiguous.
+	deleted := shortEntry("\xe5ELETED JPG", ATTR_ARCH, 6, 1000)
+	// A deleted file whose first cluster was reused by HELLO.TXT.
+	reused := shortEntry("\xe5EUSED  JPG", ATTR_ARCH, 5, 100)
+
+	// 2024-03-15 10:30:42
+	hello := shortEntry("HELLO   TXT", ATTR_ARCH, 2, 700)
+	binary.LittleEndian.PutUint16(hello[22:], 10<<11|30<<5|21)
+	binary.LittleEndian.PutUint16(hello[24:], (2024-1980)<<9|3<<5|15)
+
+	var root []byte
+	root = append(root, shortEntry("VOLUME     ", ATTR_VOLUME, 0, 0)...)
+	root = append(root, hello...)
+	root = append(root, shortEntry("SUB        ", ATTR_DIR, 3, 0)...)
+	root = append(root, deleted...)
+	root = append(root, reused...)
+	root = append(root, shortEntry("EMPTY      ", ATTR_ARCH, 0, 0)...)
+	copy(img.data[3*testSectorSize:], root)
+
+	vol, err := OpenFATVolume(bytes.NewReader(img.data))
+	if err != nil {
+		t.Fatalf("OpenFATVolume() error = %v", err)
+	}
+
+	if vol.FSType() != FSTypeFAT12 {
+		t.Errorf("FSType() = %s, want %s", vol.FSType(), FSTypeFAT12)
+	}
+
+	var files []FATFile
+	err = vol.Walk(func(f FATFile) bool {
+		files = append(files, f)
+		return true
+	})
+	if err != nil {
+		t.Fatalf("Walk() error = %v", err)
+	}
+
+	want := []FATFile{
+		{
+			Path: "HELLO.TXT",
+			Size: 700,
+			Attr: ATTR_ARCH,
+			Runs: []Region{
+				{Offset: uint64(img.clusterOffset(2)), Size: 512},
+				{Offset: uint64(img.clusterOffset(5)), Size: 188},
+			},
+			ModTime: time.Date(2024, time.March, 15, 10, 30, 42, 0, time.Local),
+		},
+		{
+			Path: "SUB/Long name.txt",
+			Size: 10,
+			Attr: ATTR_ARCH,
+			Runs: []Region{{Offset: uint64(img.clusterOffset(4)), Size: 10}},
+		},
+		{
+			Path:    "_ELETED.JPG",
+			Size:    1000,
+			Attr:    ATTR_ARCH,
+			Deleted: true,
+			Runs:    []Region{{Offset: uint64(img.clusterOffset(6)), Size: 1000}},
+		},
+		{
+			Path: "EMPTY",
+			Attr: ATTR_ARCH,
+		},
+	}
+
+	if !reflect.DeepEqual(files, want) {
+		t.Errorf("Walk() files = %+v, want %+v", files, want)
+	}
+}
+
+func TestFatEntryName(t *testing.T) {
+	tests := []struct {
+		name  string
+		entry []byte
+		want  string
+	}{
+		{"short name", shortEntry("README  MD ", 0, 0, 0), "README.MD"},
+		{"no extension", shortEntry("MAKEFILE   ", 0, 0, 0), "MAKEFILE"},
+		{"deleted", shortEntry("\xe5OTES   TXT", 0, 0, 0), "_OTES.TXT"},
+		{"leading 0xE5", shortEntry("\x05BC     TXT", 0, 0, 0), "_BC.TXT"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := fatEntryName(tt.entry, nil); got != tt.want {
+				t.Errorf("fatEntryName() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFatEntryNameLowercase(t *testing.T) {
+	e := shortEntry("README  MD ", 0, 0, 0)
+	e[12] = 0x08 | 0x10
+
+	if got := fatEntryName(e, nil); got != "readme.md" {
+		t.Errorf("fatEntryName() = %q, want %q", got, "readme.md")
+	}
+}
+
+func TestLongFileNameChecksumMismatch(t *testing.T) {
+	e := shortEntry("OTHER   TXT", 0, 0, 0)
+	lfn := lfnEntries("Some name.txt", shortEntry("SOMENA~1TXT", 0, 0, 0))
+
+	var parts [][]byte
+	for i := 0; i < len(lfn); i += FatDirEntrySize {
+		parts = append(parts, lfn[i:i+FatDirEntrySize])
+	}
+
+	if got := fatEntryName(e, parts); got != "OTHER.TXT" {
+		t.Errorf("fatEntryName() = %q, want %q", got, "OTHER.TXT")
+	}
+}
+
+func TestDosTime(t *testing.T) {
+	tests := []struct {
+		name string
+		date uint16
+		time uint16
+		want time.Time
+	}{
+		{"epoch", 0<<9 | 1<<5 | 1, 0, time.Date(1980, time.January, 1, 0, 0, 0, 0, time.Local)},
+		{"last second", 127<<9 | 12<<5 | 31, 23<<11 | 59<<5 | 29, time.Date(2107, time.December, 31, 23, 59, 58, 0, time.Local)},
+		{"not set", 0, 0, time.Time{}},
+		{"invalid month", 44<<9 | 13<<5 | 1, 0, time.Time{}},
+		{"invalid hour", 44<<9 | 1<<5 | 1, 24 << 11, time.Time{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := dosTime(tt.date, tt.time); !got.Equal(tt.want) {
+				t.Errorf("dosTime() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
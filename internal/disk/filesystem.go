@@ -0,0 +1,172 @@
+// Copyright (c) 2025 Stefano Scafiti
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package disk
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+)
+
+const (
+	FSTypeUnknown FSType = iota // No known filesystem was detected
+	FSTypeRaw                   // Unpartitioned data, scanned as a whole
+	FSTypeFAT12
+	FSTypeFAT16
+	FSTypeFAT32
+	FSTypeExFAT
+	FSTypeNTFS
+	FSTypeExt2
+	FSTypeExt3
+	FSTypeExt4
+	FSTypeHFSPlus
+	FSTypeAPFS
+)
+
+// String returns the name of the filesystem type.
+func (t FSType) String() string {
+	switch t {
+	case FSTypeRaw:
+		return "raw"
+	case FSTypeFAT12:
+		return "FAT12"
+	case FSTypeFAT16:
+		return "FAT16"
+	case FSTypeFAT32:
+		return "FAT32"
+	case FSTypeExFAT:
+		return "exFAT"
+	case FSTypeNTFS:
+		return "NTFS"
+	case FSTypeExt2:
+		return "ext2"
+	case FSTypeExt3:
+		return "ext3"
+	case FSTypeExt4:
+		return "ext4"
+	case FSTypeHFSPlus:
+		return "HFS+"
+	case FSTypeAPFS:
+		return "APFS"
+	default:
+		return "unknown"
+	}
+}
+
+const (
+	// extSuperblockOffset is the offset of the ext2/3/4 superblock from the start of the volume.
+	extSuperblockOffset = 1024
+	// extMagic is the magic number stored at offset 0x38 of the ext2/3/4 superblock.
+	extMagic = 0xEF53
+
+	extFeatureCompatHasJournal = 0x0004 // ext3 and later keep a journal
+	extFeatureIncompatExtents  = 0x0040 // ext4 files use extents
+	extFeatureIncompat64Bit    = 0x0080 // ext4 64-bit block numbers
+	extFeatureIncompatFlexBG   = 0x0200 // ext4 flexible block groups
+
+	// hfsVolumeHeaderOffset is the offset of the HFS+ volume header from the start of the volume.
+	hfsVolumeHeaderOffset = 1024
+	// apfsMagicOffset is the offset of the magic number within the APFS container superblock.
+	apfsMagicOffset = 32
+)
+
+// DetectFSType detects the filesystem of the volume starting at the given offset,
+// by looking at its boot sector and superblock.
+func DetectFSType(r io.ReaderAt, offset uint64) FSType {
+	var buf [extSuperblockOffset + 1024]byte
+
+	n, err := r.ReadAt(buf[:], int64(offset))
+	if err != nil && err != io.EOF {
+		return FSTypeUnknown
+	}
+	data := buf[:n]
+
+	if len(data) < Fat1xBootSectorSize {
+		return FSTypeUnknown
+	}
+
+	switch {
+	case bytes.Equal(data[3:11], []byte("NTFS    ")):
+		return FSTypeNTFS
+	case bytes.Equal(data[3:11], []byte("EXFAT   ")):
+		return FSTypeExFAT
+	case bytes.Equal(data[apfsMagicOffset:apfsMagicOffset+4], []byte("NXSB")):
+		return FSTypeAPFS
+	}
+
+	if binary.LittleEndian.Uint16(data[0x1FE:0x200]) == 0xAA55 {
+		switch {
+		case bytes.Equal(data[0x36:0x3E], []byte("FAT12   ")):
+			return FSTypeFAT12
+		case bytes.Equal(data[0x36:0x3E], []byte("FAT16   ")):
+			return FSTypeFAT16
+		case bytes.Equal(data[0x52:0x5A], []byte("FAT32   ")):
+			return FSTypeFAT32
+		}
+	}
+
+	if len(data) < extSuperblockOffset+0x64 {
+		return FSTypeUnknown
+	}
+
+	if hfs := data[hfsVolumeHeaderOffset:]; bytes.Equal(hfs[:2], []byte("H+")) || bytes.Equal(hfs[:2], []byte("HX")) {
+		return FSTypeHFSPlus
+	}
+
+	sb := data[extSuperblockOffset:]
+	if binary.LittleEndian.Uint16(sb[0x38:0x3A]) != extMagic {
+		return FSTypeUnknown
+	}
+
+	featureCompat := binary.LittleEndian.Uint32(sb[0x5C:0x60])
+	featureIncompat := binary.LittleEndian.Uint32(sb[0x60:0x64])
+
+	switch {
+	case featureIncompat&(extFeatureIncompatExtents|extFeatureIncompat64Bit|extFeatureIncompatFlexBG) != 0:
+		return FSTypeExt4
+	case featureCompat&extFeatureCompatHasJournal != 0:
+		return FSTypeExt3
+	default:
+		return FSTypeExt2
+	}
+}
+
+// FSBlockSize returns the block size of the filesystem of the given type starting at the
+// given offset, as stored in its superblock. It returns 0 if the block size is unknown.
+func FSBlockSize(r io.ReaderAt, offset uint64, t FSType) uint32 {
+	var buf [4]byte
+
+	switch t {
+	case FSTypeHFSPlus:
+		// The allocation block size is stored big-endian at offset 0x28 of the volume header.
+		if _, err := r.ReadAt(buf[:], int64(offset)+hfsVolumeHeaderOffset+0x28); err != nil {
+			return 0
+		}
+		return binary.BigEndian.Uint32(buf[:])
+	case FSTypeAPFS:
+		// nx_block_size follows the magic number in the container superblock.
+		if _, err := r.ReadAt(buf[:], int64(offset)+apfsMagicOffset+4); err != nil {
+			return 0
+		}
+		return binary.LittleEndian.Uint32(buf[:])
+	default:
+		return 0
+	}
+}
@@ -0,0 +1,182 @@
+// Copyright (c) 2025 Stefano Scafiti
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package disk
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"strings"
+	"unicode/utf16"
+)
+
+// GPTSignature is the signature found at the start of a GPT header.
+const GPTSignature = "EFI PART"
+
+// GPTHeaderSize is the minimum size of a GPT header.
+const GPTHeaderSize = 92
+
+// GPTHeader represents the header of a GUID Partition Table,
+// stored in the sector following the protective MBR.
+type GPTHeader struct {
+	Signature             [8]byte  // 0x00: "EFI PART"
+	Revision              uint32   // 0x08: Header revision
+	HeaderSize            uint32   // 0x0C: Header size in bytes
+	HeaderCRC32           uint32   // 0x10: CRC32 of the header
+	Reserved              uint32   // 0x14: Must be zero
+	CurrentLBA            uint64   // 0x18: LBA of this header
+	BackupLBA             uint64   // 0x20: LBA of the backup header
+	FirstUsableLBA        uint64   // 0x28: First LBA usable by partitions
+	LastUsableLBA         uint64   // 0x30: Last LBA usable by partitions
+	DiskGUID              [16]byte // 0x38: Disk GUID
+	PartitionEntriesLBA   uint64   // 0x48: Starting LBA of the partition entries array
+	NumPartitionEntries   uint32   // 0x50: Number of partition entries
+	PartitionEntrySize    uint32   // 0x54: Size of a partition entry in bytes
+	PartitionEntriesCRC32 uint32   // 0x58: CRC32 of the partition entries array
+}
+
+// PartitionEntriesSize returns the size in bytes of the partition entries array.
+func (h *GPTHeader) PartitionEntriesSize() uint64 {
+	return uint64(h.NumPartitionEntries) * uint64(h.PartitionEntrySize)
+}
+
+// ParseGPTHeader parses a GPT header from the given data, which must contain at least GPTHeaderSize bytes.
+func ParseGPTHeader(data []byte) (*GPTHeader, error) {
+	if len(data) < GPTHeaderSize {
+		return nil, fmt.Errorf("input data too short for a GPT header: expected at least %d bytes, got %d bytes", GPTHeaderSize, len(data))
+	}
+
+	var h GPTHeader
+	copy(h.Signature[:], data[0x00:0x08])
+	if string(h.Signature[:]) != GPTSignature {
+		return nil, fmt.Errorf("invalid GPT signature: %q", h.Signature[:])
+	}
+
+	h.Revision = binary.LittleEndian.Uint32(data[0x08:0x0C])
+	h.HeaderSize = binary.LittleEndian.Uint32(data[0x0C:0x10])
+	h.HeaderCRC32 = binary.LittleEndian.Uint32(data[0x10:0x14])
+	h.Reserved = binary.LittleEndian.Uint32(data[0x14:0x18])
+	h.CurrentLBA = binary.LittleEndian.Uint64(data[0x18:0x20])
+	h.BackupLBA = binary.LittleEndian.Uint64(data[0x20:0x28])
+	h.FirstUsableLBA = binary.LittleEndian.Uint64(data[0x28:0x30])
+	h.LastUsableLBA = binary.LittleEndian.Uint64(data[0x30:0x38])
+	copy(h.DiskGUID[:], data[0x38:0x48])
+	h.PartitionEntriesLBA = binary.LittleEndian.Uint64(data[0x48:0x50])
+	h.NumPartitionEntries = binary.LittleEndian.Uint32(data[0x50:0x54])
+	h.PartitionEntrySize = binary.LittleEndian.Uint32(data[0x54:0x58])
+	h.PartitionEntriesCRC32 = binary.LittleEndian.Uint32(data[0x58:0x5C])
+	return &h, nil
+}
+
+// VerifyCRC reports whether the CRC32 of the header matches data, holding the header
+// as read from the disk.
+func (h *GPTHeader) VerifyCRC(data []byte) bool {
+	if h.HeaderSize < GPTHeaderSize || uint64(h.HeaderSize) > uint64(len(data)) {
+		return false
+	}
+
+	// The CRC is computed with the CRC field set to zero.
+	hdr := bytes.Clone(data[:h.HeaderSize])
+	clear(hdr[0x10:0x14])
+	return crc32.ChecksumIEEE(hdr) == h.HeaderCRC32
+}
+
+// gptSectorSizes are the logical sector sizes probed by ReadGPTHeader.
+var gptSectorSizes = []uint32{DefaultBlocksize, 4096}
+
+// ReadGPTHeader reads the primary GPT header, stored at LBA 1, and detects the logical
+// sector size of the disk: the header is searched at byte offsets 512 and 4096 (as on
+// 4Kn drives), and the first one with a valid CRC is returned with its sector size.
+func ReadGPTHeader(r io.ReaderAt) (*GPTHeader, uint32, error) {
+	for _, sectorSize := range gptSectorSizes {
+		buf := make([]byte, sectorSize)
+		if _, err := r.ReadAt(buf, int64(sectorSize)); err != nil {
+			continue
+		}
+
+		h, err := ParseGPTHeader(buf)
+		if err != nil || h.CurrentLBA != 1 || !h.VerifyCRC(buf) {
+			continue
+		}
+		return h, sectorSize, nil
+	}
+	return nil, 0, fmt.Errorf("no valid GPT header found")
+}
+
+// GPTPartitionEntrySize is the minimum size of a GPT partition entry.
+const GPTPartitionEntrySize = 128
+
+// GPTPartitionEntry represents an entry of the GPT partition entries array.
+type GPTPartitionEntry struct {
+	Index      uint32   // Position of the entry in the array
+	TypeGUID   [16]byte // 0x00: Partition type GUID, all zeros for unused entries
+	UniqueGUID [16]byte // 0x10: Unique partition GUID
+	FirstLBA   uint64   // 0x20: First LBA of the partition
+	LastLBA    uint64   // 0x28: Last LBA of the partition (inclusive)
+	Attributes uint64   // 0x30: Attribute flags
+	Name       string   // 0x38: Partition name (UTF-16LE, 36 code units)
+}
+
+// Used reports whether the entry describes a partition.
+func (e *GPTPartitionEntry) Used() bool {
+	return e.TypeGUID != [16]byte{}
+}
+
+// ParseGPTPartitionEntries parses the used entries of a GPT partition entries array,
+// described by the given header, from data.
+func ParseGPTPartitionEntries(h *GPTHeader, data []byte) ([]GPTPartitionEntry, error) {
+	if h.PartitionEntrySize < GPTPartitionEntrySize {
+		return nil, fmt.Errorf("invalid GPT partition entry size: %d", h.PartitionEntrySize)
+	}
+
+	if uint64(len(data)) < h.PartitionEntriesSize() {
+		return nil, fmt.Errorf("input data too short for GPT partition entries: expected %d bytes, got %d bytes", h.PartitionEntriesSize(), len(data))
+	}
+
+	var entries []GPTPartitionEntry
+	for i := uint32(0); i < h.NumPartitionEntries; i++ {
+		data := data[uint64(i)*uint64(h.PartitionEntrySize):]
+
+		e := GPTPartitionEntry{Index: i}
+		copy(e.TypeGUID[:], data[0x00:0x10])
+		if !e.Used() {
+			continue
+		}
+
+		copy(e.UniqueGUID[:], data[0x10:0x20])
+		e.FirstLBA = binary.LittleEndian.Uint64(data[0x20:0x28])
+		e.LastLBA = binary.LittleEndian.Uint64(data[0x28:0x30])
+		e.Attributes = binary.LittleEndian.Uint64(data[0x30:0x38])
+
+		name := make([]uint16, 36)
+		for j := range name {
+			name[j] = binary.LittleEndian.Uint16(data[0x38+2*j:])
+		}
+		e.Name = strings.TrimRight(string(utf16.Decode(name)), "\x00")
+
+		if e.LastLBA < e.FirstLBA {
+			return nil, fmt.Errorf("invalid GPT partition entry %d: last LBA %d precedes first LBA %d", i, e.LastLBA, e.FirstLBA)
+		}
+		entries = append(entries, e)
+	}
+	return entries, nil
+}
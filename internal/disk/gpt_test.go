@@ -0,0 +1,158 @@
+package disk
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"testing"
+	"unicode/utf16"
+)
+
+func gptEntry(typeByte byte, first, last uint64, name string) []byte {
+	e := make([]byte, GPTPartitionEntrySize)
+	if typeByte != 0 {
+		e[0x00] = typeByte
+		e[0x10] = 0xAB
+	}
+	binary.LittleEndian.PutUint64(e[0x20:], first)
+	binary.LittleEndian.PutUint64(e[0x28:], last)
+	for i, c := range utf16.Encode([]rune(name)) {
+		binary.LittleEndian.PutUint16(e[0x38+2*i:], c)
+	}
+	return e
+}
+
+func TestParseGPTPartitionEntries(t *testing.T) {
+	var data []byte
+	data = append(data, gptEntry(1, 40, 119, "Mac HD")...)
+	data = append(data, gptEntry(0, 0, 0, "")...)
+	data = append(data, gptEntry(2, 120, 199, "Container")...)
+
+	h := &GPTHeader{NumPartitionEntries: 3, PartitionEntrySize: GPTPartitionEntrySize}
+
+	entries, err := ParseGPTPartitionEntries(h, data)
+	if err != nil {
+		t.Fatalf("ParseGPTPartitionEntries() error = %v", err)
+	}
+
+	if len(entries) != 2 {
+		t.Fatalf("len(entries) = %d, want 2", len(entries))
+	}
+
+	want := []struct {
+		index       uint32
+		first, last uint64
+		name        string
+	}{
+		{0, 40, 119, "Mac HD"},
+		{2, 120, 199, "Container"},
+	}
+	for i, w := range want {
+		e := entries[i]
+		if e.Index != w.index || e.FirstLBA != w.first || e.LastLBA != w.last || e.Name != w.name {
+			t.Errorf("entries[%d] = {%d, %d, %d, %q}, want {%d, %d, %d, %q}",
+				i, e.Index, e.FirstLBA, e.LastLBA, e.Name, w.index, w.first, w.last, w.name)
+		}
+	}
+}
+
+func TestParseGPTPartitionEntriesInvalid(t *testing.T) {
+	h := &GPTHeader{NumPartitionEntries: 1, PartitionEntrySize: GPTPartitionEntrySize}
+
+	if _, err := ParseGPTPartitionEntries(h, gptEntry(1, 100, 99, "")); err == nil {
+		t.Errorf("ParseGPTPartitionEntries() error = nil for last LBA preceding first LBA")
+	}
+
+	if _, err := ParseGPTPartitionEntries(h, make([]byte, GPTPartitionEntrySize-1)); err == nil {
+		t.Errorf("ParseGPTPartitionEntries() error = nil for truncated data")
+	}
+}
+
+func TestDetectFSTypeApple(t *testing.T) {
+	hfs := make([]byte, 4096)
+	copy(hfs[hfsVolumeHeaderOffset:], "H+")
+	binary.BigEndian.PutUint32(hfs[hfsVolumeHeaderOffset+0x28:], 4096)
+
+	apfs := make([]byte, 4096)
+	copy(apfs[apfsMagicOffset:], "NXSB")
+	binary.LittleEndian.PutUint32(apfs[apfsMagicOffset+4:], 16384)
+
+	tests := []struct {
+		name      string
+		data      []byte
+		fsType    FSType
+		blockSize uint32
+	}{
+		{"HFS+", hfs, FSTypeHFSPlus, 4096},
+		{"APFS", apfs, FSTypeAPFS, 16384},
+		{"empty", make([]byte, 4096), FSTypeUnknown, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := bytes.NewReader(tt.data)
+
+			fsType := DetectFSType(r, 0)
+			if fsType != tt.fsType {
+				t.Errorf("DetectFSType() = %s, want %s", fsType, tt.fsType)
+			}
+
+			if got := FSBlockSize(r, 0, fsType); got != tt.blockSize {
+				t.Errorf("FSBlockSize() = %d, want %d", got, tt.blockSize)
+			}
+		})
+	}
+}
+
+// gptHeader returns a GPT header at LBA 1, with a valid CRC, padded to the sector size.
+func gptHeader(sectorSize int) []byte {
+	h := make([]byte, sectorSize)
+	copy(h, GPTSignature)
+	binary.LittleEndian.PutUint32(h[0x0C:], GPTHeaderSize)
+	binary.LittleEndian.PutUint64(h[0x18:], 1)
+	binary.LittleEndian.PutUint64(h[0x48:], 2)
+	binary.LittleEndian.PutUint32(h[0x50:], 128)
+	binary.LittleEndian.PutUint32(h[0x54:], GPTPartitionEntrySize)
+	binary.LittleEndian.PutUint32(h[0x10:], crc32.ChecksumIEEE(h[:GPTHeaderSize]))
+	return h
+}
+
+func TestReadGPTHeader(t *testing.T) {
+	corrupted := gptHeader(512)
+	corrupted[0x50]++
+
+	tests := []struct {
+		name       string
+		sectorSize int
+		header     []byte
+		wantErr    bool
+	}{
+		{"512-byte sectors", 512, gptHeader(512), false},
+		{"4Kn", 4096, gptHeader(4096), false},
+		{"invalid CRC", 512, corrupted, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			img := make([]byte, 3*tt.sectorSize)
+			copy(img[tt.sectorSize:], tt.header)
+
+			h, sectorSize, err := ReadGPTHeader(bytes.NewReader(img))
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("ReadGPTHeader() error = nil, want error")
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("ReadGPTHeader() error = %v", err)
+			}
+
+			if sectorSize != uint32(tt.sectorSize) || h.PartitionEntriesLBA != 2 {
+				t.Errorf("ReadGPTHeader() = {entries LBA %d}, %d; want {entries LBA 2}, %d",
+					h.PartitionEntriesLBA, sectorSize, tt.sectorSize)
+			}
+		})
+	}
+}
@@ -21,6 +21,7 @@ package disk
 
 import (
 	"encoding/binary"
+	"errors"
 	"fmt"
 
 	fmtutils "github.com/ostafen/digler/pkg/util/format"
@@ -60,7 +61,7 @@ func (p *MBRPartitionEntry) String() string {
 		"  Total Sectors: %d\n"+
 		"  Size: %d bytes (%s)",
 		bootable, p.BootIndicator,
-		p.PartitionType, getPartitionTypeName(p.PartitionType),
+		uint8(p.PartitionType), getPartitionTypeName(p.PartitionType),
 		p.ReadStartLBA(),
 		p.ReadTotalSectors(),
 		p.ReadTotalSectors()*512, // Assuming 512 bytes per sector
@@ -143,32 +144,82 @@ func ParseMBR(data []byte) (*MBR, error) {
 	return &mbr, nil
 }
 
+var (
+	ErrPartitionOutOfBounds = errors.New("partition extends past the end of the disk")
+	ErrPartitionOverlap     = errors.New("partition overlaps another partition")
+)
+
+// IsEmpty reports whether the entry does not describe any partition.
+func (p *MBRPartitionEntry) IsEmpty() bool {
+	return p.PartitionType == PartitionTypeEmpty || p.ReadTotalSectors() == 0
+}
+
+// Validate checks the partition table against a disk of diskSectors sectors,
+// returning, for each entry, the reason why it is invalid, or nil.
+// An entry is invalid if it extends past the end of the disk or if its sectors
+// overlap the ones of a preceding entry. Empty entries are always valid.
+func (m *MBR) Validate(diskSectors uint64) [4]error {
+	var errs [4]error
+	for i := range m.PartitionEntries {
+		p := &m.PartitionEntries[i]
+		if p.IsEmpty() {
+			continue
+		}
+
+		start := uint64(p.ReadStartLBA())
+		end := start + uint64(p.ReadTotalSectors())
+		if end > diskSectors {
+			errs[i] = fmt.Errorf("%w: sectors %d-%d, disk has %d sectors", ErrPartitionOutOfBounds, start, end, diskSectors)
+			continue
+		}
+
+		for j := 0; j < i; j++ {
+			q := &m.PartitionEntries[j]
+			if q.IsEmpty() {
+				continue
+			}
+
+			qStart := uint64(q.ReadStartLBA())
+			qEnd := qStart + uint64(q.ReadTotalSectors())
+			if start < qEnd && qStart < end {
+				errs[i] = fmt.Errorf("%w: sectors %d-%d overlap entry %d (sectors %d-%d)", ErrPartitionOverlap, start, end, j, qStart, qEnd)
+				break
+			}
+		}
+	}
+	return errs
+}
+
 type MBRPartition uint8
 
+// Partition type IDs of MBR partition table entries.
 const (
-	PartitionTypeEmpty MBRPartition = iota
-	PartitionTypeFAT12
-	PartitionTypeXENIXRoot
-	PartitionTypeXENIXUsr
-	PartitionTypeFAT16LessThan32MB
-	PartitionTypeExtendedCHS
-	PartitionTypeFAT16GreaterThan32MB
-	PartitionTypeNTFSHPFSexFATQNX
-	PartitionTypeAIX
-	PartitionTypeAIXBootable
-	PartitionTypeOs2BootManager
-	PartitionTypeFAT32CHS
-	PartitionTypeFAT32LBA
-	PartitionTypeFAT16LBA
-	PartitionTypeUnknown
-	PartitionTypeExtendedLBA
-	PartitionTypeLinuxSwap
-	PartitionTypeLinuxFilesystem
-	PartitionTypeGPTProtectiveMBR
-	PartitionTypeEFISystemPartition
-	PartitionTypeGPT = 0xEE
+	PartitionTypeEmpty                MBRPartition = 0x00
+	PartitionTypeFAT12                MBRPartition = 0x01
+	PartitionTypeXENIXRoot            MBRPartition = 0x02
+	PartitionTypeXENIXUsr             MBRPartition = 0x03
+	PartitionTypeFAT16LessThan32MB    MBRPartition = 0x04
+	PartitionTypeExtendedCHS          MBRPartition = 0x05
+	PartitionTypeFAT16GreaterThan32MB MBRPartition = 0x06
+	PartitionTypeNTFSHPFSexFATQNX     MBRPartition = 0x07
+	PartitionTypeAIX                  MBRPartition = 0x08
+	PartitionTypeAIXBootable          MBRPartition = 0x09
+	PartitionTypeOs2BootManager       MBRPartition = 0x0A
+	PartitionTypeFAT32CHS             MBRPartition = 0x0B
+	PartitionTypeFAT32LBA             MBRPartition = 0x0C
+	PartitionTypeFAT16LBA             MBRPartition = 0x0E
+	PartitionTypeExtendedLBA          MBRPartition = 0x0F
+	PartitionTypeLinuxSwap            MBRPartition = 0x82
+	PartitionTypeLinuxFilesystem      MBRPartition = 0x83
+	PartitionTypeGPT                  MBRPartition = 0xEE // GPT protective MBR
+	PartitionTypeEFISystemPartition   MBRPartition = 0xEF
 )
 
+// String returns the name of the partition type.
+func (t MBRPartition) String() string {
+	return getPartitionTypeName(t)
+}
+
 // Helper function to map common partition type IDs to names
 func getPartitionTypeName(id MBRPartition) string {
 	switch id {
@@ -196,10 +247,10 @@ func getPartitionTypeName(id MBRPartition) string {
 		return "Linux swap"
 	case PartitionTypeLinuxFilesystem:
 		return "Linux filesystem"
-	case PartitionTypeGPTProtectiveMBR:
-		return "GPT Protective MBR"
 	case PartitionTypeEFISystemPartition:
 		return "EFI System Partition"
+	case PartitionTypeGPT:
+		return "GPT"
 	default:
 		return "Unknown"
 	}
@@ -0,0 +1,70 @@
+package disk
+
+import (
+	"encoding/binary"
+	"errors"
+	"testing"
+)
+
+func mbrEntry(typ MBRPartition, start, sectors uint32) MBRPartitionEntry {
+	e := MBRPartitionEntry{PartitionType: typ}
+	binary.LittleEndian.PutUint32(e.StartLBA[:], start)
+	binary.LittleEndian.PutUint32(e.TotalSectors[:], sectors)
+	return e
+}
+
+func TestMBRValidate(t *testing.T) {
+	const diskSectors = 1000
+
+	tests := []struct {
+		name    string
+		entries [4]MBRPartitionEntry
+		want    [4]error
+	}{
+		{
+			name: "valid",
+			entries: [4]MBRPartitionEntry{
+				mbrEntry(PartitionTypeFAT32LBA, 1, 499),
+				mbrEntry(PartitionTypeFAT16LBA, 500, 500),
+			},
+		},
+		{
+			name: "out of bounds",
+			entries: [4]MBRPartitionEntry{
+				mbrEntry(PartitionTypeFAT32LBA, 1, 499),
+				mbrEntry(PartitionTypeFAT16LBA, 500, 501),
+			},
+			want: [4]error{nil, ErrPartitionOutOfBounds},
+		},
+		{
+			name: "overlap",
+			entries: [4]MBRPartitionEntry{
+				mbrEntry(PartitionTypeFAT32LBA, 100, 400),
+				mbrEntry(PartitionTypeFAT16LBA, 600, 100),
+				mbrEntry(PartitionTypeFAT12, 499, 10),
+			},
+			want: [4]error{nil, nil, ErrPartitionOverlap},
+		},
+		{
+			name: "empty entries",
+			entries: [4]MBRPartitionEntry{
+				mbrEntry(PartitionTypeFAT32LBA, 1, 999),
+				mbrEntry(PartitionTypeEmpty, 1, 999),
+				mbrEntry(PartitionTypeFAT12, 1, 0),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mbr := MBR{PartitionEntries: tt.entries}
+
+			errs := mbr.Validate(diskSectors)
+			for i := range errs {
+				if !errors.Is(errs[i], tt.want[i]) || (errs[i] == nil) != (tt.want[i] == nil) {
+					t.Errorf("entry %d: got error %v, want %v", i, errs[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
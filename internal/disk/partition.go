@@ -26,8 +26,21 @@ type (
 
 type Partition struct {
 	FSType    FSType
+	Type      MBRPartition // Type of the partition table entry, or PartitionTypeEmpty if the disk is not partitioned
 	Num       int
-	Offset    uint64 // Offset in bytes from the start of the disk
-	Size      uint64 // Size in bytes of the partition
-	BlockSize uint32 // Block size in bytes
+	Offset    uint64   // Offset in bytes from the start of the disk
+	Size      uint64   // Size in bytes of the partition
+	BlockSize uint32   // Block size in bytes
+	Metadata  []Region // Regions holding partitioning or filesystem metadata, such as boot sectors
+	Err       error    // Reason why the partition table entry is invalid, or nil
+
+	// Unallocated is set for the synthetic partitions covering the space of the disk which
+	// no partition table entry describes, such as the areas of deleted partitions.
+	Unallocated bool
+}
+
+// Region is a byte range, relative to the start of a partition.
+type Region struct {
+	Offset uint64
+	Size   uint64
 }
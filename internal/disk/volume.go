@@ -20,33 +20,129 @@
 package disk
 
 import (
+	"fmt"
+	"os"
+	"path/filepath"
 	"runtime"
 	"strings"
 	"unicode"
 )
 
-// NormalizeVolumePath checks if a given path is a Windows volume path
-// and normalizes it to \\.\C: format if running on Windows.
+// NormalizeVolumePath expands a leading ~ to the home directory of the user, as shells do for
+// unquoted paths only. Then, if running on Windows, it checks if the path is a volume or physical
+// drive path and normalizes it to the \\.\C: or \\.\PhysicalDrive0 format.
 // Otherwise, returns the path unchanged.
 func NormalizeVolumePath(path string) string {
+	if home, err := os.UserHomeDir(); err == nil {
+		path = expandHome(path, home)
+	}
+
 	if runtime.GOOS != "windows" {
 		return path // Only normalize on Windows
 	}
+	return normalizeWindowsVolumePath(path)
+}
+
+// ResolvePath returns the canonical path of a disk image or device: an absolute path with
+// symbolic links resolved, e.g. /dev/sda for /dev/disk/by-id/ata-..., so that the scanned
+// device is identified unambiguously. The path is returned unchanged if it cannot be
+// resolved, as for Windows raw device paths.
+func ResolvePath(path string) string {
+	if runtime.GOOS == "windows" && IsDevicePath(path) {
+		return path
+	}
+
+	resolved, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		return path
+	}
+
+	abs, err := filepath.Abs(resolved)
+	if err != nil {
+		return resolved
+	}
+	return abs
+}
+
+// CheckVolumePath looks for likely mistakes in the path of a disk image or device, such as
+// a regular file under /dev, which is left behind by a write to a mistyped device, or the bare
+// name of a device. It returns a warning describing the mistake, or an empty string.
+// Paths which cannot be inspected are not reported: opening them reports the error.
+func CheckVolumePath(path string) string {
+	finfo, err := os.Stat(path)
+	if os.IsNotExist(err) && runtime.GOOS != "windows" && !strings.ContainsRune(path, filepath.Separator) {
+		if dev := filepath.Join("/dev", path); isDevice(dev) {
+			return fmt.Sprintf("%s does not exist: did you mean the device %s?", path, dev)
+		}
+	}
+	if err != nil {
+		return ""
+	}
+
+	if IsDevicePath(path) && finfo.Mode().IsRegular() {
+		return fmt.Sprintf("%s is a regular file, not a device: it is scanned as a disk image", path)
+	}
+	return ""
+}
 
+// IsDevicePath reports whether the path names a device, i.e., it is under /dev,
+// or it is a Windows raw device path such as \\.\C: or \\.\PhysicalDrive0.
+func IsDevicePath(path string) bool {
+	if runtime.GOOS == "windows" {
+		return strings.HasPrefix(path, `\\.\`)
+	}
+	return strings.HasPrefix(filepath.Clean(path), "/dev/")
+}
+
+func isDevice(path string) bool {
+	finfo, err := os.Stat(path)
+	return err == nil && finfo.Mode()&os.ModeDevice != 0
+}
+
+// expandHome replaces a leading ~ in the path with the given home directory.
+// Paths such as ~user/disk.img, referring to the home of another user, are left unchanged.
+func expandHome(path, home string) string {
+	if path == "~" {
+		return home
+	}
+	if rest, ok := strings.CutPrefix(path, "~/"); ok {
+		return filepath.Join(home, rest)
+	}
+	if rest, ok := strings.CutPrefix(path, `~\`); ok && runtime.GOOS == "windows" {
+		return filepath.Join(home, rest)
+	}
+	return path
+}
+
+func normalizeWindowsVolumePath(path string) string {
 	path = strings.TrimSpace(path)
 	path = strings.ReplaceAll(path, "/", `\`)
 	upper := strings.ToUpper(path)
 
-	// Already a raw volume path like \\.\C:
+	// Already a raw device path like \\.\C: or \\.\PhysicalDrive0
 	if strings.HasPrefix(upper, `\\.\`) {
 		return upper
 	}
 
+	// Handle physical drives like "PhysicalDrive0"
+	if n, ok := strings.CutPrefix(upper, "PHYSICALDRIVE"); ok && n != "" && isDigits(n) {
+		return `\\.\PhysicalDrive` + n
+	}
+
 	// Handle paths like "C:" or "C:\" (must be drive letter only)
-	if len(upper) >= 2 && upper[1] == ':' && unicode.IsLetter(rune(upper[0])) {
+	if (len(upper) == 2 || (len(upper) == 3 && upper[2] == '\\')) && upper[1] == ':' && unicode.IsLetter(rune(upper[0])) {
 		// Normalize to \\.\C:
-		return `\\.\` + strings.ToUpper(string(upper[0])) + `:`
+		return `\\.\` + string(upper[0]) + `:`
 	}
 
 	return path // Not a volume path
 }
+
+func isDigits(s string) bool {
+	for _, c := range s {
+		if !unicode.IsDigit(c) {
+			return false
+		}
+	}
+	return true
+}
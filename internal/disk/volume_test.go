@@ -0,0 +1,104 @@
+package disk
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestNormalizeWindowsVolumePath(t *testing.T) {
+	tests := []struct {
+		path string
+		want string
+	}{
+		{`C:`, `\\.\C:`},
+		{`d:\`, `\\.\D:`},
+		{`e:/`, `\\.\E:`},
+		{`\\.\c:`, `\\.\C:`},
+		{`PhysicalDrive0`, `\\.\PhysicalDrive0`},
+		{`physicaldrive12`, `\\.\PhysicalDrive12`},
+		{`\\.\PhysicalDrive1`, `\\.\PHYSICALDRIVE1`},
+		{`PhysicalDriveX`, `PhysicalDriveX`},
+		{`C:\images\disk.img`, `C:\images\disk.img`},
+		{`disk.img`, `disk.img`},
+	}
+
+	for _, tt := range tests {
+		if got := normalizeWindowsVolumePath(tt.path); got != tt.want {
+			t.Errorf("normalizeWindowsVolumePath(%q) = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestExpandHome(t *testing.T) {
+	home := filepath.Join("home", "user")
+
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"~", home},
+		{"~/images/disk.img", filepath.Join(home, "images", "disk.img")},
+		{"~other/disk.img", "~other/disk.img"},
+		{"images/~/disk.img", "images/~/disk.img"},
+		{"/dev/sda", "/dev/sda"},
+	}
+
+	for _, tt := range tests {
+		if got := expandHome(tt.path, home); got != tt.want {
+			t.Errorf("expandHome(%q) = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestCheckVolumePath(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("device paths under /dev are not available on Windows")
+	}
+
+	image := filepath.Join(t.TempDir(), "disk.img")
+	if err := os.WriteFile(image, make([]byte, 512), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if warning := CheckVolumePath(image); warning != "" {
+		t.Errorf("unexpected warning for a disk image: %s", warning)
+	}
+	if warning := CheckVolumePath("/dev/null"); warning != "" {
+		t.Errorf("unexpected warning for a device: %s", warning)
+	}
+	if warning := CheckVolumePath("null"); !strings.Contains(warning, "/dev/null") {
+		t.Errorf("expected a warning suggesting /dev/null, got %q", warning)
+	}
+}
+
+func TestResolvePath(t *testing.T) {
+	dir, err := filepath.EvalSymlinks(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	image := filepath.Join(dir, "disk.img")
+	if err := os.WriteFile(image, make([]byte, 512), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	link := filepath.Join(dir, "by-id")
+	if err := os.Symlink(image, link); err != nil {
+		t.Skipf("symbolic links not supported: %s", err)
+	}
+
+	if got := ResolvePath(link); got != image {
+		t.Errorf("ResolvePath(%q) = %q, want %q", link, got, image)
+	}
+	if got := ResolvePath(image); got != image {
+		t.Errorf("ResolvePath(%q) = %q, want %q", image, got, image)
+	}
+
+	missing := filepath.Join(dir, "missing.img")
+	if got := ResolvePath(missing); got != missing {
+		t.Errorf("ResolvePath(%q) = %q, want the path unchanged", missing, got)
+	}
+}
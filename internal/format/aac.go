@@ -0,0 +1,152 @@
+// Copyright (c) 2025 Stefano Scafiti
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package format
+
+import (
+	"fmt"
+	"io"
+)
+
+var aacFileHeader = FileHeader{
+	Ext:         "aac",
+	Description: "Advanced Audio Coding (ADTS stream)",
+	Category:    CategoryAudio,
+	Signatures: [][]byte{
+		{0xFF, 0xF1}, // MPEG-4, no CRC
+		{0xFF, 0xF0}, // MPEG-4, CRC
+		{0xFF, 0xF9}, // MPEG-2, no CRC
+		{0xFF, 0xF8}, // MPEG-2, CRC
+	},
+	ScanFile: ScanAAC,
+}
+
+const (
+	// adtsHeaderSize is the size of an ADTS frame header without CRC.
+	adtsHeaderSize = 7
+	// adtsCRCSize is the size of the CRC following the header when protection_absent is not set.
+	adtsCRCSize = 2
+	// minAACFrames is the minimum number of frames of a stream. Since the sync word is
+	// short, a few consistent frames are required to avoid false positives.
+	minAACFrames = 4
+)
+
+// adtsHeader represents the fields of an ADTS frame header which are
+// constant along a stream, together with the length of the frame.
+type adtsHeader struct {
+	MPEG2           bool // MPEG-2 (rather than MPEG-4) AAC
+	Profile         int  // audio object type minus one
+	SampleRateIndex int
+	ChannelConfig   int
+	HeaderSize      int // 7 bytes, or 9 when a CRC is present
+	FrameLength     int // length of the frame, including the header
+}
+
+// sameStream reports whether h and other may belong to the same stream.
+func (h adtsHeader) sameStream(other adtsHeader) bool {
+	return h.MPEG2 == other.MPEG2 &&
+		h.Profile == other.Profile &&
+		h.SampleRateIndex == other.SampleRateIndex &&
+		h.ChannelConfig == other.ChannelConfig
+}
+
+// parseADTSHeader parses a 7-byte ADTS frame header.
+func parseADTSHeader(b []byte) (adtsHeader, bool) {
+	if len(b) < adtsHeaderSize {
+		return adtsHeader{}, false
+	}
+
+	// Sync word (12 bits) and layer (2 bits), which is always 0.
+	if b[0] != 0xFF || b[1]&0xF6 != 0xF0 {
+		return adtsHeader{}, false
+	}
+
+	h := adtsHeader{
+		MPEG2:           b[1]&0x08 != 0,
+		Profile:         int(b[2] >> 6),
+		SampleRateIndex: int(b[2]>>2) & 0x0F,
+		ChannelConfig:   int(b[2]&0x01)<<2 | int(b[3]>>6),
+		HeaderSize:      adtsHeaderSize,
+		FrameLength:     int(b[3]&0x03)<<11 | int(b[4])<<3 | int(b[5]>>5),
+	}
+
+	if b[1]&0x01 == 0 {
+		h.HeaderSize += adtsCRCSize
+	}
+
+	// Indexes from 13 are reserved, or denote an explicit frequency, not allowed in ADTS.
+	if h.SampleRateIndex > 12 {
+		return adtsHeader{}, false
+	}
+
+	// The reserved profile 3 is not used by ADTS streams.
+	if h.Profile == 3 {
+		return adtsHeader{}, false
+	}
+
+	if h.FrameLength <= h.HeaderSize {
+		return adtsHeader{}, false
+	}
+	return h, true
+}
+
+// ScanAAC scans an AAC stream in ADTS format, which is a sequence of frames, each
+// starting with a header holding its length. Frames are read until EOF, a truncated
+// frame, or a frame whose header is invalid or does not match the first one.
+// A stream ending with a truncated frame is reported as truncated, without it.
+func ScanAAC(r *Reader) (*ScanResult, error) {
+	var (
+		first     adtsHeader
+		buf       [adtsHeaderSize]byte
+		size      uint64
+		numFrames int
+		truncated bool
+	)
+
+	for {
+		if _, err := io.ReadFull(r, buf[:]); err != nil {
+			break
+		}
+
+		h, ok := parseADTSHeader(buf[:])
+		if !ok {
+			break
+		}
+
+		if numFrames == 0 {
+			first = h
+		} else if !h.sameStream(first) {
+			break
+		}
+
+		n, err := r.Discard(h.FrameLength - adtsHeaderSize)
+		if err != nil || n != h.FrameLength-adtsHeaderSize {
+			truncated = err == io.EOF
+			break
+		}
+
+		size += uint64(h.FrameLength)
+		numFrames++
+	}
+
+	if numFrames < minAACFrames {
+		return nil, fmt.Errorf("detected AAC stream is too short (only %d frames)", numFrames)
+	}
+	return &ScanResult{Size: size, Truncated: truncated, Confidence: ConfidenceStructural}, nil
+}
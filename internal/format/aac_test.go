@@ -0,0 +1,94 @@
+package format
+
+import (
+	"bytes"
+	"testing"
+)
+
+// adtsFrame returns an ADTS frame (MPEG-4 AAC LC, 44.1 kHz, stereo) of the given length.
+func adtsFrame(length int, crc bool) []byte {
+	b1 := byte(0xF1)
+	if crc {
+		b1 = 0xF0
+	}
+
+	frame := []byte{
+		0xFF, b1,
+		1<<6 | 4<<2, // profile LC, 44.1 kHz
+		2<<6 | byte(length>>11)&0x03,
+		byte(length >> 3),
+		byte(length<<5) | 0x1F,
+		0xFC,
+	}
+	return append(frame, bytes.Repeat([]byte{0xAA}, length-len(frame))...)
+}
+
+func adtsStream(n, length int, crc bool) []byte {
+	var data []byte
+	for i := 0; i < n; i++ {
+		data = append(data, adtsFrame(length, crc)...)
+	}
+	return data
+}
+
+func TestScanAAC(t *testing.T) {
+	mpeg2 := adtsStream(5, 300, false)
+	for i := 0; i < len(mpeg2); i += 300 {
+		mpeg2[i+1] |= 0x08
+	}
+
+	otherRate := adtsFrame(300, false)
+	otherRate[2] = 1<<6 | 3<<2
+
+	tests := []struct {
+		name      string
+		data      []byte
+		size      int
+		truncated bool
+	}{
+		{"no CRC", adtsStream(10, 371, false), 10 * 371, false},
+		{"CRC", adtsStream(6, 200, true), 6 * 200, false},
+		{"MPEG-2", mpeg2, len(mpeg2), false},
+		{"followed by zeros", append(adtsStream(5, 300, false), make([]byte, 100)...), 5 * 300, false},
+		{"truncated frame", append(adtsStream(5, 300, false), adtsFrame(300, false)[:100]...), 5 * 300, true},
+		{"different sample rate", append(adtsStream(5, 300, false), otherRate...), 5 * 300, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res, err := ScanAAC(newBytesReader(tt.data))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if res.Size != uint64(tt.size) {
+				t.Errorf("expected size %d, got %d", tt.size, res.Size)
+			}
+			if res.Truncated != tt.truncated {
+				t.Errorf("expected truncated %v, got %v", tt.truncated, res.Truncated)
+			}
+		})
+	}
+}
+
+func TestScanAACInvalid(t *testing.T) {
+	reservedRate := adtsStream(5, 300, false)
+	reservedRate[2] = 1<<6 | 13<<2
+
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{"too few frames", adtsStream(minAACFrames-1, 300, false)},
+		{"reserved sample rate", reservedRate},
+		{"frame shorter than header", adtsStream(5, 8, true)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ScanAAC(newBytesReader(tt.data)); err == nil {
+				t.Errorf("expected an error")
+			}
+		})
+	}
+}
@@ -28,6 +28,7 @@ import (
 var sunAudioFileHeader = FileHeader{
 	Ext:         "au",
 	Description: "Audio file format developed by Sun Microsystems",
+	Category:    CategoryAudio,
 	Signatures: [][]byte{
 		{0x2E, 0x73, 0x6E, 0x64},
 	},
@@ -98,7 +99,7 @@ func ScanSunAudio(r *Reader) (*ScanResult, error) {
 	var totalAUSize uint64
 	if dataSize == AU_DATA_SIZE_UNKNOWN {
 		// Data size is not explicitly defined. Return an error for now.
-		return &ScanResult{Size: bytesRead}, fmt.Errorf("unknown AU file size")
+		return &ScanResult{Size: bytesRead, Confidence: ConfidenceHeaderOnly}, fmt.Errorf("unknown AU file size")
 	}
 
 	totalAUSize = uint64(headerSize) + uint64(dataSize)
@@ -110,11 +111,11 @@ func ScanSunAudio(r *Reader) (*ScanResult, error) {
 		if err != nil {
 			if err == io.EOF && skipped < bytesToSkip {
 				// Data chunk is truncated. The valid AU ends here.
-				return &ScanResult{Size: bytesRead + uint64(skipped)}, nil
+				return &ScanResult{Size: bytesRead + uint64(skipped), Truncated: true, Confidence: ConfidenceHeaderOnly}, nil
 			}
 			return nil, fmt.Errorf("failed to skip AU data: %w", err)
 		}
 		bytesRead += uint64(skipped)
 	}
-	return &ScanResult{Size: totalAUSize}, nil
+	return &ScanResult{Size: totalAUSize, Confidence: ConfidenceHeaderOnly}, nil
 }
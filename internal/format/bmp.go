@@ -30,6 +30,8 @@ import (
 var bmpFileHeader = FileHeader{
 	Ext:         "bmp",
 	Description: "Bitmap Image File Format",
+	Category:    CategoryImage,
+	Entropy:     EntropyLow,
 	Signatures: [][]byte{
 		[]byte("BM"),
 	},
@@ -256,5 +258,5 @@ func ScanBMP(r *Reader) (*ScanResult, error) {
 	if bmpHeader.FileSize < expectedTotalSize {
 		return nil, fmt.Errorf("inconsistent file size: header states %d, but expected at least %d based on data offset and image size", bmpHeader.FileSize, expectedTotalSize)
 	}
-	return &ScanResult{Size: uint64(bmpHeader.FileSize)}, nil
+	return &ScanResult{Size: uint64(bmpHeader.FileSize), Confidence: ConfidenceStructural}, nil
 }
@@ -0,0 +1,75 @@
+// Copyright (c) 2025 Stefano Scafiti
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package format
+
+import "bytes"
+
+// This file holds the tables used to infer the extension of media containers
+// (e.g., Ogg, Matroska) from the codecs of their streams.
+
+// oggCodecs maps the start of the first packet of a logical stream, held by its BOS page,
+// to the extension of the file.
+var oggCodecs = []struct {
+	magic []byte
+	ext   string
+}{
+	{[]byte("OpusHead"), "opus"},
+	{[]byte("\x01vorbis"), "ogg"},
+	{[]byte("Speex   "), "spx"},
+	{[]byte("\x80theora"), "ogv"},
+}
+
+// webmCodecs holds the Matroska codec IDs allowed in WebM files.
+var webmCodecs = map[string]bool{
+	"V_VP8":    true,
+	"V_VP9":    true,
+	"V_AV1":    true,
+	"A_VORBIS": true,
+	"A_OPUS":   true,
+}
+
+// oggCodecExt returns the extension of an Ogg file given the first packet of its first logical stream.
+func oggCodecExt(packet []byte) string {
+	for _, c := range oggCodecs {
+		if bytes.HasPrefix(packet, c.magic) {
+			return c.ext
+		}
+	}
+	return "ogg"
+}
+
+// matroskaCodecExt returns the extension of a Matroska file given its DocType and the codec
+// IDs of its tracks: files declaring the "webm" DocType, or whose tracks only use WebM codecs,
+// are WebM files.
+func matroskaCodecExt(docType string, codecs []string) string {
+	if docType == "webm" {
+		return "webm"
+	}
+	if len(codecs) == 0 {
+		return "mkv"
+	}
+
+	for _, c := range codecs {
+		if !webmCodecs[c] {
+			return "mkv"
+		}
+	}
+	return "webm"
+}
@@ -0,0 +1,68 @@
+// Copyright (c) 2025 Stefano Scafiti
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package format
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+var djvuFileHeader = FileHeader{
+	Ext:         "djvu",
+	Description: "DjVu scanned document",
+	Category:    CategoryDocument,
+	Signatures: [][]byte{
+		[]byte("AT&TFORM"),
+	},
+	ScanFile: ScanDjVu,
+}
+
+// djvuHeaderSize is the size of the "AT&T" preamble, followed by the
+// FORM chunk ID, its big-endian length and the form type.
+const djvuHeaderSize = 16
+
+// ScanDjVu scans a DjVu document, made of a single IFF FORM chunk preceded by
+// the "AT&T" preamble. The FORM length covers the whole document, including all
+// the pages of multi-page (DJVM) documents, so the size is read from the header.
+func ScanDjVu(r *Reader) (*ScanResult, error) {
+	var hdr [djvuHeaderSize]byte
+	if _, err := io.ReadFull(r, hdr[:]); err != nil {
+		return nil, fmt.Errorf("failed to read DjVu header: %w", err)
+	}
+
+	if string(hdr[0:8]) != "AT&TFORM" {
+		return nil, fmt.Errorf("reader does not start with DjVu signature")
+	}
+
+	switch formType := string(hdr[12:16]); formType {
+	case "DJVU", "DJVM", "DJVI":
+	default:
+		return nil, fmt.Errorf("unsupported DjVu form type %q", formType)
+	}
+
+	length := binary.BigEndian.Uint32(hdr[8:12])
+	if length < 4 {
+		return nil, fmt.Errorf("invalid DjVu FORM length: %d", length)
+	}
+
+	// The preamble and the FORM chunk header are not included in the length.
+	return &ScanResult{Size: 12 + uint64(length), Confidence: ConfidenceHeaderOnly}, nil
+}
@@ -0,0 +1,31 @@
+package format
+
+import (
+	"encoding/binary"
+	"testing"
+)
+
+func djvuFixture(formType string, body int) []byte {
+	data := []byte("AT&TFORM\x00\x00\x00\x00" + formType)
+	binary.BigEndian.PutUint32(data[8:12], uint32(4+body))
+	return append(data, make([]byte, body)...)
+}
+
+func TestScanDjVu(t *testing.T) {
+	for _, formType := range []string{"DJVU", "DJVM", "DJVI"} {
+		data := djvuFixture(formType, 100)
+
+		res, err := ScanDjVu(newBytesReader(append(data, "trailing data"...)))
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", formType, err)
+		}
+
+		if res.Size != uint64(len(data)) {
+			t.Errorf("%s: expected size %d, got %d", formType, len(data), res.Size)
+		}
+	}
+
+	if _, err := ScanDjVu(newBytesReader(djvuFixture("AIFF", 100))); err == nil {
+		t.Errorf("expected an error for an unknown form type")
+	}
+}
@@ -0,0 +1,110 @@
+// Copyright (c) 2025 Stefano Scafiti
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package format
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+var dmgFileHeader = FileHeader{
+	Ext:         "dmg",
+	Description: "Apple Disk Image (UDIF)",
+	Category:    CategoryDocument,
+	Footers: [][]byte{
+		[]byte("koly"),
+	},
+	FooterSize: dmgTrailerSize,
+	ScanFooter: ScanDMGTrailer,
+	ScanFile:   ScanDMG,
+}
+
+const (
+	// dmgTrailerSize is the size of the koly trailer ending UDIF disk images.
+	dmgTrailerSize = 512
+	// dmgVersion is the version of the koly trailer.
+	dmgVersion = 4
+)
+
+// ScanDMG always fails, since disk images have no header: they are only carved from
+// their trailer (see ScanDMGTrailer). It lets the scanner be called like the others.
+func ScanDMG(r *Reader) (*ScanResult, error) {
+	return nil, fmt.Errorf("dmg is carved from its trailer only")
+}
+
+// ScanDMGTrailer sizes an Apple disk image from its koly trailer, which ends the file
+// and holds the offsets and lengths of the data fork, of the resource fork and of the XML
+// property list describing the blocks of the image. The file ends with the last of them,
+// followed by the trailer itself.
+func ScanDMGTrailer(footer []byte) (*ScanResult, error) {
+	// UDIF trailer: http://newosxbook.com/DMG.html
+	// -----------------------------------------
+	// Signature              (4 bytes)        "koly"
+	// Version                (4 bytes)        Big-endian; 4
+	// HeaderSize             (4 bytes)        Big-endian; 512
+	// Flags                  (4 bytes)        Big-endian
+	// RunningDataForkOffset  (8 bytes)        Big-endian
+	// DataForkOffset         (8 bytes)        Big-endian; usually 0
+	// DataForkLength         (8 bytes)        Big-endian
+	// RsrcForkOffset         (8 bytes)        Big-endian
+	// RsrcForkLength         (8 bytes)        Big-endian
+	// SegmentNumber          (4 bytes)        Big-endian
+	// SegmentCount           (4 bytes)        Big-endian
+	// SegmentID              (16 bytes)       UUID
+	// DataChecksum           (136 bytes)      Type, size and value of the checksum of the data fork
+	// XMLOffset              (8 bytes)        Big-endian; at offset 216
+	// XMLLength              (8 bytes)        Big-endian
+	// ...
+
+	if len(footer) < dmgTrailerSize || string(footer[:4]) != "koly" {
+		return nil, fmt.Errorf("invalid dmg trailer")
+	}
+
+	be := binary.BigEndian
+
+	version, headerSize := be.Uint32(footer[4:8]), be.Uint32(footer[8:12])
+	if version != dmgVersion || headerSize != dmgTrailerSize {
+		return nil, fmt.Errorf("unsupported dmg trailer version %d (size %d)", version, headerSize)
+	}
+
+	var end uint64
+	for _, fork := range []struct{ offset, length int }{
+		{24, 32},   // data fork
+		{40, 48},   // resource fork
+		{216, 224}, // XML property list
+	} {
+		offset, length := be.Uint64(footer[fork.offset:]), be.Uint64(footer[fork.length:])
+		if offset+length < offset {
+			return nil, fmt.Errorf("invalid dmg fork at offset %d with length %d", offset, length)
+		}
+		if length > 0 {
+			end = max(end, offset+length)
+		}
+	}
+
+	if end == 0 {
+		return nil, fmt.Errorf("empty dmg image")
+	}
+
+	return &ScanResult{
+		Size:       end + dmgTrailerSize,
+		Confidence: ConfidenceHeaderOnly,
+	}, nil
+}
@@ -0,0 +1,46 @@
+package format
+
+import (
+	"encoding/binary"
+	"testing"
+)
+
+// dmgTrailer returns a koly trailer describing a data fork and an XML property list of
+// the given lengths, the latter following the former.
+func dmgTrailer(dataForkLength, xmlLength uint64) []byte {
+	be := binary.BigEndian
+
+	trailer := make([]byte, dmgTrailerSize)
+	copy(trailer, "koly")
+	be.PutUint32(trailer[4:8], dmgVersion)
+	be.PutUint32(trailer[8:12], dmgTrailerSize)
+	be.PutUint64(trailer[32:40], dataForkLength)
+	be.PutUint64(trailer[216:224], dataForkLength)
+	be.PutUint64(trailer[224:232], xmlLength)
+	return trailer
+}
+
+func TestScanDMGTrailer(t *testing.T) {
+	res, err := ScanDMGTrailer(dmgTrailer(8192, 300))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := uint64(8192 + 300 + dmgTrailerSize); res.Size != want {
+		t.Fatalf("expected size %d, got %d", want, res.Size)
+	}
+
+	invalid := map[string][]byte{
+		"version": func() []byte {
+			trailer := dmgTrailer(8192, 300)
+			binary.BigEndian.PutUint32(trailer[4:8], 3)
+			return trailer
+		}(),
+		"empty":    dmgTrailer(0, 0),
+		"overflow": dmgTrailer(1<<64-100, 300),
+	}
+	for name, trailer := range invalid {
+		if _, err := ScanDMGTrailer(trailer); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
@@ -0,0 +1,228 @@
+// Copyright (c) 2025 Stefano Scafiti
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package format
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+var dwgFileHeader = FileHeader{
+	Ext:         "dwg",
+	Description: "AutoCAD Drawing",
+	Category:    CategoryDocument,
+	Signatures:  dwgSignatures(),
+	ScanFile:    ScanDWG,
+}
+
+// dwgRelease is an AutoCAD release, identified by the version string starting its drawings.
+type dwgRelease struct {
+	version string
+	name    string
+	r2004   bool // whether the file header has the layout introduced by AutoCAD 2004
+}
+
+// dwgReleases are the supported releases. AutoCAD 2007 (AC1021) is missing, since its
+// file header is Reed-Solomon encoded, and so are the releases before R13, whose
+// drawings have a different structure.
+var dwgReleases = []dwgRelease{
+	{version: "AC1012", name: "R13"},
+	{version: "AC1014", name: "R14"},
+	{version: "AC1015", name: "2000"},
+	{version: "AC1018", name: "2004", r2004: true},
+	{version: "AC1024", name: "2010", r2004: true},
+	{version: "AC1027", name: "2013", r2004: true},
+	{version: "AC1032", name: "2018", r2004: true},
+}
+
+func dwgSignatures() [][]byte {
+	sigs := make([][]byte, len(dwgReleases))
+	for i, rel := range dwgReleases {
+		sigs[i] = []byte(rel.version)
+	}
+	return sigs
+}
+
+const (
+	// dwgLocatorsOffset is the offset of the section locators in the file header of
+	// R13 to 2000 drawings, which are preceded by their count.
+	dwgLocatorsOffset = 0x19
+	dwgLocatorSize    = 9
+	dwgMaxLocators    = 16
+
+	// dwgR2004HeaderSize is the size of the file header of drawings from AutoCAD 2004,
+	// whose last dwgR2004EncryptedSize bytes are encrypted.
+	dwgR2004HeaderSize    = 0x100
+	dwgR2004EncryptedSize = 0x6C
+)
+
+var (
+	// dwgHeaderSentinel ends the file header of R13 to 2000 drawings.
+	dwgHeaderSentinel = []byte{0x95, 0xA0, 0x4E, 0x28, 0x99, 0x82, 0x1A, 0xE5, 0x5E, 0x41, 0xE0, 0x5F, 0x9D, 0x3A, 0x4D, 0x00}
+	// dwgImageSentinel starts the preview image of R13 to 2000 drawings.
+	dwgImageSentinel = []byte{0x1F, 0x25, 0x6D, 0x07, 0xD4, 0x36, 0x28, 0x28, 0x9D, 0x57, 0xCA, 0x3F, 0x9D, 0x44, 0x10, 0x2B}
+
+	dwgR2004FileID = []byte("AcFssFcAJMB\x00")
+)
+
+// ScanDWG carves an AutoCAD drawing. Drawings have no terminator, so their size is
+// estimated from the file header: up to AutoCAD 2000, it is the end of the farthest
+// section listed by the section locators, or of the preview image; from AutoCAD 2004,
+// it is the end of the last section page, or of the copy of the header ending the file.
+// The release of AutoCAD which saved the drawing is reported as its version.
+func ScanDWG(r *Reader) (*ScanResult, error) {
+	var version [6]byte
+	if _, err := io.ReadFull(r, version[:]); err != nil {
+		return nil, err
+	}
+
+	var rel *dwgRelease
+	for i := range dwgReleases {
+		if string(version[:]) == dwgReleases[i].version {
+			rel = &dwgReleases[i]
+		}
+	}
+	if rel == nil {
+		return nil, fmt.Errorf("unsupported DWG version %q", version[:])
+	}
+
+	var (
+		size uint64
+		err  error
+	)
+	if rel.r2004 {
+		size, err = scanDWGR2004(r)
+	} else {
+		size, err = scanDWGR13(r)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &ScanResult{
+		Size:       size,
+		Version:    fmt.Sprintf("AutoCAD %s (%s)", rel.name, rel.version),
+		Confidence: ConfidenceStructural,
+	}, nil
+}
+
+// scanDWGR13 returns the size of an R13 to 2000 drawing, whose version string was read.
+func scanDWGR13(r *Reader) (uint64, error) {
+	// File header:
+	// 0x00  Version string          (6 bytes)
+	// 0x06  Zeros and maintenance   (7 bytes)
+	// 0x0D  Preview image offset    (4 bytes)
+	// 0x11  Unknown                 (2 bytes)
+	// 0x13  Codepage                (2 bytes)
+	// 0x15  Number of locators      (4 bytes)
+	// 0x19  Locators, of 9 bytes each: number (1 byte), offset (4 bytes) and size (4 bytes)
+	//       CRC                     (2 bytes)
+	//       Sentinel                (16 bytes)
+
+	var hdr [dwgLocatorsOffset - 6]byte
+	if _, err := io.ReadFull(r, hdr[:]); err != nil {
+		return 0, fmt.Errorf("failed to read DWG file header: %w", err)
+	}
+
+	imageOffset := uint64(binary.LittleEndian.Uint32(hdr[0x0D-6:]))
+	numLocators := binary.LittleEndian.Uint32(hdr[0x15-6:])
+	if numLocators == 0 || numLocators > dwgMaxLocators {
+		return 0, fmt.Errorf("invalid number of DWG section locators: %d", numLocators)
+	}
+
+	buf := make([]byte, int(numLocators)*dwgLocatorSize+2+len(dwgHeaderSentinel))
+	if _, err := io.ReadFull(r, buf); err != nil {
+		return 0, fmt.Errorf("failed to read DWG section locators: %w", err)
+	}
+
+	if !bytes.HasSuffix(buf, dwgHeaderSentinel) {
+		return 0, fmt.Errorf("invalid DWG file header sentinel")
+	}
+
+	headerEnd := uint64(dwgLocatorsOffset + len(buf))
+	size := headerEnd
+	for i := range int(numLocators) {
+		loc := buf[i*dwgLocatorSize:]
+		offset := uint64(binary.LittleEndian.Uint32(loc[1:5]))
+		length := uint64(binary.LittleEndian.Uint32(loc[5:9]))
+
+		if length > 0 && offset < headerEnd {
+			return 0, fmt.Errorf("DWG section %d overlaps the file header", loc[0])
+		}
+		size = max(size, offset+length)
+	}
+
+	// The preview image, if any, starts with a sentinel and its size, and ends with another sentinel.
+	if imageOffset >= headerEnd {
+		if _, err := r.Discard(int(imageOffset - headerEnd)); err != nil {
+			return size, nil
+		}
+
+		var image [20]byte
+		if _, err := io.ReadFull(r, image[:]); err == nil && bytes.Equal(image[:16], dwgImageSentinel) {
+			imageSize := uint64(binary.LittleEndian.Uint32(image[16:]))
+			size = max(size, imageOffset+uint64(len(image))+imageSize+uint64(len(dwgImageSentinel)))
+		}
+	}
+	return size, nil
+}
+
+// scanDWGR2004 returns the size of a drawing saved by AutoCAD 2004 or later, whose
+// version string was read.
+func scanDWGR2004(r *Reader) (uint64, error) {
+	// The file header is 0x100 bytes long, and the 0x6C bytes from 0x80 are encrypted.
+	// Once decrypted, they hold:
+	// 0x00  File ID "AcFssFcAJMB\0"                  (12 bytes)
+	// 0x2C  End of the last section page             (8 bytes)
+	// 0x34  Offset of the second header              (8 bytes)
+	// A copy of the header, of 0x80 bytes, is stored at the end of the file.
+
+	var hdr [dwgR2004HeaderSize - 6]byte
+	if _, err := io.ReadFull(r, hdr[:]); err != nil {
+		return 0, fmt.Errorf("failed to read DWG file header: %w", err)
+	}
+
+	data := hdr[0x80-6 : 0x80-6+dwgR2004EncryptedSize]
+	decryptDWGHeader(data)
+
+	if !bytes.Equal(data[:len(dwgR2004FileID)], dwgR2004FileID) {
+		return 0, fmt.Errorf("invalid DWG file ID")
+	}
+
+	lastPageEnd := binary.LittleEndian.Uint64(data[0x2C:])
+	secondHeader := binary.LittleEndian.Uint64(data[0x34:])
+	if secondHeader < dwgR2004HeaderSize || secondHeader > secondHeader+0x80 {
+		return 0, fmt.Errorf("invalid DWG second header offset: %d", secondHeader)
+	}
+	return max(lastPageEnd, secondHeader+0x80), nil
+}
+
+// decryptDWGHeader decrypts in place the encrypted part of the file header of drawings
+// saved by AutoCAD 2004 or later, which is XORed with a pseudo-random sequence.
+// Since the operation is symmetric, it also encrypts a header.
+func decryptDWGHeader(data []byte) {
+	seed := uint32(1)
+	for i := range data {
+		seed = seed*0x343FD + 0x269EC3
+		data[i] ^= byte(seed >> 16)
+	}
+}
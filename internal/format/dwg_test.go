@@ -0,0 +1,112 @@
+package format
+
+import (
+	"encoding/binary"
+	"testing"
+)
+
+// dwgR2000Fixture returns an AutoCAD 2000 drawing with three sections and a preview
+// image, followed by trailing data.
+func dwgR2000Fixture() (data []byte, size int) {
+	le := binary.LittleEndian
+
+	data = make([]byte, dwgLocatorsOffset)
+	copy(data, "AC1015")
+	le.PutUint32(data[0x15:], 3) // Number of locators
+
+	headerEnd := dwgLocatorsOffset + 3*dwgLocatorSize + 2 + len(dwgHeaderSentinel)
+	imageOffset := headerEnd
+	imageEnd := imageOffset + 16 + 4 + 100 + 16
+	le.PutUint32(data[0x0D:], uint32(imageOffset))
+
+	for i, loc := range [][2]int{{imageEnd, 1000}, {imageEnd + 1000, 200}, {imageEnd + 1200, 300}} {
+		data = append(data, byte(i))
+		data = le.AppendUint32(data, uint32(loc[0]))
+		data = le.AppendUint32(data, uint32(loc[1]))
+	}
+	data = append(data, 0, 0) // CRC
+	data = append(data, dwgHeaderSentinel...)
+
+	data = append(data, dwgImageSentinel...)
+	data = le.AppendUint32(data, 100)
+	data = append(data, make([]byte, 100+16+1500)...)
+
+	size = len(data)
+	return append(data, "trailing data"...), size
+}
+
+// dwgR2004Fixture returns a drawing saved by AutoCAD 2013, whose header copy ends the file,
+// followed by trailing data.
+func dwgR2004Fixture() (data []byte, size int) {
+	data = make([]byte, 4096)
+	copy(data, "AC1027")
+
+	hdr := data[0x80 : 0x80+dwgR2004EncryptedSize]
+	copy(hdr, dwgR2004FileID)
+	binary.LittleEndian.PutUint64(hdr[0x2C:], 3000)
+	binary.LittleEndian.PutUint64(hdr[0x34:], 4096-0x80)
+	decryptDWGHeader(hdr)
+
+	return append(data, "trailing data"...), len(data)
+}
+
+func TestScanDWG(t *testing.T) {
+	r2000, r2000Size := dwgR2000Fixture()
+	r2004, r2004Size := dwgR2004Fixture()
+
+	tests := []struct {
+		name    string
+		data    []byte
+		size    int
+		version string
+	}{
+		{"AutoCAD 2000", r2000, r2000Size, "AutoCAD 2000 (AC1015)"},
+		{"AutoCAD 2013", r2004, r2004Size, "AutoCAD 2013 (AC1027)"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res, err := ScanDWG(newBytesReader(tt.data))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if res.Size != uint64(tt.size) {
+				t.Errorf("expected size %d, got %d", tt.size, res.Size)
+			}
+			if res.Version != tt.version {
+				t.Errorf("expected version %q, got %q", tt.version, res.Version)
+			}
+		})
+	}
+}
+
+func TestScanDWGInvalid(t *testing.T) {
+	r2000, _ := dwgR2000Fixture()
+	badSentinel := append([]byte(nil), r2000...)
+	badSentinel[dwgLocatorsOffset+3*dwgLocatorSize+2] ^= 0xFF
+
+	r2004, _ := dwgR2004Fixture()
+	badFileID := append([]byte(nil), r2004...)
+	badFileID[0x80] ^= 0xFF
+
+	r2007 := append([]byte("AC1021"), make([]byte, 1024)...)
+
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{"bad header sentinel", badSentinel},
+		{"bad file ID", badFileID},
+		{"unsupported version", r2007},
+		{"short header", []byte("AC1015\x00\x00")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ScanDWG(newBytesReader(tt.data)); err == nil {
+				t.Errorf("expected an error")
+			}
+		})
+	}
+}
@@ -0,0 +1,118 @@
+// Copyright (c) 2025 Stefano Scafiti
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package format
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+)
+
+var dxfFileHeader = FileHeader{
+	Ext:         "dxf",
+	Description: "AutoCAD Drawing Exchange Format",
+	Category:    CategoryDocument,
+	Entropy:     EntropyLow,
+	Signatures: [][]byte{
+		[]byte("  0\r\nSECTION"),
+		[]byte("  0\nSECTION"),
+		[]byte("0\r\nSECTION"),
+		[]byte("0\nSECTION"),
+	},
+	ScanFile: ScanDXF,
+}
+
+const (
+	// dxfMaxLineSize bounds the lines of a DXF file, whose values are at most 2049 characters long.
+	dxfMaxLineSize = 4096
+	// dxfMaxFileSize bounds the search for the EOF marker.
+	dxfMaxFileSize = 512 * 1024 * 1024
+)
+
+// dxfSections are the names of the sections of a DXF file.
+var dxfSections = []string{"HEADER", "CLASSES", "TABLES", "BLOCKS", "ENTITIES", "OBJECTS", "THUMBNAILIMAGE", "ACDSDATA"}
+
+// ScanDXF carves an ASCII DXF file, made of pairs of lines holding a group code and its
+// value. The file starts with the SECTION marker (group code 0) followed by the name of a
+// section (group code 2), and ends with the EOF marker (group code 0). Every pair up to
+// the marker is parsed, so that a file overwritten by other data is rejected.
+func ScanDXF(r *Reader) (*ScanResult, error) {
+	br := bufio.NewReaderSize(r, dxfMaxLineSize)
+
+	var size uint64
+	for pair := 0; size < dxfMaxFileSize; pair++ {
+		codeLine, err := readDXFLine(br)
+		if err != nil {
+			return dxfEnd(size, pair, err)
+		}
+		size += uint64(len(codeLine))
+
+		valueLine, err := readDXFLine(br)
+		if err != nil {
+			return dxfEnd(size, pair, err)
+		}
+		size += uint64(len(valueLine))
+
+		code, err := strconv.Atoi(string(bytes.TrimSpace(codeLine)))
+		if err != nil {
+			return nil, fmt.Errorf("invalid DXF group code %q", bytes.TrimSpace(codeLine))
+		}
+		value := string(bytes.TrimSpace(valueLine))
+
+		switch {
+		case pair == 0 && (code != 0 || value != "SECTION"):
+			return nil, fmt.Errorf("DXF file does not start with a section")
+		case pair == 1 && (code != 2 || !slices.Contains(dxfSections, value)):
+			return nil, fmt.Errorf("invalid DXF section name %q", value)
+		case code == 0 && value == "EOF":
+			return &ScanResult{Size: size, Confidence: ConfidenceStructural}, nil
+		}
+	}
+	return nil, fmt.Errorf("no DXF EOF marker found")
+}
+
+// readDXFLine returns the next line of a DXF file, including its terminator, which
+// is missing for the last line of the source. Lines holding NUL bytes are rejected.
+func readDXFLine(br *bufio.Reader) ([]byte, error) {
+	line, err := br.ReadSlice('\n')
+	if err == io.EOF && len(line) > 0 {
+		err = nil
+	}
+	if err == bufio.ErrBufferFull {
+		return nil, fmt.Errorf("DXF line too long")
+	}
+	if err == nil && bytes.IndexByte(line, 0) >= 0 {
+		return nil, fmt.Errorf("unexpected NUL byte in DXF file")
+	}
+	return line, err
+}
+
+// dxfEnd returns the result of a DXF file of the given size ending, after the given
+// number of pairs of lines, with the error of reading the next line. A file cut by the
+// end of the source is reported as truncated, once its first section started.
+func dxfEnd(size uint64, pairs int, err error) (*ScanResult, error) {
+	if err != io.EOF || pairs < 2 {
+		return nil, err
+	}
+	return &ScanResult{Size: size, Truncated: true, Confidence: ConfidenceStructural}, nil
+}
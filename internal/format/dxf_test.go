@@ -0,0 +1,60 @@
+package format
+
+import (
+	"strings"
+	"testing"
+)
+
+const dxfFixture = "  0\nSECTION\n  2\nHEADER\n  9\n$ACADVER\n  1\nAC1015\n  0\nENDSEC\n" +
+	"  0\nSECTION\n  2\nENTITIES\n  0\nLINE\n  8\n0\n 10\n0.0\n 20\n0.0\n 11\n1.0\n 21\n1.0\n  0\nENDSEC\n" +
+	"  0\nEOF\n"
+
+func TestScanDXF(t *testing.T) {
+	crlf := strings.ReplaceAll(dxfFixture, "\n", "\r\n")
+	noFinalNewline := strings.TrimSuffix(dxfFixture, "\n")
+
+	tests := []struct {
+		name      string
+		data      string
+		size      int
+		truncated bool
+	}{
+		{"LF", dxfFixture + "trailing data", len(dxfFixture), false},
+		{"CRLF", crlf + "\x00\x00", len(crlf), false},
+		{"no final newline", noFinalNewline, len(noFinalNewline), false},
+		{"cut by the end of the source", dxfFixture[:60], 60, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res, err := ScanDXF(newBytesReader([]byte(tt.data)))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if res.Size != uint64(tt.size) || res.Truncated != tt.truncated {
+				t.Errorf("expected size %d (truncated: %v), got %d (truncated: %v)", tt.size, tt.truncated, res.Size, res.Truncated)
+			}
+		})
+	}
+}
+
+func TestScanDXFInvalid(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+	}{
+		{"unknown section", "  0\nSECTION\n  2\nFOO\n  0\nEOF\n"},
+		{"overwritten by binary data", dxfFixture[:60] + "\x00\x01\x02\x03\n\x04\n"},
+		{"invalid group code", "  0\nSECTION\n  2\nHEADER\nabc\ndef\n  0\nEOF\n"},
+		{"line too long", "  0\nSECTION\n  2\nHEADER\n  1\n" + strings.Repeat("a", dxfMaxLineSize) + "\n  0\nEOF\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ScanDXF(newBytesReader([]byte(tt.data))); err == nil {
+				t.Errorf("expected an error")
+			}
+		})
+	}
+}
@@ -0,0 +1,229 @@
+// Copyright (c) 2025 Stefano Scafiti
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package format
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+var elfCoreFileHeader = FileHeader{
+	Ext:         "core",
+	Description: "ELF Core Dump Format",
+	Category:    CategoryMemory,
+	Signatures: [][]byte{
+		[]byte("\x7fELF"),
+	},
+	ScanFile: ScanELFCore,
+}
+
+const (
+	elfClass32 = 1
+	elfClass64 = 2
+
+	elfDataLSB = 1
+	elfDataMSB = 2
+
+	elfTypeCore = 4
+
+	// elfPNXNum is stored in e_phnum when the number of program headers does not fit in it.
+	elfPNXNum = 0xffff
+
+	// elfMaxProgramHeaders bounds the number of program headers, to reject garbage quickly.
+	elfMaxProgramHeaders = 1 << 16
+)
+
+// elfHeader holds the fields of the ELF header locating the other parts of the file.
+type elfHeader struct {
+	class     byte
+	order     binary.ByteOrder
+	fileType  uint16
+	ehSize    uint64
+	phOff     uint64
+	phEntSize uint64
+	phNum     uint64
+	shOff     uint64
+	shEntSize uint64
+	shNum     uint64
+}
+
+// ScanELFCore carves an ELF core dump, such as those written by Linux on crashes.
+// Other ELF files, e.g. executables and libraries, are rejected.
+func ScanELFCore(r *Reader) (*ScanResult, error) {
+	hdr, err := readELFHeader(r)
+	if err != nil {
+		return nil, err
+	}
+
+	if hdr.fileType != elfTypeCore {
+		return nil, fmt.Errorf("not an ELF core file: type %d", hdr.fileType)
+	}
+	if hdr.phNum == 0 {
+		return nil, errors.New("ELF core file without program headers")
+	}
+
+	size, err := elfSize(r, hdr)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ScanResult{
+		Size:       size,
+		Confidence: ConfidenceStructural,
+	}, nil
+}
+
+// readELFHeader reads and validates the ELF header at the start of the reader.
+func readELFHeader(r *Reader) (*elfHeader, error) {
+	// ELF Header Structure: https://refspecs.linuxfoundation.org/elf/gabi4+/ch4.eheader.html
+	// -----------------------------------------
+	// e_ident              (16 bytes)       "\x7fELF", class (32/64-bit), data encoding, version, ABI, padding
+	// e_type               (2 bytes)        Object file type, 4 for core files
+	// e_machine            (2 bytes)        Architecture
+	// e_version            (4 bytes)        Object file version, 1
+	// e_entry              (4/8 bytes)      Entry point
+	// e_phoff              (4/8 bytes)      Offset of the program header table
+	// e_shoff              (4/8 bytes)      Offset of the section header table
+	// e_flags              (4 bytes)        Processor-specific flags
+	// e_ehsize             (2 bytes)        Size of the ELF header, 52 or 64 bytes
+	// e_phentsize          (2 bytes)        Size of a program header
+	// e_phnum              (2 bytes)        Number of program headers
+	// e_shentsize          (2 bytes)        Size of a section header
+	// e_shnum              (2 bytes)        Number of section headers
+	// e_shstrndx           (2 bytes)        Index of the section name string table
+
+	var ident [16]byte
+	if _, err := io.ReadFull(r, ident[:]); err != nil {
+		return nil, fmt.Errorf("failed to read ELF header: %w", err)
+	}
+
+	if !bytes.HasPrefix(ident[:], []byte("\x7fELF")) || ident[6] != 1 {
+		return nil, errors.New("invalid ELF identification")
+	}
+
+	hdr := &elfHeader{class: ident[4]}
+	switch ident[5] {
+	case elfDataLSB:
+		hdr.order = binary.LittleEndian
+	case elfDataMSB:
+		hdr.order = binary.BigEndian
+	default:
+		return nil, fmt.Errorf("invalid ELF data encoding: %d", ident[5])
+	}
+
+	var (
+		buf                   []byte
+		phEntSize, offsetSize int
+	)
+	switch hdr.class {
+	case elfClass32:
+		buf, phEntSize, offsetSize = make([]byte, 52-len(ident)), 32, 4
+	case elfClass64:
+		buf, phEntSize, offsetSize = make([]byte, 64-len(ident)), 56, 8
+	default:
+		return nil, fmt.Errorf("invalid ELF class: %d", hdr.class)
+	}
+
+	if _, err := io.ReadFull(r, buf); err != nil {
+		return nil, fmt.Errorf("failed to read ELF header: %w", err)
+	}
+
+	hdr.fileType = hdr.order.Uint16(buf[0:2])
+	if hdr.order.Uint32(buf[4:8]) != 1 {
+		return nil, errors.New("invalid ELF version")
+	}
+
+	// Fields following e_version, whose offsets depend on the class.
+	fields := buf[8+offsetSize:]
+	hdr.phOff = elfOffset(hdr.order, fields[:offsetSize])
+	hdr.shOff = elfOffset(hdr.order, fields[offsetSize:2*offsetSize])
+
+	fields = fields[2*offsetSize+4:]
+	hdr.ehSize = uint64(hdr.order.Uint16(fields[0:2]))
+	hdr.phEntSize = uint64(hdr.order.Uint16(fields[2:4]))
+	hdr.phNum = uint64(hdr.order.Uint16(fields[4:6]))
+	hdr.shEntSize = uint64(hdr.order.Uint16(fields[6:8]))
+	hdr.shNum = uint64(hdr.order.Uint16(fields[8:10]))
+
+	if hdr.ehSize != uint64(len(ident)+len(buf)) {
+		return nil, fmt.Errorf("invalid ELF header size: %d", hdr.ehSize)
+	}
+	if hdr.phNum > 0 && hdr.phEntSize != uint64(phEntSize) {
+		return nil, fmt.Errorf("invalid ELF program header size: %d", hdr.phEntSize)
+	}
+	if hdr.phNum == elfPNXNum {
+		return nil, errors.New("ELF files with extended program header numbering are not supported")
+	}
+	return hdr, nil
+}
+
+// elfSize returns the size of the ELF file whose header was just read, as the end
+// of the farthest of its header tables and of the segments of its program headers.
+func elfSize(r *Reader, hdr *elfHeader) (uint64, error) {
+	size := hdr.ehSize
+	if hdr.shNum > 0 {
+		size = max(size, hdr.shOff+hdr.shNum*hdr.shEntSize)
+	}
+
+	if hdr.phNum == 0 {
+		return size, nil
+	}
+	if hdr.phNum > elfMaxProgramHeaders || hdr.phOff < hdr.ehSize {
+		return 0, fmt.Errorf("invalid ELF program header table at offset %d", hdr.phOff)
+	}
+
+	if _, err := r.Discard(int(hdr.phOff - hdr.ehSize)); err != nil {
+		return 0, err
+	}
+	size = max(size, hdr.phOff+hdr.phNum*hdr.phEntSize)
+
+	ph := make([]byte, hdr.phEntSize)
+	for range hdr.phNum {
+		if _, err := io.ReadFull(r, ph); err != nil {
+			return 0, fmt.Errorf("failed to read ELF program header: %w", err)
+		}
+
+		// p_offset and p_filesz, the offset and size of the segment in the file.
+		var offset, fileSize uint64
+		if hdr.class == elfClass64 {
+			offset = hdr.order.Uint64(ph[8:16])
+			fileSize = hdr.order.Uint64(ph[32:40])
+		} else {
+			offset = uint64(hdr.order.Uint32(ph[4:8]))
+			fileSize = uint64(hdr.order.Uint32(ph[16:20]))
+		}
+
+		if offset+fileSize < offset {
+			return 0, errors.New("invalid ELF segment size")
+		}
+		size = max(size, offset+fileSize)
+	}
+	return size, nil
+}
+
+func elfOffset(order binary.ByteOrder, b []byte) uint64 {
+	if len(b) == 4 {
+		return uint64(order.Uint32(b))
+	}
+	return order.Uint64(b)
+}
@@ -0,0 +1,96 @@
+package format
+
+import (
+	"encoding/binary"
+	"testing"
+)
+
+type testByteOrder interface {
+	binary.ByteOrder
+	binary.AppendByteOrder
+}
+
+// elfCoreFixture returns an ELF file of the given class and type, with two segments
+// ending at offset 8192, followed by trailing data.
+func elfCoreFixture(class byte, order testByteOrder, fileType uint16) (data []byte, size int) {
+	data = []byte{0x7f, 'E', 'L', 'F', class, elfDataLSB, 1, 0, 0, 0, 0, 0, 0, 0, 0, 0}
+	if order == binary.BigEndian {
+		data[5] = elfDataMSB
+	}
+
+	segments := [][2]uint64{{4096, 4096}, {512, 256}} // p_offset, p_filesz
+
+	data = order.AppendUint16(data, fileType)
+	data = order.AppendUint16(data, 62) // e_machine
+	data = order.AppendUint32(data, 1)  // e_version
+
+	if class == elfClass64 {
+		data = order.AppendUint64(data, 0)  // e_entry
+		data = order.AppendUint64(data, 64) // e_phoff
+		data = order.AppendUint64(data, 0)  // e_shoff
+		data = order.AppendUint32(data, 0)  // e_flags
+		data = order.AppendUint16(data, 64) // e_ehsize
+		data = order.AppendUint16(data, 56) // e_phentsize
+	} else {
+		data = order.AppendUint32(data, 0)  // e_entry
+		data = order.AppendUint32(data, 52) // e_phoff
+		data = order.AppendUint32(data, 0)  // e_shoff
+		data = order.AppendUint32(data, 0)  // e_flags
+		data = order.AppendUint16(data, 52) // e_ehsize
+		data = order.AppendUint16(data, 32) // e_phentsize
+	}
+	data = order.AppendUint16(data, uint16(len(segments)))
+	data = order.AppendUint16(data, 0) // e_shentsize
+	data = order.AppendUint16(data, 0) // e_shnum
+	data = order.AppendUint16(data, 0) // e_shstrndx
+
+	for _, seg := range segments {
+		if class == elfClass64 {
+			ph := make([]byte, 56)
+			order.PutUint32(ph[0:4], 1) // PT_LOAD
+			order.PutUint64(ph[8:16], seg[0])
+			order.PutUint64(ph[32:40], seg[1])
+			data = append(data, ph...)
+		} else {
+			ph := make([]byte, 32)
+			order.PutUint32(ph[0:4], 1) // PT_LOAD
+			order.PutUint32(ph[4:8], uint32(seg[0]))
+			order.PutUint32(ph[16:20], uint32(seg[1]))
+			data = append(data, ph...)
+		}
+	}
+
+	data = append(data, make([]byte, 8192-len(data))...)
+	return append(data, "trailing data"...), 8192
+}
+
+func TestScanELFCore(t *testing.T) {
+	tests := []struct {
+		name  string
+		class byte
+		order testByteOrder
+	}{
+		{"64-bit little-endian", elfClass64, binary.LittleEndian},
+		{"32-bit big-endian", elfClass32, binary.BigEndian},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, size := elfCoreFixture(tt.class, tt.order, elfTypeCore)
+
+			res, err := ScanELFCore(newBytesReader(data))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if res.Size != uint64(size) {
+				t.Fatalf("expected size %d, got %d", size, res.Size)
+			}
+		})
+	}
+
+	// Executables are not carved.
+	data, _ := elfCoreFixture(elfClass64, binary.LittleEndian, 2)
+	if _, err := ScanELFCore(newBytesReader(data)); err == nil {
+		t.Fatal("expected an error for an ELF executable")
+	}
+}
@@ -0,0 +1,114 @@
+// Copyright (c) 2025 Stefano Scafiti
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package format
+
+import (
+	"io"
+	"math"
+)
+
+// EntropyClass is the expected Shannon entropy of the contents of the files of a format,
+// used to reject carved files which are likely false positives (see Scanner.SetEntropyFilter).
+type EntropyClass int
+
+const (
+	// EntropyAny is set for formats whose entropy varies too much to be checked.
+	EntropyAny EntropyClass = iota
+
+	// EntropyHigh is set for compressed or encrypted formats (e.g., ZIP archives or JPEG
+	// images), whose contents must have an entropy of at least MinHighEntropy.
+	EntropyHigh
+
+	// EntropyLow is set for uncompressed formats (e.g., BMP images or SVG documents),
+	// whose contents must have an entropy below MaxLowEntropy.
+	EntropyLow
+)
+
+const (
+	// MinHighEntropy is the minimum entropy, in bits per byte, of the files of EntropyHigh formats.
+	MinHighEntropy = 7.0
+
+	// MaxLowEntropy is the maximum entropy, in bits per byte, of the files of EntropyLow formats.
+	// It is well above that of text, since uncompressed photos can exceed 7 bits per byte,
+	// but below that of random or encrypted data, which is close to 8.
+	MaxLowEntropy = 7.5
+)
+
+const (
+	// entropySampleSize is the maximum number of bytes of a file whose entropy is computed.
+	entropySampleSize = 64 * 1024
+
+	// minEntropySampleSize is the minimum number of bytes of a file whose entropy is checked.
+	// The entropy of smaller samples is underestimated, even for random data.
+	minEntropySampleSize = 4096
+)
+
+// Entropy returns the Shannon entropy of data, in bits per byte, between 0 and 8.
+func Entropy(data []byte) float64 {
+	if len(data) == 0 {
+		return 0
+	}
+
+	var counts [256]int
+	for _, b := range data {
+		counts[b]++
+	}
+
+	var h float64
+	n := float64(len(data))
+	for _, c := range counts {
+		if c > 0 {
+			p := float64(c) / n
+			h -= p * math.Log2(p)
+		}
+	}
+	return h
+}
+
+// checkEntropy reports whether the entropy of the file of the given class, stored at the
+// given range of r, is within the bounds of the class. The entropy is computed over a
+// sample from the middle of the file, skipping its headers. Files too small to estimate
+// their entropy are always accepted.
+func checkEntropy(r io.ReaderAt, offset, size uint64, class EntropyClass, buf []byte) (float64, bool) {
+	if class == EntropyAny || size < minEntropySampleSize {
+		return 0, true
+	}
+
+	n := min(size, uint64(len(buf)))
+	m, err := r.ReadAt(buf[:n], int64(offset+(size-n)/2))
+	if m < minEntropySampleSize && err != nil {
+		return 0, true
+	}
+
+	h := Entropy(buf[:m])
+	if class == EntropyHigh {
+		return h, h >= MinHighEntropy
+	}
+	return h, h < MaxLowEntropy
+}
+
+// ScannerEntropy returns the entropy class of the files of the given scanner, or
+// EntropyAny if the scanner does not declare one.
+func ScannerEntropy(sc FileScanner) EntropyClass {
+	if e, ok := sc.(interface{ Entropy() EntropyClass }); ok {
+		return e.Entropy()
+	}
+	return EntropyAny
+}
@@ -0,0 +1,86 @@
+package format
+
+import (
+	"bytes"
+	"io"
+	"math/rand"
+	"slices"
+	"testing"
+
+	"github.com/ostafen/digler/internal/logger"
+)
+
+func TestEntropy(t *testing.T) {
+	all := make([]byte, 512)
+	for i := range all {
+		all[i] = byte(i)
+	}
+
+	tests := []struct {
+		data []byte
+		want float64
+	}{
+		{nil, 0},
+		{make([]byte, 100), 0},
+		{[]byte("abab"), 1},
+		{[]byte("abcd"), 2},
+		{all, 8},
+	}
+	for _, tt := range tests {
+		if got := Entropy(tt.data); got != tt.want {
+			t.Errorf("Entropy(%q) = %v, want %v", tt.data, got, tt.want)
+		}
+	}
+}
+
+func TestScanEntropyFilter(t *testing.T) {
+	const fileSize = 8192
+
+	newHeader := func(sig string, class EntropyClass) FileHeader {
+		return FileHeader{
+			Ext:        sig,
+			Signatures: [][]byte{[]byte(sig)},
+			Entropy:    class,
+			ScanFile: func(r *Reader) (*ScanResult, error) {
+				return &ScanResult{Size: fileSize}, nil
+			},
+		}
+	}
+
+	random := make([]byte, fileSize)
+	rand.New(rand.NewSource(1)).Read(random)
+
+	// Compressed files must hold random-looking data, uncompressed ones must not.
+	data := make([]byte, 4*fileSize)
+	copy(data[fileSize:], random)
+	copy(data[2*fileSize:], random)
+	for i, sig := range []string{"high", "high", "low", "low"} {
+		copy(data[i*fileSize:], sig)
+	}
+
+	r := BuildFileRegistry(
+		NewFileScanner(newHeader("high", EntropyHigh)),
+		NewFileScanner(newHeader("low", EntropyLow)),
+	)
+
+	for _, filter := range []bool{false, true} {
+		sc := NewScanner(logger.New(io.Discard, logger.ErrorLevel), r, 4096, 512, uint64(len(data)))
+		sc.SetEntropyFilter(filter)
+
+		var offsets []uint64
+		for finfo := range sc.Scan(bytes.NewReader(data), uint64(len(data))) {
+			offsets = append(offsets, finfo.Offset)
+		}
+
+		want := []uint64{0, fileSize, 2 * fileSize, 3 * fileSize}
+		if filter {
+			want = []uint64{fileSize, 3 * fileSize}
+		}
+		if !slices.Equal(offsets, want) {
+			t.Errorf("filter %v: expected files at %v, got %v", filter, want, offsets)
+		}
+		if filter && sc.EntropyRejected() != 2 {
+			t.Errorf("expected 2 rejected files, got %d", sc.EntropyRejected())
+		}
+	}
+}
@@ -22,14 +22,30 @@ package format
 type FileScanner interface {
 	Ext() string
 	Description() string
+	Category() Category
 	Signatures() [][]byte
 	ScanFile(r *Reader) (*ScanResult, error)
 }
 
+// FooterScanner is implemented by the scanners of formats recognized by a fixed-size
+// trailer ending the file. The registry searches each scanned buffer for their footers,
+// at any position, and ScanFooter is called with the FooterSize bytes starting at each
+// match, to return the size of the file ending with them.
+type FooterScanner interface {
+	Footers() [][]byte
+	FooterSize() int
+	ScanFooter(footer []byte) (*ScanResult, error)
+}
+
 type headerFileScanner struct {
 	hdr FileHeader
 }
 
+// NewFileScanner returns a FileScanner for the format described by hdr.
+func NewFileScanner(hdr FileHeader) FileScanner {
+	return &headerFileScanner{hdr: hdr}
+}
+
 func (s *headerFileScanner) Ext() string {
 	return s.hdr.Ext
 }
@@ -38,10 +54,34 @@ func (s *headerFileScanner) Description() string {
 	return s.hdr.Description
 }
 
+func (s *headerFileScanner) Category() Category {
+	return s.hdr.Category
+}
+
+func (s *headerFileScanner) Entropy() EntropyClass {
+	return s.hdr.Entropy
+}
+
 func (s *headerFileScanner) Signatures() [][]byte {
 	return s.hdr.Signatures
 }
 
+func (s *headerFileScanner) SignatureOffset() int {
+	return s.hdr.SignatureOffset
+}
+
 func (s *headerFileScanner) ScanFile(r *Reader) (*ScanResult, error) {
 	return s.hdr.ScanFile(r)
 }
+
+func (s *headerFileScanner) Footers() [][]byte {
+	return s.hdr.Footers
+}
+
+func (s *headerFileScanner) FooterSize() int {
+	return s.hdr.FooterSize
+}
+
+func (s *headerFileScanner) ScanFooter(footer []byte) (*ScanResult, error) {
+	return s.hdr.ScanFooter(footer)
+}
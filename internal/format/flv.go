@@ -0,0 +1,131 @@
+// Copyright (c) 2025 Stefano Scafiti
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package format
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+var flvFileHeader = FileHeader{
+	Ext:         "flv",
+	Description: "Flash Video",
+	Category:    CategoryVideo,
+	Signatures: [][]byte{
+		[]byte("FLV\x01"),
+	},
+	ScanFile: ScanFLV,
+}
+
+const (
+	// flvHeaderSize is the size of the FLV header: the signature, the version,
+	// the flags and the offset of the body.
+	flvHeaderSize = 9
+	// flvTagHeaderSize is the size of the header of an FLV tag: the tag type,
+	// the data size, the timestamp and the stream ID.
+	flvTagHeaderSize = 11
+	// flvPrevTagSize is the size of the PreviousTagSize field following each tag.
+	flvPrevTagSize = 4
+)
+
+// FLV tag types.
+const (
+	flvTagAudio      = 8
+	flvTagVideo      = 9
+	flvTagScriptData = 18
+)
+
+// ScanFLV scans a Flash Video file. The body is made of tags, each followed by
+// a PreviousTagSize field holding the size of the tag, and the file has no
+// explicit size: the tags are walked until the end of the data, an unknown tag
+// type or an inconsistent PreviousTagSize, which mark the end of the file.
+func ScanFLV(r *Reader) (*ScanResult, error) {
+	var hdr [flvHeaderSize]byte
+	if _, err := io.ReadFull(r, hdr[:]); err != nil {
+		return nil, fmt.Errorf("failed to read FLV header: %w", err)
+	}
+
+	if string(hdr[0:4]) != "FLV\x01" {
+		return nil, fmt.Errorf("reader does not start with FLV signature")
+	}
+
+	if hdr[4]&^0x05 != 0 {
+		return nil, fmt.Errorf("invalid FLV flags: %#x", hdr[4])
+	}
+
+	dataOffset := binary.BigEndian.Uint32(hdr[5:9])
+	if dataOffset < flvHeaderSize || dataOffset > 1024 {
+		return nil, fmt.Errorf("invalid FLV data offset: %d", dataOffset)
+	}
+
+	if _, err := r.Discard(int(dataOffset - flvHeaderSize)); err != nil {
+		return nil, fmt.Errorf("failed to read FLV header: %w", err)
+	}
+
+	var prev [flvPrevTagSize]byte
+	if _, err := io.ReadFull(r, prev[:]); err != nil {
+		return nil, fmt.Errorf("failed to read FLV header: %w", err)
+	}
+
+	if binary.BigEndian.Uint32(prev[:]) != 0 {
+		return nil, fmt.Errorf("invalid first FLV PreviousTagSize")
+	}
+
+	size := uint64(dataOffset) + flvPrevTagSize
+	tags := 0
+	for {
+		var tag [flvTagHeaderSize]byte
+		if _, err := io.ReadFull(r, tag[:]); err != nil {
+			break
+		}
+
+		// The upper bits of the type byte flag filtered (e.g., encrypted) tags.
+		switch tag[0] & 0x1f {
+		case flvTagAudio, flvTagVideo, flvTagScriptData:
+		default:
+			return flvResult(size, tags)
+		}
+
+		dataSize := uint32(tag[1])<<16 | uint32(tag[2])<<8 | uint32(tag[3])
+		if n, err := r.Discard(int(dataSize)); err != nil || n != int(dataSize) {
+			break
+		}
+
+		if _, err := io.ReadFull(r, prev[:]); err != nil {
+			break
+		}
+
+		if binary.BigEndian.Uint32(prev[:]) != flvTagHeaderSize+dataSize {
+			break
+		}
+
+		size += flvTagHeaderSize + uint64(dataSize) + flvPrevTagSize
+		tags++
+	}
+	return flvResult(size, tags)
+}
+
+func flvResult(size uint64, tags int) (*ScanResult, error) {
+	if tags == 0 {
+		return nil, fmt.Errorf("no FLV tags found")
+	}
+	return &ScanResult{Size: size, Confidence: ConfidenceStructural}, nil
+}
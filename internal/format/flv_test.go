@@ -0,0 +1,81 @@
+package format
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+)
+
+// flvTag returns an FLV tag of the given type and data size, followed by its PreviousTagSize.
+func flvTag(typ byte, dataSize int) []byte {
+	tag := []byte{typ, byte(dataSize >> 16), byte(dataSize >> 8), byte(dataSize), 0, 0, 0, 0, 0, 0, 0}
+	tag = append(tag, make([]byte, dataSize)...)
+	return binary.BigEndian.AppendUint32(tag, uint32(flvTagHeaderSize+dataSize))
+}
+
+func flvFile(tags ...[]byte) []byte {
+	data := []byte{'F', 'L', 'V', 1, 0x05, 0, 0, 0, 9, 0, 0, 0, 0}
+	for _, tag := range tags {
+		data = append(data, tag...)
+	}
+	return data
+}
+
+func TestScanFLV(t *testing.T) {
+	file := flvFile(flvTag(flvTagScriptData, 30), flvTag(flvTagVideo, 100), flvTag(flvTagAudio, 20))
+	withSuffix := func(suffix []byte) []byte {
+		return append(bytes.Clone(file), suffix...)
+	}
+
+	truncatedTag := flvTag(flvTagVideo, 50)
+	inconsistentTag := flvTag(flvTagVideo, 50)
+	inconsistentTag[len(inconsistentTag)-1]++
+
+	tests := []struct {
+		name string
+		data []byte
+		size int
+	}{
+		{"complete", file, len(file)},
+		{"followed by zeros", withSuffix(make([]byte, 32)), len(file)},
+		{"followed by unknown tag", withSuffix(flvTag(7, 10)), len(file)},
+		{"truncated tag", withSuffix(truncatedTag[:20]), len(file)},
+		{"inconsistent previous tag size", withSuffix(inconsistentTag), len(file)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res, err := ScanFLV(newBytesReader(tt.data))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if res.Size != uint64(tt.size) {
+				t.Errorf("expected size %d, got %d", tt.size, res.Size)
+			}
+		})
+	}
+}
+
+func TestScanFLVInvalid(t *testing.T) {
+	badOffset := flvFile(flvTag(flvTagVideo, 10))
+	badOffset[8] = 4
+
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{"no tags", flvFile()},
+		{"unknown first tag", flvFile(flvTag(7, 10))},
+		{"invalid data offset", badOffset},
+		{"truncated header", []byte("FLV\x01\x05")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ScanFLV(newBytesReader(tt.data)); err == nil {
+				t.Errorf("expected an error")
+			}
+		})
+	}
+}
@@ -22,6 +22,7 @@ import (
 var gifFileHeader = FileHeader{
 	Ext:         "gif",
 	Description: "Graphics Interchange Format",
+	Category:    CategoryImage,
 	Signatures: [][]byte{
 		[]byte("GIF87a"),
 		[]byte("GIF89a"),
@@ -29,6 +30,23 @@ var gifFileHeader = FileHeader{
 	ScanFile: ScanGIF,
 }
 
+// GIFOptions controls how strictly GIF files are validated.
+type GIFOptions struct {
+	// Strict rejects files containing an unknown block type. When not set, an
+	// unknown block type found after a fully parsed image is treated as the end
+	// of a file missing its trailer.
+	Strict bool
+}
+
+// NewGIFScanner returns a GIF scanner which validates files according to opts.
+func NewGIFScanner(opts GIFOptions) FileScanner {
+	hdr := gifFileHeader
+	hdr.ScanFile = func(r *Reader) (*ScanResult, error) {
+		return scanGIF(r, opts)
+	}
+	return &headerFileScanner{hdr: hdr}
+}
+
 // Section indicators.
 const (
 	sExtension       = 0x21
@@ -65,6 +83,10 @@ type gifDecoder struct {
 }
 
 func ScanGIF(r *Reader) (*ScanResult, error) {
+	return scanGIF(r, GIFOptions{})
+}
+
+func scanGIF(r *Reader, opts GIFOptions) (*ScanResult, error) {
 	d := gifDecoder{
 		loopCount: -1,
 		r:         r,
@@ -99,8 +121,12 @@ func ScanGIF(r *Reader) (*ScanResult, error) {
 				// If we haven't parsed the image descriptor, we can't have a valid image.
 				return nil, errors.New("gif: missing image data")
 			}
-			return &ScanResult{Size: r.n}, nil
+			return &ScanResult{Size: r.n, Confidence: ConfidenceStructural}, nil
 		default:
+			if !opts.Strict && d.dataParsed {
+				// The unexpected byte does not belong to the file.
+				return &ScanResult{Size: r.n - 1, Confidence: ConfidenceStructural}, nil
+			}
 			return nil, fmt.Errorf("gif: unknown block type: 0x%.2x", c)
 		}
 	}
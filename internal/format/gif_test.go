@@ -0,0 +1,41 @@
+package format
+
+import (
+	"bytes"
+	"image"
+	"image/color/palette"
+	"image/gif"
+	"testing"
+)
+
+// gifWithGarbage returns a GIF image whose trailer is replaced by an unexpected byte.
+func gifWithGarbage(t *testing.T) (data []byte, size int) {
+	var buf bytes.Buffer
+	if err := gif.Encode(&buf, image.NewPaletted(image.Rect(0, 0, 4, 4), palette.Plan9), nil); err != nil {
+		t.Fatal(err)
+	}
+
+	data = buf.Bytes()
+	size = len(data) - 1
+
+	data[size] = 0x00
+	return data, size
+}
+
+func TestScanGIFTrailingGarbage(t *testing.T) {
+	data, size := gifWithGarbage(t)
+
+	res, err := ScanGIF(newBytesReader(data))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if res.Size != uint64(size) {
+		t.Fatalf("expected size %d, got %d", size, res.Size)
+	}
+
+	_, err = scanGIF(newBytesReader(data), GIFOptions{Strict: true})
+	if err == nil {
+		t.Fatalf("expected an error in strict mode")
+	}
+}
@@ -20,21 +20,128 @@
 package format
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"path/filepath"
 	"plugin"
+	"strings"
+	"sync"
 )
 
 type ScanResult struct {
 	Name string
 	Ext  string
 	Size uint64
+
+	// Truncated is set when the size of the file exceeded the maximum file size
+	// (or the end of the source), so Size was capped. Scanners set it when the
+	// source ends before the end declared by the file, returning the size read.
+	Truncated bool
+
+	// Confidence is how thoroughly the scanner validated the file.
+	Confidence Confidence
+
+	// Version is the version of the format of the file, or of the application which
+	// wrote it, when the scanner can tell it (e.g., "AutoCAD 2013 (AC1027)").
+	Version string
+
+	// Companions are files stored right after this one and belonging with it, such as the
+	// write-ahead log of a database. Each one is reported as a file of its own, named after
+	// this one, and the next file is searched for after the last of them.
+	Companions []Companion
+}
+
+// Companion is a file which belongs with a carved file, stored right after it.
+type Companion struct {
+	Suffix string // Suffix appended to the name of the carved file, e.g. "-wal".
+	Ext    string
+	Size   uint64
+}
+
+// totalSize returns the size of the file together with its companions.
+func (res *ScanResult) totalSize() uint64 {
+	size := res.Size
+	for _, c := range res.Companions {
+		size += c.Size
+	}
+	return size
+}
+
+// Confidence is the depth of the validation of a carved file, in increasing order.
+type Confidence int
+
+const (
+	// ConfidenceHeaderOnly is set when only the header of the file was checked, and the size
+	// is taken from it or from a terminator. It is the default, e.g. for plugins.
+	ConfidenceHeaderOnly Confidence = iota
+	// ConfidenceStructural is set when the internal structure of the file, such as its chunks,
+	// boxes, segments or frames, was walked and found consistent.
+	ConfidenceStructural
+	// ConfidenceFullyValidated is set when the content was also verified, e.g. by checksums.
+	ConfidenceFullyValidated
+)
+
+var confidenceNames = []string{
+	ConfidenceHeaderOnly:     "header",
+	ConfidenceStructural:     "structural",
+	ConfidenceFullyValidated: "validated",
+}
+
+// String returns the name of the confidence level.
+func (c Confidence) String() string {
+	if c < 0 || int(c) >= len(confidenceNames) {
+		return fmt.Sprintf("Confidence(%d)", int(c))
+	}
+	return confidenceNames[c]
+}
+
+// ParseConfidence parses the name of a confidence level: header, structural or validated.
+func ParseConfidence(s string) (Confidence, error) {
+	for c, name := range confidenceNames {
+		if s == name {
+			return Confidence(c), nil
+		}
+	}
+	return 0, fmt.Errorf("invalid confidence level %q: must be one of %s", s, strings.Join(confidenceNames, ", "))
 }
 
+// Category groups related file formats, e.g., audio or image formats.
+type Category string
+
+const (
+	CategoryAudio    Category = "audio"
+	CategoryImage    Category = "image"
+	CategoryVideo    Category = "video"
+	CategoryDocument Category = "document"
+	CategoryDatabase Category = "database"
+	CategoryMemory   Category = "memory"
+	// CategoryOther is reported for scanners which do not declare a category.
+	CategoryOther Category = "other"
+)
+
 type FileHeader struct {
 	Ext         string // File extension, e.g., "mp3", "wav"
 	Description string
+	Category    Category
 	Signatures  [][]byte
 	ScanFile    func(r *Reader) (*ScanResult, error)
+
+	// SignatureOffset is the offset of the signatures from the start of the file,
+	// for formats whose magic bytes do not come first (e.g., MOBI e-books).
+	SignatureOffset int
+
+	// Footers are the signatures of a trailer of FooterSize bytes ending the file, for
+	// formats recognized by their end rather than by a header (e.g., Apple disk images).
+	// ScanFooter is called with the trailer of each match, and returns the size of the
+	// whole file, which is carved backwards from the end of the trailer.
+	Footers    [][]byte
+	FooterSize int
+	ScanFooter func(footer []byte) (*ScanResult, error)
+
+	// Entropy is the expected entropy of the files of the format, checked when the
+	// entropy filter is enabled.
+	Entropy EntropyClass
 }
 
 var fileHeaders = []FileHeader{
@@ -43,6 +150,8 @@ var fileHeaders = []FileHeader{
 	wavFileHeader,
 	sunAudioFileHeader,
 	wmaFileHeader,
+	aacFileHeader,
+	oggFileHeader,
 	// image formats
 	jpegFileHeader,
 	pngFileHeader,
@@ -50,12 +159,62 @@ var fileHeaders = []FileHeader{
 	gifFileHeader,
 	pcxFileHeader,
 	tiffFileHeader,
+	svgFileHeader,
+	// video formats
+	flvFileHeader,
+	mp4FileHeader,
+	movFileHeader,
+	matroskaFileHeader,
 	// generic/documents formats
 	zipFileHeader,
 	rarFileHeader,
 	pdfFileHeader,
+	djvuFileHeader,
+	mobiFileHeader,
+	plistFileHeader,
+	dmgFileHeader,
 	// database formats
 	sqliteFileHeader,
+	registryFileHeader,
+	// CAD formats
+	dwgFileHeader,
+	dxfFileHeader,
+	// memory dump formats
+	minidumpFileHeader,
+	elfCoreFileHeader,
+}
+
+// ErrUnknownExtension is returned by GetFileScanners when
+// no built-in scanner is registered for a requested extension.
+var ErrUnknownExtension = errors.New("unknown file extension")
+
+// ErrDuplicateExtension is returned by RegisterScanner when
+// a scanner is already registered for the same extension.
+var ErrDuplicateExtension = errors.New("duplicate file extension")
+
+var (
+	registeredMu       sync.Mutex
+	registeredScanners []FileScanner
+)
+
+// RegisterScanner adds a scanner to the built-in ones, so that embedding programs can
+// support additional formats without building a plugin. Registered scanners are
+// returned by GetFileScanners and GetAllFileScanners, after the built-in ones.
+func RegisterScanner(sc FileScanner) error {
+	if err := ValidateScanner(sc); err != nil {
+		return err
+	}
+
+	registeredMu.Lock()
+	defer registeredMu.Unlock()
+
+	for _, other := range allFileScanners() {
+		if other.Ext() == sc.Ext() {
+			return fmt.Errorf("%w: a scanner is already registered for %q", ErrDuplicateExtension, sc.Ext())
+		}
+	}
+	registeredScanners = append(registeredScanners, sc)
+	return nil
 }
 
 func GetFileScanners(ext ...string) ([]FileScanner, error) {
@@ -64,28 +223,125 @@ func GetFileScanners(ext ...string) ([]FileScanner, error) {
 		return scanners, nil
 	}
 
-	headersByExt := make(map[string]FileHeader)
-	for _, hdr := range fileHeaders {
-		headersByExt[hdr.Ext] = hdr
+	scannersByExt := make(map[string]FileScanner)
+	for _, sc := range GetAllFileScanners() {
+		scannersByExt[sc.Ext()] = sc
 	}
 
 	scanners := make([]FileScanner, len(ext))
 	for i, e := range ext {
-		hdr, ok := headersByExt[e]
+		sc, ok := scannersByExt[e]
 		if !ok {
-			return nil, fmt.Errorf("unknown file extension: \"%s\"", hdr.Ext)
+			return nil, fmt.Errorf("%w: %q", ErrUnknownExtension, e)
 		}
-		scanners[i] = &headerFileScanner{hdr: hdr}
+		scanners[i] = sc
 	}
 	return scanners, nil
 }
 
 func GetAllFileScanners() []FileScanner {
-	scanners := make([]FileScanner, len(fileHeaders))
+	registeredMu.Lock()
+	defer registeredMu.Unlock()
+
+	return allFileScanners()
+}
+
+// SupportedExtensions returns the extensions of the built-in and the registered
+// file formats, in the order they are searched for.
+func SupportedExtensions() []string {
+	scanners := GetAllFileScanners()
+
+	exts := make([]string, len(scanners))
+	for i, sc := range scanners {
+		exts[i] = sc.Ext()
+	}
+	return exts
+}
+
+// Lookup returns the description of the built-in or registered format with the given
+// extension, and reports whether it is supported. For scanners not created by
+// NewFileScanner, the header is filled from their methods, including the optional ones.
+func Lookup(ext string) (FileHeader, bool) {
+	for _, sc := range GetAllFileScanners() {
+		if sc.Ext() == ext {
+			return scannerHeader(sc), true
+		}
+	}
+	return FileHeader{}, false
+}
+
+// scannerHeader returns the FileHeader describing the format of sc.
+func scannerHeader(sc FileScanner) FileHeader {
+	if s, ok := sc.(*headerFileScanner); ok {
+		hdr := s.hdr
+		hdr.Category = ScannerCategory(sc)
+		return hdr
+	}
+
+	hdr := FileHeader{
+		Ext:             sc.Ext(),
+		Description:     sc.Description(),
+		Category:        ScannerCategory(sc),
+		Signatures:      sc.Signatures(),
+		ScanFile:        sc.ScanFile,
+		SignatureOffset: ScannerSignatureOffset(sc),
+		Entropy:         ScannerEntropy(sc),
+	}
+	if f, ok := sc.(FooterScanner); ok {
+		hdr.Footers = f.Footers()
+		hdr.FooterSize = f.FooterSize()
+		hdr.ScanFooter = f.ScanFooter
+	}
+	return hdr
+}
+
+// allFileScanners returns the built-in and the registered scanners.
+// The caller must hold registeredMu.
+func allFileScanners() []FileScanner {
+	scanners := make([]FileScanner, len(fileHeaders), len(fileHeaders)+len(registeredScanners))
 	for i, hdr := range fileHeaders {
 		scanners[i] = &headerFileScanner{hdr: hdr}
 	}
-	return scanners
+	return append(scanners, registeredScanners...)
+}
+
+// Categories returns the categories of the built-in file formats.
+func Categories() []Category {
+	return []Category{
+		CategoryAudio,
+		CategoryImage,
+		CategoryVideo,
+		CategoryDocument,
+		CategoryDatabase,
+		CategoryMemory,
+	}
+}
+
+// ScannerCategory returns the category of the given scanner,
+// or CategoryOther if the scanner does not declare one.
+func ScannerCategory(sc FileScanner) Category {
+	if c := sc.Category(); c != "" {
+		return c
+	}
+	return CategoryOther
+}
+
+// ScannerSignatureOffset returns the offset of the signatures of the given scanner from
+// the start of the file, or 0 if the scanner does not declare one.
+func ScannerSignatureOffset(sc FileScanner) int {
+	if o, ok := sc.(interface{ SignatureOffset() int }); ok {
+		return o.SignatureOffset()
+	}
+	return 0
+}
+
+// ScannerFooters returns the footer signatures of the given scanner (see FooterScanner),
+// or nil if the scanner does not declare any.
+func ScannerFooters(sc FileScanner) [][]byte {
+	if f, ok := sc.(FooterScanner); ok {
+		return f.Footers()
+	}
+	return nil
 }
 
 func BuildFileRegistry(scanners ...FileScanner) *FileRegistry {
@@ -96,18 +352,46 @@ func BuildFileRegistry(scanners ...FileScanner) *FileRegistry {
 	return r
 }
 
+// LoadPlugins loads the scanners exported by the given plugins.
+// Files with the .wasm extension are loaded as WebAssembly modules,
+// while any other file is assumed to be a native Go plugin.
 func LoadPlugins(pluginPaths ...string) ([]FileScanner, error) {
 	scanners := make([]FileScanner, len(pluginPaths))
 	for i, path := range pluginPaths {
-		sc, err := loadPlugin(path)
+		var (
+			sc  FileScanner
+			err error
+		)
+
+		if filepath.Ext(path) == ".wasm" {
+			sc, err = loadWasmPlugin(path)
+		} else {
+			sc, err = loadPlugin(path)
+		}
 		if err != nil {
+			ClosePlugins(scanners[:i])
 			return nil, fmt.Errorf("failed to load plugin %s: %w", path, err)
 		}
 		scanners[i] = sc
+
+		if err := ValidateScanner(sc); err != nil {
+			ClosePlugins(scanners[:i+1])
+			return nil, fmt.Errorf("plugin %s returned an invalid scanner: %w", path, err)
+		}
 	}
 	return scanners, nil
 }
 
+// ClosePlugins releases the resources held by the scanners returned by LoadPlugins,
+// such as the runtimes of WebAssembly modules. The scanners cannot be used afterwards.
+func ClosePlugins(scanners []FileScanner) {
+	for _, sc := range scanners {
+		if c, ok := sc.(interface{ Close(context.Context) error }); ok {
+			_ = c.Close(context.Background())
+		}
+	}
+}
+
 func loadPlugin(path string) (FileScanner, error) {
 	plug, err := plugin.Open(path)
 	if err != nil {
@@ -123,9 +407,49 @@ func loadPlugin(path string) (FileScanner, error) {
 	if !ok {
 		return nil, fmt.Errorf("plugin %s GetScanner has wrong type", path)
 	}
+
 	return getScanner()
 }
 
+// ValidateScanner checks that a scanner declares a non-empty extension
+// and at least one signature, and that none of its signatures is empty.
+// An empty signature would match at every block of the scanned image.
+func ValidateScanner(sc FileScanner) error {
+	if sc == nil {
+		return fmt.Errorf("scanner is nil")
+	}
+
+	if sc.Ext() == "" {
+		return fmt.Errorf("scanner has an empty extension")
+	}
+
+	sigs := sc.Signatures()
+	footers := ScannerFooters(sc)
+	if len(sigs) == 0 && len(footers) == 0 {
+		return fmt.Errorf("scanner %q declares no signatures", sc.Ext())
+	}
+
+	for i, sig := range sigs {
+		if len(sig) == 0 {
+			return fmt.Errorf("scanner %q has an empty signature at index %d", sc.Ext(), i)
+		}
+	}
+
+	if len(footers) > 0 {
+		size := sc.(FooterScanner).FooterSize()
+		for i, footer := range footers {
+			if len(footer) == 0 || len(footer) > size {
+				return fmt.Errorf("scanner %q has an invalid footer at index %d", sc.Ext(), i)
+			}
+		}
+	}
+	return nil
+}
+
 func (r *FileRegistry) Signatures() int {
-	return r.table.Size()
+	n := 0
+	for _, t := range r.tables {
+		n += t.table.Size()
+	}
+	return n + len(r.footers)
 }
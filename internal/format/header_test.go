@@ -0,0 +1,178 @@
+package format
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestGetFileScanners(t *testing.T) {
+	scanners, err := GetFileScanners("jpeg", "png")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(scanners) != 2 || scanners[0].Ext() != "jpeg" || scanners[1].Ext() != "png" {
+		t.Fatalf("unexpected scanners: %v", scanners)
+	}
+}
+
+func TestScanFileFooterOnly(t *testing.T) {
+	// Footer-only scanners may be called like the others by library users.
+	scanners, err := GetFileScanners("dmg")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := scanners[0].ScanFile(newBytesReader(make([]byte, 16))); err == nil {
+		t.Errorf("expected an error from the dmg scanner")
+	}
+}
+
+func TestGetFileScannersUnknownExtension(t *testing.T) {
+	_, err := GetFileScanners("jpeg", "nope")
+	if !errors.Is(err, ErrUnknownExtension) {
+		t.Fatalf("expected ErrUnknownExtension, got %v", err)
+	}
+
+	if !strings.Contains(err.Error(), `"nope"`) {
+		t.Fatalf("error message should report the offending extension: %v", err)
+	}
+}
+
+func TestRegisterScanner(t *testing.T) {
+	t.Cleanup(func() { registeredScanners = nil })
+
+	sc := NewFileScanner(FileHeader{
+		Ext:        "custom",
+		Signatures: [][]byte{[]byte("CUSTOM")},
+		ScanFile: func(r *Reader) (*ScanResult, error) {
+			return &ScanResult{Size: 6}, nil
+		},
+	})
+
+	if err := RegisterScanner(sc); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	scanners, err := GetFileScanners("custom")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(scanners) != 1 || scanners[0] != sc {
+		t.Fatalf("unexpected scanners: %v", scanners)
+	}
+
+	if all := GetAllFileScanners(); all[len(all)-1] != sc {
+		t.Fatalf("registered scanner should follow the built-in ones")
+	}
+
+	for _, ext := range []string{"custom", "jpeg"} {
+		dup := NewFileScanner(FileHeader{Ext: ext, Signatures: [][]byte{[]byte("X")}})
+		if err := RegisterScanner(dup); !errors.Is(err, ErrDuplicateExtension) {
+			t.Errorf("expected ErrDuplicateExtension for %q, got %v", ext, err)
+		}
+	}
+
+	if err := RegisterScanner(NewFileScanner(FileHeader{Ext: "nosig"})); err == nil {
+		t.Errorf("expected an error for a scanner without signatures")
+	}
+}
+
+// customScanner is a FileScanner not created by NewFileScanner.
+type customScanner struct{}
+
+func (customScanner) Ext() string                           { return "tail" }
+func (customScanner) Description() string                   { return "Custom format" }
+func (customScanner) Category() Category                    { return "" }
+func (customScanner) Signatures() [][]byte                  { return [][]byte{[]byte("HEAD")} }
+func (customScanner) ScanFile(*Reader) (*ScanResult, error) { return nil, nil }
+func (customScanner) Footers() [][]byte                     { return [][]byte{[]byte("TAIL")} }
+func (customScanner) FooterSize() int                       { return 8 }
+func (customScanner) ScanFooter([]byte) (*ScanResult, error) {
+	return nil, nil
+}
+
+func TestLookup(t *testing.T) {
+	t.Cleanup(func() { registeredScanners = nil })
+
+	if err := RegisterScanner(customScanner{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	exts := SupportedExtensions()
+	if len(exts) != len(fileHeaders)+1 || exts[0] != fileHeaders[0].Ext || exts[len(exts)-1] != "tail" {
+		t.Fatalf("unexpected extensions: %v", exts)
+	}
+
+	tests := []struct {
+		ext        string
+		found      bool
+		category   Category
+		footerSize int
+	}{
+		{ext: "jpeg", found: true, category: CategoryImage},
+		{ext: "tail", found: true, category: CategoryOther, footerSize: 8},
+		{ext: "nope", found: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.ext, func(t *testing.T) {
+			hdr, ok := Lookup(tt.ext)
+			if ok != tt.found {
+				t.Fatalf("Lookup(%q) found = %v, want %v", tt.ext, ok, tt.found)
+			}
+			if !ok {
+				return
+			}
+
+			if hdr.Ext != tt.ext || hdr.Description == "" || len(hdr.Signatures) == 0 || hdr.ScanFile == nil {
+				t.Errorf("incomplete header: %+v", hdr)
+			}
+			if hdr.Category != tt.category {
+				t.Errorf("Category = %q, want %q", hdr.Category, tt.category)
+			}
+			if hdr.FooterSize != tt.footerSize {
+				t.Errorf("FooterSize = %d, want %d", hdr.FooterSize, tt.footerSize)
+			}
+		})
+	}
+}
+
+func TestParseConfidence(t *testing.T) {
+	for _, c := range []Confidence{ConfidenceHeaderOnly, ConfidenceStructural, ConfidenceFullyValidated} {
+		got, err := ParseConfidence(c.String())
+		if err != nil || got != c {
+			t.Errorf("ParseConfidence(%q) = %v, %v, want %v", c.String(), got, err, c)
+		}
+	}
+
+	if _, err := ParseConfidence("high"); err == nil {
+		t.Error("expected an error for an unknown confidence level")
+	}
+}
+
+func TestFindSignatureConflicts(t *testing.T) {
+	if conflicts := FindSignatureConflicts(GetAllFileScanners()...); len(conflicts) != 0 {
+		t.Errorf("unexpected conflicts between built-in signatures: %+v", conflicts)
+	}
+
+	short := NewFileScanner(FileHeader{Ext: "short", Signatures: [][]byte{[]byte("AB")}})
+	long := NewFileScanner(FileHeader{Ext: "long", Signatures: [][]byte{[]byte("ABC"), []byte("XYZ")}})
+	same := NewFileScanner(FileHeader{Ext: "same", Signatures: [][]byte{[]byte("XYZ")}})
+	offset := NewFileScanner(FileHeader{Ext: "offset", Signatures: [][]byte{[]byte("A")}, SignatureOffset: 4})
+	twice := NewFileScanner(FileHeader{Ext: "twice", Signatures: [][]byte{[]byte("TW"), []byte("TW")}})
+
+	conflicts := FindSignatureConflicts(short, long, same, offset, twice)
+	if len(conflicts) != 2 {
+		t.Fatalf("expected 2 conflicts, got %+v", conflicts)
+	}
+
+	if c := conflicts[0]; c.Ext != "long" || string(c.Signature) != "ABC" || c.PrefixExt != "short" || string(c.Prefix) != "AB" {
+		t.Errorf("unexpected conflict %+v", c)
+	}
+	if c := conflicts[1]; c.Ext != "twice" || c.PrefixExt != "twice" {
+		t.Errorf("unexpected conflict %+v", c)
+	}
+}
@@ -16,18 +16,47 @@
 package format
 
 import (
+	"bytes"
 	"fmt"
+	"io"
 )
 
 var jpegFileHeader = FileHeader{
 	Ext:         "jpeg",
 	Description: "Joint Photographic Experts Group Format",
+	Category:    CategoryImage,
+	Entropy:     EntropyHigh,
 	Signatures: [][]byte{
 		{0xFF, 0xD8, 0xFF},
 	},
 	ScanFile: ScanJPEG,
 }
 
+// maxJPEGTrailingData bounds the trailing bytes included after the last EOI marker
+// when JPEGOptions.IncludeTrailingData is set, to avoid carving unrelated data.
+const maxJPEGTrailingData = 1024 * 1024
+
+// JPEGOptions controls how the end of a JPEG file is determined.
+// The zero value stops at the first End Of Image marker.
+type JPEGOptions struct {
+	// FollowConcatenated continues past an EOI marker immediately followed
+	// by another SOI marker, as in MPO (multi-picture/stereo) files lacking
+	// an MP index, which are otherwise sized through it.
+	FollowConcatenated bool
+	// IncludeTrailingData includes the bytes following the last EOI marker, up to the
+	// next recognized file header (and at most maxJPEGTrailingData bytes).
+	IncludeTrailingData bool
+}
+
+// NewJPEGScanner returns a JPEG scanner which determines the end of a file according to opts.
+func NewJPEGScanner(opts JPEGOptions) FileScanner {
+	hdr := jpegFileHeader
+	hdr.ScanFile = func(r *Reader) (*ScanResult, error) {
+		return scanJPEG(r, opts)
+	}
+	return &headerFileScanner{hdr: hdr}
+}
+
 const (
 	sof0Marker = 0xc0 // Start Of Frame (Baseline Sequential).
 	sof1Marker = 0xc1 // Start Of Frame (Extended Sequential).
@@ -63,10 +92,18 @@ const (
 // (0xFF fill bytes), and restart markers (RSTn) in a robust way, mimicking
 // libjpeg's leniency, to reliably locate the file's end.
 //
-// It returns the total size of the JPEG file (the offset of the EOI marker
-// plus its 2-byte length) or the buffer's length if the file appears truncated.
-// It returns an error if the file is malformed or doesn't start with an SOI marker.
+// It returns a ScanResult whose Size is the total size of the JPEG file (the offset
+// of the EOI marker plus its 2-byte length). It returns an error if the file is
+// malformed, truncated or doesn't start with an SOI marker.
+//
+// Files in the Multi-Picture Format, whose first image indexes the images following it,
+// are carved whole, with the "mpo" extension if the images form a stereo or multi-angle
+// set. The images can be carved individually through recursive carving.
 func ScanJPEG(r *Reader) (*ScanResult, error) {
+	return scanJPEG(r, JPEGOptions{})
+}
+
+func scanJPEG(r *Reader, opts JPEGOptions) (*ScanResult, error) {
 	// Check for the Start Of Image marker.
 	var tmp [2]byte
 
@@ -79,6 +116,9 @@ func ScanJPEG(r *Reader) (*ScanResult, error) {
 		return nil, fmt.Errorf("missing SOI marker")
 	}
 
+	// The MP index of the file, if it holds multiple images.
+	var mpf *mpIndex
+
 	// Process the remaining segments until the End Of Image marker.
 	for {
 		_, err := r.Read(tmp[:])
@@ -126,7 +166,26 @@ func ScanJPEG(r *Reader) (*ScanResult, error) {
 			}
 		}
 		if marker == eoiMarker { // End Of Image.
-			return &ScanResult{Size: uint64(r.BytesRead())}, nil
+			if mpf != nil && skipMPImages(r, mpf) {
+				ext := "jpeg"
+				if mpf.stereo {
+					ext = "mpo"
+				}
+				return &ScanResult{Size: r.BytesRead(), Ext: ext, Confidence: ConfidenceStructural}, nil
+			}
+
+			if opts.FollowConcatenated && followsSOI(r) {
+				// Skip the SOI marker of the next image and keep scanning.
+				if _, err := r.Discard(2); err != nil {
+					return nil, err
+				}
+				continue
+			}
+
+			if opts.IncludeTrailingData {
+				skipTrailingData(r)
+			}
+			return &ScanResult{Size: uint64(r.BytesRead()), Confidence: ConfidenceStructural}, nil
 		}
 		if rst0Marker <= marker && marker <= rst7Marker {
 			// Figures B.2 and B.16 of the specification suggest that restart markers should
@@ -153,6 +212,19 @@ func ScanJPEG(r *Reader) (*ScanResult, error) {
 			dhtMarker, dqtMarker, sosMarker,
 			driMarker, app0Marker, app14Marker:
 			_, err = r.Discard(n)
+		case app2Marker:
+			// APP2 segments also hold ICC profiles: only read the MPF one.
+			if id, _ := r.Peek(len(mpfIdentifier)); mpf != nil || n < len(mpfIdentifier) || !bytes.Equal(id, mpfIdentifier) {
+				_, err = r.Discard(n)
+				break
+			}
+
+			segOffset := r.BytesRead()
+
+			seg := make([]byte, n)
+			if _, err = io.ReadFull(r, seg); err == nil {
+				mpf = parseMPF(seg, segOffset)
+			}
 		default:
 			if app0Marker <= marker && marker <= app15Marker || marker == comMarker {
 				_, err = r.Discard(n)
@@ -167,3 +239,19 @@ func ScanJPEG(r *Reader) (*ScanResult, error) {
 		}
 	}
 }
+
+// followsSOI reports whether the next bytes of r are the start of another JPEG image.
+func followsSOI(r *Reader) bool {
+	next, err := r.Peek(3)
+	return err == nil && next[0] == 0xff && next[1] == soiMarker && next[2] == 0xff
+}
+
+// skipTrailingData consumes the bytes following a JPEG image, stopping at
+// the next recognized file header, at the end of r or after maxJPEGTrailingData bytes.
+func skipTrailingData(r *Reader) {
+	for n := 0; n < maxJPEGTrailingData && !r.AtHeader(); n++ {
+		if _, err := r.ReadByte(); err != nil {
+			return
+		}
+	}
+}
@@ -0,0 +1,261 @@
+// Copyright (c) 2025 Stefano Scafiti
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package format
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"math/bits"
+)
+
+var matroskaFileHeader = FileHeader{
+	Ext:         "mkv",
+	Description: "Matroska/WebM media container",
+	Category:    CategoryVideo,
+	Signatures: [][]byte{
+		{0x1A, 0x45, 0xDF, 0xA3}, // EBML header element ID
+	},
+	ScanFile: ScanMatroska,
+}
+
+// EBML element IDs, including their length marker.
+const (
+	ebmlIDHeader     = 0x1A45DFA3
+	ebmlIDDocType    = 0x4282
+	mkvIDSegment     = 0x18538067
+	mkvIDTracks      = 0x1654AE6B
+	mkvIDTrackEntry  = 0xAE
+	mkvIDCodecID     = 0x86
+	mkvIDCluster     = 0x1F43B675
+	mkvIDSeekHead    = 0x114D9B74
+	mkvIDInfo        = 0x1549A966
+	mkvIDCues        = 0x1C53BB6B
+	mkvIDAttachments = 0x1941A469
+	mkvIDChapters    = 0x1043A770
+	mkvIDTags        = 0x1254C367
+	ebmlIDVoid       = 0xEC
+	ebmlIDCRC32      = 0xBF
+)
+
+const (
+	// ebmlUnknownSize is returned as the size of elements whose size is unknown.
+	ebmlUnknownSize = ^uint64(0)
+	// ebmlMaxHeaderSize is the size of the largest element header: a 4-byte ID and an 8-byte size.
+	ebmlMaxHeaderSize = 12
+	// mkvMaxEBMLHeaderSize bounds the size of the EBML header element.
+	mkvMaxEBMLHeaderSize = 1024
+	// mkvMaxTracksSize bounds the size of the Tracks element, which is read to sniff the codecs.
+	mkvMaxTracksSize = 1024 * 1024
+)
+
+// mkvSegmentChildren holds the IDs of the top-level elements of a Matroska segment.
+var mkvSegmentChildren = map[uint32]bool{
+	mkvIDSeekHead:    true,
+	mkvIDInfo:        true,
+	mkvIDTracks:      true,
+	mkvIDCluster:     true,
+	mkvIDCues:        true,
+	mkvIDAttachments: true,
+	mkvIDChapters:    true,
+	mkvIDTags:        true,
+	ebmlIDVoid:       true,
+	ebmlIDCRC32:      true,
+}
+
+// ScanMatroska scans a Matroska (or WebM) file, made of the EBML header and of a Segment
+// element holding the whole content. The size is taken from the segment; when the segment
+// size is unknown, as for live recordings, its children are walked until the end of the
+// data or an unexpected element. The codecs of the tracks decide between mkv and webm.
+func ScanMatroska(r *Reader) (*ScanResult, error) {
+	id, size, hdrLen, err := readEBMLElementHeader(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read EBML header: %w", err)
+	}
+
+	if id != ebmlIDHeader || size > mkvMaxEBMLHeaderSize {
+		return nil, fmt.Errorf("invalid EBML header")
+	}
+
+	body := make([]byte, size)
+	if _, err := io.ReadFull(r, body); err != nil {
+		return nil, fmt.Errorf("failed to read EBML header: %w", err)
+	}
+
+	var docType string
+	walkEBMLElements(body, func(id uint32, data []byte) {
+		if id == ebmlIDDocType {
+			docType = string(data)
+		}
+	})
+
+	if docType != "matroska" && docType != "webm" {
+		return nil, fmt.Errorf("unsupported EBML document type %q", docType)
+	}
+
+	id, segSize, segHdrLen, err := readEBMLElementHeader(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read Matroska segment: %w", err)
+	}
+
+	if id != mkvIDSegment {
+		return nil, fmt.Errorf("EBML header is not followed by a Matroska segment")
+	}
+
+	walked, codecs := walkMatroskaSegment(r, segSize)
+
+	total := uint64(hdrLen) + size + uint64(segHdrLen)
+	if segSize != ebmlUnknownSize {
+		total += segSize
+	} else {
+		total += walked
+	}
+
+	return &ScanResult{
+		Ext:        matroskaCodecExt(docType, codecs),
+		Size:       total,
+		Confidence: ConfidenceStructural,
+	}, nil
+}
+
+// walkMatroskaSegment walks the children of a segment of the given size (possibly unknown),
+// and returns the size of the children walked and the codec IDs of the tracks.
+func walkMatroskaSegment(r *Reader, segSize uint64) (uint64, []string) {
+	var (
+		walked uint64
+		codecs []string
+	)
+
+	for segSize == ebmlUnknownSize || walked < segSize {
+		id, size, hdrLen, err := readEBMLElementHeader(r)
+		if err != nil || !mkvSegmentChildren[id] || size == ebmlUnknownSize {
+			break
+		}
+
+		if id == mkvIDTracks && size <= mkvMaxTracksSize {
+			tracks := make([]byte, size)
+			if _, err := io.ReadFull(r, tracks); err != nil {
+				break
+			}
+			codecs = append(codecs, matroskaCodecs(tracks)...)
+		} else if size > math.MaxInt64 {
+			break
+		} else if n, err := r.Discard(int(size)); err != nil || uint64(n) != size {
+			break
+		}
+		walked += uint64(hdrLen) + size
+	}
+	return walked, codecs
+}
+
+// matroskaCodecs returns the codec IDs of the entries of a Tracks element.
+func matroskaCodecs(tracks []byte) []string {
+	var codecs []string
+	walkEBMLElements(tracks, func(id uint32, data []byte) {
+		if id != mkvIDTrackEntry {
+			return
+		}
+
+		walkEBMLElements(data, func(id uint32, data []byte) {
+			if id == mkvIDCodecID {
+				codecs = append(codecs, string(data))
+			}
+		})
+	})
+	return codecs
+}
+
+// readEBMLElementHeader reads the ID and the data size of an EBML element, and returns
+// them together with the length of the header.
+func readEBMLElementHeader(r *Reader) (uint32, uint64, int, error) {
+	hdr, err := r.Peek(ebmlMaxHeaderSize)
+	if len(hdr) == 0 {
+		if err == nil {
+			err = io.EOF
+		}
+		return 0, 0, 0, err
+	}
+
+	id, size, n, ok := parseEBMLElementHeader(hdr)
+	if !ok {
+		return 0, 0, 0, fmt.Errorf("invalid EBML element header")
+	}
+
+	if _, err := r.Discard(n); err != nil {
+		return 0, 0, 0, err
+	}
+	return id, size, n, nil
+}
+
+// walkEBMLElements calls fn for each element of data, stopping at the first invalid one.
+func walkEBMLElements(data []byte, fn func(id uint32, data []byte)) {
+	for len(data) > 0 {
+		id, size, n, ok := parseEBMLElementHeader(data)
+		if !ok || size > uint64(len(data)-n) {
+			return
+		}
+
+		fn(id, data[n:n+int(size)])
+		data = data[n+int(size):]
+	}
+}
+
+// parseEBMLElementHeader parses the ID and the data size of the EBML element at the start
+// of b. Unlike data sizes, IDs keep their length marker.
+func parseEBMLElementHeader(b []byte) (id uint32, size uint64, n int, ok bool) {
+	idLen := ebmlVintLen(b)
+	if idLen == 0 || idLen > 4 {
+		return 0, 0, 0, false
+	}
+
+	for _, c := range b[:idLen] {
+		id = id<<8 | uint32(c)
+	}
+
+	sizeLen := ebmlVintLen(b[idLen:])
+	if sizeLen == 0 {
+		return 0, 0, 0, false
+	}
+
+	size = uint64(b[idLen]) & (0xFF >> sizeLen)
+	for _, c := range b[idLen+1 : idLen+sizeLen] {
+		size = size<<8 | uint64(c)
+	}
+
+	// A size with all the value bits set is reserved for elements of unknown size.
+	if size == 1<<(7*sizeLen)-1 {
+		size = ebmlUnknownSize
+	}
+	return id, size, idLen + sizeLen, true
+}
+
+// ebmlVintLen returns the length of the variable-length integer at the start of b,
+// given by the position of the first set bit, or 0 if it is invalid or truncated.
+func ebmlVintLen(b []byte) int {
+	if len(b) == 0 || b[0] == 0 {
+		return 0
+	}
+
+	n := bits.LeadingZeros8(b[0]) + 1
+	if n > len(b) {
+		return 0
+	}
+	return n
+}
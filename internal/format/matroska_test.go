@@ -0,0 +1,114 @@
+package format
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+)
+
+// ebmlElement encodes an EBML element with an 8-byte data size.
+func ebmlElement(id uint32, data ...[]byte) []byte {
+	var elem []byte
+	for shift := 24; shift >= 0; shift -= 8 {
+		if b := byte(id >> shift); b != 0 || len(elem) > 0 {
+			elem = append(elem, b)
+		}
+	}
+
+	body := bytes.Join(data, nil)
+	elem = binary.BigEndian.AppendUint64(elem, uint64(len(body)))
+	elem[len(elem)-8] = 0x01 // length marker
+	return append(elem, body...)
+}
+
+func matroskaFile(docType string, segmentSize uint64, codecs ...string) []byte {
+	var tracks [][]byte
+	for _, c := range codecs {
+		tracks = append(tracks, ebmlElement(mkvIDTrackEntry, ebmlElement(mkvIDCodecID, []byte(c))))
+	}
+
+	segment := ebmlElement(mkvIDSegment,
+		ebmlElement(mkvIDInfo, make([]byte, 20)),
+		ebmlElement(mkvIDTracks, tracks...),
+		ebmlElement(mkvIDCluster, make([]byte, 1000)),
+		ebmlElement(mkvIDCues, make([]byte, 30)),
+	)
+	if segmentSize != 0 {
+		binary.BigEndian.PutUint64(segment[4:12], segmentSize)
+		segment[4] = 0x01
+	}
+
+	return append(ebmlElement(ebmlIDHeader, ebmlElement(ebmlIDDocType, []byte(docType))), segment...)
+}
+
+func TestScanMatroska(t *testing.T) {
+	tests := []struct {
+		name    string
+		docType string
+		codecs  []string
+		ext     string
+	}{
+		{"webm", "webm", []string{"V_VP9", "A_OPUS"}, "webm"},
+		{"vp8 in matroska", "matroska", []string{"V_VP8"}, "webm"},
+		{"mkv", "matroska", []string{"V_MPEG4/ISO/AVC", "A_AAC"}, "mkv"},
+		{"av1 and vorbis in matroska", "matroska", []string{"V_AV1", "A_VORBIS"}, "webm"},
+		{"webm doctype with mkv codecs", "webm", []string{"V_VP9", "A_AAC"}, "webm"},
+		{"no tracks", "webm", nil, "webm"},
+		{"no tracks in matroska", "matroska", nil, "mkv"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			file := matroskaFile(tt.docType, 0, tt.codecs...)
+			data := append(bytes.Clone(file), make([]byte, 64)...)
+
+			res, err := ScanMatroska(newBytesReader(data))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if res.Size != uint64(len(file)) {
+				t.Errorf("expected size %d, got %d", len(file), res.Size)
+			}
+
+			if res.Ext != tt.ext {
+				t.Errorf("expected ext %q, got %q", tt.ext, res.Ext)
+			}
+		})
+	}
+}
+
+func TestScanMatroskaUnknownSegmentSize(t *testing.T) {
+	file := matroskaFile("webm", 1<<56-1, "V_VP8", "A_OPUS")
+	data := append(bytes.Clone(file), matroskaFile("webm", 0)...)
+
+	res, err := ScanMatroska(newBytesReader(data))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if res.Size != uint64(len(file)) {
+		t.Errorf("expected size %d, got %d", len(file), res.Size)
+	}
+}
+
+func TestScanMatroskaInvalid(t *testing.T) {
+	noSegment := ebmlElement(ebmlIDHeader, ebmlElement(ebmlIDDocType, []byte("webm")))
+
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{"unknown doctype", matroskaFile("foo", 0)},
+		{"no segment", append(noSegment, ebmlElement(mkvIDCluster)...)},
+		{"truncated header", matroskaFile("webm", 0)[:10]},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ScanMatroska(newBytesReader(tt.data)); err == nil {
+				t.Errorf("expected an error")
+			}
+		})
+	}
+}
@@ -0,0 +1,198 @@
+// Copyright (c) 2025 Stefano Scafiti
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package format
+
+import (
+	"cmp"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+)
+
+var minidumpFileHeader = FileHeader{
+	Ext:         "dmp",
+	Description: "Windows Minidump Format",
+	Category:    CategoryMemory,
+	Signatures: [][]byte{
+		[]byte("MDMP\x93\xa7"),
+	},
+	ScanFile: ScanMinidump,
+}
+
+const (
+	minidumpHeaderSize         = 32
+	minidumpDirectoryEntrySize = 12
+
+	// minidumpMaxStreams and minidumpMaxMemoryRanges bound the tables of a minidump,
+	// to reject garbage quickly.
+	minidumpMaxStreams      = 4096
+	minidumpMaxMemoryRanges = 1 << 20
+
+	minidumpMemoryListStream   = 5
+	minidumpMemory64ListStream = 9
+)
+
+// minidumpLocation is the location of a stream of a minidump.
+type minidumpLocation struct {
+	streamType uint32
+	size       uint64
+	rva        uint64
+}
+
+// ScanMinidump carves a Windows minidump, sizing it from its stream directory.
+// Streams listing memory ranges are read too, since the memory they describe is
+// stored outside of them, usually at the end of the file.
+func ScanMinidump(r *Reader) (*ScanResult, error) {
+	// Minidump Header Structure: https://learn.microsoft.com/en-us/windows/win32/api/minidumpapiset/ns-minidumpapiset-minidump_header
+	// -----------------------------------------
+	// Signature            (4 bytes)        "MDMP"
+	// Version              (4 bytes)        Little-endian; the low word is 0xA793
+	// NumberOfStreams      (4 bytes)        Little-endian; number of entries of the stream directory
+	// StreamDirectoryRva   (4 bytes)        Little-endian; offset of the stream directory
+	// CheckSum             (4 bytes)        Little-endian; usually 0
+	// TimeDateStamp        (4 bytes)        Little-endian; creation time
+	// Flags                (8 bytes)        Little-endian; MINIDUMP_TYPE flags
+	//
+	// Each directory entry is made of the stream type, and of the size and offset (RVA) of the stream.
+
+	var hdr [minidumpHeaderSize]byte
+	if _, err := io.ReadFull(r, hdr[:]); err != nil {
+		return nil, fmt.Errorf("failed to read minidump header: %w", err)
+	}
+
+	numStreams := binary.LittleEndian.Uint32(hdr[8:12])
+	dirRva := uint64(binary.LittleEndian.Uint32(hdr[12:16]))
+	if numStreams == 0 || numStreams > minidumpMaxStreams {
+		return nil, fmt.Errorf("invalid number of minidump streams: %d", numStreams)
+	}
+	if dirRva < minidumpHeaderSize {
+		return nil, fmt.Errorf("invalid minidump stream directory offset: %d", dirRva)
+	}
+
+	if _, err := r.Discard(int(dirRva - minidumpHeaderSize)); err != nil {
+		return nil, err
+	}
+
+	dirEnd := dirRva + uint64(numStreams)*minidumpDirectoryEntrySize
+	size := dirEnd
+
+	streams := make([]minidumpLocation, numStreams)
+	for i := range streams {
+		var entry [minidumpDirectoryEntrySize]byte
+		if _, err := io.ReadFull(r, entry[:]); err != nil {
+			return nil, fmt.Errorf("failed to read minidump stream directory: %w", err)
+		}
+
+		streams[i] = minidumpLocation{
+			streamType: binary.LittleEndian.Uint32(entry[0:4]),
+			size:       uint64(binary.LittleEndian.Uint32(entry[4:8])),
+			rva:        uint64(binary.LittleEndian.Uint32(entry[8:12])),
+		}
+		size = max(size, streams[i].rva+streams[i].size)
+	}
+
+	// Memory lists are read by increasing offset, since the reader only moves forward.
+	var memoryLists []minidumpLocation
+	for _, s := range streams {
+		if s.streamType == minidumpMemoryListStream || s.streamType == minidumpMemory64ListStream {
+			memoryLists = append(memoryLists, s)
+		}
+	}
+	slices.SortFunc(memoryLists, func(a, b minidumpLocation) int {
+		return cmp.Compare(a.rva, b.rva)
+	})
+
+	pos := dirEnd
+	for _, s := range memoryLists {
+		if s.rva < pos {
+			return nil, errors.New("overlapping minidump memory lists")
+		}
+		if _, err := r.Discard(int(s.rva - pos)); err != nil {
+			return nil, err
+		}
+
+		end, n, err := readMinidumpMemoryList(r, s)
+		if err != nil {
+			return nil, err
+		}
+		pos = s.rva + n
+		size = max(size, end)
+	}
+
+	return &ScanResult{
+		Size:       size,
+		Confidence: ConfidenceStructural,
+	}, nil
+}
+
+// readMinidumpMemoryList reads the memory list stream at the current position of
+// the reader, returning the end of the memory it describes and the bytes read.
+func readMinidumpMemoryList(r *Reader, s minidumpLocation) (end, n uint64, err error) {
+	// MINIDUMP_MEMORY_LIST: NumberOfMemoryRanges (4 bytes), followed by descriptors of
+	// 16 bytes: StartOfMemoryRange (8 bytes), DataSize (4 bytes) and Rva (4 bytes).
+	// MINIDUMP_MEMORY64_LIST: NumberOfMemoryRanges (8 bytes) and BaseRva (8 bytes), followed by
+	// descriptors of 16 bytes: StartOfMemoryRange (8 bytes) and DataSize (8 bytes). The memory
+	// of the ranges is stored contiguously from BaseRva.
+
+	is64 := s.streamType == minidumpMemory64ListStream
+
+	hdr := make([]byte, 4)
+	if is64 {
+		hdr = make([]byte, 16)
+	}
+	if _, err := io.ReadFull(r, hdr); err != nil {
+		return 0, 0, fmt.Errorf("failed to read minidump memory list: %w", err)
+	}
+
+	var count, baseRva uint64
+	if is64 {
+		count = binary.LittleEndian.Uint64(hdr[0:8])
+		baseRva = binary.LittleEndian.Uint64(hdr[8:16])
+	} else {
+		count = uint64(binary.LittleEndian.Uint32(hdr[0:4]))
+	}
+
+	if count > minidumpMaxMemoryRanges || uint64(len(hdr))+count*16 > s.size {
+		return 0, 0, fmt.Errorf("invalid number of minidump memory ranges: %d", count)
+	}
+
+	end = baseRva
+	for range count {
+		var desc [16]byte
+		if _, err := io.ReadFull(r, desc[:]); err != nil {
+			return 0, 0, fmt.Errorf("failed to read minidump memory descriptor: %w", err)
+		}
+
+		if is64 {
+			dataSize := binary.LittleEndian.Uint64(desc[8:16])
+			if end+dataSize < end {
+				return 0, 0, errors.New("invalid minidump memory range size")
+			}
+			end += dataSize
+		} else {
+			dataSize := uint64(binary.LittleEndian.Uint32(desc[8:12]))
+			rva := uint64(binary.LittleEndian.Uint32(desc[12:16]))
+			end = max(end, rva+dataSize)
+		}
+	}
+	return end, uint64(len(hdr)) + count*16, nil
+}
@@ -0,0 +1,59 @@
+package format
+
+import (
+	"encoding/binary"
+	"testing"
+)
+
+// minidumpFixture returns a minidump with a thread list stream and a memory64 list stream
+// describing two memory ranges, stored at the end of the file, followed by trailing data.
+func minidumpFixture() (data []byte, size int) {
+	le := binary.LittleEndian
+
+	data = make([]byte, 32)
+	copy(data, "MDMP")
+	le.PutUint32(data[4:8], 0xa793)
+	le.PutUint32(data[8:12], 2)   // NumberOfStreams
+	le.PutUint32(data[12:16], 32) // StreamDirectoryRva
+
+	// Stream directory, followed by the streams: the memory list comes first in the file.
+	threadListRva, memoryListRva := uint32(32+2*12+48), uint32(32+2*12)
+	data = le.AppendUint32(data, 3) // ThreadListStream
+	data = le.AppendUint32(data, 16)
+	data = le.AppendUint32(data, threadListRva)
+	data = le.AppendUint32(data, minidumpMemory64ListStream)
+	data = le.AppendUint32(data, 48)
+	data = le.AppendUint32(data, memoryListRva)
+
+	memoryRva := threadListRva + 16
+	data = le.AppendUint64(data, 2) // NumberOfMemoryRanges
+	data = le.AppendUint64(data, uint64(memoryRva))
+	data = le.AppendUint64(data, 0x7ff000000000)
+	data = le.AppendUint64(data, 4096)
+	data = le.AppendUint64(data, 0x7ff000010000)
+	data = le.AppendUint64(data, 8192)
+
+	data = append(data, make([]byte, 16)...) // Thread list.
+	data = append(data, make([]byte, 4096+8192)...)
+
+	size = len(data)
+	return append(data, "trailing data"...), size
+}
+
+func TestScanMinidump(t *testing.T) {
+	data, size := minidumpFixture()
+
+	res, err := ScanMinidump(newBytesReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Size != uint64(size) {
+		t.Fatalf("expected size %d, got %d", size, res.Size)
+	}
+
+	// A memory list describing more ranges than the stream holds is rejected.
+	binary.LittleEndian.PutUint64(data[56:64], 100)
+	if _, err := ScanMinidump(newBytesReader(data)); err == nil {
+		t.Fatal("expected an error for an invalid memory list")
+	}
+}
@@ -0,0 +1,120 @@
+// Copyright (c) 2025 Stefano Scafiti
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package format
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+var mobiFileHeader = FileHeader{
+	Ext:         "mobi",
+	Description: "Mobipocket e-book (MOBI/PRC)",
+	Category:    CategoryDocument,
+	Signatures: [][]byte{
+		[]byte("BOOKMOBI"), // PDB type and creator
+	},
+	SignatureOffset: mobiTypeOffset,
+	ScanFile:        ScanMOBI,
+}
+
+const (
+	// mobiTypeOffset is the offset of the type and creator fields of the PDB header.
+	mobiTypeOffset = 60
+	// pdbHeaderSize is the size of the Palm Database header, followed by the record list.
+	pdbHeaderSize = 78
+	// pdbRecordInfoSize is the size of an entry of the record list.
+	pdbRecordInfoSize = 8
+	// palmDOCHeaderSize is the size of the PalmDOC header at the start of record 0,
+	// followed by the MOBI header.
+	palmDOCHeaderSize = 16
+)
+
+// mobiEOFRecord is the content of the record which usually ends a MOBI file.
+var mobiEOFRecord = []byte{0xE9, 0x8E, 0x0D, 0x0A}
+
+// ScanMOBI scans a Mobipocket e-book, stored as a Palm Database (PDB) whose records
+// are listed, by offset, after the header. Since the size of the last record is not
+// stored, the file is assumed to end with the usual 4-byte EOF record; otherwise,
+// the last record is assumed to be as large as the one preceding it, and the file is
+// reported as header-only, since its size is a guess.
+func ScanMOBI(r *Reader) (*ScanResult, error) {
+	var hdr [pdbHeaderSize]byte
+	if _, err := io.ReadFull(r, hdr[:]); err != nil {
+		return nil, fmt.Errorf("failed to read PDB header: %w", err)
+	}
+
+	if string(hdr[mobiTypeOffset:mobiTypeOffset+8]) != "BOOKMOBI" {
+		return nil, fmt.Errorf("reader does not start with a MOBI PDB header")
+	}
+
+	numRecords := int(binary.BigEndian.Uint16(hdr[76:78]))
+	if numRecords == 0 {
+		return nil, fmt.Errorf("MOBI file has no records")
+	}
+
+	recordList := make([]byte, numRecords*pdbRecordInfoSize)
+	if _, err := io.ReadFull(r, recordList); err != nil {
+		return nil, fmt.Errorf("failed to read PDB record list: %w", err)
+	}
+
+	offsets := make([]uint64, numRecords)
+	for i := range offsets {
+		offsets[i] = uint64(binary.BigEndian.Uint32(recordList[i*pdbRecordInfoSize:]))
+
+		if i == 0 && offsets[i] < r.BytesRead() || i > 0 && offsets[i] <= offsets[i-1] {
+			return nil, fmt.Errorf("invalid offset %d of PDB record %d", offsets[i], i)
+		}
+	}
+
+	// Record 0 holds the PalmDOC header, followed by the MOBI header.
+	if _, err := r.Discard(int(offsets[0] - r.BytesRead())); err != nil {
+		return nil, fmt.Errorf("failed to reach PDB record 0: %w", err)
+	}
+
+	var rec0 [palmDOCHeaderSize + 4]byte
+	if _, err := io.ReadFull(r, rec0[:]); err != nil {
+		return nil, fmt.Errorf("failed to read MOBI header: %w", err)
+	}
+
+	if string(rec0[palmDOCHeaderSize:]) != "MOBI" {
+		return nil, fmt.Errorf("missing MOBI header magic")
+	}
+
+	last := offsets[numRecords-1]
+	if numRecords == 1 {
+		// The size of the only record is unknown: only its headers are counted.
+		return &ScanResult{Size: last + uint64(len(rec0)), Confidence: ConfidenceHeaderOnly}, nil
+	}
+
+	// Estimate of the size of the last record, used when it is not the EOF record.
+	lastSize := last - offsets[numRecords-2]
+
+	if _, err := r.Discard(int(last - r.BytesRead())); err != nil {
+		// The file is truncated before its last record.
+		return &ScanResult{Size: r.BytesRead(), Confidence: ConfidenceHeaderOnly, Truncated: true}, nil
+	}
+
+	if tail, err := r.Peek(len(mobiEOFRecord)); err == nil && string(tail) == string(mobiEOFRecord) {
+		return &ScanResult{Size: last + uint64(len(mobiEOFRecord)), Confidence: ConfidenceStructural}, nil
+	}
+	return &ScanResult{Size: last + lastSize, Confidence: ConfidenceHeaderOnly}, nil
+}
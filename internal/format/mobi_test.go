@@ -0,0 +1,104 @@
+package format
+
+import (
+	"encoding/binary"
+	"testing"
+)
+
+// mobiFixture returns a MOBI file made of the given records.
+func mobiFixture(records ...[]byte) []byte {
+	data := make([]byte, pdbHeaderSize+len(records)*pdbRecordInfoSize+2)
+	copy(data, "Test_Book")
+	copy(data[mobiTypeOffset:], "BOOKMOBI")
+	binary.BigEndian.PutUint16(data[76:], uint16(len(records)))
+
+	for i, rec := range records {
+		binary.BigEndian.PutUint32(data[pdbHeaderSize+i*pdbRecordInfoSize:], uint32(len(data)))
+		data = append(data, rec...)
+	}
+	return data
+}
+
+func mobiRecord0() []byte {
+	rec := make([]byte, palmDOCHeaderSize+232)
+	copy(rec[palmDOCHeaderSize:], "MOBI")
+	return rec
+}
+
+func TestScanMOBI(t *testing.T) {
+	text := make([]byte, 300)
+	padding := make([]byte, 1000)
+
+	withEOF := mobiFixture(mobiRecord0(), text, text, mobiEOFRecord)
+	withoutEOF := mobiFixture(mobiRecord0(), text, text)
+	single := mobiFixture(mobiRecord0())
+	headerSize := len(single) - len(mobiRecord0()) + palmDOCHeaderSize + 4
+	truncated := withoutEOF[:len(withoutEOF)-len(text)-10]
+
+	tests := []struct {
+		name       string
+		data       []byte
+		size       int
+		confidence Confidence
+		truncated  bool
+	}{
+		{"EOF record", append(withEOF, padding...), len(withEOF), ConfidenceStructural, false},
+		{"estimated last record", append(withoutEOF, padding...), len(withoutEOF), ConfidenceHeaderOnly, false},
+		{"single record", append(single, padding...), headerSize, ConfidenceHeaderOnly, false},
+		{"truncated", truncated, len(truncated), ConfidenceHeaderOnly, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res, err := ScanMOBI(newBytesReader(tt.data))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if res.Size != uint64(tt.size) {
+				t.Errorf("expected size %d, got %d", tt.size, res.Size)
+			}
+			if res.Confidence != tt.confidence || res.Truncated != tt.truncated {
+				t.Errorf("expected confidence %v (truncated: %v), got %v (truncated: %v)",
+					tt.confidence, tt.truncated, res.Confidence, res.Truncated)
+			}
+		})
+	}
+}
+
+func TestScanMOBIInvalid(t *testing.T) {
+	noMagic := mobiFixture(make([]byte, 100), make([]byte, 100))
+	if _, err := ScanMOBI(newBytesReader(noMagic)); err == nil {
+		t.Errorf("expected an error for a missing MOBI header")
+	}
+
+	unordered := mobiFixture(mobiRecord0(), make([]byte, 100))
+	copy(unordered[pdbHeaderSize+pdbRecordInfoSize:], unordered[pdbHeaderSize:pdbHeaderSize+4])
+	if _, err := ScanMOBI(newBytesReader(unordered)); err == nil {
+		t.Errorf("expected an error for unordered record offsets")
+	}
+}
+
+func TestRegistrySearchSignatureOffset(t *testing.T) {
+	registry := BuildFileRegistry(GetAllFileScanners()...)
+
+	data := mobiFixture(mobiRecord0(), mobiEOFRecord)
+
+	var exts []string
+	registry.Search(data, func(sc FileScanner) bool {
+		exts = append(exts, sc.Ext())
+		return false
+	})
+
+	if len(exts) != 1 || exts[0] != "mobi" {
+		t.Errorf("Search() matched %v, want [mobi]", exts)
+	}
+
+	if !registry.Match(data) {
+		t.Errorf("Match() = false, want true")
+	}
+
+	if registry.Match(data[:mobiTypeOffset+4]) {
+		t.Errorf("Match() = true for data shorter than the signature")
+	}
+}
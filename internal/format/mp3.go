@@ -24,11 +24,15 @@ import (
 	"encoding/binary"
 	"fmt"
 	"io"
+	"strings"
+	"unicode/utf16"
 )
 
 var mp3FileHeader = FileHeader{
 	Ext:         "mp3",
 	Description: "MPEG Audio Layer III audio format",
+	Category:    CategoryAudio,
+	Entropy:     EntropyHigh,
 	Signatures: [][]byte{
 		{0xFF, 0xFA},
 		{0xFF, 0xFB},
@@ -227,6 +231,17 @@ func ScanMP3(r *Reader) (*ScanResult, error) {
 	}
 	n += skippedBytes // Move past the ID3v2 tag
 
+	var name string
+	if skippedBytes > 0 {
+		if err := r.Unread(skippedBytes); err != nil {
+			return nil, err
+		}
+		name = readID3v2Name(r, skippedBytes)
+		if _, err := r.Discard(skippedBytes - int(r.BytesRead())); err != nil {
+			return nil, err
+		}
+	}
+
 	var headerBytes [4]byte
 	numFrames := 0
 
@@ -267,5 +282,144 @@ func ScanMP3(r *Reader) (*ScanResult, error) {
 	if numFrames < MinimumRequiredFrames {
 		return nil, fmt.Errorf("detected MP3 stream is too short (only %d frames)", numFrames)
 	}
-	return &ScanResult{Size: uint64(n)}, nil
+	return &ScanResult{Name: name, Size: uint64(n), Confidence: ConfidenceStructural}, nil
+}
+
+// maxID3v2TextFrameSize is the maximum size of the title and artist frames of an ID3v2 tag:
+// larger ones are skipped.
+const maxID3v2TextFrameSize = 1024
+
+// readID3v2Name reads the title and artist frames of the ID3v2 tag, of tagSize bytes header
+// included, at the start of the reader, and returns the name of the file made of them,
+// e.g. "Artist - Title.mp3", or an empty string if the tag has no title. Only version 2.3
+// and 2.4 tags, which are not unsynchronised, are read. The reader is left inside the tag.
+func readID3v2Name(r *Reader, tagSize int) string {
+	var hdr [10]byte
+	if _, err := io.ReadFull(r, hdr[:]); err != nil {
+		return ""
+	}
+
+	version, flags := hdr[3], hdr[5]
+	if (version != 3 && version != 4) || flags&0x80 != 0 {
+		return ""
+	}
+
+	// Skip the extended header, whose size excludes itself in version 2.3.
+	if flags&0x40 != 0 {
+		var ext [4]byte
+		if _, err := io.ReadFull(r, ext[:]); err != nil {
+			return ""
+		}
+
+		size := int(binary.BigEndian.Uint32(ext[:]))
+		if version == 4 {
+			size, _ = parseSynchsafeInt(ext[:])
+			size -= len(ext)
+		}
+		if size < 0 || size > tagSize {
+			return ""
+		}
+		if _, err := r.Discard(size); err != nil {
+			return ""
+		}
+	}
+
+	var title, artist string
+	for int(r.BytesRead())+10 <= tagSize && (title == "" || artist == "") {
+		var frame [10]byte
+		if _, err := io.ReadFull(r, frame[:]); err != nil || frame[0] == 0 {
+			break // end of the tag, or padding
+		}
+
+		size := int(binary.BigEndian.Uint32(frame[4:8]))
+		if version == 4 {
+			size, _ = parseSynchsafeInt(frame[4:8])
+		}
+		if size < 0 || int(r.BytesRead())+size > tagSize {
+			break
+		}
+
+		id := string(frame[:4])
+
+		// Compressed, encrypted and otherwise transformed frames are skipped.
+		if (id != "TIT2" && id != "TPE1") || frame[9] != 0 || size > maxID3v2TextFrameSize {
+			if _, err := r.Discard(size); err != nil {
+				break
+			}
+			continue
+		}
+
+		data := make([]byte, size)
+		if _, err := io.ReadFull(r, data); err != nil {
+			break
+		}
+
+		if id == "TIT2" {
+			title = decodeID3v2Text(data)
+		} else {
+			artist = decodeID3v2Text(data)
+		}
+	}
+
+	if strings.TrimSpace(title) == "" {
+		return ""
+	}
+	if strings.TrimSpace(artist) != "" {
+		title = artist + " - " + title
+	}
+	return metadataName(title, "mp3")
+}
+
+// decodeID3v2Text decodes the content of an ID3v2 text frame, made of an encoding byte
+// followed by the text. Only the first of multiple null-separated values is returned.
+func decodeID3v2Text(data []byte) string {
+	if len(data) == 0 {
+		return ""
+	}
+
+	text := data[1:]
+	switch data[0] {
+	case 0: // ISO-8859-1
+		runes := make([]rune, 0, len(text))
+		for _, b := range text {
+			if b == 0 {
+				break
+			}
+			runes = append(runes, rune(b))
+		}
+		return string(runes)
+	case 1: // UTF-16 with byte order mark
+		if len(text) < 2 {
+			return ""
+		}
+		bigEndian := text[0] == 0xFE && text[1] == 0xFF
+		if !bigEndian && (text[0] != 0xFF || text[1] != 0xFE) {
+			return ""
+		}
+		return decodeUTF16(text[2:], bigEndian)
+	case 2: // UTF-16BE
+		return decodeUTF16(text, true)
+	case 3: // UTF-8
+		s, _, _ := strings.Cut(string(text), "\x00")
+		return s
+	}
+	return ""
+}
+
+// decodeUTF16 decodes UTF-16 text, up to the first null character.
+func decodeUTF16(b []byte, bigEndian bool) string {
+	var order binary.ByteOrder = binary.LittleEndian
+	if bigEndian {
+		order = binary.BigEndian
+	}
+
+	chars := make([]uint16, 0, len(b)/2)
+	for i := 0; i+1 < len(b); i += 2 {
+		c := order.Uint16(b[i:])
+		if c == 0 {
+			break
+		}
+		chars = append(chars, c)
+	}
+	return string(utf16.Decode(chars))
 }
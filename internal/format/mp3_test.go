@@ -0,0 +1,86 @@
+package format
+
+import (
+	"encoding/binary"
+	"testing"
+)
+
+// id3v2Frame returns an ID3v2.3 frame with the given ID and content.
+func id3v2Frame(id string, content []byte) []byte {
+	frame := append([]byte(id), binary.BigEndian.AppendUint32(nil, uint32(len(content)))...)
+	return append(append(frame, 0, 0), content...)
+}
+
+// mp3Fixture returns an MP3 stream of two frames, preceded by an ID3v2.3 tag with the
+// given frames and some padding, followed by some trailing data, and the size of the stream.
+func mp3Fixture(frames ...[]byte) ([]byte, int) {
+	var body []byte
+	for _, f := range frames {
+		body = append(body, f...)
+	}
+	body = append(body, make([]byte, 32)...) // padding
+
+	size := len(body)
+	data := []byte{'I', 'D', '3', 3, 0, 0, byte(size >> 21 & 0x7f), byte(size >> 14 & 0x7f), byte(size >> 7 & 0x7f), byte(size & 0x7f)}
+	data = append(data, body...)
+
+	// MPEG-1 Layer III frames, at 128 kbps and 44.1 kHz, of 417 bytes each.
+	for range 2 {
+		frame := make([]byte, 417)
+		copy(frame, []byte{0xFF, 0xFB, 0x90, 0x00})
+		data = append(data, frame...)
+	}
+
+	size = len(data)
+	return append(data, make([]byte, 64)...), size
+}
+
+func TestScanMP3Name(t *testing.T) {
+	tests := []struct {
+		name   string
+		frames [][]byte
+		want   string
+	}{
+		{
+			name: "title and artist",
+			frames: [][]byte{
+				id3v2Frame("TALB", []byte("\x00Album")),
+				id3v2Frame("TIT2", []byte("\x00Song/Title\x00")),
+				id3v2Frame("TPE1", []byte("\x03Artist")),
+			},
+			want: "Artist - Song_Title.mp3",
+		},
+		{
+			name:   "UTF-16 title",
+			frames: [][]byte{id3v2Frame("TIT2", []byte("\x01\xff\xfeC\x00a\x00f\x00\xe9\x00\x00\x00"))},
+			want:   "Café.mp3",
+		},
+		{
+			name:   "no title",
+			frames: [][]byte{id3v2Frame("TPE1", []byte("\x00Artist"))},
+			want:   "",
+		},
+		{
+			name:   "no frames",
+			frames: nil,
+			want:   "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, size := mp3Fixture(tt.frames...)
+
+			res, err := ScanMP3(newBytesReader(data))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if res.Size != uint64(size) {
+				t.Errorf("expected size %d, got %d", size, res.Size)
+			}
+			if res.Name != tt.want {
+				t.Errorf("expected name %q, got %q", tt.want, res.Name)
+			}
+		})
+	}
+}
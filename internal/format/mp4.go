@@ -0,0 +1,249 @@
+// Copyright (c) 2025 Stefano Scafiti
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package format
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"slices"
+)
+
+var mp4FileHeader = FileHeader{
+	Ext:         "mp4",
+	Description: "ISO base media file (MP4/MOV/3GP)",
+	Category:    CategoryVideo,
+	Signatures: [][]byte{
+		[]byte("ftyp"),
+	},
+	SignatureOffset: mp4BoxTypeOffset,
+	ScanFile:        ScanMP4,
+}
+
+const (
+	// mp4BoxHeaderSize is the size of a box header: the 32-bit size and the box type.
+	mp4BoxHeaderSize = 8
+	// mp4BoxTypeOffset is the offset of the type field in a box header.
+	mp4BoxTypeOffset = 4
+	// mp4MaxFtypSize bounds the size of the ftyp box, which holds the major brand,
+	// its version and the list of compatible brands.
+	mp4MaxFtypSize = 256
+)
+
+// mp4BrandExts maps the brands of the ftyp box to the extension of the file.
+var mp4BrandExts = map[string]string{
+	"isom": "mp4",
+	"iso2": "mp4",
+	"mp41": "mp4",
+	"mp42": "mp4",
+	"avc1": "mp4",
+	"dash": "mp4",
+	"M4V ": "m4v",
+	"M4A ": "m4a",
+	"M4B ": "m4b",
+	"qt  ": "mov",
+	"3gp4": "3gp",
+	"3gp5": "3gp",
+	"3g2a": "3g2",
+}
+
+// ScanMP4 scans an ISO base media file (MP4, QuickTime, 3GP, ...), made of a sequence
+// of top-level boxes starting with the ftyp box. The size is the sum of the sizes of
+// the boxes, which are walked until the end of the data or an invalid box header.
+// Files without moov box are rejected, unless a box extends past the available data,
+// in which case they are reported as truncated.
+// The extension is taken from the major brand, or from the first known compatible brand.
+func ScanMP4(r *Reader) (*ScanResult, error) {
+	var hdr [mp4BoxHeaderSize]byte
+	if _, err := io.ReadFull(r, hdr[:]); err != nil {
+		return nil, fmt.Errorf("failed to read ftyp box: %w", err)
+	}
+
+	if string(hdr[4:8]) != "ftyp" {
+		return nil, fmt.Errorf("reader does not start with an ftyp box")
+	}
+
+	ftypSize := binary.BigEndian.Uint32(hdr[0:4])
+	if ftypSize < mp4BoxHeaderSize+8 || ftypSize > mp4MaxFtypSize || ftypSize%4 != 0 {
+		return nil, fmt.Errorf("invalid ftyp box size: %d", ftypSize)
+	}
+
+	ftyp := make([]byte, ftypSize-mp4BoxHeaderSize)
+	if _, err := io.ReadFull(r, ftyp); err != nil {
+		return nil, fmt.Errorf("failed to read ftyp box: %w", err)
+	}
+
+	ext, err := mp4BrandExt(ftyp)
+	if err != nil {
+		return nil, err
+	}
+
+	size, hasMovie, truncated := walkMP4Boxes(r, false)
+	if !hasMovie {
+		if !truncated {
+			return nil, fmt.Errorf("no moov box found")
+		}
+
+		// The moov box may follow a mdat box extending past the available data, e.g. the
+		// end of the source: the boxes seen so far are carved, as the file may be repaired.
+		return &ScanResult{Ext: ext, Size: uint64(ftypSize) + size, Confidence: ConfidenceHeaderOnly, Truncated: true}, nil
+	}
+	return &ScanResult{Ext: ext, Size: uint64(ftypSize) + size, Confidence: ConfidenceStructural}, nil
+}
+
+// movFirstBoxes are the types of the boxes which can start a QuickTime movie without ftyp box.
+var movFirstBoxes = [][]byte{
+	[]byte("mdat"),
+	[]byte("moov"),
+	[]byte("wide"),
+	[]byte("free"),
+}
+
+// movMovieChildren are the types of the boxes which can start a QuickTime moov box:
+// the movie header, or the compressed movie box.
+var movMovieChildren = []string{"mvhd", "cmov"}
+
+var movFileHeader = FileHeader{
+	Ext:             "mov",
+	Description:     "QuickTime movie without ftyp box",
+	Category:        CategoryVideo,
+	Signatures:      movFirstBoxes,
+	SignatureOffset: mp4BoxTypeOffset,
+	ScanFile:        ScanMOV,
+}
+
+// ScanMOV scans a QuickTime movie lacking the ftyp box, as written by older software,
+// which starts directly with a mdat, moov, wide or free box. Since bare box headers
+// are weak signatures, the file is only accepted if a moov box, starting with a movie
+// header, is found. The size is computed like in ScanMP4.
+func ScanMOV(r *Reader) (*ScanResult, error) {
+	hdr, err := r.Peek(mp4BoxHeaderSize)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read box header: %w", err)
+	}
+
+	if !slices.ContainsFunc(movFirstBoxes, func(t []byte) bool { return bytes.Equal(t, hdr[4:8]) }) {
+		return nil, fmt.Errorf("reader does not start with a QuickTime box")
+	}
+
+	size, hasMovie, _ := walkMP4Boxes(r, true)
+	if !hasMovie {
+		return nil, fmt.Errorf("no moov box found")
+	}
+	return &ScanResult{Size: size, Confidence: ConfidenceStructural}, nil
+}
+
+// walkMP4Boxes walks a sequence of top-level boxes, until the end of the data or an
+// invalid box header, and returns the sum of their sizes, whether a moov or moof box
+// was found, and whether the last box extends past the available data. If strict is
+// set, only moov boxes starting with a movie header are counted.
+func walkMP4Boxes(r *Reader, strict bool) (size uint64, hasMovie, truncated bool) {
+	var hdr [mp4BoxHeaderSize]byte
+
+	for {
+		if _, err := io.ReadFull(r, hdr[:]); err != nil {
+			break
+		}
+
+		boxType := hdr[4:8]
+		if !isMP4BoxType(boxType) {
+			break
+		}
+
+		headerSize := uint64(mp4BoxHeaderSize)
+		boxSize := uint64(binary.BigEndian.Uint32(hdr[0:4]))
+		if boxSize == 1 {
+			var largeSize [8]byte
+			if _, err := io.ReadFull(r, largeSize[:]); err != nil {
+				break
+			}
+			boxSize = binary.BigEndian.Uint64(largeSize[:])
+			headerSize += 8
+		}
+
+		// A size of 0 extends the box to the end of the file, which is unknown here.
+		if boxSize < headerSize || boxSize > math.MaxInt64 {
+			break
+		}
+
+		switch string(boxType) {
+		case "moov":
+			hasMovie = hasMovie || !strict || isMOVMovie(r, boxSize-headerSize)
+		case "moof":
+			hasMovie = hasMovie || !strict
+		}
+
+		size += boxSize
+		if n, err := r.Discard(int(boxSize - headerSize)); err != nil || uint64(n) != boxSize-headerSize {
+			// The box extends past the available data: the result is capped by the caller.
+			truncated = true
+			break
+		}
+	}
+	return size, hasMovie, truncated
+}
+
+// isMOVMovie reports whether the content of a moov box of the given size,
+// which the reader is positioned at, starts with one of movMovieChildren.
+func isMOVMovie(r *Reader, size uint64) bool {
+	if size < mp4BoxHeaderSize {
+		return false
+	}
+
+	child, err := r.Peek(mp4BoxHeaderSize)
+	if err != nil {
+		return false
+	}
+
+	childSize := uint64(binary.BigEndian.Uint32(child[0:4]))
+	return childSize >= mp4BoxHeaderSize && childSize <= size &&
+		slices.Contains(movMovieChildren, string(child[4:8]))
+}
+
+// mp4BrandExt returns the extension of the file given the content of its ftyp box:
+// the major brand, its minor version and the compatible brands.
+func mp4BrandExt(ftyp []byte) (string, error) {
+	if !isMP4BoxType(ftyp[0:4]) {
+		return "", fmt.Errorf("invalid major brand %q", ftyp[0:4])
+	}
+
+	if ext, ok := mp4BrandExts[string(ftyp[0:4])]; ok {
+		return ext, nil
+	}
+
+	for i := 8; i+4 <= len(ftyp); i += 4 {
+		if ext, ok := mp4BrandExts[string(ftyp[i:i+4])]; ok {
+			return ext, nil
+		}
+	}
+	return "mp4", nil
+}
+
+// isMP4BoxType reports whether t is a valid four-character code, made of printable characters.
+func isMP4BoxType(t []byte) bool {
+	for _, c := range t {
+		if c < 0x20 || c > 0x7e {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,181 @@
+package format
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+)
+
+// mp4Box returns a box of the given type, holding the given children followed by dataSize zero bytes.
+func mp4Box(typ string, dataSize int, children ...[]byte) []byte {
+	content := append(bytes.Join(children, nil), make([]byte, dataSize)...)
+
+	box := binary.BigEndian.AppendUint32(nil, uint32(mp4BoxHeaderSize+len(content)))
+	box = append(box, typ...)
+	return append(box, content...)
+}
+
+func mp4Ftyp(brands ...string) []byte {
+	box := binary.BigEndian.AppendUint32(nil, uint32(mp4BoxHeaderSize+4+4*len(brands)))
+	box = append(box, "ftyp"...)
+	box = append(box, brands[0]...)
+	box = append(box, 0, 0, 0, 0) // minor version
+	for _, b := range brands[1:] {
+		box = append(box, b...)
+	}
+	return box
+}
+
+func mp4File(brands ...string) []byte {
+	return bytes.Join([][]byte{
+		mp4Ftyp(brands...),
+		mp4Box("free", 8),
+		mp4Box("mdat", 1000),
+		mp4Box("moov", 200),
+	}, nil)
+}
+
+func TestScanMP4(t *testing.T) {
+	tests := []struct {
+		name   string
+		brands []string
+		ext    string
+	}{
+		{"mp4", []string{"isom", "isom", "avc1"}, "mp4"},
+		{"quicktime", []string{"qt  ", "qt  "}, "mov"},
+		{"m4a", []string{"M4A ", "M4A ", "mp42", "isom"}, "m4a"},
+		{"3gp4", []string{"3gp4", "3gp4", "isom"}, "3gp"},
+		{"3gp5", []string{"3gp5", "3gp5", "isom"}, "3gp"},
+		{"3g2a", []string{"3g2a", "3g2a"}, "3g2"},
+		{"compatible brand", []string{"abcd", "3gp5"}, "3gp"},
+		{"unknown brand", []string{"abcd"}, "mp4"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			file := mp4File(tt.brands...)
+			data := append(bytes.Clone(file), make([]byte, 64)...)
+
+			res, err := ScanMP4(newBytesReader(data))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if res.Size != uint64(len(file)) {
+				t.Errorf("expected size %d, got %d", len(file), res.Size)
+			}
+
+			if res.Ext != tt.ext {
+				t.Errorf("expected ext %q, got %q", tt.ext, res.Ext)
+			}
+		})
+	}
+}
+
+func TestScanMP4LargeSize(t *testing.T) {
+	mdat := binary.BigEndian.AppendUint32(nil, 1)
+	mdat = append(mdat, "mdat"...)
+	mdat = binary.BigEndian.AppendUint64(mdat, 16+500)
+	mdat = append(mdat, make([]byte, 500)...)
+
+	file := bytes.Join([][]byte{mp4Ftyp("mp42", "isom"), mp4Box("moov", 100), mdat}, nil)
+
+	res, err := ScanMP4(newBytesReader(file))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if res.Size != uint64(len(file)) {
+		t.Errorf("expected size %d, got %d", len(file), res.Size)
+	}
+}
+
+func TestScanMP4Truncated(t *testing.T) {
+	ftyp := mp4Ftyp("isom", "isom")
+	mdat := mp4Box("mdat", 10000)
+	data := append(bytes.Clone(ftyp), mdat[:500]...)
+
+	res, err := ScanMP4(newBytesReader(data))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !res.Truncated || res.Confidence != ConfidenceHeaderOnly {
+		t.Errorf("expected a truncated header-only result, got truncated: %v, confidence: %v", res.Truncated, res.Confidence)
+	}
+	if res.Size != uint64(len(ftyp)+len(mdat)) {
+		t.Errorf("expected size %d, got %d", len(ftyp)+len(mdat), res.Size)
+	}
+}
+
+func TestScanMP4Invalid(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{"no moov box", bytes.Join([][]byte{mp4Ftyp("isom"), mp4Box("mdat", 100)}, nil)},
+		{"invalid ftyp size", append(binary.BigEndian.AppendUint32(nil, 4096), "ftypisom"...)},
+		{"no ftyp box", mp4Box("moov", 100)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ScanMP4(newBytesReader(tt.data)); err == nil {
+				t.Errorf("expected an error")
+			}
+		})
+	}
+}
+
+// movMoov returns a moov box starting with a child box of the given type.
+func movMoov(child string) []byte {
+	return mp4Box("moov", 0, mp4Box(child, 100))
+}
+
+func TestScanMOV(t *testing.T) {
+	tests := []struct {
+		name  string
+		boxes [][]byte
+	}{
+		{"mdat first", [][]byte{mp4Box("wide", 0), mp4Box("mdat", 1000), movMoov("mvhd")}},
+		{"moov first", [][]byte{movMoov("mvhd"), mp4Box("free", 16), mp4Box("mdat", 1000)}},
+		{"compressed movie", [][]byte{mp4Box("mdat", 1000), movMoov("cmov")}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			file := bytes.Join(tt.boxes, nil)
+			data := append(bytes.Clone(file), make([]byte, 64)...)
+
+			res, err := ScanMOV(newBytesReader(data))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if res.Size != uint64(len(file)) {
+				t.Errorf("expected size %d, got %d", len(file), res.Size)
+			}
+		})
+	}
+}
+
+func TestScanMOVInvalid(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{"no moov box", bytes.Join([][]byte{mp4Box("mdat", 100), mp4Box("free", 8)}, nil)},
+		{"moov without movie header", bytes.Join([][]byte{mp4Box("free", 8), movMoov("abcd")}, nil)},
+		{"empty moov", bytes.Join([][]byte{mp4Box("mdat", 100), mp4Box("moov", 0)}, nil)},
+		{"unexpected first box", bytes.Join([][]byte{mp4Box("abcd", 8), movMoov("mvhd")}, nil)},
+		{"text", []byte("....free text, then a moov box")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ScanMOV(newBytesReader(tt.data)); err == nil {
+				t.Errorf("expected an error")
+			}
+		})
+	}
+}
@@ -0,0 +1,177 @@
+// Copyright (c) 2025 Stefano Scafiti
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package format
+
+import (
+	"bytes"
+	"encoding/binary"
+	"sort"
+)
+
+// Multi-Picture Format (CIPA DC-007), used by MPO (stereo) files and by cameras storing
+// previews or depth maps after the primary image: the images are concatenated JPEG
+// streams, indexed by an APP2 segment of the first one.
+
+const app2Marker = 0xe2
+
+var mpfIdentifier = []byte("MPF\x00")
+
+const (
+	mpTagNumberOfImages = 0xB001
+	mpTagEntry          = 0xB002
+
+	mpEntrySize = 16
+
+	// MP types of the images of stereo and multi-angle sets, which make the file an MPO.
+	mpTypeDisparity  = 0x020002
+	mpTypeMultiAngle = 0x020003
+	mpTypeMask       = 0xFFFFFF
+)
+
+// mpImage is an image listed in the MP index, with its offset from the start of the file.
+type mpImage struct {
+	offset uint64
+	size   uint64
+}
+
+// mpIndex is the MP index of a multi-picture file.
+type mpIndex struct {
+	images []mpImage // Images after the first one, sorted by offset.
+	stereo bool      // Whether the images form a stereo or multi-angle set.
+}
+
+// parseMPF parses the data of an APP2 segment, starting at the given offset of the file.
+// It returns nil if the segment is not a valid MP index listing more than one image.
+func parseMPF(seg []byte, segOffset uint64) *mpIndex {
+	if !bytes.HasPrefix(seg, mpfIdentifier) {
+		return nil
+	}
+
+	// Offsets are relative to the MP header, a TIFF header following the identifier.
+	hdr := seg[len(mpfIdentifier):]
+	hdrOffset := segOffset + uint64(len(mpfIdentifier))
+
+	if len(hdr) < 8 {
+		return nil
+	}
+
+	var order binary.ByteOrder
+	switch string(hdr[:4]) {
+	case "II*\x00":
+		order = binary.LittleEndian
+	case "MM\x00*":
+		order = binary.BigEndian
+	default:
+		return nil
+	}
+
+	ifd := uint64(order.Uint32(hdr[4:]))
+	if ifd+2 > uint64(len(hdr)) {
+		return nil
+	}
+
+	var numImages, entriesOffset, entriesSize uint64
+
+	count := uint64(order.Uint16(hdr[ifd:]))
+	for i := uint64(0); i < count; i++ {
+		field := ifd + 2 + i*12
+		if field+12 > uint64(len(hdr)) {
+			return nil
+		}
+
+		switch order.Uint16(hdr[field:]) {
+		case mpTagNumberOfImages:
+			numImages = uint64(order.Uint32(hdr[field+8:]))
+		case mpTagEntry:
+			entriesSize = uint64(order.Uint32(hdr[field+4:]))
+			entriesOffset = uint64(order.Uint32(hdr[field+8:]))
+		}
+	}
+
+	if numImages < 2 || entriesSize != numImages*mpEntrySize || entriesOffset+entriesSize > uint64(len(hdr)) {
+		return nil
+	}
+
+	index := &mpIndex{}
+	for i := uint64(0); i < numImages; i++ {
+		entry := hdr[entriesOffset+i*mpEntrySize:]
+
+		typ := order.Uint32(entry) & mpTypeMask
+		if typ == mpTypeDisparity || typ == mpTypeMultiAngle {
+			index.stereo = true
+		}
+
+		// The offset of the first image is 0, as it precedes the MP header.
+		if i == 0 {
+			continue
+		}
+
+		size := uint64(order.Uint32(entry[4:]))
+		offset := uint64(order.Uint32(entry[8:]))
+		if size < 4 { // SOI and EOI markers
+			return nil
+		}
+		index.images = append(index.images, mpImage{offset: hdrOffset + offset, size: size})
+	}
+
+	sort.Slice(index.images, func(i, j int) bool {
+		return index.images[i].offset < index.images[j].offset
+	})
+	return index
+}
+
+// skipMPImages consumes the images listed in index, which must follow the first one,
+// whose EOI marker was just read. Each image must start with an SOI marker, and the last
+// one must end with an EOI marker. If they do not, r is moved back to the end of the
+// first image and false is returned.
+func skipMPImages(r *Reader, index *mpIndex) bool {
+	end := r.BytesRead()
+
+	ok := func() bool {
+		var marker [2]byte
+		for _, img := range index.images {
+			if img.offset < r.BytesRead() {
+				return false
+			}
+			if _, err := r.Discard(int(img.offset - r.BytesRead())); err != nil {
+				return false
+			}
+
+			next, err := r.Peek(2)
+			if err != nil || next[0] != 0xff || next[1] != soiMarker {
+				return false
+			}
+
+			if _, err := r.Discard(int(img.size) - len(marker)); err != nil {
+				return false
+			}
+			if _, err := r.Read(marker[:]); err != nil || marker[0] != 0xff || marker[1] != eoiMarker {
+				return false
+			}
+		}
+		return true
+	}()
+
+	if !ok {
+		_ = r.Unread(int(r.BytesRead() - end))
+	}
+	return ok
+}
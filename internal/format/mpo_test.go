@@ -0,0 +1,106 @@
+package format
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/jpeg"
+	"testing"
+)
+
+func encodeJPEG(t *testing.T) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, image.NewGray(image.Rect(0, 0, 8, 8)), nil); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// multiPictureFile returns a JPEG image followed by a second one, whose types are set in
+// the MP index of the first image, and the offset of the second image.
+func multiPictureFile(t *testing.T, primaryType, secondType uint32) ([]byte, int) {
+	first, second := encodeJPEG(t), encodeJPEG(t)
+
+	le := binary.LittleEndian
+
+	// MP header, MP index IFD with the number of images and the MP entries, and the entries.
+	var hdr []byte
+	hdr = append(hdr, "II*\x00"...)
+	hdr = le.AppendUint32(hdr, 8)
+	hdr = le.AppendUint16(hdr, 2)
+	hdr = le.AppendUint16(hdr, mpTagNumberOfImages)
+	hdr = le.AppendUint16(hdr, 4) // LONG
+	hdr = le.AppendUint32(hdr, 1)
+	hdr = le.AppendUint32(hdr, 2)
+	hdr = le.AppendUint16(hdr, mpTagEntry)
+	hdr = le.AppendUint16(hdr, 7) // UNDEFINED
+	hdr = le.AppendUint32(hdr, 2*mpEntrySize)
+	hdr = le.AppendUint32(hdr, 8+2+2*12+4)
+	hdr = le.AppendUint32(hdr, 0) // next IFD
+
+	segLen := 2 + len(mpfIdentifier) + len(hdr) + 2*mpEntrySize
+	firstSize := len(first) + 2 + segLen
+	// Offset of the MP header: SOI, APP2 marker, segment length and identifier.
+	hdrOffset := 2 + 2 + 2 + len(mpfIdentifier)
+
+	hdr = le.AppendUint32(hdr, primaryType)
+	hdr = le.AppendUint32(hdr, uint32(firstSize))
+	hdr = le.AppendUint32(hdr, 0)
+	hdr = le.AppendUint32(hdr, 0)
+	hdr = le.AppendUint32(hdr, secondType)
+	hdr = le.AppendUint32(hdr, uint32(len(second)))
+	hdr = le.AppendUint32(hdr, uint32(firstSize-hdrOffset))
+	hdr = le.AppendUint32(hdr, 0)
+
+	var data []byte
+	data = append(data, first[:2]...) // SOI
+	data = append(data, 0xff, app2Marker)
+	data = binary.BigEndian.AppendUint16(data, uint16(segLen))
+	data = append(data, mpfIdentifier...)
+	data = append(data, hdr...)
+	data = append(data, first[2:]...)
+	data = append(data, second...)
+	return data, firstSize
+}
+
+func TestScanJPEGMultiPicture(t *testing.T) {
+	tests := []struct {
+		name       string
+		secondType uint32
+		wantExt    string
+	}{
+		{name: "stereo", secondType: mpTypeDisparity, wantExt: "mpo"},
+		{name: "preview", secondType: 0x010001, wantExt: "jpeg"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, _ := multiPictureFile(t, 0x20030000, tt.secondType)
+
+			res, err := ScanJPEG(newBytesReader(append(data, make([]byte, 64)...)))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if res.Size != uint64(len(data)) || res.Ext != tt.wantExt {
+				t.Errorf("expected a %s file of %d bytes, got %q of %d bytes", tt.wantExt, len(data), res.Ext, res.Size)
+			}
+		})
+	}
+}
+
+func TestScanJPEGMultiPictureMissingImage(t *testing.T) {
+	data, firstSize := multiPictureFile(t, 0x20030000, mpTypeDisparity)
+
+	// The second image was overwritten: only the first one is carved.
+	clear(data[firstSize:])
+
+	res, err := ScanJPEG(newBytesReader(data))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if res.Size != uint64(firstSize) || res.Ext != "" {
+		t.Errorf("expected the first image of %d bytes, got %q of %d bytes", firstSize, res.Ext, res.Size)
+	}
+}
@@ -0,0 +1,241 @@
+// Copyright (c) 2025 Stefano Scafiti
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package format
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+)
+
+// DefaultNameTemplate is the template of the names of carved files, made
+// of the index of the block where the file starts and of its extension.
+const DefaultNameTemplate = "f{block}.{ext}"
+
+// nameTokens are the tokens supported by name templates.
+var nameTokens = map[string]bool{
+	"offset": true, // offset of the file within the scanned source
+	"block":  true, // index of the block where the file starts
+	"index":  true, // index of the file in the order it was found, starting from 0
+	"size":   true, // size of the file in bytes
+	"ext":    true, // file extension
+}
+
+// nameTokenSpec matches the format spec of numeric tokens, e.g. "x" or "08x".
+var nameTokenSpec = regexp.MustCompile(`^(0?[0-9]*)([dxX])$`)
+
+// NameValues holds the values of the tokens of a name template.
+type NameValues struct {
+	Offset uint64
+	Block  uint64
+	Index  int
+	Size   uint64
+	Ext    string
+}
+
+// NameTemplate builds the names of carved files from a template such as "{offset:x}.{ext}".
+// Tokens are enclosed in braces; numeric tokens accept an optional format spec after a
+// colon, made of an optional zero-padded width and of a base: d (decimal), x or X (hex).
+type NameTemplate struct {
+	parts []namePart
+}
+
+// namePart is either a literal string or a token to expand.
+type namePart struct {
+	literal string
+	token   string
+	verb    string // fmt verb used to expand numeric tokens
+}
+
+// ParseNameTemplate parses a name template. Since names must be unique, the template
+// must include the offset, block or index of the file; it cannot include path separators.
+func ParseNameTemplate(s string) (*NameTemplate, error) {
+	if strings.ContainsAny(s, `/\`) {
+		return nil, fmt.Errorf("invalid name template %q: path separators are not allowed", s)
+	}
+
+	t := &NameTemplate{}
+	unique := false
+
+	for rest := s; rest != ""; {
+		start := strings.IndexAny(rest, "{}")
+		if start < 0 {
+			t.parts = append(t.parts, namePart{literal: rest})
+			break
+		}
+		if rest[start] == '}' {
+			return nil, fmt.Errorf("invalid name template %q: unexpected '}'", s)
+		}
+		if start > 0 {
+			t.parts = append(t.parts, namePart{literal: rest[:start]})
+		}
+
+		end := strings.IndexByte(rest[start:], '}')
+		if end < 0 {
+			return nil, fmt.Errorf("invalid name template %q: missing '}'", s)
+		}
+
+		part, err := parseNameToken(rest[start+1 : start+end])
+		if err != nil {
+			return nil, fmt.Errorf("invalid name template %q: %w", s, err)
+		}
+		t.parts = append(t.parts, part)
+
+		unique = unique || part.token == "offset" || part.token == "block" || part.token == "index"
+		rest = rest[start+end+1:]
+	}
+
+	if !unique {
+		return nil, fmt.Errorf("invalid name template %q: it must include one of {offset}, {block} or {index}", s)
+	}
+	return t, nil
+}
+
+func parseNameToken(s string) (namePart, error) {
+	token, spec, hasSpec := strings.Cut(s, ":")
+	if !nameTokens[token] {
+		return namePart{}, fmt.Errorf("unknown token {%s}", token)
+	}
+
+	part := namePart{token: token, verb: "%d"}
+	if !hasSpec {
+		return part, nil
+	}
+
+	if token == "ext" {
+		return namePart{}, fmt.Errorf("token {ext} does not accept a format")
+	}
+
+	m := nameTokenSpec.FindStringSubmatch(spec)
+	if m == nil {
+		return namePart{}, fmt.Errorf("invalid format %q for token {%s}", spec, token)
+	}
+	part.verb = "%" + m[1] + m[2]
+	return part, nil
+}
+
+// Expand returns the name built from the template with the given values.
+func (t *NameTemplate) Expand(v NameValues) string {
+	var sb strings.Builder
+	for _, p := range t.parts {
+		switch p.token {
+		case "":
+			sb.WriteString(p.literal)
+		case "ext":
+			sb.WriteString(v.Ext)
+		case "offset":
+			fmt.Fprintf(&sb, p.verb, v.Offset)
+		case "block":
+			fmt.Fprintf(&sb, p.verb, v.Block)
+		case "index":
+			fmt.Fprintf(&sb, p.verb, v.Index)
+		case "size":
+			fmt.Fprintf(&sb, p.verb, v.Size)
+		}
+	}
+	return sb.String()
+}
+
+var defaultNameTemplate = mustParseNameTemplate(DefaultNameTemplate)
+
+func mustParseNameTemplate(s string) *NameTemplate {
+	t, err := ParseNameTemplate(s)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+// maxMetadataNameLen is the maximum length, in bytes, of a name taken from the
+// metadata of a file, extension excluded.
+const maxMetadataNameLen = 100
+
+// metadataName returns the name of a file with the given extension after a title found
+// in its metadata, or an empty string if the title is blank. Control characters are
+// replaced by spaces, runs of spaces are collapsed, path separators and characters
+// reserved on Windows are replaced by underscores, and long titles are cut.
+func metadataName(title, ext string) string {
+	title = strings.Map(func(r rune) rune {
+		switch {
+		case r == utf8.RuneError || unicode.IsControl(r):
+			return ' '
+		case strings.ContainsRune(`/\<>:"|?*`, r):
+			return '_'
+		}
+		return r
+	}, title)
+
+	// Leading dots would hide the file on Unix, trailing ones are dropped by Windows.
+	title = strings.Trim(strings.Join(strings.Fields(title), " "), ". ")
+
+	if len(title) > maxMetadataNameLen {
+		cut := maxMetadataNameLen
+		for cut > 0 && !utf8.RuneStart(title[cut]) {
+			cut--
+		}
+		title = strings.TrimRight(title[:cut], ". ")
+	}
+
+	if title == "" {
+		return ""
+	}
+	return title + "." + ext
+}
+
+// NameSet tracks the names of the files written to a directory, so that files carved from
+// different partitions, or taking their names from metadata, do not overwrite each other.
+// Names are compared case-insensitively, as on Windows and macOS filesystems. It is safe
+// for concurrent use.
+type NameSet struct {
+	mu   sync.Mutex
+	used map[string]int // lowercase name -> last counter appended to it
+}
+
+// NewNameSet returns an empty NameSet.
+func NewNameSet() *NameSet {
+	return &NameSet{used: make(map[string]int)}
+}
+
+// Unique returns name if it was not taken yet, or name with a counter appended to its base
+// (e.g. "report (2).pdf") otherwise, and marks the returned name as taken.
+func (s *NameSet) Unique(name string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := strings.ToLower(name)
+	if _, ok := s.used[key]; !ok {
+		s.used[key] = 1
+		return name
+	}
+
+	ext := filepath.Ext(name)
+	for n := s.used[key] + 1; ; n++ {
+		unique := fmt.Sprintf("%s (%d)%s", strings.TrimSuffix(name, ext), n, ext)
+		if _, ok := s.used[strings.ToLower(unique)]; !ok {
+			s.used[key] = n
+			s.used[strings.ToLower(unique)] = 1
+			return unique
+		}
+	}
+}
@@ -0,0 +1,105 @@
+package format
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestNameTemplateExpand(t *testing.T) {
+	values := NameValues{
+		Offset: 0x1a2b,
+		Block:  13,
+		Index:  7,
+		Size:   1024,
+		Ext:    "jpeg",
+	}
+
+	tests := []struct {
+		template string
+		want     string
+	}{
+		{DefaultNameTemplate, "f13.jpeg"},
+		{"{offset:x}.{ext}", "1a2b.jpeg"},
+		{"{offset:X}.{ext}", "1A2B.jpeg"},
+		{"{offset:010x}.{ext}", "0000001a2b.jpeg"},
+		{"{offset}.{ext}", "6699.jpeg"},
+		{"img_{index:04d}_{size}", "img_0007_1024"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.template, func(t *testing.T) {
+			tmpl, err := ParseNameTemplate(tt.template)
+			if err != nil {
+				t.Fatalf("ParseNameTemplate() error = %v", err)
+			}
+
+			if got := tmpl.Expand(values); got != tt.want {
+				t.Errorf("Expand() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseNameTemplateInvalid(t *testing.T) {
+	tests := []string{
+		"{size}.{ext}",       // names would not be unique
+		"{offset}.{name}",    // unknown token
+		"{offset:q}.{ext}",   // invalid format
+		"{offset}.{ext:x}",   // format of a non-numeric token
+		"{offset.{ext}",      // missing '}'
+		"offset}.{ext}",      // unexpected '}'
+		"dir/{offset}.{ext}", // path separator
+		`dir\{offset}.{ext}`, // path separator
+	}
+
+	for _, template := range tests {
+		if _, err := ParseNameTemplate(template); err == nil {
+			t.Errorf("ParseNameTemplate(%q) error = nil, want error", template)
+		}
+	}
+}
+
+func TestMetadataName(t *testing.T) {
+	tests := []struct {
+		title string
+		want  string
+	}{
+		{"Annual Report", "Annual Report.pdf"},
+		{"  a\tb\n\nc  ", "a b c.pdf"},
+		{"../../etc/passwd", "_.._etc_passwd.pdf"},
+		{`C:\Users\me "quoted"?`, "C__Users_me _quoted__.pdf"},
+		{".hidden.", "hidden.pdf"},
+		{"bad \xff utf8", "bad utf8.pdf"},
+		{" \x00 ", ""},
+		{"...", ""},
+		{strings.Repeat("é", 60), strings.Repeat("é", 50) + ".pdf"},
+	}
+
+	for _, tt := range tests {
+		if got := metadataName(tt.title, "pdf"); got != tt.want {
+			t.Errorf("metadataName(%q) = %q, want %q", tt.title, got, tt.want)
+		}
+	}
+}
+
+func TestNameSetUnique(t *testing.T) {
+	names := NewNameSet()
+
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"Report.pdf", "Report.pdf"},
+		{"report.PDF", "report (2).PDF"},
+		{"Report (3).pdf", "Report (3).pdf"},
+		{"Report.pdf", "Report (4).pdf"},
+		{"f0.png", "f0.png"},
+		{"f0.png", "f0 (2).png"},
+	}
+
+	for _, tt := range tests {
+		if got := names.Unique(tt.name); got != tt.want {
+			t.Errorf("Unique(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,177 @@
+// Copyright (c) 2025 Stefano Scafiti
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package format
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+var oggFileHeader = FileHeader{
+	Ext:         "ogg",
+	Description: "Ogg media container",
+	Category:    CategoryAudio,
+	Signatures: [][]byte{
+		oggCapturePattern,
+	},
+	ScanFile: ScanOgg,
+}
+
+const (
+	// oggPageHeaderSize is the size of the fixed part of an Ogg page header,
+	// which is followed by the segment table.
+	oggPageHeaderSize = 27
+	// oggMaxPageSize is the size of the largest Ogg page: the header, 255 segment
+	// sizes and 255 segments of 255 bytes.
+	oggMaxPageSize = oggPageHeaderSize + 255 + 255*255
+)
+
+// Flags of the header_type field of an Ogg page.
+const (
+	oggFlagBOS = 0x02 // first page of a logical stream
+	oggFlagEOS = 0x04 // last page of a logical stream
+)
+
+var oggCapturePattern = []byte("OggS")
+
+// oggPage holds the fields of an Ogg page used to walk a physical stream.
+type oggPage struct {
+	flags   byte
+	serial  uint32
+	payload []byte
+	size    int
+}
+
+// ScanOgg scans an Ogg physical stream, made of pages which multiplex one or more
+// logical streams. Pages are read, verifying their checksums, until all the logical
+// streams have ended with an EOS page, or until the end of the data or an invalid page.
+// The extension is inferred from the codec of the first logical stream.
+func ScanOgg(r *Reader) (*ScanResult, error) {
+	buf := make([]byte, oggMaxPageSize)
+
+	page, err := readOggPage(r, buf)
+	if err != nil {
+		return nil, err
+	}
+
+	if page.flags&oggFlagBOS == 0 {
+		return nil, fmt.Errorf("first Ogg page is not the beginning of a stream")
+	}
+
+	ext := oggCodecExt(page.payload)
+	size := uint64(page.size)
+	open := map[uint32]bool{}
+	for {
+		switch {
+		case page.flags&oggFlagBOS != 0:
+			open[page.serial] = true
+		case !open[page.serial]:
+			// Pages of unknown streams belong to another file.
+			return &ScanResult{Ext: ext, Size: size - uint64(page.size), Confidence: ConfidenceFullyValidated}, nil
+		}
+
+		if page.flags&oggFlagEOS != 0 {
+			delete(open, page.serial)
+			if len(open) == 0 {
+				break
+			}
+		}
+
+		page, err = readOggPage(r, buf)
+		if err != nil {
+			break
+		}
+		size += uint64(page.size)
+	}
+	return &ScanResult{Ext: ext, Size: size, Confidence: ConfidenceFullyValidated}, nil
+}
+
+// readOggPage reads an Ogg page into buf and verifies its checksum.
+func readOggPage(r *Reader, buf []byte) (*oggPage, error) {
+	hdr := buf[:oggPageHeaderSize]
+	if _, err := io.ReadFull(r, hdr); err != nil {
+		return nil, err
+	}
+
+	if !bytes.Equal(hdr[0:4], oggCapturePattern) || hdr[4] != 0 {
+		return nil, fmt.Errorf("invalid Ogg page header")
+	}
+
+	numSegments := int(hdr[26])
+	segments := buf[oggPageHeaderSize : oggPageHeaderSize+numSegments]
+	if _, err := io.ReadFull(r, segments); err != nil {
+		return nil, err
+	}
+
+	payloadSize := 0
+	for _, s := range segments {
+		payloadSize += int(s)
+	}
+
+	headerSize := oggPageHeaderSize + numSegments
+	payload := buf[headerSize : headerSize+payloadSize]
+	if _, err := io.ReadFull(r, payload); err != nil {
+		return nil, err
+	}
+
+	page := buf[:headerSize+payloadSize]
+	checksum := binary.LittleEndian.Uint32(page[22:26])
+	if oggChecksum(page) != checksum {
+		return nil, fmt.Errorf("invalid Ogg page checksum")
+	}
+
+	return &oggPage{
+		flags:   hdr[5],
+		serial:  binary.LittleEndian.Uint32(hdr[14:18]),
+		payload: payload,
+		size:    len(page),
+	}, nil
+}
+
+// oggCRCTable is the table of the CRC-32 used by Ogg, with polynomial 0x04C11DB7
+// and no bit reflection, which hash/crc32 does not provide.
+var oggCRCTable = func() (t [256]uint32) {
+	for i := range t {
+		crc := uint32(i) << 24
+		for j := 0; j < 8; j++ {
+			if crc&0x80000000 != 0 {
+				crc = crc<<1 ^ 0x04C11DB7
+			} else {
+				crc <<= 1
+			}
+		}
+		t[i] = crc
+	}
+	return t
+}()
+
+// oggChecksum computes the checksum of an Ogg page, reading its checksum field as zero.
+func oggChecksum(page []byte) uint32 {
+	var crc uint32
+	for i, b := range page {
+		if i >= 22 && i < 26 {
+			b = 0
+		}
+		crc = crc<<8 ^ oggCRCTable[byte(crc>>24)^b]
+	}
+	return crc
+}
@@ -0,0 +1,132 @@
+package format
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+)
+
+// oggTestPage returns an Ogg page of the given logical stream holding a single packet.
+func oggTestPage(flags byte, serial uint32, seq uint32, packet []byte) []byte {
+	page := make([]byte, oggPageHeaderSize)
+	copy(page, oggCapturePattern)
+	page[5] = flags
+	binary.LittleEndian.PutUint32(page[14:], serial)
+	binary.LittleEndian.PutUint32(page[18:], seq)
+
+	var segments []byte
+	n := len(packet)
+	for ; n >= 255; n -= 255 {
+		segments = append(segments, 255)
+	}
+	segments = append(segments, byte(n))
+
+	page[26] = byte(len(segments))
+	page = append(page, segments...)
+	page = append(page, packet...)
+	binary.LittleEndian.PutUint32(page[22:], oggChecksum(page))
+	return page
+}
+
+func oggTestStream(serial uint32, head string) []byte {
+	return bytes.Join([][]byte{
+		oggTestPage(oggFlagBOS, serial, 0, []byte(head+"-header")),
+		oggTestPage(0, serial, 1, bytes.Repeat([]byte{0x55}, 1000)),
+		oggTestPage(oggFlagEOS, serial, 2, bytes.Repeat([]byte{0x66}, 300)),
+	}, nil)
+}
+
+func TestScanOgg(t *testing.T) {
+	tests := []struct {
+		name string
+		head string
+		ext  string
+	}{
+		{"vorbis", "\x01vorbis", "ogg"},
+		{"opus", "OpusHead", "opus"},
+		{"speex", "Speex   ", "spx"},
+		{"theora", "\x80theora", "ogv"},
+		{"unknown codec", "\x7fFLAC", "ogg"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			file := oggTestStream(1, tt.head)
+			data := append(bytes.Clone(file), oggTestStream(2, tt.head)...)
+
+			res, err := ScanOgg(newBytesReader(data))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if res.Size != uint64(len(file)) {
+				t.Errorf("expected size %d, got %d", len(file), res.Size)
+			}
+
+			if res.Ext != tt.ext {
+				t.Errorf("expected ext %q, got %q", tt.ext, res.Ext)
+			}
+		})
+	}
+}
+
+func TestScanOggMultiplexed(t *testing.T) {
+	pages := [][]byte{
+		oggTestPage(oggFlagBOS, 1, 0, []byte("OpusHead")),
+		oggTestPage(oggFlagBOS, 2, 0, []byte("\x01vorbis")),
+		oggTestPage(oggFlagEOS, 1, 1, []byte("a")),
+		oggTestPage(0, 2, 1, []byte("b")),
+		oggTestPage(oggFlagEOS, 2, 2, []byte("c")),
+	}
+	file := bytes.Join(pages, nil)
+	head := bytes.Join(pages[:4], nil)
+
+	tests := []struct {
+		name string
+		data []byte
+		size int
+	}{
+		{"complete", file, len(file)},
+		{"corrupted page", append(bytes.Clone(file[:len(file)-1]), 'x'), len(head)},
+		{"page of another stream", append(bytes.Clone(head), oggTestPage(0, 3, 0, []byte("d"))...), len(head)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res, err := ScanOgg(newBytesReader(tt.data))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if res.Size != uint64(tt.size) {
+				t.Errorf("expected size %d, got %d", tt.size, res.Size)
+			}
+
+			if res.Ext != "opus" {
+				t.Errorf("expected ext opus, got %q", res.Ext)
+			}
+		})
+	}
+}
+
+func TestScanOggInvalid(t *testing.T) {
+	badChecksum := oggTestStream(1, "OpusHead")
+	badChecksum[22]++
+
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{"first page not BOS", oggTestPage(0, 1, 0, []byte("OpusHead"))},
+		{"invalid checksum", badChecksum},
+		{"truncated page", oggTestStream(1, "OpusHead")[:20]},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ScanOgg(newBytesReader(tt.data)); err == nil {
+				t.Errorf("expected an error")
+			}
+		})
+	}
+}
@@ -30,6 +30,7 @@ import (
 var pcxFileHeader = FileHeader{
 	Ext:         "pcx",
 	Description: "Picture Exchange Format",
+	Category:    CategoryImage,
 	Signatures: [][]byte{
 		{0x0A},
 	},
@@ -59,33 +60,36 @@ type PCXHeader struct {
 }
 
 // readRLEScanline reads one RLE compressed scanline for a single plane.
-// It returns the number of bytes consumed from the reader for this scanline.
-func readRLEScanline(r *Reader, expectedUncompressedBytes uint16) (int, error) {
+// carry is the number of bytes of the scanline already decoded by a run which
+// started in a previous scanline.
+// It returns the number of bytes consumed from the reader for this scanline, and
+// the number of decoded bytes overflowing into the next scanline.
+func readRLEScanline(r *Reader, expectedUncompressedBytes uint16, carry int) (int, int, error) {
 	bytesRead := 0
-	decodedBytes := 0
+	decodedBytes := carry
 
 	for decodedBytes < int(expectedUncompressedBytes) {
 		b, err := r.ReadByte()
 		if err != nil {
 			if errors.Is(err, io.EOF) {
-				return bytesRead, errors.New("unexpected EOF while reading RLE data")
+				return bytesRead, 0, errors.New("unexpected EOF while reading RLE data")
 			}
-			return bytesRead, fmt.Errorf("failed to read RLE byte: %w", err)
+			return bytesRead, 0, fmt.Errorf("failed to read RLE byte: %w", err)
 		}
 		bytesRead++
 
 		if b&0xC0 == 0xC0 { // If top two bits are 11 (0xC0), it's a run-length byte
 			runLength := int(b & 0x3F) // Lower 6 bits are the run length
 			if runLength == 0 {
-				return bytesRead, errors.New("invalid RLE run length of 0")
+				return bytesRead, 0, errors.New("invalid RLE run length of 0")
 			}
 			// Read the data byte that is repeated
 			_, err = r.ReadByte()
 			if err != nil {
 				if errors.Is(err, io.EOF) {
-					return bytesRead, errors.New("unexpected EOF while reading RLE run data byte")
+					return bytesRead, 0, errors.New("unexpected EOF while reading RLE run data byte")
 				}
-				return bytesRead, fmt.Errorf("failed to read RLE run data byte: %w", err)
+				return bytesRead, 0, fmt.Errorf("failed to read RLE run data byte: %w", err)
 			}
 
 			bytesRead++
@@ -98,9 +102,10 @@ func readRLEScanline(r *Reader, expectedUncompressedBytes uint16) (int, error) {
 		// This means that when a run of data is being encoded, and the end of the scan line is reached,
 		// the run should stop and not continue across to the next scan line, if it is possible to stop it.
 		// Since some encoders have been ignored this rule, enforcing decodedBytes to be strictly less than expectedUncompressedBytes
-		// can be too restrictive for the purposes of file carving.
+		// can be too restrictive for the purposes of file carving. Instead, the bytes decoded past the end of
+		// the scanline are carried to the next one.
 	}
-	return bytesRead, nil
+	return bytesRead, decodedBytes - int(expectedUncompressedBytes), nil
 }
 
 // ScanPCX attempts to carve a PCX file from the given io.Reader and returns its size.
@@ -194,12 +199,14 @@ func ScanPCX(r *Reader) (*ScanResult, error) {
 	} else { // RLE compressed (Encoding == 1)
 		// For RLE, we must parse the RLE data to know its actual compressed size.
 		// We need to read 'height' scanlines, each composed of 'NumPlanes' planes.
+		carry := 0
 		for y := uint32(0); y < height; y++ {
 			for p := uint8(0); p < header.NumPlanes; p++ {
 				// Each scanline for each plane is RLE encoded.
 				// We need to know how many bytes to decode for this specific scanline.
 				// This is `header.BytesPerLine` *for this plane*.
-				consumed, err := readRLEScanline(r, header.BytesPerLine)
+				var consumed int
+				consumed, carry, err = readRLEScanline(r, header.BytesPerLine, carry)
 				if err != nil {
 					return nil, fmt.Errorf("error reading RLE scanline (Y:%d, Plane:%d): %w", y, p, err)
 				}
@@ -218,7 +225,7 @@ func ScanPCX(r *Reader) (*ScanResult, error) {
 			// or it's a valid PCX v5 8bpp without the palette marker.
 			// It's common for some files to omit it, so we don't treat EOF as hard error here.
 			if errors.Is(err, io.EOF) {
-				return &ScanResult{Size: uint64(totalBytesRead)}, nil
+				return &ScanResult{Size: uint64(totalBytesRead), Confidence: ConfidenceStructural}, nil
 			}
 			return nil, fmt.Errorf("failed to read palette marker: %w", err)
 		}
@@ -241,5 +248,5 @@ func ScanPCX(r *Reader) (*ScanResult, error) {
 		// If the byte was not 0x0C, it means there's no 256-color palette or it's corrupted,
 		// but the file might still be valid up to that point. We just count that single byte.
 	}
-	return &ScanResult{Size: uint64(totalBytesRead)}, nil
+	return &ScanResult{Size: uint64(totalBytesRead), Confidence: ConfidenceStructural}, nil
 }
@@ -0,0 +1,58 @@
+package format
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+
+	"github.com/ostafen/digler/pkg/reader"
+)
+
+func newBytesReader(data []byte) *Reader {
+	return NewReader(
+		reader.NewBufferedReadSeeker(bytes.NewReader(data), 4096),
+		uint64(len(data)),
+	)
+}
+
+// pcxFixture returns an RLE encoded 4x2 PCX image, followed by some trailing data.
+// Its first run spans both scanlines, as produced by encoders which do not break runs at line ends.
+func pcxFixture(t *testing.T) (data []byte, size int) {
+	header := PCXHeader{
+		Manufacturer: 0x0A,
+		Version:      3,
+		Encoding:     1,
+		BitsPerPixel: 8,
+		XMax:         3,
+		YMax:         1,
+		NumPlanes:    1,
+		BytesPerLine: 4,
+		PaletteType:  1,
+	}
+
+	var buf bytes.Buffer
+	if err := binary.Write(&buf, binary.LittleEndian, &header); err != nil {
+		t.Fatal(err)
+	}
+
+	// A run of 6 bytes (4 bytes of the first scanline, 2 of the second one),
+	// followed by the last 2 literal bytes of the second scanline.
+	buf.Write([]byte{0xC6, 0x00, 0x01, 0x02})
+	size = buf.Len()
+
+	buf.Write([]byte{0x05, 0x05, 0x05, 0x05})
+	return buf.Bytes(), size
+}
+
+func TestScanPCXCrossLineRuns(t *testing.T) {
+	data, size := pcxFixture(t)
+
+	res, err := ScanPCX(newBytesReader(data))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if res.Size != uint64(size) {
+		t.Fatalf("expected size %d, got %d", size, res.Size)
+	}
+}
@@ -22,11 +22,15 @@ package format
 import (
 	"bytes"
 	"fmt"
+	"io"
+	"regexp"
+	"strconv"
 )
 
 var pdfFileHeader = FileHeader{
 	Ext:         "pdf",
 	Description: "Portable Document Format",
+	Category:    CategoryDocument,
 	Signatures:  [][]byte{pdfHeader},
 	ScanFile:    ScanPDF,
 }
@@ -36,6 +40,18 @@ var (
 	eofMarker = []byte("%%EOF")
 
 	pdfMaxFileSize = 16 * 1024 * 1024 // 16MB
+
+	// pdfInfoRef matches the reference to the document information dictionary in a trailer.
+	pdfInfoRef  = regexp.MustCompile(`/Info\s*(\d+)\s+(\d+)\s+R`)
+	pdfTitleKey = []byte("/Title")
+)
+
+const (
+	// pdfInfoSearchSize is the size of the end of a PDF file searched for the trailer.
+	pdfInfoSearchSize = 64 * 1024
+
+	// pdfMaxInfoSize is the maximum size of the document information dictionary.
+	pdfMaxInfoSize = 4096
 )
 
 // ScanPDF reads a byte stream from an io.Reader, identifies a potential PDF file,
@@ -56,14 +72,14 @@ func ScanPDF(r *Reader) (*ScanResult, error) {
 	}
 
 	var size uint64
-	for {
-		n := r.BytesRead()
 
-		seeked, err := SeekAt(r, eofMarker, pdfMaxFileSize)
+	offset := uint64(len(headerBuf))
+	for {
+		skipped, err := SeekIndex(r, eofMarker, pdfMaxFileSize)
 		if err != nil {
 			return nil, err
 		}
-		if !seeked {
+		if skipped < 0 {
 			break
 		}
 
@@ -72,11 +88,236 @@ func ScanPDF(r *Reader) (*ScanResult, error) {
 			return nil, err
 		}
 
-		size = r.BytesRead() - n + uint64(len(eofMarker))
+		offset += uint64(skipped) + uint64(len(eofMarker))
+		size = offset
 	}
 
 	if size == 0 {
 		return nil, fmt.Errorf("invalid pdf file")
 	}
-	return &ScanResult{Size: size}, nil
+	return &ScanResult{Name: pdfName(r, size), Size: size, Confidence: ConfidenceHeaderOnly}, nil
+}
+
+// pdfName returns the name of the PDF file of the given size, at the start of the reader,
+// after the title in its document information dictionary, or an empty string if the
+// file has no title or if it cannot be read, e.g. because the document is encrypted.
+func pdfName(r *Reader, size uint64) string {
+	// The trailer of the last incremental update refers to the current dictionary.
+	tail := min(size, pdfInfoSearchSize)
+	if err := r.Unread(int(r.BytesRead() - (size - tail))); err != nil {
+		return ""
+	}
+
+	buf := make([]byte, tail)
+	if _, err := io.ReadFull(r, buf); err != nil {
+		return ""
+	}
+
+	if bytes.Contains(buf, []byte("/Encrypt")) {
+		return ""
+	}
+
+	refs := pdfInfoRef.FindAllSubmatch(buf, -1)
+	if refs == nil {
+		return ""
+	}
+	ref := refs[len(refs)-1]
+	objHeader := []byte(fmt.Sprintf("%s %s obj", ref[1], ref[2]))
+
+	if err := r.Unread(int(r.BytesRead())); err != nil {
+		return ""
+	}
+
+	// Objects redefined by incremental updates are replaced by their last definition.
+	objOffset := -1
+	for {
+		skipped, err := SeekIndex(r, objHeader, int(size-r.BytesRead()))
+		if err != nil {
+			return ""
+		}
+		if skipped < 0 {
+			break
+		}
+
+		// The object number must not be the end of a larger one, e.g. "11 0 obj".
+		if r.BytesRead() == 0 || pdfPrecededByDelimiter(r) {
+			objOffset = int(r.BytesRead())
+		}
+		if _, err := r.Discard(len(objHeader)); err != nil {
+			return ""
+		}
+	}
+
+	if objOffset < 0 {
+		return ""
+	}
+	if err := r.Unread(int(r.BytesRead()) - objOffset); err != nil {
+		return ""
+	}
+
+	obj := make([]byte, min(pdfMaxInfoSize, size-r.BytesRead()))
+	if _, err := io.ReadFull(r, obj); err != nil {
+		return ""
+	}
+	if end := bytes.Index(obj, []byte("endobj")); end >= 0 {
+		obj = obj[:end]
+	}
+
+	title, ok := pdfStringValue(obj, pdfTitleKey)
+	if !ok {
+		return ""
+	}
+	return metadataName(title, "pdf")
+}
+
+// pdfPrecededByDelimiter reports whether the byte before the reader is a whitespace
+// or a delimiter character, leaving the reader unchanged.
+func pdfPrecededByDelimiter(r *Reader) bool {
+	if err := r.Unread(1); err != nil {
+		return false
+	}
+	b, err := r.ReadByte()
+	return err == nil && isPDFDelimiter(b)
+}
+
+func isPDFDelimiter(b byte) bool {
+	switch b {
+	case 0, '\t', '\n', '\f', '\r', ' ', '(', ')', '<', '>', '[', ']', '{', '}', '/', '%':
+		return true
+	}
+	return false
+}
+
+// pdfStringValue returns the text of the string value of key in a dictionary.
+// It returns false if the key is missing, or if its value is not a direct string.
+func pdfStringValue(dict, key []byte) (string, bool) {
+	for i := 0; ; {
+		idx := bytes.Index(dict[i:], key)
+		if idx < 0 {
+			return "", false
+		}
+		i += idx + len(key)
+
+		// Skip keys starting with key, e.g. "/TitleFont".
+		if i < len(dict) && !isPDFDelimiter(dict[i]) {
+			continue
+		}
+
+		raw, ok := parsePDFString(bytes.TrimLeft(dict[i:], "\x00\t\n\f\r "))
+		if !ok {
+			return "", false
+		}
+		return decodePDFText(raw), true
+	}
+}
+
+// parsePDFString parses the literal, e.g. "(text)", or hexadecimal, e.g. "<74657874>",
+// string at the start of b, and returns its bytes.
+func parsePDFString(b []byte) ([]byte, bool) {
+	if len(b) == 0 {
+		return nil, false
+	}
+
+	switch b[0] {
+	case '(':
+		return parsePDFLiteralString(b[1:])
+	case '<':
+		end := bytes.IndexByte(b, '>')
+		if end < 0 {
+			return nil, false
+		}
+
+		digits := make([]byte, 0, end)
+		for _, c := range b[1:end] {
+			if !isPDFDelimiter(c) {
+				digits = append(digits, c)
+			}
+		}
+		// A missing final digit is assumed to be 0.
+		if len(digits)%2 != 0 {
+			digits = append(digits, '0')
+		}
+
+		raw := make([]byte, len(digits)/2)
+		for i := range raw {
+			v, err := strconv.ParseUint(string(digits[2*i:2*i+2]), 16, 8)
+			if err != nil {
+				return nil, false
+			}
+			raw[i] = byte(v)
+		}
+		return raw, true
+	}
+	return nil, false
+}
+
+// parsePDFLiteralString parses a literal string following its opening parenthesis,
+// which may contain balanced parentheses and escape sequences.
+func parsePDFLiteralString(b []byte) ([]byte, bool) {
+	var raw []byte
+
+	depth := 0
+	for i := 0; i < len(b); i++ {
+		c := b[i]
+		switch c {
+		case '(':
+			depth++
+		case ')':
+			if depth == 0 {
+				return raw, true
+			}
+			depth--
+		case '\\':
+			i++
+			if i == len(b) {
+				return nil, false
+			}
+
+			switch c = b[i]; c {
+			case 'n':
+				c = '\n'
+			case 'r':
+				c = '\r'
+			case 't':
+				c = '\t'
+			case 'b':
+				c = '\b'
+			case 'f':
+				c = '\f'
+			case '\r', '\n':
+				// A backslash at the end of a line continues the string on the next one.
+				if c == '\r' && i+1 < len(b) && b[i+1] == '\n' {
+					i++
+				}
+				continue
+			case '0', '1', '2', '3', '4', '5', '6', '7':
+				// Octal character code of up to 3 digits.
+				v := c - '0'
+				for n := 1; n < 3 && i+1 < len(b) && b[i+1] >= '0' && b[i+1] <= '7'; n++ {
+					i++
+					v = v<<3 | (b[i] - '0')
+				}
+				c = v
+			}
+		}
+		raw = append(raw, c)
+	}
+	return nil, false
+}
+
+// decodePDFText decodes a text string, encoded in UTF-16BE or in UTF-8 if it starts
+// with a byte order mark, or else in PDFDocEncoding, approximated by Latin-1.
+func decodePDFText(raw []byte) string {
+	switch {
+	case bytes.HasPrefix(raw, []byte{0xFE, 0xFF}):
+		return decodeUTF16(raw[2:], true)
+	case bytes.HasPrefix(raw, []byte{0xEF, 0xBB, 0xBF}):
+		return string(raw[3:])
+	}
+
+	runes := make([]rune, len(raw))
+	for i, b := range raw {
+		runes[i] = rune(b)
+	}
+	return string(runes)
 }
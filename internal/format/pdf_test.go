@@ -0,0 +1,71 @@
+package format
+
+import (
+	"fmt"
+	"testing"
+)
+
+// pdfFixture returns a PDF file whose document information dictionary is the given one,
+// followed by some trailing data, and the size of the file.
+func pdfFixture(info, trailer string) ([]byte, int) {
+	pdf := "%PDF-1.4\n" +
+		"1 0 obj\n<< /Type /Catalog /Pages 2 0 R >>\nendobj\n" +
+		"2 0 obj\n<< /Type /Pages /Kids [] /Count 0 >>\nendobj\n" +
+		"3 0 obj\n" + info + "\nendobj\n" +
+		"13 0 obj\n<< /Title (Not the title) >>\nendobj\n" +
+		"xref\n0 0\ntrailer\n" + trailer + "\nstartxref\n0\n%%EOF"
+	return append([]byte(pdf), make([]byte, 64)...), len(pdf)
+}
+
+func TestScanPDFTitle(t *testing.T) {
+	tests := []struct {
+		name    string
+		info    string
+		trailer string
+		want    string
+	}{
+		{"literal", "<< /Producer (x) /Title (Annual \\(draft\\) report\\056) >>", "<< /Root 1 0 R /Info 3 0 R >>", "Annual (draft) report.pdf"},
+		{"nested parentheses", "<< /Title (a (b) c) >>", "<< /Info 3 0 R >>", "a (b) c.pdf"},
+		{"hex UTF-16", "<< /Title <FEFF00E9007400E9> >>", "<< /Info 3 0 R >>", "été.pdf"},
+		{"Latin-1", "<< /Title (caf\\351) >>", "<< /Info 3 0 R >>", "café.pdf"},
+		{"key prefix", "<< /TitleFont (x) /Title (y) >>", "<< /Info 3 0 R >>", "y.pdf"},
+		{"no title", "<< /Producer (x) >>", "<< /Info 3 0 R >>", ""},
+		{"indirect title", "<< /Title 5 0 R >>", "<< /Info 3 0 R >>", ""},
+		{"no info", "<< /Title (x) >>", "<< /Root 1 0 R >>", ""},
+		{"encrypted", "<< /Title (x) >>", "<< /Info 3 0 R /Encrypt 3 0 R >>", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, size := pdfFixture(tt.info, tt.trailer)
+
+			res, err := ScanPDF(newBytesReader(data))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if res.Size != uint64(size) {
+				t.Errorf("expected size %d, got %d", size, res.Size)
+			}
+			if res.Name != tt.want {
+				t.Errorf("expected name %q, got %q", tt.want, res.Name)
+			}
+		})
+	}
+}
+
+func TestScanPDFTitleIncrementalUpdate(t *testing.T) {
+	data, size := pdfFixture("<< /Title (Old) >>", "<< /Info 3 0 R >>")
+
+	// An incremental update replaces the dictionary with a new object.
+	update := fmt.Sprintf("\n3 0 obj\n<< /Title (New) >>\nendobj\ntrailer\n<< /Prev %d /Info 3 0 R >>\n%%%%EOF", size)
+	data = append(data[:size], update...)
+	size = len(data)
+
+	res, err := ScanPDF(newBytesReader(data))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if res.Size != uint64(size) || res.Name != "New.pdf" {
+		t.Errorf("expected size %d and name New.pdf, got %d and %q", size, res.Size, res.Name)
+	}
+}
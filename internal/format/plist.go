@@ -0,0 +1,165 @@
+// Copyright (c) 2025 Stefano Scafiti
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package format
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+// plistFileHeader is registered after the other formats sharing the "<?xml"
+// signature (e.g., SVG), so that generic XML files are only reported as
+// property lists when no more specific scanner claims them.
+var plistFileHeader = FileHeader{
+	Ext:         "plist",
+	Description: "Apple property list",
+	Category:    CategoryDocument,
+	Signatures: [][]byte{
+		bplistMagic,
+		[]byte("<?xml"),
+		plistDoctype,
+	},
+	ScanFile: ScanPlist,
+}
+
+const (
+	// bplistTrailerSize is the size of the trailer ending a binary property list.
+	bplistTrailerSize = 32
+	// plistHeadSize is the size of the start of the file searched for the plist DOCTYPE.
+	plistHeadSize = 512
+	// plistMaxFileSize bounds the search for the end of a property list.
+	plistMaxFileSize = 16 * 1024 * 1024
+	// plistChunkSize is the size of the chunks in which binary property lists are searched for the trailer.
+	plistChunkSize = 32 * 1024
+)
+
+var (
+	bplistMagic     = []byte("bplist00")
+	plistDoctype    = []byte("<!DOCTYPE plist")
+	plistCloseTag   = []byte("</plist>")
+	bplistTrailerID = make([]byte, 6) // unused bytes and sort version of the trailer
+)
+
+// ScanPlist scans an Apple property list, either in the binary (bplist00)
+// or in the XML format.
+func ScanPlist(r *Reader) (*ScanResult, error) {
+	head, err := r.Peek(plistHeadSize)
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+
+	if bytes.HasPrefix(head, bplistMagic) {
+		return scanBinaryPlist(r)
+	}
+	return scanXMLPlist(r, head)
+}
+
+// scanXMLPlist scans an XML property list, which must declare the plist DOCTYPE
+// near the start of the file. The file ends after the </plist> tag, since property
+// lists cannot be nested.
+func scanXMLPlist(r *Reader, head []byte) (*ScanResult, error) {
+	if !bytes.HasPrefix(head, []byte("<?xml")) && !bytes.HasPrefix(head, plistDoctype) {
+		return nil, fmt.Errorf("reader does not start with an XML declaration or a plist DOCTYPE")
+	}
+
+	if !bytes.Contains(head, plistDoctype) {
+		return nil, fmt.Errorf("no plist DOCTYPE found at the start of the file")
+	}
+
+	skipped, err := SeekIndex(r, plistCloseTag, plistMaxFileSize)
+	if err != nil {
+		return nil, err
+	}
+	if skipped < 0 {
+		return nil, fmt.Errorf("no </plist> tag found")
+	}
+	return &ScanResult{Size: uint64(skipped + len(plistCloseTag)), Confidence: ConfidenceHeaderOnly}, nil
+}
+
+// scanBinaryPlist scans a binary property list. The file ends with a 32-byte trailer
+// describing the offset table, which follows the objects and precedes the trailer:
+// a trailer is found when the offset table it points to ends right where the trailer
+// starts, which gives the exact size of the file.
+func scanBinaryPlist(r *Reader) (*ScanResult, error) {
+	var (
+		offset uint64 // offset of buf[0] from the start of the file
+		carry  int
+	)
+
+	buf := make([]byte, plistChunkSize+bplistTrailerSize-1)
+	for offset < plistMaxFileSize {
+		n, err := io.ReadFull(r, buf[carry:])
+		if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+			return nil, err
+		}
+
+		data := buf[:carry+n]
+		for i := 0; i+bplistTrailerSize <= len(data); i++ {
+			j := bytes.Index(data[i:len(data)-bplistTrailerSize+len(bplistTrailerID)], bplistTrailerID)
+			if j < 0 {
+				break
+			}
+			i += j
+
+			pos := offset + uint64(i)
+			if isBinaryPlistTrailer(data[i:i+bplistTrailerSize], pos) {
+				return &ScanResult{Size: pos + bplistTrailerSize, Confidence: ConfidenceStructural}, nil
+			}
+		}
+
+		if err != nil {
+			break
+		}
+
+		i := max(len(data)-(bplistTrailerSize-1), 0)
+		carry = copy(buf, data[i:])
+		offset += uint64(i)
+	}
+	return nil, fmt.Errorf("no binary plist trailer found")
+}
+
+// isBinaryPlistTrailer reports whether t is a valid binary plist trailer located at offset pos.
+func isBinaryPlistTrailer(t []byte, pos uint64) bool {
+	offsetIntSize := t[6]
+	objectRefSize := t[7]
+	if !isPlistIntSize(offsetIntSize) || !isPlistIntSize(objectRefSize) {
+		return false
+	}
+
+	numObjects := binary.BigEndian.Uint64(t[8:16])
+	topObject := binary.BigEndian.Uint64(t[16:24])
+	offsetTableOffset := binary.BigEndian.Uint64(t[24:32])
+
+	if numObjects == 0 || numObjects > plistMaxFileSize || topObject >= numObjects {
+		return false
+	}
+
+	// Objects start right after the magic, so the offset table cannot start before them.
+	if offsetTableOffset <= uint64(len(bplistMagic)) || offsetTableOffset >= pos {
+		return false
+	}
+	return offsetTableOffset+numObjects*uint64(offsetIntSize) == pos
+}
+
+func isPlistIntSize(n byte) bool {
+	return n == 1 || n == 2 || n == 4 || n == 8
+}
@@ -0,0 +1,84 @@
+package format
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+)
+
+// newBinaryPlist returns a binary plist whose objects are followed by an offset
+// table of numObjects 1-byte entries and by the trailer.
+func newBinaryPlist(objects []byte, numObjects int) []byte {
+	data := append([]byte("bplist00"), objects...)
+
+	offsetTableOffset := len(data)
+	for i := 0; i < numObjects; i++ {
+		data = append(data, 8)
+	}
+
+	var trailer [bplistTrailerSize]byte
+	trailer[6] = 1 // offset int size
+	trailer[7] = 1 // object ref size
+	binary.BigEndian.PutUint64(trailer[8:], uint64(numObjects))
+	binary.BigEndian.PutUint64(trailer[24:], uint64(offsetTableOffset))
+	return append(data, trailer[:]...)
+}
+
+func TestScanPlist(t *testing.T) {
+	small := newBinaryPlist([]byte{0x52, 'h', 'i'}, 1)
+	large := newBinaryPlist(append([]byte{0x4f, 0x13, 0, 0, 0, 0, 0, 1, 0, 0}, make([]byte, 2*plistChunkSize)...), 3)
+	xml := `<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">
+<plist version="1.0">
+<dict><key>a</key><string>b</string></dict>
+</plist>`
+
+	tests := []struct {
+		name string
+		data []byte
+		size int
+	}{
+		{"binary", small, len(small)},
+		{"binary followed by data", append(bytes.Clone(small), make([]byte, 64)...), len(small)},
+		{"binary spanning chunks", large, len(large)},
+		{"xml", []byte(xml + "\n\x00\x00"), len(xml)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res, err := ScanPlist(newBytesReader(tt.data))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if res.Size != uint64(tt.size) {
+				t.Errorf("expected size %d, got %d", tt.size, res.Size)
+			}
+		})
+	}
+}
+
+func TestScanPlistInvalid(t *testing.T) {
+	small := newBinaryPlist([]byte{0x52, 'h', 'i'}, 1)
+
+	misplaced := bytes.Clone(small)
+	misplaced[len(misplaced)-1]--
+
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{"generic XML", []byte(`<?xml version="1.0"?><note></note>`)},
+		{"xml without closing tag", []byte(`<?xml version="1.0"?><!DOCTYPE plist><plist><dict/>`)},
+		{"binary without trailer", small[:len(small)-1]},
+		{"binary with misplaced offset table", misplaced},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ScanPlist(newBytesReader(tt.data)); err == nil {
+				t.Errorf("expected an error")
+			}
+		})
+	}
+}
@@ -0,0 +1,41 @@
+// Copyright (c) 2025 Stefano Scafiti
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+//go:build !cgo || !(linux || darwin || freebsd)
+// +build !cgo !linux,!darwin,!freebsd
+
+package format
+
+import (
+	"errors"
+	"fmt"
+	"runtime"
+)
+
+// NativePluginsSupported returns an error explaining why native Go plugins (.so files)
+// cannot be loaded: they are only supported on Linux, macOS and FreeBSD, by binaries
+// built with cgo. WebAssembly plugins are supported everywhere.
+func NativePluginsSupported() error {
+	switch runtime.GOOS {
+	case "linux", "darwin", "freebsd":
+		return errors.New("native plugins require a binary built with cgo (CGO_ENABLED=1)")
+	}
+	return fmt.Errorf("native plugins are not supported on %s", runtime.GOOS)
+}
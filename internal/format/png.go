@@ -25,6 +25,7 @@ import (
 var pngFileHeader = FileHeader{
 	Ext:         "png",
 	Description: "Portable Network Graphics Format",
+	Category:    CategoryImage,
 	Signatures:  [][]byte{[]byte(pngHeader)},
 	ScanFile:    ScanPNG,
 }
@@ -154,6 +155,9 @@ func (d *decoder) verifyChecksum() error {
 	return nil
 }
 
+// ScanPNG validates a PNG file by walking its chunks up to the IEND chunk,
+// checking the CRC of each one. It returns a ScanResult whose Size is the
+// total size of the PNG file, or an error if the file is malformed or truncated.
 func ScanPNG(r *Reader) (*ScanResult, error) {
 	d := &decoder{
 		r:   r,
@@ -175,5 +179,5 @@ func ScanPNG(r *Reader) (*ScanResult, error) {
 			return nil, err
 		}
 	}
-	return &ScanResult{Size: r.BytesRead()}, nil
+	return &ScanResult{Size: r.BytesRead(), Confidence: ConfidenceFullyValidated}, nil
 }
@@ -35,6 +35,7 @@ var (
 var rarFileHeader = FileHeader{
 	Ext:         "rar",
 	Description: "Rar Archive Format",
+	Category:    CategoryDocument,
 	Signatures: [][]byte{
 		Rar15Signature,
 		Rar50Signature,
@@ -88,7 +89,8 @@ func scanRar15(r *Reader) (*ScanResult, error) {
 	}
 
 	return &ScanResult{
-		Size: r.BytesRead(),
+		Size:       r.BytesRead(),
+		Confidence: ConfidenceStructural,
 	}, nil
 }
 
@@ -189,7 +191,8 @@ func scanRar50(r *Reader) (*ScanResult, error) {
 	}
 
 	return &ScanResult{
-		Size: r.BytesRead(),
+		Size:       r.BytesRead(),
+		Confidence: ConfidenceStructural,
 	}, nil
 }
 
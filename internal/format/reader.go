@@ -20,16 +20,29 @@
 package format
 
 import (
+	"errors"
+	"fmt"
 	"io"
 
 	"github.com/ostafen/digler/pkg/reader"
 )
 
+// ErrBudgetExceeded is returned by the read methods of a Reader when the scanner
+// consumed more bytes than allowed by the budget (see SetBudget).
+var ErrBudgetExceeded = errors.New("scan budget exceeded")
+
 type Reader struct {
 	r *reader.BufferedReadSeeker
 
+	// registry, if set, is used to detect the start of other files.
+	registry *FileRegistry
+
 	n    uint64
 	size uint64
+
+	// consumed counts the bytes read, including those read again after Unread.
+	consumed uint64
+	budget   uint64
 }
 
 func NewReader(r *reader.BufferedReadSeeker, size uint64) *Reader {
@@ -39,6 +52,25 @@ func NewReader(r *reader.BufferedReadSeeker, size uint64) *Reader {
 	}
 }
 
+// SetBudget limits the number of bytes which can be read, to abandon pathological
+// inputs quickly. Bytes read again after Unread are counted again, while bytes skipped
+// with Discard are not, so that large payloads which are only seeked over, as the media
+// data of MP4 files, do not count. Bytes searched by SeekAt and SeekIndex are counted.
+// Once the budget is exceeded, the read methods return ErrBudgetExceeded.
+// A budget of 0 means no limit.
+func (r *Reader) SetBudget(budget uint64) {
+	r.budget = budget
+}
+
+// consume accounts for n bytes read, checking the budget.
+func (r *Reader) consume(n uint64) error {
+	r.consumed += n
+	if r.budget != 0 && r.consumed > r.budget {
+		return ErrBudgetExceeded
+	}
+	return nil
+}
+
 func (r *Reader) ReadByte() (byte, error) {
 	if r.n >= r.size {
 		return 0, io.EOF
@@ -49,6 +81,7 @@ func (r *Reader) ReadByte() (byte, error) {
 	_, err := r.r.Read(buf[:])
 	if err == nil {
 		r.n++
+		err = r.consume(1)
 	}
 	return buf[0], err
 }
@@ -65,6 +98,9 @@ func (r *Reader) Read(buf []byte) (int, error) {
 
 	if n > 0 {
 		r.n += uint64(n)
+		if err := r.consume(uint64(n)); err != nil {
+			return n, err
+		}
 	}
 	return n, err
 }
@@ -73,8 +109,16 @@ func (r *Reader) Seek(offset int64, whence int) (int64, error) {
 	return r.r.Seek(offset, whence)
 }
 
+// Unread moves the reader n bytes back, so that they are not counted as read.
 func (r *Reader) Unread(n int) error {
+	if uint64(n) > r.n {
+		return fmt.Errorf("cannot unread %d bytes: only %d bytes read", n, r.n)
+	}
+
 	_, err := r.Seek(-int64(n), io.SeekCurrent)
+	if err == nil {
+		r.n -= uint64(n)
+	}
 	return err
 }
 
@@ -82,7 +126,15 @@ func (r *Reader) UnreadByte() error {
 	return r.Unread(1)
 }
 
+// Discard skips the next n bytes. A negative n moves the reader back, like Unread.
 func (r *Reader) Discard(n int) (int, error) {
+	if n < 0 {
+		if err := r.Unread(-n); err != nil {
+			return 0, err
+		}
+		return n, nil
+	}
+
 	offset, err := r.r.Seek(int64(n), io.SeekCurrent)
 	if err != nil {
 		return 0, err
@@ -99,15 +151,55 @@ func (r *Reader) Discard(n int) (int, error) {
 	)
 
 	r.n += discarded
-
 	if discarded < uint64(n) {
 		err = io.EOF
 	}
 	return int(discarded), err
 }
 
+// Peek returns the next n bytes without advancing the reader.
+// Like the other read methods, it does not go past the size of the reader:
+// if fewer than n bytes are left, it returns them together with io.EOF.
 func (r *Reader) Peek(n int) ([]byte, error) {
-	return r.r.Peek(n)
+	if r.n >= r.size {
+		return nil, io.EOF
+	}
+
+	remaining := r.size - r.n
+	if uint64(n) <= remaining {
+		return r.r.Peek(n)
+	}
+
+	data, err := r.r.Peek(int(remaining))
+	if err != nil {
+		return data, err
+	}
+	return data, io.EOF
+}
+
+// AtHeader reports whether the upcoming bytes match the signature of a registered
+// file format. It always returns false when the reader is not attached to a registry.
+func (r *Reader) AtHeader() bool {
+	if r.registry == nil || r.n >= r.size {
+		return false
+	}
+
+	n := min(
+		uint64(r.registry.MaxSignatureLen()),
+		uint64(r.BufferSize()),
+		r.size-r.n,
+	)
+
+	data, err := r.Peek(int(n))
+	if err != nil && err != io.EOF {
+		return false
+	}
+	return r.registry.Match(data)
+}
+
+// Offset returns the absolute position of the reader in the underlying stream.
+func (r *Reader) Offset() int64 {
+	return r.r.Offset()
 }
 
 func (r *Reader) BytesRead() uint64 {
@@ -0,0 +1,113 @@
+package format
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/ostafen/digler/pkg/reader"
+)
+
+func TestReaderPeekRespectsSize(t *testing.T) {
+	data := []byte("0123456789")
+
+	r := NewReader(reader.NewBufferedReadSeeker(bytes.NewReader(data), 4096), 6)
+
+	peeked, err := r.Peek(4)
+	if err != nil || string(peeked) != "0123" {
+		t.Fatalf("Peek(4) = %q, %v", peeked, err)
+	}
+
+	if _, err := r.Discard(4); err != nil {
+		t.Fatal(err)
+	}
+
+	peeked, err = r.Peek(4)
+	if err != io.EOF || string(peeked) != "45" {
+		t.Fatalf("Peek(4) at boundary = %q, %v, want \"45\", io.EOF", peeked, err)
+	}
+
+	if _, err := r.Discard(2); err != nil {
+		t.Fatal(err)
+	}
+
+	peeked, err = r.Peek(1)
+	if err != io.EOF || len(peeked) != 0 {
+		t.Fatalf("Peek(1) past size = %q, %v, want empty, io.EOF", peeked, err)
+	}
+}
+
+func TestReaderOffset(t *testing.T) {
+	data := []byte("0123456789")
+
+	br := reader.NewBufferedReadSeeker(bytes.NewReader(data), 4)
+	if _, err := br.Seek(2, io.SeekStart); err != nil {
+		t.Fatal(err)
+	}
+
+	r := NewReader(br, 8)
+
+	steps := []struct {
+		name string
+		op   func() error
+		want int64
+	}{
+		{"start", func() error { return nil }, 2},
+		{"read", func() error { _, err := r.Read(make([]byte, 3)); return err }, 5},
+		{"peek", func() error { _, err := r.Peek(2); return err }, 5},
+		{"discard", func() error { _, err := r.Discard(2); return err }, 7},
+		{"unread", func() error { return r.Unread(4) }, 3},
+		{"read byte", func() error { _, err := r.ReadByte(); return err }, 4},
+	}
+
+	for _, step := range steps {
+		if err := step.op(); err != nil {
+			t.Fatalf("%s: %v", step.name, err)
+		}
+		if got := r.Offset(); got != step.want {
+			t.Fatalf("%s: Offset() = %d, want %d", step.name, got, step.want)
+		}
+	}
+}
+
+func TestReaderBudget(t *testing.T) {
+	r := newBytesReader(make([]byte, 64*1024))
+	r.SetBudget(1024)
+
+	var buf [512]byte
+	if _, err := r.Read(buf[:]); err != nil {
+		t.Fatalf("Read() error = %v", err)
+	}
+
+	// Bytes read again are counted again.
+	if err := r.Unread(512); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.Read(buf[:]); err != nil {
+		t.Fatalf("Read() error = %v", err)
+	}
+
+	if _, err := r.ReadByte(); !errors.Is(err, ErrBudgetExceeded) {
+		t.Errorf("ReadByte() error = %v, want %v", err, ErrBudgetExceeded)
+	}
+
+	// Discarded bytes are not counted.
+	r = newBytesReader(make([]byte, 64*1024))
+	r.SetBudget(1024)
+
+	if _, err := r.Discard(32 * 1024); err != nil {
+		t.Fatalf("Discard() error = %v", err)
+	}
+	if _, err := r.Read(buf[:]); err != nil {
+		t.Fatalf("Read() error = %v", err)
+	}
+
+	// A missing signature is not searched for beyond the budget.
+	r = newBytesReader(make([]byte, 64*1024))
+	r.SetBudget(8192)
+
+	if _, err := SeekAt(r, []byte("PK\x07\x08"), MaxZipFileSize); !errors.Is(err, ErrBudgetExceeded) {
+		t.Errorf("SeekAt() error = %v, want %v", err, ErrBudgetExceeded)
+	}
+}
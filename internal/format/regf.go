@@ -0,0 +1,145 @@
+// Copyright (c) 2025 Stefano Scafiti
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package format
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+var registryFileHeader = FileHeader{
+	Ext:         "hive",
+	Description: "Windows Registry Hive",
+	Category:    CategoryDatabase,
+	Signatures: [][]byte{
+		[]byte("regf"),
+	},
+	ScanFile: ScanRegistry,
+}
+
+const (
+	// regfBaseBlockSize is the size of the base block, which is followed by the hive bins.
+	regfBaseBlockSize = 4096
+	// regfChecksumOffset is the offset of the checksum of the first 508 bytes of the base block.
+	regfChecksumOffset = 508
+	// regfHiveBinHeaderSize is the size of the header of a hive bin.
+	regfHiveBinHeaderSize = 32
+	// regfMaxHiveBinsSize bounds the size of the hive bins: hives are limited to 2GiB.
+	regfMaxHiveBinsSize = 2 << 30
+	// regfPrimaryFile is the type of primary hive files, as opposed to transaction logs.
+	regfPrimaryFile = 0
+)
+
+// ScanRegistry carves a Windows Registry hive, such as SYSTEM or NTUSER.DAT, sized from
+// the hive bins data size of its base block. The hive bins are walked to check that they
+// fill the declared size. Hives whose sequence numbers differ were not cleanly written,
+// as the transaction log was not applied to them, and are reported as structurally valid only.
+func ScanRegistry(r *Reader) (*ScanResult, error) {
+	// Base block: https://github.com/msuhanov/regf/blob/master/Windows%20registry%20file%20format%20specification.md
+	// -----------------------------------------
+	// Signature            (4 bytes)        "regf"
+	// PrimarySequence      (4 bytes)        Little-endian; incremented before writing the hive
+	// SecondarySequence    (4 bytes)        Little-endian; set to the primary one once written
+	// LastWritten          (8 bytes)        FILETIME
+	// MajorVersion         (4 bytes)        Little-endian; 1
+	// MinorVersion         (4 bytes)        Little-endian; 2 to 6
+	// FileType             (4 bytes)        Little-endian; 0 for primary files
+	// FileFormat           (4 bytes)        Little-endian; 1 (direct memory load)
+	// RootCellOffset       (4 bytes)        Little-endian
+	// HiveBinsDataSize     (4 bytes)        Little-endian; multiple of 4096
+	// ClusteringFactor     (4 bytes)        Little-endian; 1
+	// FileName             (64 bytes)       UTF-16LE, last characters of the path of the hive
+	// ...
+	// Checksum             (4 bytes)        At offset 508; XOR of the preceding 32-bit words
+
+	var base [regfBaseBlockSize]byte
+	if _, err := io.ReadFull(r, base[:]); err != nil {
+		return nil, fmt.Errorf("failed to read registry base block: %w", err)
+	}
+
+	le := binary.LittleEndian
+
+	if checksum := le.Uint32(base[regfChecksumOffset:]); checksum != regfChecksum(base[:regfChecksumOffset]) {
+		return nil, fmt.Errorf("registry base block checksum mismatch: %#x", checksum)
+	}
+
+	major, minor := le.Uint32(base[20:24]), le.Uint32(base[24:28])
+	if major != 1 || minor < 2 || minor > 6 {
+		return nil, fmt.Errorf("unsupported registry hive version %d.%d", major, minor)
+	}
+
+	if fileType := le.Uint32(base[28:32]); fileType != regfPrimaryFile {
+		return nil, fmt.Errorf("not a primary registry hive (file type %d)", fileType)
+	}
+
+	binsSize := le.Uint32(base[40:44])
+	if binsSize == 0 || binsSize%regfBaseBlockSize != 0 || binsSize > regfMaxHiveBinsSize {
+		return nil, fmt.Errorf("invalid registry hive bins data size: %d", binsSize)
+	}
+
+	// Walk the hive bins, each starting with its signature and its offset from the first one.
+	for offset := uint32(0); offset < binsSize; {
+		var hdr [regfHiveBinHeaderSize]byte
+		if _, err := io.ReadFull(r, hdr[:]); err != nil {
+			return nil, fmt.Errorf("failed to read registry hive bin at offset %d: %w", offset, err)
+		}
+
+		binSize := le.Uint32(hdr[8:12])
+		if string(hdr[:4]) != "hbin" || le.Uint32(hdr[4:8]) != offset {
+			return nil, fmt.Errorf("invalid registry hive bin at offset %d", offset)
+		}
+		if binSize == 0 || binSize%regfBaseBlockSize != 0 || binSize > binsSize-offset {
+			return nil, fmt.Errorf("invalid size %d of registry hive bin at offset %d", binSize, offset)
+		}
+
+		if _, err := r.Discard(int(binSize - regfHiveBinHeaderSize)); err != nil {
+			return nil, err
+		}
+		offset += binSize
+	}
+
+	confidence := ConfidenceFullyValidated
+	if le.Uint32(base[4:8]) != le.Uint32(base[8:12]) {
+		confidence = ConfidenceStructural
+	}
+
+	return &ScanResult{
+		Size:       regfBaseBlockSize + uint64(binsSize),
+		Confidence: confidence,
+	}, nil
+}
+
+// regfChecksum returns the checksum of the base block of a registry hive: the XOR of its
+// first 127 32-bit words, where 0xFFFFFFFF becomes 0xFFFFFFFE and 0 becomes 1.
+func regfChecksum(data []byte) uint32 {
+	var sum uint32
+	for i := 0; i+4 <= len(data); i += 4 {
+		sum ^= binary.LittleEndian.Uint32(data[i:])
+	}
+
+	switch sum {
+	case 0xFFFFFFFF:
+		return 0xFFFFFFFE
+	case 0:
+		return 1
+	}
+	return sum
+}
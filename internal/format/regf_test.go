@@ -0,0 +1,93 @@
+package format
+
+import (
+	"encoding/binary"
+	"testing"
+)
+
+// registryFixture returns a registry hive with two hive bins, of 4096 and 8192 bytes,
+// whose base block has the given sequence numbers, followed by trailing data.
+func registryFixture(primarySeq, secondarySeq uint32) (data []byte, size int) {
+	le := binary.LittleEndian
+
+	data = make([]byte, regfBaseBlockSize)
+	copy(data, "regf")
+	le.PutUint32(data[4:8], primarySeq)
+	le.PutUint32(data[8:12], secondarySeq)
+	le.PutUint32(data[20:24], 1)      // MajorVersion
+	le.PutUint32(data[24:28], 5)      // MinorVersion
+	le.PutUint32(data[32:36], 1)      // FileFormat
+	le.PutUint32(data[36:40], 32)     // RootCellOffset
+	le.PutUint32(data[40:44], 0x3000) // HiveBinsDataSize
+	le.PutUint32(data[44:48], 1)      // ClusteringFactor
+	le.PutUint32(data[regfChecksumOffset:], regfChecksum(data[:regfChecksumOffset]))
+
+	for _, bin := range []struct{ offset, size uint32 }{{0, 0x1000}, {0x1000, 0x2000}} {
+		hbin := make([]byte, bin.size)
+		copy(hbin, "hbin")
+		le.PutUint32(hbin[4:8], bin.offset)
+		le.PutUint32(hbin[8:12], bin.size)
+		data = append(data, hbin...)
+	}
+
+	size = len(data)
+	return append(data, "trailing data"...), size
+}
+
+func TestScanRegistry(t *testing.T) {
+	tests := []struct {
+		name         string
+		secondarySeq uint32
+		want         Confidence
+	}{
+		{"clean", 7, ConfidenceFullyValidated},
+		{"dirty", 6, ConfidenceStructural},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, size := registryFixture(7, tt.secondarySeq)
+
+			res, err := ScanRegistry(newBytesReader(data))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if res.Size != uint64(size) || res.Confidence != tt.want {
+				t.Fatalf("expected size %d and confidence %s, got %d and %s", size, tt.want, res.Size, res.Confidence)
+			}
+		})
+	}
+}
+
+func TestScanRegistryInvalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		corrupt func(data []byte)
+	}{
+		{
+			name:    "checksum",
+			corrupt: func(data []byte) { data[100] ^= 1 },
+		},
+		{
+			name:    "hive bin signature",
+			corrupt: func(data []byte) { copy(data[regfBaseBlockSize+0x1000:], "hbix") },
+		},
+		{
+			name: "hive bin size",
+			corrupt: func(data []byte) {
+				binary.LittleEndian.PutUint32(data[regfBaseBlockSize+0x1000+8:], 0x3000)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, _ := registryFixture(1, 1)
+			tt.corrupt(data)
+
+			if _, err := ScanRegistry(newBytesReader(data)); err == nil {
+				t.Fatal("expected an error")
+			}
+		})
+	}
+}
@@ -20,46 +20,230 @@
 package format
 
 import (
+	"bytes"
+
 	"github.com/ostafen/digler/pkg/table"
 )
 
 type FileRegistry struct {
-	table *table.PrefixTable[scanners]
+	// tables holds the registered signatures, grouped by their offset from the start of
+	// the file, by increasing offset. The first table holds the signatures at offset 0.
+	tables       []offsetTable
+	maxSignature int
+
+	// footers holds the footer signatures of the scanners implementing FooterScanner.
+	footers []footerEntry
+}
+
+// offsetTable holds the signatures found at a given offset from the start of the file.
+type offsetTable struct {
+	offset int
+	table  *table.PrefixTable[*entry]
 }
 
 type scanners []FileScanner
 
+// footerEntry is a footer signature of a scanner.
+type footerEntry struct {
+	signature []byte
+	scanner   FileScanner
+}
+
+// entry groups all the scanners registered for the same signature.
+// The signature is stored alongside the scanners so that a match
+// reported by the table can be verified against the actual bytes.
+type entry struct {
+	signature []byte
+	scanners  scanners
+}
+
 func NewFileRegisty() *FileRegistry {
 	return &FileRegistry{
-		table: table.New[scanners](),
+		tables: []offsetTable{{offset: 0, table: table.New[*entry]()}},
 	}
 }
 
 func (r *FileRegistry) Add(sc FileScanner) {
+	offset := ScannerSignatureOffset(sc)
+	t := r.offsetTable(offset)
+
 	for _, sig := range sc.Signatures() {
-		scanners, _ := r.table.Get(sig)
+		e, ok := t.Get(sig)
+		if !ok {
+			e = &entry{signature: bytes.Clone(sig)}
+			t.Insert(sig, e)
+		}
+		e.scanners = append(e.scanners, sc)
+		r.maxSignature = max(r.maxSignature, offset+len(sig))
+	}
+
+	for _, footer := range ScannerFooters(sc) {
+		r.footers = append(r.footers, footerEntry{signature: bytes.Clone(footer), scanner: sc})
+	}
+}
 
-		r.table.Insert(
-			sig,
-			append(scanners, sc),
-		)
+// offsetTable returns the table of the signatures at the given offset, creating it if needed.
+func (r *FileRegistry) offsetTable(offset int) *table.PrefixTable[*entry] {
+	i := 0
+	for i < len(r.tables) && r.tables[i].offset < offset {
+		i++
 	}
+
+	if i < len(r.tables) && r.tables[i].offset == offset {
+		return r.tables[i].table
+	}
+
+	t := offsetTable{offset: offset, table: table.New[*entry]()}
+	r.tables = append(r.tables[:i], append([]offsetTable{t}, r.tables[i:]...)...)
+	return t.table
 }
 
-// Searches the registry for headers where the key matches a prefix of `data`.
-// The search starts with `r.minKeyLen` and iteratively extends the key length
-// as long as matching headers are found. Each found header is processed by `handleHeader`.
+// MaxSignatureLen returns the length of the longest registered signature,
+// including its offset from the start of the file.
+func (r *FileRegistry) MaxSignatureLen() int {
+	return r.maxSignature
+}
+
+// Searches the registry for headers whose signature is a prefix of `data`,
+// from the shortest to the longest one. Before being handed to `handleHeader`,
+// each candidate signature is compared against the corresponding bytes of `data`,
+// so that scanners are only invoked on exact matches.
+// Signatures found at an offset from the start of the file are searched after those
+// at offset 0, by increasing offset, and are matched against the bytes at that offset.
+// The search stops as soon as `handleHeader` returns true.
 func (r *FileRegistry) Search(data []byte, handleHeader func(sc FileScanner) bool) {
-	if r.table.Size() == 0 {
-		return
+	for _, t := range r.tables {
+		if t.table.Size() == 0 || len(data) < t.offset {
+			continue
+		}
+
+		sigData := data[t.offset:]
+
+		found := false
+		t.table.Walk(sigData, func(e *entry) bool {
+			if !bytes.HasPrefix(sigData, e.signature) {
+				return false
+			}
+
+			for _, sc := range e.scanners {
+				if handleHeader(sc) {
+					found = true
+					return true
+				}
+			}
+			return false
+		})
+
+		if found {
+			return
+		}
+	}
+}
+
+// HasFooters reports whether any footer signature is registered.
+func (r *FileRegistry) HasFooters() bool {
+	return len(r.footers) > 0
+}
+
+// SearchFooters calls handleFooter for each registered footer signature found in data,
+// by increasing position, with the position of the match and the scanner of the footer.
+// The search stops as soon as handleFooter returns true.
+func (r *FileRegistry) SearchFooters(data []byte, handleFooter func(pos int, sc FileScanner) bool) {
+	// The next match of each footer at or after pos, or -1 if there is none.
+	next := make([]int, len(r.footers))
+	for i, f := range r.footers {
+		next[i] = bytes.Index(data, f.signature)
+	}
+
+	for {
+		i := -1
+		for j, pos := range next {
+			if pos >= 0 && (i < 0 || pos < next[i]) {
+				i = j
+			}
+		}
+		if i < 0 {
+			return
+		}
+
+		pos := next[i]
+		if handleFooter(pos, r.footers[i].scanner) {
+			return
+		}
+
+		if k := bytes.Index(data[pos+1:], r.footers[i].signature); k >= 0 {
+			next[i] = pos + 1 + k
+		} else {
+			next[i] = -1
+		}
+	}
+}
+
+// Match reports whether a registered signature is a prefix of `data`,
+// or of the bytes of `data` at the offset of the signature.
+func (r *FileRegistry) Match(data []byte) bool {
+	for _, t := range r.tables {
+		if len(data) < t.offset {
+			continue
+		}
+
+		sigData := data[t.offset:]
+
+		found := false
+		t.table.Walk(sigData, func(e *entry) bool {
+			found = bytes.HasPrefix(sigData, e.signature)
+			return found
+		})
+
+		if found {
+			return true
+		}
 	}
+	return false
+}
+
+// SignatureConflict is a signature of a scanner which another signature, of the same
+// scanner or of a different one, is a prefix of. Since the registry tries the scanners
+// of shorter signatures first, the scanner of the longer one only gets to scan a file
+// when the others reject it.
+type SignatureConflict struct {
+	Offset    int
+	Signature []byte
+	Ext       string
+	Prefix    []byte
+	PrefixExt string
+}
 
-	r.table.Walk(data, func(scanners scanners) bool {
-		for _, sc := range scanners {
-			if handleHeader(sc) {
-				return true
+// FindSignatureConflicts returns the conflicts between the signatures of the given scanners.
+// Scanners declaring the same signature do not conflict, as all of them are tried, but a
+// scanner declaring the same signature twice does.
+func FindSignatureConflicts(scanners ...FileScanner) []SignatureConflict {
+	var conflicts []SignatureConflict
+	for i, a := range scanners {
+		for j, b := range scanners {
+			if ScannerSignatureOffset(a) != ScannerSignatureOffset(b) {
+				continue
+			}
+
+			for k, sig := range a.Signatures() {
+				for l, prefix := range b.Signatures() {
+					isConflict := len(prefix) < len(sig) && bytes.HasPrefix(sig, prefix)
+					if i == j {
+						isConflict = isConflict || (l < k && bytes.Equal(sig, prefix))
+					}
+
+					if isConflict {
+						conflicts = append(conflicts, SignatureConflict{
+							Offset:    ScannerSignatureOffset(a),
+							Signature: sig,
+							Ext:       a.Ext(),
+							Prefix:    prefix,
+							PrefixExt: b.Ext(),
+						})
+					}
+				}
 			}
 		}
-		return false
-	})
+	}
+	return conflicts
 }
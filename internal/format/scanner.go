@@ -21,24 +21,74 @@ package format
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
 	"io"
+	"runtime/debug"
+	"slices"
+	"sort"
+	"strings"
+	"time"
 
 	"github.com/ostafen/digler/internal/logger"
-	"github.com/ostafen/digler/pkg/pbar"
 	"github.com/ostafen/digler/pkg/reader"
 )
 
+// nestedScanBufferSize is the size of the buffers used to search carved files for embedded ones.
+const nestedScanBufferSize = 64 * 1024
+
+// ProgressInterval is the minimum interval between two calls of the progress callback.
+const ProgressInterval = 500 * time.Millisecond
+
+// ProgressFunc is called periodically during a scan with the number of bytes processed
+// out of the total, and with the number of files found so far.
+type ProgressFunc func(processed, total int64, filesFound int)
+
 type Scanner struct {
 	blockSize   int
 	maxFileSize uint64
-	buf         []byte
+	scanBudget  uint64
+	maxDepth    int
+	nestedBufs  [][]byte
+	skip        []region
+	skipErrors  bool
+	fatalPanics bool
+	headerOnly  bool
+	names       *NameTemplate
+	customNames bool
+
+	// entropyBuf holds the samples of carved files whose entropy is checked.
+	// It is nil when the entropy filter is disabled.
+	entropyBuf []byte
+
+	baseOffset uint64
+
+	// usedNames holds the names given to carved files (see SetNameSet).
+	usedNames *NameSet
+
+	progress     ProgressFunc
+	lastProgress time.Time
+
+	// buf is the scan buffer, followed in window by the bytes of the buffer overlap.
+	buf    []byte
+	window []byte
 
 	r         *FileRegistry
 	logger    *logger.Logger
 	bufReader *reader.BufferedReadSeeker
 
 	foundSignatures int
+	badBlocks       int
+	panics          int
+	budgetExceeded  int
+	entropyRejected int
+	err             error
+}
+
+// region is a byte range of the scanned source.
+type region struct {
+	offset uint64
+	size   uint64
 }
 
 type FileInfo struct {
@@ -46,6 +96,54 @@ type FileInfo struct {
 	Ext    string
 	Offset uint64 // Offset in the file where the format starts
 	Size   uint64 // Size of the format in bytes
+
+	// Truncated reports whether the file was cut at the maximum file size
+	// or at the end of the source, so its carved content is incomplete.
+	Truncated bool
+
+	// Confidence is how thoroughly the scanner validated the file.
+	Confidence Confidence
+
+	// Version is the version of the format of the file, if reported by the scanner.
+	Version string
+
+	// ModTime is the original modification time of the file, when known
+	// from filesystem metadata. Carved files have the zero time.
+	ModTime time.Time
+
+	// Parent is the name of the file which a companion file belongs with (see Companion).
+	Parent string
+
+	// Detection tells how the file was detected. It is zero for files which were not
+	// detected by a signature, such as companion files and files recovered from metadata.
+	Detection Detection
+
+	// Runs are the byte ranges of the scanned source holding the contents of a fragmented
+	// file, in order, such as a file recovered by following its FAT cluster chain. It is
+	// empty for contiguous files, whose contents are the Size bytes at Offset.
+	Runs []ByteRun
+}
+
+// ByteRun is a contiguous byte range of the scanned source holding a part of a file.
+type ByteRun struct {
+	Offset uint64
+	Size   uint64
+}
+
+// ByteRuns returns the byte ranges of the scanned source holding the contents of the file, in order.
+func (f *FileInfo) ByteRuns() []ByteRun {
+	if len(f.Runs) > 0 {
+		return f.Runs
+	}
+	return []ByteRun{{Offset: f.Offset, Size: f.Size}}
+}
+
+// Detection describes the signature match which led to carving a file.
+type Detection struct {
+	Scanner         string // Extension of the file scanner which carved the file.
+	Description     string // Description of the format of the file scanner.
+	Signature       []byte // Matched signature.
+	SignatureOffset int    // Offset of the signature from the start of the file.
 }
 
 func NewScanner(
@@ -55,46 +153,176 @@ func NewScanner(
 	blockSize int,
 	maxFileSize uint64,
 ) *Scanner {
-	return &Scanner{
+	sc := &Scanner{
 		blockSize:   blockSize,
 		maxFileSize: maxFileSize,
-		buf:         make([]byte, roundToMul(bufferSize, int(blockSize))),
+		names:       defaultNameTemplate,
+		usedNames:   NewNameSet(),
 		r:           r,
 		logger:      logger,
 		bufReader:   reader.NewBufferedReadSeeker(nil, 4096),
 	}
+	sc.allocBuffer(roundToMul(bufferSize, int(blockSize)), r.MaxSignatureLen())
+	return sc
+}
+
+// SetBufferOverlap sets the number of bytes following the scan buffer which are searched
+// together with it, so that signatures of files starting in the last blocks of the buffer,
+// and extending past its end, are matched. The overlap is read again with the next buffer.
+// It defaults to the length of the longest signature, including its offset, which is
+// enough for the built-in formats.
+func (sc *Scanner) SetBufferOverlap(overlap int) {
+	sc.allocBuffer(len(sc.buf), overlap)
+}
+
+func (sc *Scanner) allocBuffer(size, overlap int) {
+	sc.window = make([]byte, size+overlap)
+	sc.buf = sc.window[:size]
+}
+
+// SetMaxDepth enables recursive carving: each carved file is searched for
+// embedded files (e.g., EXIF thumbnails or images inside archives), which are
+// in turn searched up to the given depth. A depth of 0 disables recursive carving.
+func (sc *Scanner) SetMaxDepth(depth int) {
+	sc.maxDepth = depth
+}
+
+// SetNameTemplate sets the template of the names of carved files (see DefaultNameTemplate).
+// Unlike the default template, it also replaces the names that file scanners choose from
+// metadata. Names of embedded files are not affected.
+func (sc *Scanner) SetNameTemplate(t *NameTemplate) {
+	sc.names = t
+	sc.customNames = true
+}
+
+// SetNameSet sets the set of the names already given to files, which carved files are
+// given unique names against. Scanners whose files are written to the same directory,
+// such as those of the partitions of a disk, must share it.
+func (sc *Scanner) SetNameSet(names *NameSet) {
+	sc.usedNames = names
+}
+
+// SetBaseOffset sets the offset of the scanned source within a larger one (e.g., when only a
+// byte range of a partition is scanned), which must be a multiple of the block size.
+// Skipped regions and the offsets and blocks in the names of carved files are relative to
+// the larger source, while the offsets of carved files stay relative to the scanned one.
+func (sc *Scanner) SetBaseOffset(offset uint64) {
+	sc.baseOffset = offset
+}
+
+// SetProgress sets a callback reporting the progress of the scans, called at most once
+// every ProgressInterval, and once more with processed equal to total when a scan completes.
+func (sc *Scanner) SetProgress(fn ProgressFunc) {
+	sc.progress = fn
+}
+
+// SkipRegion excludes the given byte range of the scanned source from carving:
+// no file is searched for at blocks starting within the range. The range is
+// relative to the larger source when a base offset is set (see SetBaseOffset).
+func (sc *Scanner) SkipRegion(offset, size uint64) {
+	sc.skip = append(sc.skip, region{offset: offset, size: size})
+}
+
+// SetSkipErrors controls how read errors are handled. When skip is true, the blocks
+// which cannot be read are logged, zero-filled and skipped, and the scan goes on.
+// Otherwise, the scan stops at the first read error, which is reported by Err.
+func (sc *Scanner) SetSkipErrors(skip bool) {
+	sc.skipErrors = skip
+}
+
+// SetFatalPanics controls how panics of file scanners, e.g. of buggy plugins, are handled.
+// By default, a panic is logged with the offset and format of the candidate file,
+// no file is carved and the scan goes on. When fatal is true, panics are propagated,
+// which is useful to debug scanners.
+func (sc *Scanner) SetFatalPanics(fatal bool) {
+	sc.fatalPanics = fatal
+}
+
+// SetScanBudget limits the number of bytes which a file scanner can read or skip while
+// validating a single candidate file, which is abandoned when the budget is exceeded.
+// Unlike the maximum file size, which truncates larger files, the budget prevents
+// pathological inputs, e.g. a missing terminator, from stalling the scan.
+// A budget of 0 means no limit.
+func (sc *Scanner) SetScanBudget(budget uint64) {
+	sc.scanBudget = budget
+}
+
+// SetHeaderOnly enables a fast indexing mode, in which file scanners are not run: each
+// signature match is reported as a file of one block, with ConfidenceHeaderOnly, and the
+// scan goes on from the next block. Embedded files are not searched for.
+func (sc *Scanner) SetHeaderOnly(headerOnly bool) {
+	sc.headerOnly = headerOnly
+}
+
+// SetEntropyFilter enables the rejection of carved files whose entropy does not match
+// that expected for their format (see EntropyClass), e.g. when the signature of a ZIP
+// archive precedes uncompressed data, or that of a BMP image precedes random data.
+// Rejected candidates are not carved, and the scan goes on from the next block.
+// Embedded files and files carved from their footers are not checked.
+func (sc *Scanner) SetEntropyFilter(enabled bool) {
+	sc.entropyBuf = nil
+	if enabled {
+		sc.entropyBuf = make([]byte, entropySampleSize)
+	}
 }
 
 func (sc *Scanner) Scan(r io.ReaderAt, size uint64) func(yield func(FileInfo) bool) {
 	return func(yield func(FileInfo) bool) {
 		stop := false
 
-		pb := pbar.NewProgressBarState(int64(size))
-		defer pb.Finish()
-
 		filesFound := 0
+		sc.lastProgress = time.Time{}
+
+		sc.skip = mergeRegions(sc.skip)
+
+		// carved holds the files carved from the current buffer, whose footers are ignored.
+		var carved []region
 
 		for blockOffset := uint64(0); !stop && blockOffset < size; {
-			n, err := r.ReadAt(sc.buf, int64(blockOffset))
+			// Jump past skipped regions, without reading them.
+			if skip, ok := sc.skippedRegion(sc.baseOffset + blockOffset); ok {
+				blockOffset = roundToMul(skip.offset+skip.size-sc.baseOffset, uint64(sc.blockSize))
+				continue
+			}
+
+			n, err := sc.readBuffer(r, blockOffset)
 			if err != nil && err != io.EOF {
+				sc.err = fmt.Errorf("read error at offset %d: %w", blockOffset, err)
 				return
 			}
 
-			n = roundToMul(n, sc.blockSize) / sc.blockSize
+			// Bytes of the buffer holding data to scan, which must not go past size.
+			dataSize := min(n, int(size-blockOffset))
+
+			// Bytes searched for signatures, including those of the overlap.
+			searchSize := dataSize
+			if n == len(sc.buf) {
+				searchSize = min(n+sc.readOverlap(r, blockOffset+uint64(n)), int(size-blockOffset))
+			}
+
+			n = roundToMul(dataSize, sc.blockSize) / sc.blockSize
+
+			sc.reportProgress(blockOffset, size, filesFound, false)
 
 			nextBlockOffset := blockOffset + uint64(len(sc.buf))
+			carved = carved[:0]
 
-			sc.scanBuffer(n, func(blockIdx int, fileScanner FileScanner) uint64 {
+			sc.scanBuffer(n, searchSize, blockOffset, func(blockIdx int, fileScanner FileScanner) uint64 {
 				sc.foundSignatures++
 
 				globalBlock := blockOffset/uint64(sc.blockSize) + uint64(blockIdx)
 				globalOffset := globalBlock * uint64(sc.blockSize)
 
-				pb.ProcessedBytes = int64(globalOffset)
-				pb.FilesFound = filesFound
-				pb.Render(false)
+				sc.reportProgress(globalOffset, size, filesFound, false)
+
+				if sc.headerOnly {
+					res := &ScanResult{Size: min(uint64(sc.blockSize), size-globalOffset)}
+					stop = !yield(sc.fileInfo(res, globalOffset, fileScanner, sc.window[blockIdx*sc.blockSize:searchSize], filesFound))
+					filesFound++
+					return res.Size
+				}
 
-				bufData := sc.buf[blockIdx*sc.blockSize : n*sc.blockSize]
+				bufData := sc.buf[blockIdx*sc.blockSize : dataSize]
 
 				remainingSize := max(
 					int64(size)-(int64(blockOffset)+int64(len(sc.buf))),
@@ -120,50 +348,167 @@ func (sc *Scanner) Scan(r io.ReaderAt, size uint64) func(yield func(FileInfo) bo
 					uint64(len(bufData))+uint64(remainingSize),
 				)
 
-				r := NewReader(
+				fr := NewReader(
 					sc.bufReader,
 					maxSize,
 				)
+				fr.registry = sc.r
+				fr.SetBudget(sc.scanBudget)
 
-				res, err := fileScanner.ScanFile(r)
-				if err != nil {
+				res, err := sc.scanFile(fileScanner, fr, globalOffset)
+				if err != nil || res == nil {
 					return 0
 				}
+				capSize(res, maxSize)
+
+				if sc.entropyBuf != nil {
+					h, ok := checkEntropy(r, globalOffset, res.Size, ScannerEntropy(fileScanner), sc.entropyBuf)
+					if !ok {
+						sc.entropyRejected++
+						sc.logger.Debugf("%s candidate at offset %d rejected: entropy %.2f bits per byte", fileScanner.Ext(), sc.baseOffset+globalOffset, h)
+						return 0
+					}
+				}
 
-				finfo := scanResultToFileInfo(
-					res,
-					uint32(globalBlock),
-					globalOffset,
-					fileScanner.Ext(),
-				)
+				finfo := sc.fileInfo(res, globalOffset, fileScanner, sc.window[blockIdx*sc.blockSize:searchSize], filesFound)
 
 				stop = !yield(finfo)
 
 				filesFound++
 
+				if !stop && sc.maxDepth > 0 {
+					stop = !sc.scanNested(r, finfo, 1, func(finfo FileInfo) bool {
+						filesFound++
+						return yield(finfo)
+					})
+				}
+
+				companionOffset := globalOffset + res.Size
+				for _, c := range res.Companions {
+					if stop {
+						break
+					}
+
+					stop = !yield(FileInfo{
+						Name:       finfo.Name + c.Suffix,
+						Ext:        c.Ext,
+						Offset:     companionOffset,
+						Size:       c.Size,
+						Confidence: res.Confidence,
+						Parent:     finfo.Name,
+					})
+					filesFound++
+					companionOffset += c.Size
+				}
+
+				carved = append(carved, region{offset: globalOffset, size: res.totalSize()})
+
 				nextBlockOffset = max(
 					nextBlockOffset,
-					roundToMul(globalOffset+res.Size, uint64(sc.blockSize)),
+					roundToMul(globalOffset+res.totalSize(), uint64(sc.blockSize)),
 				)
-				return res.Size
+				return res.totalSize()
 			})
+
+			if !stop && !sc.headerOnly && sc.r.HasFooters() {
+				stop = !sc.scanFooters(r, blockOffset, dataSize, searchSize, size, carved, filesFound, func(finfo FileInfo) bool {
+					filesFound++
+					return yield(finfo)
+				})
+			}
+
 			if err == io.EOF {
 				break
 			}
 			blockOffset = nextBlockOffset
 		}
 
-		pb.ProcessedBytes = int64(size)
-		pb.FilesFound = filesFound
-		pb.Render(true)
+		if !stop {
+			sc.reportProgress(size, size, filesFound, true)
+		}
+	}
+}
+
+// fileInfo returns the information of a file carved at the given offset of the scanned
+// source, whose first bytes are data, naming it after the name template unless the file
+// scanner chose a name.
+func (sc *Scanner) fileInfo(res *ScanResult, offset uint64, fileScanner FileScanner, data []byte, index int) FileInfo {
+	finfo := scanResultToFileInfo(res, offset, fileScanner, data)
+	if res.Name != "" && !sc.customNames {
+		finfo.Name = sc.usedNames.Unique(res.Name)
+	} else {
+		finfo.Name = sc.usedNames.Unique(sc.names.Expand(NameValues{
+			Offset: sc.baseOffset + offset,
+			Block:  (sc.baseOffset + offset) / uint64(sc.blockSize),
+			Index:  index,
+			Size:   finfo.Size,
+			Ext:    finfo.Ext,
+		}))
 	}
+	return finfo
+}
+
+// reportProgress calls the progress callback, if set, unless it was called less than
+// ProgressInterval ago and force is false.
+func (sc *Scanner) reportProgress(processed, total uint64, filesFound int, force bool) {
+	if sc.progress == nil {
+		return
+	}
+
+	if !force && time.Since(sc.lastProgress) < ProgressInterval {
+		return
+	}
+	sc.lastProgress = time.Now()
+
+	sc.progress(int64(processed), int64(total), filesFound)
 }
 
-func (sc *Scanner) scanBuffer(n int, scanFile func(blockIdx int, sc FileScanner) uint64) {
+// readOverlap reads the bytes of the overlap, following the scan buffer, at the given offset,
+// and returns how many were read. Read errors are not reported: the bytes are read again,
+// and errors handled, with the next buffer.
+func (sc *Scanner) readOverlap(r io.ReaderAt, offset uint64) int {
+	n, _ := r.ReadAt(sc.window[len(sc.buf):], int64(offset))
+	return n
+}
+
+// readBuffer fills the scan buffer with the data at the given offset. If the read fails and
+// errors are skipped, the buffer is read again block by block, zero-filling unreadable blocks.
+func (sc *Scanner) readBuffer(r io.ReaderAt, offset uint64) (int, error) {
+	n, err := r.ReadAt(sc.buf, int64(offset))
+	if err == nil || err == io.EOF || !sc.skipErrors {
+		return n, err
+	}
+
+	for n = 0; n < len(sc.buf); n += sc.blockSize {
+		block := sc.buf[n : n+sc.blockSize]
+
+		m, err := r.ReadAt(block, int64(offset)+int64(n))
+		if err == io.EOF {
+			return n + m, io.EOF
+		}
+
+		if err != nil {
+			sc.badBlocks++
+			sc.logger.Warnf("unable to read block at offset %d: %s", offset+uint64(n), err)
+
+			clear(block)
+		}
+	}
+	return n, nil
+}
+
+// scanBuffer searches the first n blocks of the buffer for signatures, within the first
+// searchSize bytes of the window.
+func (sc *Scanner) scanBuffer(n, searchSize int, bufOffset uint64, scanFile func(blockIdx int, sc FileScanner) uint64) {
 	for blockIdx := 0; blockIdx < n; {
+		if sc.skipped(sc.baseOffset + bufOffset + uint64(blockIdx*sc.blockSize)) {
+			blockIdx++
+			continue
+		}
+
 		var size uint64
 
-		sc.r.Search(sc.buf[blockIdx*sc.blockSize:], func(sc FileScanner) bool {
+		sc.r.Search(sc.window[blockIdx*sc.blockSize:searchSize], func(sc FileScanner) bool {
 			size = scanFile(blockIdx, sc)
 			return size > 0
 		})
@@ -177,35 +522,336 @@ func (sc *Scanner) scanBuffer(n int, scanFile func(blockIdx int, sc FileScanner)
 	}
 }
 
+// scanFooters searches the first dataSize bytes of the buffer at bufOffset for footers,
+// ignoring those within the carved files, and yields the files ending with them, indexing
+// them from filesFound. Since files are stored from the start of a block, a file is only
+// carved if the size returned by its scanner makes it start at a block; files larger than
+// the maximum file size are not carved, as their end cannot be cut. It returns false if
+// yield requested to stop.
+func (sc *Scanner) scanFooters(
+	r io.ReaderAt,
+	bufOffset uint64,
+	dataSize, searchSize int,
+	size uint64,
+	carved []region,
+	filesFound int,
+	yield func(FileInfo) bool,
+) bool {
+	stop := false
+	sc.r.SearchFooters(sc.window[:searchSize], func(pos int, fileScanner FileScanner) bool {
+		if pos >= dataSize {
+			return true // Searched again with the next buffer.
+		}
+
+		offset := bufOffset + uint64(pos)
+		if sc.skipped(sc.baseOffset+offset) || slices.ContainsFunc(carved, func(c region) bool {
+			return offset >= c.offset && offset < c.offset+c.size
+		}) {
+			return false
+		}
+		sc.foundSignatures++
+
+		footerScanner := fileScanner.(FooterScanner)
+		footerSize := footerScanner.FooterSize()
+		if offset+uint64(footerSize) > size {
+			return false
+		}
+
+		footer := make([]byte, footerSize)
+		if _, err := r.ReadAt(footer, int64(offset)); err != nil {
+			return false
+		}
+
+		res, err := sc.scanFooter(footerScanner, fileScanner.Ext(), footer, offset)
+		if err != nil || res == nil {
+			return false
+		}
+
+		end := offset + uint64(footerSize)
+		if res.Size < uint64(footerSize) || res.Size > end || res.Size > sc.maxFileSize ||
+			(end-res.Size)%uint64(sc.blockSize) != 0 {
+			return false
+		}
+		start := end - res.Size
+
+		finfo := sc.fileInfo(res, start, fileScanner, nil, filesFound)
+		filesFound++
+		finfo.Detection.SignatureOffset = int(res.Size) - footerSize
+		for _, sig := range footerScanner.Footers() {
+			if len(sig) > len(finfo.Detection.Signature) && bytes.HasPrefix(footer, sig) {
+				finfo.Detection.Signature = sig
+			}
+		}
+
+		stop = !yield(finfo)
+		return stop
+	})
+	return !stop
+}
+
+// scanFooter runs the footer scanner of the format with the given extension over the
+// footer found at the given offset of the scanned source, recovering its panics like scanFile.
+func (sc *Scanner) scanFooter(footerScanner FooterScanner, ext string, footer []byte, offset uint64) (res *ScanResult, err error) {
+	if !sc.fatalPanics {
+		defer func() {
+			if v := recover(); v != nil {
+				sc.panics++
+				sc.logger.Errorf("%s footer scanner panicked at offset %d: %v\n%s", ext, sc.baseOffset+offset, v, debug.Stack())
+				res, err = nil, fmt.Errorf("%s footer scanner panicked: %v", ext, v)
+			}
+		}()
+	}
+	return footerScanner.ScanFooter(footer)
+}
+
+// scanNested searches the byte range of a carved file for embedded files, which are
+// yielded with offsets relative to the image. The search is byte-aligned, since embedded
+// files are not aligned to blocks. It returns false if yield requested to stop.
+func (sc *Scanner) scanNested(r io.ReaderAt, parent FileInfo, depth int, yield func(FileInfo) bool) bool {
+	buf := sc.nestedBuffer(depth)
+
+	// Bytes at the end of each chunk are searched again at the start of the next one,
+	// so that signatures crossing chunks are not missed.
+	overlap := sc.r.MaxSignatureLen()
+
+	end := parent.Offset + parent.Size
+	for pos := parent.Offset + 1; pos < end; {
+		n, err := r.ReadAt(buf[:min(uint64(len(buf)), end-pos)], int64(pos))
+		if n == 0 {
+			return true
+		}
+
+		if err == io.EOF {
+			// The carved file extends past the end of the image.
+			end = pos + uint64(n)
+		}
+
+		limit := n
+		if pos+uint64(n) < end {
+			limit = max(n-overlap, 1)
+		}
+
+		i := 0
+		for i < limit {
+			offset := pos + uint64(i)
+
+			var (
+				res     *ScanResult
+				scanner FileScanner
+			)
+			sc.r.Search(buf[i:n], func(fileScanner FileScanner) bool {
+				sc.foundSignatures++
+
+				res = sc.scanRange(r, offset, end-offset, fileScanner)
+				scanner = fileScanner
+				return res != nil
+			})
+
+			if res == nil {
+				i++
+				continue
+			}
+
+			capSize(res, end-offset)
+
+			finfo := scanResultToFileInfo(res, offset, scanner, buf[i:n])
+			if res.Name != "" {
+				finfo.Name = sc.usedNames.Unique(res.Name)
+			} else {
+				finfo.Name = fmt.Sprintf("%s_%d.%s", strings.TrimSuffix(parent.Name, "."+parent.Ext), offset-parent.Offset, finfo.Ext)
+			}
+
+			if !yield(finfo) {
+				return false
+			}
+
+			if depth < sc.maxDepth && !sc.scanNested(r, finfo, depth+1, yield) {
+				return false
+			}
+			i += int(res.Size)
+		}
+		pos += uint64(i)
+	}
+	return true
+}
+
+// scanRange runs fileScanner over the given range of r. It returns nil if no file was recognized.
+func (sc *Scanner) scanRange(r io.ReaderAt, offset, size uint64, fileScanner FileScanner) *ScanResult {
+	sc.bufReader.Reset(io.NewSectionReader(r, int64(offset), int64(size)))
+
+	fr := NewReader(sc.bufReader, min(sc.maxFileSize, size))
+	fr.registry = sc.r
+	fr.SetBudget(sc.scanBudget)
+
+	res, err := sc.scanFile(fileScanner, fr, offset)
+	if err != nil || res == nil || res.Size == 0 {
+		return nil
+	}
+	capSize(res, min(sc.maxFileSize, size))
+	return res
+}
+
+// scanFile runs fileScanner over the candidate file starting at the given offset of the
+// scanned source. Unless panics are fatal (see SetFatalPanics), a panic of the scanner
+// is logged and returned as an error.
+func (sc *Scanner) scanFile(fileScanner FileScanner, r *Reader, offset uint64) (res *ScanResult, err error) {
+	if !sc.fatalPanics {
+		defer func() {
+			if v := recover(); v != nil {
+				sc.panics++
+				sc.logger.Errorf("%s scanner panicked at offset %d: %v\n%s", fileScanner.Ext(), sc.baseOffset+offset, v, debug.Stack())
+				res, err = nil, fmt.Errorf("%s scanner panicked: %v", fileScanner.Ext(), v)
+			}
+		}()
+	}
+	res, err = fileScanner.ScanFile(r)
+	if errors.Is(err, ErrBudgetExceeded) {
+		sc.budgetExceeded++
+		sc.logger.Debugf("%s scanner exceeded the scan budget at offset %d", fileScanner.Ext(), sc.baseOffset+offset)
+	}
+	return res, err
+}
+
+// capSize limits the size of a scan result, marking it as truncated when
+// the size reported by the scanner exceeds maxSize. Companions not fitting
+// within maxSize are dropped.
+func capSize(res *ScanResult, maxSize uint64) {
+	if res.Size > maxSize {
+		res.Size = maxSize
+		res.Truncated = true
+	}
+
+	size := res.Size
+	for i, c := range res.Companions {
+		if size+c.Size > maxSize {
+			res.Companions = res.Companions[:i]
+			break
+		}
+		size += c.Size
+	}
+}
+
+// nestedBuffer returns the buffer used to search embedded files at the given depth.
+func (sc *Scanner) nestedBuffer(depth int) []byte {
+	for len(sc.nestedBufs) < depth {
+		size := max(nestedScanBufferSize, 2*sc.r.MaxSignatureLen())
+		sc.nestedBufs = append(sc.nestedBufs, make([]byte, size))
+	}
+	return sc.nestedBufs[depth-1]
+}
+
+// skipped reports whether the given offset falls within a skipped region.
+// Skipped regions must be sorted and not overlapping (see mergeRegions).
+func (sc *Scanner) skipped(offset uint64) bool {
+	_, ok := sc.skippedRegion(offset)
+	return ok
+}
+
+// skippedRegion returns the skipped region containing the given offset, if any.
+func (sc *Scanner) skippedRegion(offset uint64) (region, bool) {
+	i := sort.Search(len(sc.skip), func(i int) bool {
+		return sc.skip[i].offset+sc.skip[i].size > offset
+	})
+	if i < len(sc.skip) && sc.skip[i].offset <= offset {
+		return sc.skip[i], true
+	}
+	return region{}, false
+}
+
+// mergeRegions sorts the given regions by offset, merging the overlapping and adjacent ones.
+func mergeRegions(regions []region) []region {
+	sort.Slice(regions, func(i, j int) bool {
+		return regions[i].offset < regions[j].offset
+	})
+
+	merged := regions[:0]
+	for _, r := range regions {
+		if last := len(merged) - 1; last >= 0 && r.offset <= merged[last].offset+merged[last].size {
+			merged[last].size = max(merged[last].size, r.offset+r.size-merged[last].offset)
+			continue
+		}
+		merged = append(merged, r)
+	}
+	return merged
+}
+
 func (sc *Scanner) FoundSignatures() int {
 	return sc.foundSignatures
 }
 
+// BadBlocks returns the number of blocks which could not be read.
+func (sc *Scanner) BadBlocks() int {
+	return sc.badBlocks
+}
+
+// Panics returns the number of panics of file scanners which were recovered.
+func (sc *Scanner) Panics() int {
+	return sc.panics
+}
+
+// BudgetExceeded returns the number of candidate files abandoned because their
+// scanner exceeded the scan budget (see SetScanBudget).
+func (sc *Scanner) BudgetExceeded() int {
+	return sc.budgetExceeded
+}
+
+// EntropyRejected returns the number of candidate files rejected because their
+// entropy did not match their format (see SetEntropyFilter).
+func (sc *Scanner) EntropyRejected() int {
+	return sc.entropyRejected
+}
+
+// Err returns the error which stopped the last scan, if any.
+func (sc *Scanner) Err() error {
+	return sc.err
+}
+
 func roundToMul[T int | int64 | uint64](n, m T) T {
 	k := (n + m - 1) / m
 	return k * m
 }
 
+// scanResultToFileInfo returns the information of the file found by fileScanner at the given
+// offset, whose first bytes are data.
 func scanResultToFileInfo(
 	res *ScanResult,
-	block uint32,
 	offset uint64,
-	defaultExt string,
+	fileScanner FileScanner,
+	data []byte,
 ) FileInfo {
-	ext := defaultExt
+	ext := fileScanner.Ext()
 	if res.Ext != "" {
 		ext = res.Ext
 	}
 
-	name := res.Name
-	if name == "" {
-		name = fmt.Sprintf("f%d.%s", block, ext)
+	return FileInfo{
+		Name:       res.Name,
+		Ext:        ext,
+		Offset:     offset,
+		Size:       res.Size,
+		Truncated:  res.Truncated,
+		Confidence: res.Confidence,
+		Version:    res.Version,
+		Detection:  detect(fileScanner, data),
 	}
+}
 
-	return FileInfo{
-		Name:   name,
-		Ext:    ext,
-		Offset: offset,
-		Size:   res.Size,
+// detect returns the detection of a file by fileScanner, whose first bytes are data,
+// reporting the longest of its signatures matching them.
+func detect(fileScanner FileScanner, data []byte) Detection {
+	d := Detection{
+		Scanner:         fileScanner.Ext(),
+		Description:     fileScanner.Description(),
+		SignatureOffset: ScannerSignatureOffset(fileScanner),
+	}
+
+	if d.SignatureOffset > len(data) {
+		return d
+	}
+	for _, sig := range fileScanner.Signatures() {
+		if len(sig) > len(d.Signature) && bytes.HasPrefix(data[d.SignatureOffset:], sig) {
+			d.Signature = sig
+		}
 	}
+	return d
 }
@@ -0,0 +1,208 @@
+package format
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/png"
+	"io"
+	"slices"
+	"testing"
+
+	"github.com/ostafen/digler/internal/logger"
+)
+
+// regionReaderAt fails reads overlapping a given byte range.
+type regionReaderAt struct {
+	r             io.ReaderAt
+	offset, limit int64
+}
+
+func (r *regionReaderAt) ReadAt(p []byte, off int64) (int, error) {
+	if off < r.limit && off+int64(len(p)) > r.offset {
+		return 0, errors.New("read of a skipped region")
+	}
+	return r.r.ReadAt(p, off)
+}
+
+func TestScanSkipRegion(t *testing.T) {
+	var img bytes.Buffer
+	if err := png.Encode(&img, image.NewGray(image.Rect(0, 0, 4, 4))); err != nil {
+		t.Fatal(err)
+	}
+
+	data := make([]byte, 64*1024)
+	for _, off := range []int{1024, 20480, 49152} {
+		copy(data[off:], img.Bytes())
+	}
+
+	sc := NewScanner(logger.New(io.Discard, logger.ErrorLevel), BuildFileRegistry(NewFileScanner(pngFileHeader)), 4096, 512, uint64(len(data)))
+	sc.SkipRegion(16384, 16384)
+
+	r := &regionReaderAt{r: bytes.NewReader(data), offset: 16384, limit: 32768}
+
+	var offsets []uint64
+	for finfo := range sc.Scan(r, uint64(len(data))) {
+		offsets = append(offsets, finfo.Offset)
+	}
+	if err := sc.Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(offsets) != 2 || offsets[0] != 1024 || offsets[1] != 49152 {
+		t.Fatalf("expected files at offsets 1024 and 49152, got %v", offsets)
+	}
+}
+
+func TestScanBufferOverlap(t *testing.T) {
+	const signatureOffset = 600
+
+	hdr := FileHeader{
+		Ext:             "magic",
+		Signatures:      [][]byte{[]byte("MAGIC")},
+		SignatureOffset: signatureOffset,
+		ScanFile: func(r *Reader) (*ScanResult, error) {
+			return &ScanResult{Size: 1024}, nil
+		},
+	}
+
+	// The signature of the file at offset 3584, in the last block of the first
+	// buffer, is in the second buffer.
+	data := make([]byte, 16*1024)
+	copy(data[3584+signatureOffset:], "MAGIC")
+
+	tests := []struct {
+		overlap int
+		want    int
+	}{
+		{0, 0},
+		{signatureOffset + len("MAGIC") - 512 - 1, 0},
+		{signatureOffset + len("MAGIC") - 512, 1},
+	}
+
+	for _, tt := range tests {
+		sc := NewScanner(logger.New(io.Discard, logger.ErrorLevel), BuildFileRegistry(NewFileScanner(hdr)), 4096, 512, uint64(len(data)))
+		sc.SetBufferOverlap(tt.overlap)
+
+		var offsets []uint64
+		for finfo := range sc.Scan(bytes.NewReader(data), uint64(len(data))) {
+			offsets = append(offsets, finfo.Offset)
+		}
+
+		if len(offsets) != tt.want || (tt.want == 1 && offsets[0] != 3584) {
+			t.Errorf("overlap %d: got files at offsets %v, want %d file(s) at offset 3584", tt.overlap, offsets, tt.want)
+		}
+	}
+
+	// The overlap defaults to the length of the longest signature.
+	sc := NewScanner(logger.New(io.Discard, logger.ErrorLevel), BuildFileRegistry(NewFileScanner(hdr)), 4096, 512, uint64(len(data)))
+	found := 0
+	for range sc.Scan(bytes.NewReader(data), uint64(len(data))) {
+		found++
+	}
+	if found != 1 {
+		t.Errorf("expected a file with the default overlap, got %d", found)
+	}
+}
+
+func TestScanDetection(t *testing.T) {
+	hdr := FileHeader{
+		Ext:             "magic",
+		Description:     "Magic File",
+		Signatures:      [][]byte{[]byte("MAG"), []byte("MAGIC")},
+		SignatureOffset: 4,
+		ScanFile: func(r *Reader) (*ScanResult, error) {
+			return &ScanResult{Size: 512, Ext: "mgc"}, nil
+		},
+	}
+
+	data := make([]byte, 4096)
+	copy(data[1024+4:], "MAGIC")
+
+	sc := NewScanner(logger.New(io.Discard, logger.ErrorLevel), BuildFileRegistry(NewFileScanner(hdr)), 4096, 512, uint64(len(data)))
+
+	var found []FileInfo
+	for finfo := range sc.Scan(bytes.NewReader(data), uint64(len(data))) {
+		found = append(found, finfo)
+	}
+
+	if len(found) != 1 {
+		t.Fatalf("expected a file, got %+v", found)
+	}
+
+	d := found[0].Detection
+	if found[0].Ext != "mgc" || d.Scanner != "magic" || d.Description != "Magic File" || string(d.Signature) != "MAGIC" || d.SignatureOffset != 4 {
+		t.Fatalf("unexpected detection %+v of file %+v", d, found[0])
+	}
+}
+
+func TestScanUniqueNames(t *testing.T) {
+	hdr := FileHeader{
+		Ext:        "named",
+		Signatures: [][]byte{[]byte("NAMED")},
+		ScanFile: func(r *Reader) (*ScanResult, error) {
+			return &ScanResult{Name: "Report.named", Size: 512}, nil
+		},
+	}
+
+	data := make([]byte, 4096)
+	for _, offset := range []int{0, 1024, 2048} {
+		copy(data[offset:], "NAMED")
+	}
+
+	sc := NewScanner(logger.New(io.Discard, logger.ErrorLevel), BuildFileRegistry(NewFileScanner(hdr)), 4096, 512, uint64(len(data)))
+
+	var names []string
+	for finfo := range sc.Scan(bytes.NewReader(data), uint64(len(data))) {
+		names = append(names, finfo.Name)
+	}
+
+	want := []string{"Report.named", "Report (2).named", "Report (3).named"}
+	if !slices.Equal(names, want) {
+		t.Errorf("expected names %q, got %q", want, names)
+	}
+}
+
+func TestScanFooter(t *testing.T) {
+	data := make([]byte, 16384)
+
+	// A disk image of 2 blocks, ending with its trailer.
+	dmgOffset := 4096
+	dmg := append(make([]byte, 8192-300-dmgTrailerSize), make([]byte, 300)...)
+	copy(data[dmgOffset:], append(dmg, dmgTrailer(uint64(len(dmg)-300), 300)...))
+
+	// A trailer within a carved file, and one whose image would not start at a block.
+	copy(data[12288:], "\x89PNG")
+	copy(data[12288+512:], dmgTrailer(512, 0))
+	pngScanner := NewFileScanner(FileHeader{
+		Ext:        "png",
+		Signatures: [][]byte{[]byte("\x89PNG")},
+		ScanFile: func(r *Reader) (*ScanResult, error) {
+			return &ScanResult{Size: 1024}, nil
+		},
+	})
+	copy(data[14336:], dmgTrailer(100, 0))
+
+	registry := BuildFileRegistry(pngScanner, NewFileScanner(dmgFileHeader))
+	sc := NewScanner(logger.New(io.Discard, logger.ErrorLevel), registry, 4096, 512, uint64(len(data)))
+
+	var found []FileInfo
+	for finfo := range sc.Scan(bytes.NewReader(data), uint64(len(data))) {
+		found = append(found, finfo)
+	}
+
+	if len(found) != 2 {
+		t.Fatalf("expected 2 files, got %+v", found)
+	}
+
+	dmgInfo := found[0]
+	if dmgInfo.Ext != "dmg" || dmgInfo.Offset != uint64(dmgOffset) || dmgInfo.Size != 8192 {
+		t.Errorf("unexpected disk image %+v", dmgInfo)
+	}
+	if d := dmgInfo.Detection; string(d.Signature) != "koly" || d.SignatureOffset != 8192-dmgTrailerSize {
+		t.Errorf("unexpected detection %+v", d)
+	}
+	if found[1].Ext != "png" {
+		t.Errorf("expected the png file, got %+v", found[1])
+	}
+}
@@ -41,6 +41,13 @@ import (
 //	bool: True if the signature is found, false otherwise.
 //	error: An error if an I/O error occurs during reading, other than io.EOF.
 func SeekAt(r *Reader, sig []byte, n int) (bool, error) {
+	skipped, err := SeekIndex(r, sig, n)
+	return skipped >= 0, err
+}
+
+// SeekIndex works like SeekAt, but returns the number of bytes skipped
+// before the signature, or -1 if the signature is not found.
+func SeekIndex(r *Reader, sig []byte, n int) (int, error) {
 	sigLen := len(sig)
 
 	// `pad` ensures that enough bytes are kept from the end of the previous buffer
@@ -61,7 +68,7 @@ func SeekAt(r *Reader, sig []byte, n int) (bool, error) {
 
 		peekBuf, err := r.Peek(len(buf) - pad)
 		if err != nil && err != io.EOF {
-			return false, err
+			return -1, err
 		}
 
 		m := len(peekBuf)
@@ -90,8 +97,10 @@ func SeekAt(r *Reader, sig []byte, n int) (bool, error) {
 					discard -= pad
 				}
 
-				_, err = r.Discard(discard)
-				return true, err
+				if _, err = r.Discard(discard); err == nil {
+					err = r.consume(uint64(discard))
+				}
+				return offset + discard, err
 			}
 		}
 
@@ -101,10 +110,14 @@ func SeekAt(r *Reader, sig []byte, n int) (bool, error) {
 
 		offset += m
 
+		// The searched bytes were read, so they are charged to the budget.
 		_, err = r.Discard(m)
+		if err == nil {
+			err = r.consume(uint64(m))
+		}
 		if err != nil {
-			return false, err
+			return -1, err
 		}
 	}
-	return false, nil
+	return -1, nil
 }
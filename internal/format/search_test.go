@@ -0,0 +1,44 @@
+package format
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/ostafen/digler/pkg/reader"
+)
+
+func TestSeekIndex(t *testing.T) {
+	data := append(bytes.Repeat([]byte{'x'}, 30), []byte("%%EOF")...)
+
+	// Use a small buffer, so that the signature spans multiple peeks.
+	r := NewReader(reader.NewBufferedReadSeeker(bytes.NewReader(data), 16), uint64(len(data)))
+
+	skipped, err := SeekIndex(r, []byte("%%EOF"), len(data))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if skipped != 30 {
+		t.Fatalf("expected 30 skipped bytes, got %d", skipped)
+	}
+
+	skipped, err = SeekIndex(r, []byte("%PDF"), len(data))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if skipped != -1 {
+		t.Fatalf("expected -1 for a missing signature, got %d", skipped)
+	}
+}
+
+func TestScanPDFIncrementalUpdates(t *testing.T) {
+	pdf := []byte("%PDF-1.4\n1 0 obj\n%%EOF\n2 0 obj\n%%EOF")
+	data := append(pdf, []byte("\ntrailing")...)
+
+	res, err := ScanPDF(newBytesReader(data))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if res.Size != uint64(len(pdf)) {
+		t.Fatalf("expected size %d, got %d", len(pdf), res.Size)
+	}
+}
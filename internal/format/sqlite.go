@@ -23,6 +23,7 @@ import (
 	"bytes"
 	"encoding/binary"
 	"fmt"
+	"io"
 )
 
 const SQLiteSignature = "SQLite format 3\x00"
@@ -30,14 +31,36 @@ const SQLiteSignature = "SQLite format 3\x00"
 var sqliteFileHeader = FileHeader{
 	Ext:         "sqlite",
 	Description: "SQLite Database Format",
+	Category:    CategoryDatabase,
 	Signatures: [][]byte{
 		[]byte(SQLiteSignature),
 	},
 	ScanFile: ScanSQLite,
 }
 
+// SQLiteOptions controls how SQLite databases are carved.
+type SQLiteOptions struct {
+	// IncludeWAL looks for a write-ahead log stored right after a database, which
+	// holds the changes not yet checkpointed into it. When found, the log is reported
+	// as a companion of the database, named after it with the "-wal" suffix.
+	IncludeWAL bool
+}
+
+// NewSQLiteScanner returns a SQLite scanner which carves databases according to opts.
+func NewSQLiteScanner(opts SQLiteOptions) FileScanner {
+	hdr := sqliteFileHeader
+	hdr.ScanFile = func(r *Reader) (*ScanResult, error) {
+		return scanSQLite(r, opts)
+	}
+	return &headerFileScanner{hdr: hdr}
+}
+
 // ScanSqlite tries to carve a single SQLite DB starting at offset 0 in the reader.
 func ScanSQLite(r *Reader) (*ScanResult, error) {
+	return scanSQLite(r, SQLiteOptions{})
+}
+
+func scanSQLite(r *Reader, opts SQLiteOptions) (*ScanResult, error) {
 	// SQLite 3 Database Header Structure: https://www.sqlite.org/fileformat2.html#the_database_header
 	// -----------------------------------------
 	// Magic                (16 bytes)       "SQLite format 3\0" magic string
@@ -94,13 +117,109 @@ func ScanSQLite(r *Reader) (*ScanResult, error) {
 	versionValidFor := binary.BigEndian.Uint32(hdr[92:96])
 
 	var size uint64 = 0
+	confidence := ConfidenceHeaderOnly
 	if fileSizeInPage != 0 && fileChangeCounter == versionValidFor {
+		// The database size is only valid if the header was written by a version
+		// of SQLite which keeps it in sync with the change counter.
 		size = uint64(fileSizeInPage) * uint64(pageSize)
+		confidence = ConfidenceStructural
+	}
+
+	res := &ScanResult{
+		Size:       size,
+		Confidence: confidence,
+	}
+
+	if opts.IncludeWAL && size > 0 {
+		if _, err := r.Discard(int(size) - len(hdr)); err == nil {
+			if walSize := sqliteWALSize(r, pageSize); walSize > 0 {
+				res.Companions = []Companion{{Suffix: "-wal", Ext: "sqlite-wal", Size: walSize}}
+			}
+		}
+	}
+	return res, nil
+}
+
+const (
+	sqliteWALHeaderSize      = 32
+	sqliteWALFrameHeaderSize = 24
+	sqliteWALVersion         = 3007000
+)
+
+// sqliteWALSize returns the size of the write-ahead log starting at the current
+// position of the reader, or 0 if there is none. The log ends at the first frame
+// which is not part of it, as told by its salts and cumulative checksum.
+func sqliteWALSize(r *Reader, pageSize int) uint64 {
+	// Write-Ahead Log Header Structure: https://www.sqlite.org/fileformat2.html#walformat
+	// -----------------------------------------
+	// Magic                (4 bytes)        0x377f0682 or 0x377f0683 (big-endian checksums)
+	// Version              (4 bytes)        File format version, 3007000
+	// PageSize             (4 bytes)        Database page size
+	// CheckpointSeq        (4 bytes)        Checkpoint sequence number
+	// Salt1, Salt2         (8 bytes)        Random values, copied into each frame
+	// Checksum1, Checksum2 (8 bytes)        Checksum of the first 24 bytes of the header
+	//
+	// Each frame is a 24-byte header, with the page number, the database size for commit
+	// frames, the salts and the cumulative checksum, followed by the page data.
+
+	var hdr [sqliteWALHeaderSize]byte
+	if _, err := io.ReadFull(r, hdr[:]); err != nil {
+		return 0
+	}
+
+	magic := binary.BigEndian.Uint32(hdr[0:4])
+	if magic&^1 != 0x377f0682 ||
+		binary.BigEndian.Uint32(hdr[4:8]) != sqliteWALVersion ||
+		binary.BigEndian.Uint32(hdr[8:12]) != uint32(pageSize) {
+		return 0
+	}
+
+	var order binary.ByteOrder = binary.LittleEndian
+	if magic&1 != 0 {
+		order = binary.BigEndian
 	}
 
-	return &ScanResult{
-		Size: size,
-	}, nil
+	s0, s1 := sqliteWALChecksum(order, hdr[:24], 0, 0)
+	if s0 != binary.BigEndian.Uint32(hdr[24:28]) || s1 != binary.BigEndian.Uint32(hdr[28:32]) {
+		return 0
+	}
+
+	frame := make([]byte, sqliteWALFrameHeaderSize+pageSize)
+
+	frames := uint64(0)
+	for {
+		if _, err := io.ReadFull(r, frame); err != nil {
+			break
+		}
+
+		if !bytes.Equal(frame[8:16], hdr[16:24]) {
+			break
+		}
+
+		f0, f1 := sqliteWALChecksum(order, frame[:8], s0, s1)
+		f0, f1 = sqliteWALChecksum(order, frame[sqliteWALFrameHeaderSize:], f0, f1)
+		if f0 != binary.BigEndian.Uint32(frame[16:20]) || f1 != binary.BigEndian.Uint32(frame[20:24]) {
+			break
+		}
+
+		s0, s1 = f0, f1
+		frames++
+	}
+
+	if frames == 0 {
+		return 0
+	}
+	return sqliteWALHeaderSize + frames*uint64(len(frame))
+}
+
+// sqliteWALChecksum continues the checksum of a write-ahead log over data,
+// whose length must be a multiple of 8.
+func sqliteWALChecksum(order binary.ByteOrder, data []byte, s0, s1 uint32) (uint32, uint32) {
+	for i := 0; i+8 <= len(data); i += 8 {
+		s0 += order.Uint32(data[i:]) + s1
+		s1 += order.Uint32(data[i+4:]) + s0
+	}
+	return s0, s1
 }
 
 func isPowerOfTwo(x uint32) bool {
@@ -0,0 +1,108 @@
+package format
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"testing"
+
+	"github.com/ostafen/digler/internal/logger"
+)
+
+const testSQLitePageSize = 512
+
+// sqliteFixture returns a database of two pages followed by a write-ahead log of the
+// given number of frames, and by a stale frame left over from a previous log.
+func sqliteFixture(frames int) []byte {
+	db := make([]byte, 2*testSQLitePageSize)
+	copy(db, SQLiteSignature)
+	binary.BigEndian.PutUint16(db[16:18], testSQLitePageSize)
+	binary.BigEndian.PutUint32(db[24:28], 7) // FileChangeCounter
+	binary.BigEndian.PutUint32(db[28:32], 2) // FileSizeInPage
+	binary.BigEndian.PutUint32(db[92:96], 7) // VersionValidFor
+
+	wal := make([]byte, sqliteWALHeaderSize)
+	binary.BigEndian.PutUint32(wal[0:4], 0x377f0682)
+	binary.BigEndian.PutUint32(wal[4:8], sqliteWALVersion)
+	binary.BigEndian.PutUint32(wal[8:12], testSQLitePageSize)
+	binary.BigEndian.PutUint32(wal[16:20], 0xdeadbeef) // Salt1
+	binary.BigEndian.PutUint32(wal[20:24], 0xcafebabe) // Salt2
+
+	s0, s1 := sqliteWALChecksum(binary.LittleEndian, wal[:24], 0, 0)
+	binary.BigEndian.PutUint32(wal[24:28], s0)
+	binary.BigEndian.PutUint32(wal[28:32], s1)
+
+	for i := 0; i <= frames; i++ {
+		frame := make([]byte, sqliteWALFrameHeaderSize+testSQLitePageSize)
+		binary.BigEndian.PutUint32(frame[0:4], uint32(i+1))
+		copy(frame[8:16], wal[16:24])
+		if i == frames {
+			frame[8] ^= 0xff // Stale salt.
+		}
+		for j := range frame[sqliteWALFrameHeaderSize:] {
+			frame[sqliteWALFrameHeaderSize+j] = byte(i + j)
+		}
+
+		f0, f1 := sqliteWALChecksum(binary.LittleEndian, frame[:8], s0, s1)
+		f0, f1 = sqliteWALChecksum(binary.LittleEndian, frame[sqliteWALFrameHeaderSize:], f0, f1)
+		binary.BigEndian.PutUint32(frame[16:20], f0)
+		binary.BigEndian.PutUint32(frame[20:24], f1)
+		s0, s1 = f0, f1
+
+		wal = append(wal, frame...)
+	}
+	return append(db, wal...)
+}
+
+func TestScanSQLiteWAL(t *testing.T) {
+	data := sqliteFixture(2)
+
+	dbSize := uint64(2 * testSQLitePageSize)
+	walSize := uint64(sqliteWALHeaderSize + 2*(sqliteWALFrameHeaderSize+testSQLitePageSize))
+
+	res, err := scanSQLite(newBytesReader(data), SQLiteOptions{IncludeWAL: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Size != dbSize || len(res.Companions) != 1 || res.Companions[0].Size != walSize {
+		t.Fatalf("expected a %d bytes database with a %d bytes log, got %+v", dbSize, walSize, res)
+	}
+
+	res, err = ScanSQLite(newBytesReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Size != dbSize || len(res.Companions) != 0 {
+		t.Fatalf("expected a %d bytes database without log, got %+v", dbSize, res)
+	}
+
+	// A log without valid frames is ignored.
+	res, err = scanSQLite(newBytesReader(sqliteFixture(0)), SQLiteOptions{IncludeWAL: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Companions) != 0 {
+		t.Fatalf("expected no log, got %+v", res.Companions)
+	}
+}
+
+func TestScanSQLiteWALCompanion(t *testing.T) {
+	data := make([]byte, 16*1024)
+	copy(data[1024:], sqliteFixture(2))
+
+	sc := NewScanner(logger.New(io.Discard, logger.ErrorLevel), BuildFileRegistry(NewSQLiteScanner(SQLiteOptions{IncludeWAL: true})), 4096, 512, uint64(len(data)))
+
+	var found []FileInfo
+	for finfo := range sc.Scan(bytes.NewReader(data), uint64(len(data))) {
+		found = append(found, finfo)
+	}
+
+	if len(found) != 2 {
+		t.Fatalf("expected a database and its log, got %+v", found)
+	}
+
+	db, wal := found[0], found[1]
+	if wal.Name != db.Name+"-wal" || wal.Parent != db.Name || wal.Offset != db.Offset+db.Size || wal.Ext != "sqlite-wal" {
+		t.Fatalf("unexpected log %+v of database %+v", wal, db)
+	}
+}
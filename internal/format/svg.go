@@ -0,0 +1,174 @@
+// Copyright (c) 2025 Stefano Scafiti
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package format
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+)
+
+var svgFileHeader = FileHeader{
+	Ext:         "svg",
+	Description: "Scalable Vector Graphics",
+	Category:    CategoryImage,
+	Entropy:     EntropyLow,
+	Signatures: [][]byte{
+		[]byte("<?xml"),
+		[]byte("<svg"),
+	},
+	ScanFile: ScanSVG,
+}
+
+const (
+	// svgHeadSize is the size of the start of the file searched for the <svg> tag.
+	svgHeadSize = 1024
+	// svgMaxFileSize bounds the search for the end of the root <svg> element.
+	svgMaxFileSize = 32 * 1024 * 1024
+	// svgChunkSize is the size of the chunks in which the file is searched for tags.
+	svgChunkSize = 32 * 1024
+)
+
+var (
+	svgOpenTag   = []byte("<svg")
+	svgCloseTag  = []byte("</svg>")
+	svgNamespace = []byte(`xmlns="http://www.w3.org/2000/svg"`)
+)
+
+// ScanSVG scans an SVG image. Since XML declarations are generic, the start of the
+// file must hold an <svg> tag or the SVG namespace. The file ends after the </svg> tag
+// closing the root element, or after the root tag itself if it is self-closing; nested
+// <svg> elements are tracked, so that they do not end the file early. The search stops at the first NUL byte, which is never part of an SVG.
+func ScanSVG(r *Reader) (*ScanResult, error) {
+	head, err := r.Peek(svgHeadSize)
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+
+	if !bytes.HasPrefix(head, []byte("<?xml")) && !bytes.HasPrefix(head, svgOpenTag) {
+		return nil, fmt.Errorf("reader does not start with an XML declaration or an <svg> tag")
+	}
+
+	if !bytes.Contains(head, svgOpenTag) && !bytes.Contains(head, svgNamespace) {
+		return nil, fmt.Errorf("no <svg> tag found at the start of the file")
+	}
+
+	var (
+		size   uint64 // end of the last </svg> tag
+		offset uint64 // offset of buf[0] from the start of the file
+		depth  int
+		carry  int
+	)
+
+	buf := make([]byte, svgChunkSize+len(svgCloseTag))
+	for offset < svgMaxFileSize {
+		n, err := io.ReadFull(r, buf[carry:])
+		if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+			return nil, err
+		}
+		eof := err != nil
+
+		data := buf[:carry+n]
+		if end := bytes.IndexByte(data, 0); end >= 0 {
+			data, eof = data[:end], true
+		}
+
+		i := 0
+	tags:
+		for {
+			j := bytes.IndexByte(data[i:], '<')
+			if j < 0 {
+				i = len(data)
+				break
+			}
+
+			pos := i + j
+			if !eof && len(data)-pos < len(svgCloseTag) {
+				// The tag may continue in the next chunk.
+				i = pos
+				break
+			}
+
+			tag := data[pos:]
+			switch {
+			case bytes.HasPrefix(tag, svgOpenTag) && len(tag) > len(svgOpenTag) && isSVGTagEnd(tag[len(svgOpenTag)]):
+				end := svgTagEnd(tag)
+				if end < 0 && !eof && pos > 0 {
+					// The tag continues in the next chunk.
+					i = pos
+					break tags
+				}
+
+				// A self-closing tag has no </svg> tag: if it is the root, it ends the file.
+				if end > 0 && tag[end-1] == '/' {
+					if depth == 0 {
+						return &ScanResult{Size: offset + uint64(pos+end+1), Confidence: ConfidenceHeaderOnly}, nil
+					}
+					break
+				}
+				depth++
+			case bytes.HasPrefix(tag, svgCloseTag):
+				size = offset + uint64(pos+len(svgCloseTag))
+				depth--
+			}
+
+			if depth <= 0 && size > 0 {
+				return &ScanResult{Size: size, Confidence: ConfidenceHeaderOnly}, nil
+			}
+			i = pos + 1
+		}
+
+		if eof {
+			break
+		}
+
+		carry = copy(buf, data[i:])
+		offset += uint64(i)
+	}
+
+	if size == 0 {
+		return nil, fmt.Errorf("no </svg> tag found")
+	}
+	return &ScanResult{Size: size, Confidence: ConfidenceHeaderOnly}, nil
+}
+
+// svgTagEnd returns the index of the '>' ending the tag at the start of data,
+// ignoring those within quoted attribute values, or -1 if it is not found.
+func svgTagEnd(data []byte) int {
+	var quote byte
+	for i, c := range data {
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '>':
+			return i
+		}
+	}
+	return -1
+}
+
+// isSVGTagEnd reports whether c can follow the name of an <svg> tag.
+func isSVGTagEnd(c byte) bool {
+	return c == '>' || c == '/' || c == ' ' || c == '\t' || c == '\r' || c == '\n'
+}
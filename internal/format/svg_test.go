@@ -0,0 +1,68 @@
+package format
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestScanSVG(t *testing.T) {
+	nested := `<?xml version="1.0" encoding="UTF-8"?>
+<svg xmlns="http://www.w3.org/2000/svg" width="10" height="10">
+  <svg x="5"><rect width="5" height="5"/></svg>
+  <text>` + string(bytes.Repeat([]byte("a"), 2*svgChunkSize)) + `</text>
+</svg>`
+
+	plain := `<svg width="1"><circle r="1"/></svg>`
+	selfClosing := `<?xml version="1.0"?>
+<svg xmlns="http://www.w3.org/2000/svg" title="a/>b" width="1"/>`
+	// A root tag starting in the first chunk, and ending in the second one.
+	straddling := `<?xml version="1.0"?>
+<svg title="` + string(bytes.Repeat([]byte("a"), svgChunkSize-25)) + `"/>`
+
+	tests := []struct {
+		name string
+		data string
+		size int
+	}{
+		{"plain", plain, len(plain)},
+		{"nested svg", nested, len(nested)},
+		{"followed by svg", `<svg></svg><svg></svg>`, 11},
+		{"followed by binary data", "<svg>\n<g></g>\n</svg>\n\x00\x00</svg>", 20},
+		{"self-closing root", selfClosing + "\n<svg></svg>", len(selfClosing)},
+		{"self-closing root across chunks", straddling + "\x00\x00", len(straddling)},
+		{"self-closing nested svg", `<svg><svg x="1"/></svg>`, 23},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res, err := ScanSVG(newBytesReader([]byte(tt.data)))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if res.Size != uint64(tt.size) {
+				t.Errorf("expected size %d, got %d", tt.size, res.Size)
+			}
+		})
+	}
+}
+
+func TestScanSVGInvalid(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+	}{
+		{"generic XML", `<?xml version="1.0"?><note><to>Tove</to></note>`},
+		{"svg after the first KB", `<?xml version="1.0"?>` + string(bytes.Repeat([]byte(" "), svgHeadSize)) + `<svg></svg>`},
+		{"no closing tag", `<svg width="1"><circle r="1"/>`},
+		{"svgz tag name", `<svgz></svgz>`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ScanSVG(newBytesReader([]byte(tt.data))); err == nil {
+				t.Errorf("expected an error")
+			}
+		})
+	}
+}
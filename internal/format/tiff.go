@@ -22,6 +22,8 @@ package format
 import (
 	"encoding/binary"
 	"fmt"
+	"io"
+	"strings"
 )
 
 const (
@@ -32,6 +34,7 @@ const (
 var tiffFileHeader = FileHeader{
 	Ext:         "tif",
 	Description: "Tagged Image File Format",
+	Category:    CategoryImage,
 	Signatures: [][]byte{
 		[]byte(tiffHeaderLittle),
 		[]byte(tiffHeaderBig),
@@ -39,13 +42,72 @@ var tiffFileHeader = FileHeader{
 	ScanFile: ScanTIFF,
 }
 
-func ScanTIFF(r *Reader) (*ScanResult, error) {
-	const (
-		tiffHeaderSize = 8
-	)
+// TIFF tags used to find the extent of the file.
+const (
+	tiffTagMake                  = 0x010F
+	tiffTagStripOffsets          = 0x0111
+	tiffTagStripByteCounts       = 0x0117
+	tiffTagTileOffsets           = 0x0144
+	tiffTagTileByteCounts        = 0x0145
+	tiffTagSubIFDs               = 0x014A
+	tiffTagJPEGInterchangeFormat = 0x0201
+	tiffTagJPEGInterchangeLength = 0x0202
+	tiffTagExifIFD               = 0x8769
+	tiffTagDNGVersion            = 0xC612
+)
+
+const (
+	tiffHeaderSize    = 8
+	tiffIFDEntrySize  = 12
+	tiffMaxIFDs       = 256     // bounds the number of parsed IFDs, protecting against loops
+	tiffMaxValueCount = 1 << 20 // bounds the number of values read from a single entry
+)
+
+// cr2Marker follows the TIFF header of Canon CR2 raw images.
+const cr2Marker = "CR\x02\x00"
 
-	header, err := r.Peek(tiffHeaderSize)
-	if err != nil {
+// tiffTypeSizes maps TIFF field types to the size of a single value.
+var tiffTypeSizes = map[uint16]uint64{
+	1:  1, // BYTE
+	2:  1, // ASCII
+	3:  2, // SHORT
+	4:  4, // LONG
+	5:  8, // RATIONAL
+	6:  1, // SBYTE
+	7:  1, // UNDEFINED
+	8:  2, // SSHORT
+	9:  4, // SLONG
+	10: 8, // SRATIONAL
+	11: 4, // FLOAT
+	12: 8, // DOUBLE
+	13: 4, // IFD
+}
+
+// tiffEntry is an entry of an Image File Directory.
+type tiffEntry struct {
+	tag   uint16
+	typ   uint16
+	count uint64
+	value [4]byte // the value itself, if it fits, or its offset
+}
+
+// tiffParser parses the structure of a TIFF file, tracking the end of its furthest part.
+type tiffParser struct {
+	r         *Reader
+	byteOrder binary.ByteOrder
+	end       uint64
+	visited   map[uint64]bool
+	maker     string // value of the Make tag, naming the camera manufacturer
+	dng       bool   // whether the DNGVersion tag was found
+}
+
+// ScanTIFF scans a TIFF image, including TIFF-based camera raw images (CR2, NEF, ARW, DNG).
+// The size of the file is the end of its furthest part among IFDs, tag values, image
+// strips and tiles, and embedded JPEG thumbnails, so that the (possibly large) image
+// data of the file is included even when it is stored after the last IFD.
+func ScanTIFF(r *Reader) (*ScanResult, error) {
+	header, err := r.Peek(tiffHeaderSize + len(cr2Marker))
+	if err != nil && (err != io.EOF || len(header) < tiffHeaderSize) {
 		return nil, fmt.Errorf("not enough data for TIFF header: %w", err)
 	}
 
@@ -68,67 +130,211 @@ func ScanTIFF(r *Reader) (*ScanResult, error) {
 
 	// Read offset to first IFD
 	firstIFDOffset := byteOrder.Uint32(header[4:8])
-	if firstIFDOffset < 8 {
+	if firstIFDOffset < tiffHeaderSize {
 		return nil, fmt.Errorf("invalid IFD offset: %d", firstIFDOffset)
 	}
 
-	// Consume the header
-	_, _ = r.Discard(tiffHeaderSize)
-	offset := uint64(tiffHeaderSize)
+	ext := "tif"
+	if string(header[tiffHeaderSize:]) == cr2Marker {
+		ext = "cr2"
+	}
+
+	p := &tiffParser{
+		r:         r,
+		byteOrder: byteOrder,
+		end:       tiffHeaderSize,
+		visited:   make(map[uint64]bool),
+	}
+
+	if err := p.parseIFDChain(uint64(firstIFDOffset)); err != nil {
+		return nil, err
+	}
 
-	// Seek to first IFD
-	skip := int(firstIFDOffset - tiffHeaderSize)
-	if skip > 0 {
-		n, err := r.Discard(skip)
-		if err != nil || n != skip {
-			return nil, fmt.Errorf("failed to reach first IFD at offset %d", firstIFDOffset)
+	switch {
+	case ext != "tif":
+	case p.dng:
+		ext = "dng"
+	default:
+		ext = tiffRawExt(p.maker)
+	}
+
+	return &ScanResult{
+		Ext:        ext,
+		Size:       p.end,
+		Confidence: ConfidenceStructural,
+	}, nil
+}
+
+// tiffRawExt returns the extension of the camera raw images of the given
+// manufacturer, which are plain TIFF files, or "tif" for other manufacturers.
+func tiffRawExt(maker string) string {
+	switch {
+	case strings.HasPrefix(maker, "NIKON"):
+		return "nef"
+	case strings.HasPrefix(maker, "SONY"):
+		return "arw"
+	default:
+		return "tif"
+	}
+}
+
+// parseIFDChain parses the chain of IFDs starting at the given offset. Only the first
+// IFD is required to be valid: a broken link ends the chain at the last valid IFD.
+func (p *tiffParser) parseIFDChain(offset uint64) error {
+	for first := true; offset != 0; first = false {
+		next, err := p.parseIFD(offset)
+		if err != nil {
+			if first {
+				return err
+			}
+			break
 		}
-		offset += uint64(n)
+		offset = next
+	}
+	return nil
+}
+
+// parseIFD parses the IFD at the given offset, and the IFDs it links to, returning the offset of the next IFD.
+func (p *tiffParser) parseIFD(offset uint64) (uint64, error) {
+	if p.visited[offset] || len(p.visited) >= tiffMaxIFDs {
+		return 0, fmt.Errorf("IFD loop or too many IFDs at offset %d", offset)
+	}
+	p.visited[offset] = true
+
+	var buf [4]byte
+	if err := p.readAt(offset, buf[:2]); err != nil {
+		return 0, fmt.Errorf("failed to read IFD entry count: %w", err)
+	}
+
+	entryCount := uint64(p.byteOrder.Uint16(buf[:2]))
+	if entryCount == 0 {
+		return 0, fmt.Errorf("empty IFD at offset %d", offset)
+	}
+
+	data := make([]byte, entryCount*tiffIFDEntrySize+4)
+	if err := p.readAt(offset+2, data); err != nil {
+		return 0, fmt.Errorf("failed to read IFD entries: %w", err)
 	}
+	p.extend(offset + 2 + uint64(len(data)))
 
-	// Parse IFD chain
-	for {
-		var buf [4]byte
+	entries := make(map[uint16]tiffEntry, entryCount)
+	for i := uint64(0); i < entryCount; i++ {
+		e := data[i*tiffIFDEntrySize:]
 
-		// Read entry count (2 bytes)
-		if _, err := r.Read(buf[:2]); err != nil {
-			return nil, fmt.Errorf("failed to read IFD entry count: %w", err)
+		entry := tiffEntry{
+			tag:   p.byteOrder.Uint16(e[0:2]),
+			typ:   p.byteOrder.Uint16(e[2:4]),
+			count: uint64(p.byteOrder.Uint32(e[4:8])),
 		}
-		entryCount := byteOrder.Uint16(buf[:])
-		offset += 2
+		copy(entry.value[:], e[8:12])
+		entries[entry.tag] = entry
 
-		// Read all entries: each is 12 bytes
-		entriesSize := int(entryCount) * 12
-		if _, err := r.Discard(entriesSize); err != nil {
-			return nil, fmt.Errorf("failed to skip IFD entries: %w", err)
+		// Values not fitting the entry are stored elsewhere in the file.
+		if size, ok := tiffTypeSizes[entry.typ]; ok && size*entry.count > 4 {
+			p.extend(uint64(p.byteOrder.Uint32(entry.value[:])) + size*entry.count)
 		}
-		offset += uint64(entriesSize)
+	}
+
+	if e, ok := entries[tiffTagMake]; ok && p.maker == "" {
+		p.maker = p.readString(e)
+	}
+
+	if _, ok := entries[tiffTagDNGVersion]; ok {
+		p.dng = true
+	}
 
-		// Read next IFD offset (4 bytes)
-		if _, err := r.Read(buf[:]); err != nil {
-			return nil, fmt.Errorf("failed to read next IFD offset: %w", err)
+	p.extendData(entries, tiffTagStripOffsets, tiffTagStripByteCounts)
+	p.extendData(entries, tiffTagTileOffsets, tiffTagTileByteCounts)
+	p.extendData(entries, tiffTagJPEGInterchangeFormat, tiffTagJPEGInterchangeLength)
+
+	// Camera raw images often store the full size image in a sub-IFD.
+	for _, tag := range []uint16{tiffTagSubIFDs, tiffTagExifIFD} {
+		if e, ok := entries[tag]; ok {
+			offsets, _ := p.readValues(e)
+			for _, subOffset := range offsets {
+				_ = p.parseIFDChain(subOffset)
+			}
 		}
-		nextOffset := byteOrder.Uint32(buf[:])
-		offset += 4
+	}
+
+	return uint64(p.byteOrder.Uint32(data[len(data)-4:])), nil
+}
+
+// extendData extends the file to the end of the data blocks described by
+// the entries with the given tags, holding their offsets and sizes.
+func (p *tiffParser) extendData(entries map[uint16]tiffEntry, offsetsTag, sizesTag uint16) {
+	offsetsEntry, ok1 := entries[offsetsTag]
+	sizesEntry, ok2 := entries[sizesTag]
+	if !ok1 || !ok2 {
+		return
+	}
 
-		if nextOffset == 0 {
-			break // no more IFDs
+	offsets, err1 := p.readValues(offsetsEntry)
+	sizes, err2 := p.readValues(sizesEntry)
+	if err1 != nil || err2 != nil {
+		return
+	}
+
+	for i := range min(len(offsets), len(sizes)) {
+		p.extend(offsets[i] + sizes[i])
+	}
+}
+
+// readValues reads the values of an entry of integer type.
+func (p *tiffParser) readValues(e tiffEntry) ([]uint64, error) {
+	size, ok := tiffTypeSizes[e.typ]
+	if !ok || (size != 2 && size != 4) || e.typ == 8 || e.typ == 9 {
+		return nil, fmt.Errorf("unsupported type %d for tag 0x%04x", e.typ, e.tag)
+	}
+
+	if e.count > tiffMaxValueCount {
+		return nil, fmt.Errorf("too many values for tag 0x%04x: %d", e.tag, e.count)
+	}
+
+	data := e.value[:]
+	if size*e.count > 4 {
+		data = make([]byte, size*e.count)
+		if err := p.readAt(uint64(p.byteOrder.Uint32(e.value[:])), data); err != nil {
+			return nil, err
 		}
+	}
 
-		skip := int(nextOffset) - int(offset)
-		if skip < 0 {
-			return nil, fmt.Errorf("invalid backward IFD pointer")
+	values := make([]uint64, e.count)
+	for i := range values {
+		if size == 2 {
+			values[i] = uint64(p.byteOrder.Uint16(data[2*i:]))
+		} else {
+			values[i] = uint64(p.byteOrder.Uint32(data[4*i:]))
 		}
-		if skip > 0 {
-			if _, err := r.Discard(skip); err != nil {
-				return nil, fmt.Errorf("failed to skip to next IFD: %w", err)
-			}
-			offset += uint64(skip)
+	}
+	return values, nil
+}
+
+// readString reads the value of an entry of ASCII type.
+func (p *tiffParser) readString(e tiffEntry) string {
+	if e.typ != 2 || e.count > 256 {
+		return ""
+	}
+
+	data := e.value[:min(e.count, 4)]
+	if e.count > 4 {
+		data = make([]byte, e.count)
+		if err := p.readAt(uint64(p.byteOrder.Uint32(e.value[:])), data); err != nil {
+			return ""
 		}
 	}
+	return strings.TrimRight(string(data), "\x00")
+}
 
-	return &ScanResult{
-		Ext:  "tif",
-		Size: offset,
-	}, nil
+// readAt reads len(buf) bytes at the given offset from the start of the file.
+func (p *tiffParser) readAt(offset uint64, buf []byte) error {
+	if _, err := p.r.Discard(int(int64(offset) - int64(p.r.BytesRead()))); err != nil {
+		return err
+	}
+	_, err := io.ReadFull(p.r, buf)
+	return err
+}
+
+func (p *tiffParser) extend(end uint64) {
+	p.end = max(p.end, end)
 }
@@ -0,0 +1,209 @@
+package format
+
+import (
+	"encoding/binary"
+	"testing"
+)
+
+// tiffField is an IFD entry of a TIFF fixture. Values not fitting the entry are appended to the file.
+type tiffField struct {
+	tag    uint16
+	typ    uint16
+	values []uint32 // for SHORT and LONG fields
+	text   string   // for ASCII fields
+}
+
+// tiffBuilder builds little-endian TIFF fixtures.
+type tiffBuilder struct {
+	data []byte
+}
+
+func newTIFFBuilder(marker string) *tiffBuilder {
+	return &tiffBuilder{data: []byte(tiffHeaderLittle + "\x00\x00\x00\x00" + marker)}
+}
+
+// addData appends data to the file, returning its offset.
+func (b *tiffBuilder) addData(data []byte) uint32 {
+	offset := uint32(len(b.data))
+	b.data = append(b.data, data...)
+	return offset
+}
+
+// addIFD appends an IFD holding the given fields, returning its offset.
+// The offset of the next IFD is left to 0, and can be set with linkIFD.
+func (b *tiffBuilder) addIFD(fields ...tiffField) uint32 {
+	entries := make([]byte, 2+len(fields)*tiffIFDEntrySize+4)
+	binary.LittleEndian.PutUint16(entries, uint16(len(fields)))
+
+	for i, f := range fields {
+		var value []byte
+		switch f.typ {
+		case 2:
+			value = append([]byte(f.text), 0)
+		case 3:
+			for _, v := range f.values {
+				value = binary.LittleEndian.AppendUint16(value, uint16(v))
+			}
+		default:
+			for _, v := range f.values {
+				value = binary.LittleEndian.AppendUint32(value, v)
+			}
+		}
+
+		e := entries[2+i*tiffIFDEntrySize:]
+		binary.LittleEndian.PutUint16(e[0:], f.tag)
+		binary.LittleEndian.PutUint16(e[2:], f.typ)
+		binary.LittleEndian.PutUint32(e[4:], uint32(len(value)/int(tiffTypeSizes[f.typ])))
+		if len(value) <= 4 {
+			copy(e[8:], value)
+		} else {
+			binary.LittleEndian.PutUint32(e[8:], b.addData(value))
+		}
+	}
+	return b.addData(entries)
+}
+
+// linkIFD sets the offset of the IFD following the one at the given offset.
+// A zero offset sets the first IFD of the file.
+func (b *tiffBuilder) linkIFD(offset, next uint32) {
+	if offset == 0 {
+		binary.LittleEndian.PutUint32(b.data[4:], next)
+		return
+	}
+
+	count := binary.LittleEndian.Uint16(b.data[offset:])
+	binary.LittleEndian.PutUint32(b.data[offset+2+uint32(count)*tiffIFDEntrySize:], next)
+}
+
+func TestScanTIFF(t *testing.T) {
+	// A CR2-like file: IFD0 with a thumbnail, followed by a raw IFD whose strips
+	// are stored before the IFDs, and by a sub-IFD whose tile is the last part of the file.
+	b := newTIFFBuilder(cr2Marker + "\x00\x00\x00\x00")
+
+	strip1 := b.addData(make([]byte, 1000))
+	strip2 := b.addData(make([]byte, 500))
+	thumb := b.addData(make([]byte, 200))
+
+	raw := b.addIFD(
+		tiffField{tag: tiffTagStripOffsets, typ: 4, values: []uint32{strip1, strip2}},
+		tiffField{tag: tiffTagStripByteCounts, typ: 3, values: []uint32{1000, 500}},
+	)
+
+	tileIFD := b.addIFD(
+		tiffField{tag: tiffTagTileOffsets, typ: 4, values: []uint32{0}},
+		tiffField{tag: tiffTagTileByteCounts, typ: 4, values: []uint32{4096}},
+	)
+
+	ifd0 := b.addIFD(
+		tiffField{tag: tiffTagMake, typ: 2, text: "Canon"},
+		tiffField{tag: tiffTagJPEGInterchangeFormat, typ: 4, values: []uint32{thumb}},
+		tiffField{tag: tiffTagJPEGInterchangeLength, typ: 4, values: []uint32{200}},
+		tiffField{tag: tiffTagSubIFDs, typ: 4, values: []uint32{tileIFD}},
+	)
+	b.linkIFD(0, ifd0)
+	b.linkIFD(ifd0, raw)
+
+	tile := b.addData(make([]byte, 4096))
+	binary.LittleEndian.PutUint32(b.data[tileIFD+2+8:], tile)
+
+	size := len(b.data)
+
+	res, err := ScanTIFF(newBytesReader(append(b.data, make([]byte, 1000)...)))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if res.Ext != "cr2" {
+		t.Errorf("expected ext cr2, got %s", res.Ext)
+	}
+
+	if res.Size != uint64(size) {
+		t.Errorf("expected size %d, got %d", size, res.Size)
+	}
+}
+
+func TestScanTIFFRawExt(t *testing.T) {
+	tests := []struct {
+		maker string
+		ext   string
+	}{
+		{"NIKON CORPORATION", "nef"},
+		{"SONY", "arw"},
+		{"Scanner Inc.", "tif"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.maker, func(t *testing.T) {
+			b := newTIFFBuilder("")
+			b.linkIFD(0, b.addIFD(tiffField{tag: tiffTagMake, typ: 2, text: tt.maker}))
+
+			res, err := ScanTIFF(newBytesReader(b.data))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if res.Ext != tt.ext {
+				t.Errorf("expected ext %s, got %s", tt.ext, res.Ext)
+			}
+		})
+	}
+}
+
+func TestScanTIFFLoop(t *testing.T) {
+	b := newTIFFBuilder("")
+	ifd := b.addIFD(tiffField{tag: tiffTagMake, typ: 2, text: "Loop"})
+	b.linkIFD(0, ifd)
+	b.linkIFD(ifd, ifd)
+
+	res, err := ScanTIFF(newBytesReader(b.data))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if res.Size != uint64(len(b.data)) {
+		t.Errorf("expected size %d, got %d", len(b.data), res.Size)
+	}
+}
+
+func TestScanTIFFDNG(t *testing.T) {
+	// A DNG as written by converters: IFD0 holds the preview, while the raw
+	// image is in a sub-IFD, with its strips stored at the end of the file.
+	b := newTIFFBuilder("")
+
+	preview := b.addData(make([]byte, 2048))
+
+	rawIFD := b.addIFD(
+		tiffField{tag: tiffTagStripOffsets, typ: 4, values: []uint32{0, 0}},
+		tiffField{tag: tiffTagStripByteCounts, typ: 4, values: []uint32{8192, 4096}},
+	)
+
+	ifd0 := b.addIFD(
+		tiffField{tag: tiffTagMake, typ: 2, text: "NIKON CORPORATION"},
+		tiffField{tag: tiffTagStripOffsets, typ: 4, values: []uint32{preview}},
+		tiffField{tag: tiffTagStripByteCounts, typ: 4, values: []uint32{2048}},
+		tiffField{tag: tiffTagSubIFDs, typ: 4, values: []uint32{rawIFD}},
+		tiffField{tag: tiffTagDNGVersion, typ: 1, values: []uint32{0x00000401}},
+	)
+	b.linkIFD(0, ifd0)
+
+	strip1 := b.addData(make([]byte, 8192))
+	strip2 := b.addData(make([]byte, 4096))
+
+	// The strip offsets of the raw IFD are stored out of line.
+	offsets := binary.LittleEndian.Uint32(b.data[rawIFD+2+8:])
+	binary.LittleEndian.PutUint32(b.data[offsets:], strip1)
+	binary.LittleEndian.PutUint32(b.data[offsets+4:], strip2)
+
+	res, err := ScanTIFF(newBytesReader(append(b.data, make([]byte, 1000)...)))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if res.Ext != "dng" {
+		t.Errorf("expected ext dng, got %s", res.Ext)
+	}
+
+	if res.Size != uint64(len(b.data)) {
+		t.Errorf("expected size %d, got %d", len(b.data), res.Size)
+	}
+}
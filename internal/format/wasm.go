@@ -0,0 +1,265 @@
+// Copyright (c) 2025 Stefano Scafiti
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package format
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/tetratelabs/wazero"
+	"github.com/tetratelabs/wazero/api"
+	"github.com/tetratelabs/wazero/imports/wasi_snapshot_preview1"
+)
+
+// WebAssembly plugin ABI
+// ------------------------------
+// A WebAssembly plugin is a module exporting its linear memory and the following functions.
+// Strings and buffers returned to the host are packed into a single i64 as (ptr << 32) | len.
+//
+// alloc(size i32) -> i32			Allocates size bytes in the module memory and returns a pointer to them
+// ext() -> i64					Packed pointer to the file extension handled by the plugin
+// description() -> i64				Packed pointer to a short description of the file format
+// signatures() -> i64				Packed pointer to the signature list, where each signature is encoded
+//						as a 1-byte length followed by the signature bytes
+// scan(ptr i32, len i32) -> i64		Scans the len bytes at ptr, which start with one of the signatures,
+//						and returns the size of the carved file, or a value <= 0 if the data
+//						is not recognized
+//
+// The host instantiates the module once, calls alloc to obtain a buffer of wasmScanWindowSize bytes,
+// and passes to scan at most that many bytes from the beginning of each candidate file.
+// Modules are run in a sandbox, with no access to the filesystem or the network. Their memory
+// is limited to wasmMemoryLimitPages, and a module is closed when one of its functions runs
+// for longer than wasmCallTimeout, after which its scanner fails.
+
+const (
+	// wasmScanWindowSize is the maximum number of bytes passed to the scan function of a WebAssembly plugin.
+	wasmScanWindowSize = 64 * 1024
+
+	// wasmMemoryLimitPages is the maximum number of 64KiB pages of the memory of a WebAssembly plugin (64MiB).
+	wasmMemoryLimitPages = 1024
+)
+
+// wasmCallTimeout is the maximum duration of a call to a function of a WebAssembly plugin.
+var wasmCallTimeout = 5 * time.Second
+
+type wasmFileScanner struct {
+	mu sync.Mutex
+
+	ctx  context.Context
+	rt   wazero.Runtime
+	mod  api.Module
+	scan api.Function
+
+	ext         string
+	description string
+	signatures  [][]byte
+
+	bufPtr uint32
+	buf    []byte
+}
+
+func loadWasmPlugin(path string) (FileScanner, error) {
+	wasmBytes, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read plugin %s: %w", path, err)
+	}
+
+	sc, err := newWasmPlugin(wasmBytes)
+	if err != nil {
+		return nil, fmt.Errorf("plugin %s: %w", path, err)
+	}
+	return sc, nil
+}
+
+// newWasmPlugin instantiates the given WebAssembly module in a new runtime, and returns its scanner.
+func newWasmPlugin(wasmBytes []byte) (*wasmFileScanner, error) {
+	ctx := context.Background()
+
+	rt := wazero.NewRuntimeWithConfig(ctx, wazero.NewRuntimeConfig().
+		WithCloseOnContextDone(true).
+		WithMemoryLimitPages(wasmMemoryLimitPages))
+
+	// Modules compiled for WASI (e.g. with TinyGo or GOOS=wasip1) import its functions
+	// even when they are never used. Stdio, filesystem and clock are not exposed.
+	wasi_snapshot_preview1.MustInstantiate(ctx, rt)
+
+	mod, err := rt.InstantiateWithConfig(ctx, wasmBytes,
+		wazero.NewModuleConfig().WithStartFunctions("_initialize"))
+	if err != nil {
+		_ = rt.Close(ctx)
+		return nil, fmt.Errorf("failed to instantiate module: %w", err)
+	}
+
+	sc, err := newWasmFileScanner(ctx, mod)
+	if err != nil {
+		_ = rt.Close(ctx)
+		return nil, err
+	}
+	sc.rt = rt
+	return sc, nil
+}
+
+// Close releases the runtime of the module, which cannot be called afterwards.
+func (sc *wasmFileScanner) Close(ctx context.Context) error {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+
+	return sc.rt.Close(ctx)
+}
+
+func newWasmFileScanner(ctx context.Context, mod api.Module) (*wasmFileScanner, error) {
+	if mod.Memory() == nil {
+		return nil, fmt.Errorf("module does not export its memory")
+	}
+
+	fns := make(map[string]api.Function)
+	for _, name := range []string{"alloc", "ext", "description", "signatures", "scan"} {
+		fn := mod.ExportedFunction(name)
+		if fn == nil {
+			return nil, fmt.Errorf("module does not export function %q", name)
+		}
+		fns[name] = fn
+	}
+
+	sc := &wasmFileScanner{
+		ctx:  ctx,
+		mod:  mod,
+		scan: fns["scan"],
+		buf:  make([]byte, wasmScanWindowSize),
+	}
+
+	ext, err := sc.callBytes(fns["ext"])
+	if err != nil {
+		return nil, fmt.Errorf("ext: %w", err)
+	}
+	sc.ext = string(ext)
+
+	description, err := sc.callBytes(fns["description"])
+	if err != nil {
+		return nil, fmt.Errorf("description: %w", err)
+	}
+	sc.description = string(description)
+
+	sigData, err := sc.callBytes(fns["signatures"])
+	if err != nil {
+		return nil, fmt.Errorf("signatures: %w", err)
+	}
+
+	sc.signatures, err = decodeWasmSignatures(sigData)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := sc.call(fns["alloc"], wasmScanWindowSize)
+	if err != nil {
+		return nil, fmt.Errorf("alloc: %w", err)
+	}
+	sc.bufPtr = uint32(res[0])
+	return sc, nil
+}
+
+// call calls fn, closing the module if the call does not return within wasmCallTimeout.
+func (sc *wasmFileScanner) call(fn api.Function, params ...uint64) ([]uint64, error) {
+	ctx, cancel := context.WithTimeout(sc.ctx, wasmCallTimeout)
+	defer cancel()
+
+	res, err := fn.Call(ctx, params...)
+	if err != nil && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return nil, fmt.Errorf("%w after %s", ctx.Err(), wasmCallTimeout)
+	}
+	return res, err
+}
+
+// callBytes calls a function returning a packed pointer, and returns
+// a copy of the memory region it points to.
+func (sc *wasmFileScanner) callBytes(fn api.Function) ([]byte, error) {
+	res, err := sc.call(fn)
+	if err != nil {
+		return nil, err
+	}
+
+	ptr, size := uint32(res[0]>>32), uint32(res[0])
+
+	data, ok := sc.mod.Memory().Read(ptr, size)
+	if !ok {
+		return nil, fmt.Errorf("out of range memory access (ptr=%d, len=%d)", ptr, size)
+	}
+	return append([]byte(nil), data...), nil
+}
+
+func decodeWasmSignatures(data []byte) ([][]byte, error) {
+	var sigs [][]byte
+	for len(data) > 0 {
+		n := int(data[0])
+		if 1+n > len(data) {
+			return nil, fmt.Errorf("truncated signature list")
+		}
+		sigs = append(sigs, data[1:1+n])
+		data = data[1+n:]
+	}
+	return sigs, nil
+}
+
+func (sc *wasmFileScanner) Ext() string {
+	return sc.ext
+}
+
+func (sc *wasmFileScanner) Description() string {
+	return sc.description
+}
+
+// Category returns CategoryOther, since WebAssembly plugins do not declare a category.
+func (sc *wasmFileScanner) Category() Category {
+	return CategoryOther
+}
+
+func (sc *wasmFileScanner) Signatures() [][]byte {
+	return sc.signatures
+}
+
+func (sc *wasmFileScanner) ScanFile(r *Reader) (*ScanResult, error) {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+
+	n, err := io.ReadFull(r, sc.buf)
+	if err != nil && err != io.ErrUnexpectedEOF {
+		return nil, err
+	}
+
+	if !sc.mod.Memory().Write(sc.bufPtr, sc.buf[:n]) {
+		return nil, fmt.Errorf("%s: out of range memory access", sc.ext)
+	}
+
+	res, err := sc.call(sc.scan, uint64(sc.bufPtr), uint64(n))
+	if err != nil {
+		return nil, fmt.Errorf("%s: scan failed: %w", sc.ext, err)
+	}
+
+	size := int64(res[0])
+	if size <= 0 {
+		return nil, fmt.Errorf("%s: data not recognized by plugin", sc.ext)
+	}
+	return &ScanResult{Size: uint64(size), Confidence: ConfidenceStructural}, nil
+}
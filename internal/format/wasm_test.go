@@ -0,0 +1,223 @@
+package format
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestDecodeWasmSignatures(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    []byte
+		want    [][]byte
+		wantErr bool
+	}{
+		{"empty", nil, nil, false},
+		{"single", []byte("\x04TEST"), [][]byte{[]byte("TEST")}, false},
+		{"several", []byte("\x04TEST\x02AB"), [][]byte{[]byte("TEST"), []byte("AB")}, false},
+		{"truncated", []byte("\x04TES"), nil, true},
+		{"missing signature", []byte("\x02AB\x01"), nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sigs, err := decodeWasmSignatures(tt.data)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("expected error %v, got %v", tt.wantErr, err)
+			}
+			if len(sigs) != len(tt.want) {
+				t.Fatalf("expected %d signatures, got %d", len(tt.want), len(sigs))
+			}
+			for i := range sigs {
+				if !bytes.Equal(sigs[i], tt.want[i]) {
+					t.Errorf("signature %d: expected %q, got %q", i, tt.want[i], sigs[i])
+				}
+			}
+		})
+	}
+}
+
+// Bodies of the scan function of the test modules, which returns an i64 from the
+// pointer and the length (locals 0 and 1) of the data.
+var (
+	// wasmScanLength returns the length of the data.
+	wasmScanLength = []byte{0x20, 0x01, 0xAD} // local.get 1; i64.extend_i32_u
+
+	// wasmScanReject returns 0, so that no data is recognized.
+	wasmScanReject = []byte{0x42, 0x00} // i64.const 0
+
+	// wasmScanLoop never returns.
+	wasmScanLoop = []byte{0x03, 0x40, 0x0C, 0x00, 0x0B, 0x42, 0x00} // loop; br 0; end; i64.const 0
+)
+
+// wasmTestModule returns a WebAssembly plugin handling the "tst" extension, whose scan
+// function has the given body, and whose memory has the given number of pages.
+func wasmTestModule(scanBody []byte, memPages uint32) []byte {
+	const (
+		extPtr  = 0
+		descPtr = 16
+		sigsPtr = 64
+		bufPtr  = 1024
+	)
+	ext, desc, sigs := "tst", "Test file", "\x04TEST\x02AB"
+
+	packed := func(ptr int, s string) []byte {
+		return append([]byte{0x42}, appendSLEB(nil, int64(ptr)<<32|int64(len(s)))...) // i64.const
+	}
+
+	types := []byte{3,
+		0x60, 1, 0x7F, 1, 0x7F, // (i32) -> i32
+		0x60, 0, 1, 0x7E, // () -> i64
+		0x60, 2, 0x7F, 0x7F, 1, 0x7E, // (i32, i32) -> i64
+	}
+	funcs := []byte{5, 0, 1, 1, 1, 2}
+	memory := append([]byte{1, 0x00}, appendULEB(nil, uint64(memPages))...)
+
+	exports := []byte{6}
+	for i, name := range []string{"alloc", "ext", "description", "signatures", "scan"} {
+		exports = append(exports, byte(len(name)))
+		exports = append(exports, name...)
+		exports = append(exports, 0x00, byte(i)) // function
+	}
+	exports = append(exports, 6)
+	exports = append(exports, "memory"...)
+	exports = append(exports, 0x02, 0) // memory
+
+	bodies := [][]byte{
+		append([]byte{0x41}, appendSLEB(nil, bufPtr)...), // i32.const
+		packed(extPtr, ext),
+		packed(descPtr, desc),
+		packed(sigsPtr, sigs),
+		scanBody,
+	}
+	code := []byte{byte(len(bodies))}
+	for _, body := range bodies {
+		fn := append(append([]byte{0}, body...), 0x0B) // no locals; end
+		code = append(appendULEB(code, uint64(len(fn))), fn...)
+	}
+
+	data := []byte{3}
+	for _, seg := range []struct {
+		ptr int
+		s   string
+	}{{extPtr, ext}, {descPtr, desc}, {sigsPtr, sigs}} {
+		data = append(data, 0x00, 0x41) // active segment; i32.const
+		data = append(appendSLEB(data, int64(seg.ptr)), 0x0B)
+		data = append(appendULEB(data, uint64(len(seg.s))), seg.s...)
+	}
+
+	mod := []byte("\x00asm\x01\x00\x00\x00")
+	for _, sec := range []struct {
+		id   byte
+		data []byte
+	}{{1, types}, {3, funcs}, {5, memory}, {7, exports}, {10, code}, {11, data}} {
+		mod = append(appendULEB(append(mod, sec.id), uint64(len(sec.data))), sec.data...)
+	}
+	return mod
+}
+
+func appendULEB(b []byte, v uint64) []byte {
+	for v >= 0x80 {
+		b = append(b, byte(v)|0x80)
+		v >>= 7
+	}
+	return append(b, byte(v))
+}
+
+func appendSLEB(b []byte, v int64) []byte {
+	for {
+		c := byte(v & 0x7F)
+		v >>= 7
+		if (v == 0 && c&0x40 == 0) || (v == -1 && c&0x40 != 0) {
+			return append(b, c)
+		}
+		b = append(b, c|0x80)
+	}
+}
+
+func TestWasmPlugin(t *testing.T) {
+	sc, err := newWasmPlugin(wasmTestModule(wasmScanLength, 2))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer sc.Close(context.Background())
+
+	if sc.Ext() != "tst" || sc.Description() != "Test file" {
+		t.Errorf("unexpected extension %q or description %q", sc.Ext(), sc.Description())
+	}
+	if len(sc.Signatures()) != 2 || string(sc.Signatures()[0]) != "TEST" || string(sc.Signatures()[1]) != "AB" {
+		t.Errorf("unexpected signatures %q", sc.Signatures())
+	}
+
+	tests := []struct {
+		name string
+		size int
+		want uint64
+	}{
+		{"shorter than the window", 100, 100},
+		{"longer than the window", 3 * wasmScanWindowSize, wasmScanWindowSize},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data := append([]byte("TEST"), make([]byte, tt.size-4)...)
+
+			res, err := sc.ScanFile(newBytesReader(data))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if res.Size != tt.want {
+				t.Errorf("expected size %d, got %d", tt.want, res.Size)
+			}
+		})
+	}
+}
+
+func TestWasmPluginRejects(t *testing.T) {
+	sc, err := newWasmPlugin(wasmTestModule(wasmScanReject, 2))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer sc.Close(context.Background())
+
+	if _, err := sc.ScanFile(newBytesReader([]byte("TEST"))); err == nil {
+		t.Errorf("expected an error for data not recognized by the plugin")
+	}
+}
+
+func TestWasmPluginTimeout(t *testing.T) {
+	defer func(timeout time.Duration) { wasmCallTimeout = timeout }(wasmCallTimeout)
+	wasmCallTimeout = 100 * time.Millisecond
+
+	sc, err := newWasmPlugin(wasmTestModule(wasmScanLoop, 2))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer sc.Close(context.Background())
+
+	if _, err := sc.ScanFile(newBytesReader([]byte("TEST"))); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected a deadline error for a scan running past the timeout, got %v", err)
+	}
+}
+
+func TestWasmPluginMemoryLimit(t *testing.T) {
+	if _, err := newWasmPlugin(wasmTestModule(wasmScanLength, wasmMemoryLimitPages+1)); err == nil {
+		t.Errorf("expected an error for a module requiring more than %d pages", wasmMemoryLimitPages)
+	}
+}
+
+func TestWasmPluginClose(t *testing.T) {
+	sc, err := newWasmPlugin(wasmTestModule(wasmScanLength, 2))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	ClosePlugins([]FileScanner{sc})
+
+	if _, err := sc.ScanFile(newBytesReader([]byte("TEST"))); err == nil {
+		t.Errorf("expected an error from a closed plugin")
+	}
+}
@@ -28,6 +28,7 @@ import (
 var wavFileHeader = FileHeader{
 	Ext:         "wav",
 	Description: "Waveform Audio File Format",
+	Category:    CategoryAudio,
 	Signatures: [][]byte{
 		[]byte("RIFF"),
 		[]byte("RIFX"),
@@ -162,7 +163,7 @@ func ScanWAV(r *Reader) (*ScanResult, error) {
 			if err == io.EOF && skipped < int(chunkSize) {
 				// Truncated chunk data, can't determine full WAV size
 				bytesRead += uint64(skipped)
-				return &ScanResult{Size: bytesRead}, nil // Return what was read before truncation
+				return &ScanResult{Size: bytesRead, Truncated: true, Confidence: ConfidenceHeaderOnly}, nil // Return what was read before truncation
 			}
 			return nil, fmt.Errorf("failed to skip chunk data while searching for 'data': %w", err)
 		}
@@ -179,7 +180,7 @@ func ScanWAV(r *Reader) (*ScanResult, error) {
 	// If the RIFF chunk size was smaller than the calculated totalWAVSize,
 	// it means the file is truncated, and the actual valid data ends at the RIFF chunk boundary.
 	if totalWAVSize > uint64(riffChunkSize)+8 {
-		return &ScanResult{Size: uint64(riffChunkSize) + 8}, nil // Return the size declared by RIFF if data chunk extends beyond it.
+		return &ScanResult{Size: uint64(riffChunkSize) + 8, Confidence: ConfidenceHeaderOnly}, nil // Return the size declared by RIFF if data chunk extends beyond it.
 	}
 
 	// If the user wants to read the entire data chunk after this, they can do so
@@ -190,11 +191,11 @@ func ScanWAV(r *Reader) (*ScanResult, error) {
 	if err != nil {
 		if err == io.EOF && skipped < int(dataChunkSize) {
 			// Data chunk is truncated. The valid WAV ends here.
-			return &ScanResult{Size: bytesRead + uint64(skipped)}, nil
+			return &ScanResult{Size: bytesRead + uint64(skipped), Truncated: true, Confidence: ConfidenceStructural}, nil
 		}
 		return nil, fmt.Errorf("failed to skip 'data' chunk: %w", err)
 	}
 	bytesRead += uint64(skipped)
 
-	return &ScanResult{Size: totalWAVSize}, nil
+	return &ScanResult{Size: totalWAVSize, Confidence: ConfidenceStructural}, nil
 }
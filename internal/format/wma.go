@@ -29,6 +29,7 @@ import (
 var wmaFileHeader = FileHeader{
 	Ext:         "wma",
 	Description: "Windows Media Audio Format",
+	Category:    CategoryAudio,
 	Signatures: [][]byte{
 		asfHeaderGUID,
 	},
@@ -75,8 +76,10 @@ const (
 	// minGeneralSubObjectHeaderSize is the minimum size to read any ASF object's header (GUID + ObjectSize).
 	minGeneralSubObjectHeaderSize = 24
 
-	// minFilePropObjSize is the minimum expected size for an ASF File Properties Object.
-	minFilePropObjSize = 40
+	// minFilePropObjSize is the minimum expected size for an ASF File Properties Object,
+	// which must at least contain its header, the FileID and the FileSize fields.
+	// 24 (header) + 16 (FileID) + 8 (FileSize) = 48 bytes.
+	minFilePropObjSize = 48
 
 	// filePropFileSizeOffset is the byte offset of the FileSize field within the ASF File Properties Object,
 	// relative to the start of the File Properties Object's data (after its own 24-byte header).
@@ -167,29 +170,28 @@ func ScanWMA(r *Reader) (*ScanResult, error) {
 				return nil, errors.New("invalid ASF File Properties Object size")
 			}
 
-			buf, err := r.Peek(minFilePropObjSize - minGeneralSubObjectHeaderSize)
-			if err != nil {
-				return nil, err
-			}
-
 			// The file_size field is at offset `filePropFileSizeOffset` within this object.
 			fileSizeFieldOffset := bytesRead + filePropFileSizeOffset
-			//fileSizeFieldOffset+8 > bufLen ||
 			if fileSizeFieldOffset+8 > bytesRead+objSize {
 				return nil, errors.New("truncated ASF File Properties Object for 'file_size'")
 			}
 
-			totalFileSize = binary.LittleEndian.Uint64(buf[16 : 16+8])
+			// Peek the object data up to the end of the file_size field.
+			// The object header has already been consumed.
+			fileSizeDataOffset := filePropFileSizeOffset - minGeneralSubObjectHeaderSize
+
+			buf, err := r.Peek(fileSizeDataOffset + 8)
+			if err != nil {
+				return nil, err
+			}
+
+			totalFileSize = binary.LittleEndian.Uint64(buf[fileSizeDataOffset : fileSizeDataOffset+8])
 
 			// Let's ensure the reported totalFileSize is at least big enough for the basic header.
 			if totalFileSize < headerObjectSize { // At least the size of the initial header block
 				return nil, errors.New("invalid total file size in File Properties Object")
 			}
 		} else if bytes.Equal(objID, asfStreamPropGUID) {
-			buf, err := r.Peek(minFilePropObjSize - minStreamPropObjSize)
-			if err != nil {
-				return nil, err
-			}
 			// Found the ASF Stream Properties Object
 			if objSize < minStreamPropObjSize {
 				return nil, errors.New("invalid ASF Stream Properties Object size")
@@ -197,13 +199,20 @@ func ScanWMA(r *Reader) (*ScanResult, error) {
 
 			// Check if this is a WMA stream type
 			streamTypeFieldOffset := bytesRead + streamPropStreamTypeOffset
-
-			//streamTypeFieldOffset+16 > bufLen
 			if streamTypeFieldOffset+16 > bytesRead+objSize {
 				return nil, errors.New("truncated ASF Stream Properties Object for 'stream_type'")
 			}
 
-			streamType := buf[:16]
+			// Peek the object data up to the end of the stream_type field.
+			// The object header has already been consumed.
+			streamTypeDataOffset := streamPropStreamTypeOffset - minGeneralSubObjectHeaderSize
+
+			buf, err := r.Peek(streamTypeDataOffset + 16)
+			if err != nil {
+				return nil, err
+			}
+
+			streamType := buf[streamTypeDataOffset : streamTypeDataOffset+16]
 			if bytes.Equal(streamType, streamTypeWMA) {
 				isWMAStreamFound = true
 			}
@@ -233,6 +242,6 @@ func ScanWMA(r *Reader) (*ScanResult, error) {
 	}
 
 	// If the calculated total file size extends beyond the buffer, it's a truncated file.
-	return &ScanResult{Size: totalFileSize}, nil
+	return &ScanResult{Size: totalFileSize, Confidence: ConfidenceStructural}, nil
 
 }
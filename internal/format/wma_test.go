@@ -0,0 +1,79 @@
+package format
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+)
+
+// asfObject returns an ASF object with the given GUID and data.
+func asfObject(guid []byte, data []byte) []byte {
+	obj := append([]byte{}, guid...)
+	obj = binary.LittleEndian.AppendUint64(obj, uint64(len(guid)+8+len(data)))
+	return append(obj, data...)
+}
+
+// asfFile returns an ASF file having a File Properties and a Stream Properties
+// object of the given stream type, followed by dataSize bytes of data.
+func asfFile(streamType []byte, dataSize int) []byte {
+	// File Properties Object (104 bytes): FileID, FileSize, CreationDate, DataPacketsCount,
+	// PlayDuration, SendDuration, Preroll, Flags, MinPacketSize, MaxPacketSize, MaxBitrate.
+	filePropData := make([]byte, 80)
+
+	// Stream Properties Object (78 bytes): StreamType, ErrorCorrectionType, TimeOffset,
+	// TypeSpecificDataLength, ErrorCorrectionDataLength, Flags, Reserved.
+	streamPropData := append(append([]byte{}, streamType...), make([]byte, 38)...)
+
+	paddingGUID := bytes.Repeat([]byte{0xAB}, 16)
+
+	objects := [][]byte{
+		asfObject(asfFilePropGUID, filePropData),
+		asfObject(asfStreamPropGUID, streamPropData),
+		asfObject(paddingGUID, nil),
+		asfObject(paddingGUID, nil),
+	}
+
+	headerSize := minASFHeaderObjSize
+	for _, obj := range objects {
+		headerSize += len(obj)
+	}
+	fileSize := headerSize + dataSize
+
+	// FileSize is right after the FileID of the File Properties Object.
+	binary.LittleEndian.PutUint64(objects[0][filePropFileSizeOffset:], uint64(fileSize))
+
+	header := append([]byte{}, asfHeaderGUID...)
+	header = binary.LittleEndian.AppendUint64(header, uint64(headerSize))
+	header = binary.LittleEndian.AppendUint32(header, uint32(len(objects)))
+	header = append(header, 0x01, 0x02)
+
+	file := bytes.Join(append([][]byte{header}, objects...), nil)
+	return append(file, make([]byte, dataSize)...)
+}
+
+func TestScanWMA(t *testing.T) {
+	file := asfFile(streamTypeWMA, 100)
+
+	data := append(file, bytes.Repeat([]byte{0xFF}, 64)...)
+
+	res, err := ScanWMA(newBytesReader(data))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if res.Size != uint64(len(file)) {
+		t.Fatalf("expected size %d, got %d", len(file), res.Size)
+	}
+}
+
+func TestScanWMANoAudioStream(t *testing.T) {
+	// Video Media stream type: {BC19EFC0-5B4D-11CF-A8FD-00805F5C442B}
+	streamTypeVideo := []byte{
+		0xC0, 0xEF, 0x19, 0xBC, 0x4D, 0x5B, 0xCF, 0x11,
+		0xA8, 0xFD, 0x00, 0x80, 0x5F, 0x5C, 0x44, 0x2B,
+	}
+
+	_, err := ScanWMA(newBytesReader(asfFile(streamTypeVideo, 100)))
+	if err == nil {
+		t.Fatalf("expected an error for an ASF file without audio streams")
+	}
+}
@@ -22,16 +22,23 @@ package format
 
 import (
 	"bytes"
+	"compress/flate"
 	"encoding/binary"
+	"encoding/xml"
 	"errors"
 	"fmt"
+	"io"
 	"math"
+	"path"
+	"strings"
 	"unsafe"
 )
 
 var zipFileHeader = FileHeader{
 	Ext:         "zip",
 	Description: "Archive File Format for Lossless Data Compression",
+	Category:    CategoryDocument,
+	Entropy:     EntropyHigh,
 	Signatures: [][]byte{
 		{'P', 'K', 0x03, 0x04},
 		{'P', 'K', '0', '0', 'P', 'K', 0x03, 0x04},
@@ -66,8 +73,19 @@ const (
 	// and sizes are not known at the time the local file header is written.
 	ZipDataDescriptorHeader uint32 = 0x08074B50
 
+	// zip64Version is the version needed to extract ZIP64 entries (4.5).
+	zip64Version = 45
+	// zip64ExtraID is the header ID of the ZIP64 extended information extra field.
+	zip64ExtraID = 0x0001
+
 	// ZipFileEntrySize defines the fixed size of the ZipFileEntry struct in bytes.
 	ZipFileEntrySize = int(unsafe.Sizeof(ZipFileEntry{}))
+
+	// zipCorePropsName is the name of the entry holding the properties of OOXML documents.
+	zipCorePropsName = "docProps/core.xml"
+	// zipMaxCorePropsSize is the maximum size of the properties of OOXML documents, read
+	// for their title.
+	zipMaxCorePropsSize = 64 * 1024
 )
 
 // ZipFileEntry represents the structure of a local file header in a ZIP file.
@@ -85,11 +103,32 @@ type ZipFileEntry struct {
 	ExtraLength      uint16 // Length of extra field
 }
 
+// ZIPOptions controls how thoroughly ZIP archives (and OOXML documents) are validated.
+type ZIPOptions struct {
+	// VerifyCentralDir walks the central directory, rejecting archives whose entries
+	// do not match the local file headers. Archives passing the check are reported
+	// as fully validated.
+	VerifyCentralDir bool
+}
+
+// NewZIPScanner returns a ZIP scanner which validates archives according to opts.
+func NewZIPScanner(opts ZIPOptions) FileScanner {
+	hdr := zipFileHeader
+	hdr.ScanFile = func(r *Reader) (*ScanResult, error) {
+		return scanZIP(r, opts)
+	}
+	return &headerFileScanner{hdr: hdr}
+}
+
 // ScanZIP scans a byte slice for ZIP file content and estimates the total ZIP size.
 // It reads the initial bytes to identify the ZIP signature (standard or WinZIPv8)
 // and then iteratively parses file entries and central directory records to
 // determine the total size of the ZIP archive.
 func ScanZIP(r *Reader) (*ScanResult, error) {
+	return scanZIP(r, ZIPOptions{})
+}
+
+func scanZIP(r *Reader, opts ZIPOptions) (*ScanResult, error) {
 	var dec zipDecoder
 
 	if err := dec.readHeader(r); err != nil {
@@ -107,19 +146,38 @@ func ScanZIP(r *Reader) (*ScanResult, error) {
 
 		switch hdr := binary.LittleEndian.Uint32(hdrBuf[:]); hdr {
 		case ZipFileEntryHeader:
+			if opts.VerifyCentralDir {
+				dec.localHeaders = append(dec.localHeaders, r.BytesRead()-uint64(len(hdrBuf)))
+			}
 			err = dec.parseZipFileEntry(r)
 			entries++
 		case ZipCentralDirHeader:
 			if entries == 0 {
 				return nil, fmt.Errorf("%w: zip file doesn't contain any file", ErrInvalidZip)
 			}
+
+			if opts.VerifyCentralDir {
+				size, err := dec.verifyZipCentralDir(r)
+				if err != nil {
+					return nil, err
+				}
+				return &ScanResult{
+					Name:       dec.name(),
+					Size:       size,
+					Ext:        dec.inferExt(),
+					Confidence: ConfidenceFullyValidated,
+				}, nil
+			}
+
 			size, err := dec.parseZipCentralDir(r)
 			if err != nil {
 				return nil, err
 			}
 			return &ScanResult{
-				Size: size,
-				Ext:  dec.inferExt(),
+				Name:       dec.name(),
+				Size:       size,
+				Ext:        dec.inferExt(),
+				Confidence: ConfidenceStructural,
 			}, nil
 		default:
 			return nil, ErrInvalidZip
@@ -131,11 +189,23 @@ func ScanZIP(r *Reader) (*ScanResult, error) {
 }
 
 type zipDecoder struct {
+	// localHeaders are the offsets of the local file headers, when verifying the central directory.
+	localHeaders []uint64
+
 	contentTypesSeen    bool
 	relsSeen            bool
 	wordDocumentSeen    bool
 	pptPresentationSeen bool
 	xlWorkbookSeen      bool
+
+	// title is the title of an OOXML document, read from its properties.
+	title string
+
+	// entries counts the entries, other than metadata added by archivers, and rootDir
+	// is the top-level directory shared by all of them, if any.
+	entries   int
+	firstName string
+	rootDir   string
 }
 
 func (d *zipDecoder) readHeader(r *Reader) error {
@@ -173,14 +243,20 @@ func (dec *zipDecoder) parseZipFileEntry(r *Reader) error {
 	if err != nil {
 		return err
 	}
-	dec.processFileName(string(filenameBuf[:entry.FilenameLength]))
-
-	// Discard the extra field bytes if ExtraLength is greater than 0.
+	name := string(filenameBuf[:entry.FilenameLength])
+	dec.processFileName(name)
+
+	// Entries larger than 4GiB use the ZIP64 format, which stores the sizes in an extra
+	// field, and in 8-byte fields of the data descriptor (APPNOTE 4.3.9). The version
+	// needed to extract is not enough to tell, since later features such as bzip2, AES
+	// and LZMA require higher versions, but keep 4-byte sizes.
+	var zip64 bool
 	if entry.ExtraLength > 0 {
-		_, err := r.Discard(int(entry.ExtraLength))
-		if err != nil {
+		extra := filenameBuf[:entry.ExtraLength]
+		if _, err := io.ReadFull(r, extra); err != nil {
 			return err
 		}
+		zip64 = hasZIP64Extra(extra)
 	}
 
 	size := entry.UncompressedSize
@@ -197,9 +273,15 @@ func (dec *zipDecoder) parseZipFileEntry(r *Reader) error {
 	}
 
 	// Handle the file data based on whether a data descriptor is present.
-	if hasDesc {
+	if !hasDesc && name == zipCorePropsName && size <= zipMaxCorePropsSize {
+		data := make([]byte, size)
+		if _, err := io.ReadFull(r, data); err != nil {
+			return err
+		}
+		dec.title = readCorePropsTitle(data, entry.Compression)
+	} else if hasDesc {
 		// If a data descriptor is present, seek to its signature.
-		err = seekToZIPDescriptor(r)
+		err = seekToZIPDescriptor(r, zip64)
 	} else if size > 0 {
 		// If no data descriptor and data size is known, discard the file data.
 		_, err = r.Discard(int(size))
@@ -207,9 +289,29 @@ func (dec *zipDecoder) parseZipFileEntry(r *Reader) error {
 	return err
 }
 
+// hasZIP64Extra reports whether the extra field of a file entry contains a ZIP64 record.
+func hasZIP64Extra(extra []byte) bool {
+	for len(extra) >= 4 {
+		id := binary.LittleEndian.Uint16(extra)
+		if id == zip64ExtraID {
+			return true
+		}
+
+		size := int(binary.LittleEndian.Uint16(extra[2:]))
+		if 4+size > len(extra) {
+			return false
+		}
+		extra = extra[4+size:]
+	}
+	return false
+}
+
 // seekToZIPDescriptor searches for the data descriptor signature and discards
 // the bytes until it's found. It then verifies the descriptor's signature.
-func seekToZIPDescriptor(r *Reader) error {
+// The descriptors of ZIP64 entries have 8-byte sizes. Since some writers do not follow
+// the rule, the other size is chosen when only the descriptor of that size is followed
+// by a ZIP record.
+func seekToZIPDescriptor(r *Reader, zip64 bool) error {
 	var zipDescriptorSignature = []byte{0x50, 0x4B, 0x07, 0x08}
 
 	seeked, err := SeekAt(r, zipDescriptorSignature, MaxZipFileSize)
@@ -220,10 +322,18 @@ func seekToZIPDescriptor(r *Reader) error {
 		return fmt.Errorf("zip entry descriptor not found")
 	}
 
-	// Read 16 bytes (CRC-32, Compressed Size, Uncompressed Size) of the data descriptor.
-	// The signature itself is 4 bytes, followed by 12 bytes of data.
-	var descBuf [16]byte
-	if _, err := r.Read(descBuf[:]); err != nil {
+	// Read the signature (4 bytes), followed by the CRC-32 (4 bytes) and by the
+	// compressed and uncompressed sizes (4 bytes each, or 8 bytes for ZIP64).
+	var descBuf [24]byte
+
+	descLen, altLen := 16, 24
+	if zip64 {
+		descLen, altLen = 24, 16
+	}
+	if next, _ := r.Peek(24 + 4); !isZIPRecord(next, descLen) && isZIPRecord(next, altLen) {
+		descLen = altLen
+	}
+	if _, err := io.ReadFull(r, descBuf[:descLen]); err != nil {
 		return err
 	}
 
@@ -238,6 +348,19 @@ func seekToZIPDescriptor(r *Reader) error {
 	return nil
 }
 
+// isZIPRecord reports whether data holds the signature of a ZIP record at the given offset.
+func isZIPRecord(data []byte, offset int) bool {
+	if len(data) < offset+4 || data[offset] != 'P' || data[offset+1] != 'K' {
+		return false
+	}
+
+	switch [2]byte{data[offset+2], data[offset+3]} {
+	case [2]byte{1, 2}, [2]byte{3, 4}, [2]byte{5, 6}, [2]byte{6, 6}, [2]byte{6, 7}, [2]byte{7, 8}:
+		return true
+	}
+	return false
+}
+
 // parseZipCentralDir parses the central directory record of a ZIP file.
 // It searches for the end of central directory (EOCD) signature and reads
 // the EOCD record to determine the total size of the ZIP archive.
@@ -269,7 +392,101 @@ func (dec *zipDecoder) parseZipCentralDir(r *Reader) (uint64, error) {
 	return r.BytesRead() + uint64(commentLen), nil
 }
 
+// verifyZipCentralDir parses the central directory of a ZIP file, whose first header
+// signature has just been read, up to the end of central directory (EOCD) record.
+// It checks that the central directory lists exactly the local file headers found,
+// in the same order, and that the EOCD record agrees on their number and location.
+// It returns the total size of the ZIP archive.
+func (dec *zipDecoder) verifyZipCentralDir(r *Reader) (uint64, error) {
+	// Offset of the central directory, whose first signature was read.
+	cdOffset := r.BytesRead() - 4
+
+	// Fixed part of a central directory file header, following the signature.
+	var hdr [42]byte
+	var sig uint32
+
+	entries := 0
+	for {
+		if _, err := io.ReadFull(r, hdr[:]); err != nil {
+			return 0, err
+		}
+
+		if entries >= len(dec.localHeaders) {
+			return 0, fmt.Errorf("%w: central directory lists more than %d files", ErrInvalidZip, len(dec.localHeaders))
+		}
+
+		offset := binary.LittleEndian.Uint32(hdr[38:])
+		if offset != math.MaxUint32 && uint64(offset) != dec.localHeaders[entries] {
+			return 0, fmt.Errorf("%w: central directory entry %d points at offset %d, not at a local file header", ErrInvalidZip, entries, offset)
+		}
+		entries++
+
+		// Skip the file name, extra field and comment.
+		varLen := int(binary.LittleEndian.Uint16(hdr[24:])) +
+			int(binary.LittleEndian.Uint16(hdr[26:])) +
+			int(binary.LittleEndian.Uint16(hdr[28:]))
+		if _, err := r.Discard(varLen); err != nil {
+			return 0, err
+		}
+
+		var sigBuf [4]byte
+		if _, err := io.ReadFull(r, sigBuf[:]); err != nil {
+			return 0, err
+		}
+
+		sig = binary.LittleEndian.Uint32(sigBuf[:])
+		if sig != ZipCentralDirHeader {
+			break
+		}
+	}
+
+	if entries != len(dec.localHeaders) {
+		return 0, fmt.Errorf("%w: central directory lists %d of %d files", ErrInvalidZip, entries, len(dec.localHeaders))
+	}
+
+	switch sig {
+	case ZipEndCentralDirHeader:
+	case ZipCentralDir64Header:
+		// The counts of ZIP64 archives are stored in the ZIP64 records: since the
+		// central directory entries were checked, locate the EOCD as usual.
+		return dec.parseZipCentralDir(r)
+	default:
+		return 0, fmt.Errorf("%w: unexpected signature 0x%08x in central directory", ErrInvalidZip, sig)
+	}
+
+	// Rest of the EOCD record, following the signature.
+	var eocd [18]byte
+	if _, err := io.ReadFull(r, eocd[:]); err != nil {
+		return 0, err
+	}
+
+	total := binary.LittleEndian.Uint16(eocd[6:])
+	if total != math.MaxUint16 && int(total) != entries {
+		return 0, fmt.Errorf("%w: end of central directory counts %d files, %d found", ErrInvalidZip, total, entries)
+	}
+
+	offset := binary.LittleEndian.Uint32(eocd[12:])
+	if offset != math.MaxUint32 && uint64(offset) != cdOffset {
+		return 0, fmt.Errorf("%w: end of central directory points at offset %d, not at the central directory", ErrInvalidZip, offset)
+	}
+
+	commentLen := binary.LittleEndian.Uint16(eocd[16:])
+	return r.BytesRead() + uint64(commentLen), nil
+}
+
 func (dec *zipDecoder) processFileName(name string) {
+	if !isZIPMetadataEntry(name) {
+		dir, _, inDir := strings.Cut(name, "/")
+		if !inDir || (dec.entries > 0 && dir != dec.rootDir) {
+			dir = ""
+		}
+		if dec.entries == 0 {
+			dec.firstName = name
+		}
+		dec.rootDir = dir
+		dec.entries++
+	}
+
 	switch name {
 	case "[Content_Types].xml":
 		dec.contentTypesSeen = true
@@ -284,6 +501,57 @@ func (dec *zipDecoder) processFileName(name string) {
 	}
 }
 
+// isZIPMetadataEntry reports whether an entry holds metadata added by an archiver or
+// by the OS, rather than content of the archive.
+func isZIPMetadataEntry(name string) bool {
+	switch path.Base(name) {
+	case ".DS_Store", "Thumbs.db", "desktop.ini":
+		return true
+	}
+	return strings.HasPrefix(name, "__MACOSX/")
+}
+
+// name returns the name of the archive after its content: the title of an OOXML document,
+// the top-level directory shared by all entries of a plain archive, or its only entry.
+// It returns an empty string if there is no such name.
+func (dec *zipDecoder) name() string {
+	ext := dec.inferExt()
+	if ext != "zip" {
+		return metadataName(dec.title, ext)
+	}
+
+	if dec.rootDir != "" {
+		return metadataName(dec.rootDir, ext)
+	}
+	if dec.entries == 1 {
+		base := path.Base(dec.firstName)
+		return metadataName(strings.TrimSuffix(base, path.Ext(base)), ext)
+	}
+	return ""
+}
+
+// readCorePropsTitle returns the title in the properties of an OOXML document, stored
+// with the given compression method, or an empty string if it cannot be read.
+func readCorePropsTitle(data []byte, compression uint16) string {
+	var r io.Reader
+	switch compression {
+	case 0: // stored
+		r = bytes.NewReader(data)
+	case 8: // deflated
+		r = flate.NewReader(bytes.NewReader(data))
+	default:
+		return ""
+	}
+
+	var props struct {
+		Title string `xml:"title"`
+	}
+	if err := xml.NewDecoder(io.LimitReader(r, 4*zipMaxCorePropsSize)).Decode(&props); err != nil {
+		return ""
+	}
+	return props.Title
+}
+
 func (dec *zipDecoder) inferExt() string {
 	isOfficeDocType := dec.contentTypesSeen && dec.relsSeen
 
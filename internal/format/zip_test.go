@@ -0,0 +1,281 @@
+package format
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/flate"
+	"encoding/binary"
+	"hash/crc32"
+	"testing"
+)
+
+// zipArchive returns a ZIP archive with the given files, followed by some padding,
+// and the size of the archive.
+func zipArchive(t *testing.T, names ...string) ([]byte, int) {
+	var buf bytes.Buffer
+
+	w := zip.NewWriter(&buf)
+	for _, name := range names {
+		f, err := w.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := f.Write([]byte("contents of " + name)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	size := buf.Len()
+	buf.Write(make([]byte, 64))
+	return buf.Bytes(), size
+}
+
+// eocdOffset returns the offset of the end of central directory record of a ZIP archive of the given size.
+func eocdOffset(size int) int {
+	return size - 22
+}
+
+func TestScanZIPVerifyCentralDir(t *testing.T) {
+	data, size := zipArchive(t, "a.txt", "b.txt", "c.txt")
+
+	res, err := scanZIP(newBytesReader(data), ZIPOptions{VerifyCentralDir: true})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if res.Size != uint64(size) {
+		t.Errorf("expected size %d, got %d", size, res.Size)
+	}
+	if res.Confidence != ConfidenceFullyValidated {
+		t.Errorf("expected confidence %s, got %s", ConfidenceFullyValidated, res.Confidence)
+	}
+
+	res, err = ScanZIP(newBytesReader(data))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if res.Size != uint64(size) || res.Confidence != ConfidenceStructural {
+		t.Errorf("expected size %d and confidence %s, got %d and %s", size, ConfidenceStructural, res.Size, res.Confidence)
+	}
+}
+
+func TestScanZIPVerifyCentralDirMismatch(t *testing.T) {
+	tests := []struct {
+		name    string
+		corrupt func(data []byte, size int)
+	}{
+		{
+			name: "entry count",
+			corrupt: func(data []byte, size int) {
+				binary.LittleEndian.PutUint16(data[eocdOffset(size)+10:], 4)
+			},
+		},
+		{
+			name: "local header offset",
+			corrupt: func(data []byte, size int) {
+				cd := int(binary.LittleEndian.Uint32(data[eocdOffset(size)+16:]))
+				binary.LittleEndian.PutUint32(data[cd+42:], 1)
+			},
+		},
+		{
+			name: "central directory offset",
+			corrupt: func(data []byte, size int) {
+				binary.LittleEndian.PutUint32(data[eocdOffset(size)+16:], 0)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, size := zipArchive(t, "a.txt", "b.txt", "c.txt")
+			tt.corrupt(data, size)
+
+			if _, err := ScanZIP(newBytesReader(data)); err != nil {
+				t.Fatalf("unexpected error without verification: %v", err)
+			}
+
+			if _, err := scanZIP(newBytesReader(data), ZIPOptions{VerifyCentralDir: true}); err == nil {
+				t.Errorf("expected an error when verifying the central directory")
+			}
+		})
+	}
+}
+
+// streamedZIPArchive returns a ZIP archive with a single entry, whose sizes are stored
+// in a data descriptor following the data, and the size of the archive. The descriptor
+// has 8-byte sizes when zip64Desc is set.
+func streamedZIPArchive(version uint16, zip64Extra, zip64Desc bool) ([]byte, int) {
+	le := binary.LittleEndian
+	name, contents := []byte("a.txt"), []byte("hello")
+
+	var extra []byte
+	if zip64Extra {
+		// A ZIP64 record with no sizes, as sizes follow in the descriptor.
+		extra = le.AppendUint16(le.AppendUint16(nil, zip64ExtraID), 0)
+	}
+
+	var buf []byte
+	buf = le.AppendUint32(buf, ZipFileEntryHeader)
+	buf = le.AppendUint16(buf, version)
+	buf = le.AppendUint16(buf, 0x0008) // sizes in the data descriptor
+	buf = append(buf, make([]byte, 2+2+2+4+4+4)...)
+	buf = le.AppendUint16(buf, uint16(len(name)))
+	buf = le.AppendUint16(buf, uint16(len(extra)))
+	buf = append(buf, name...)
+	buf = append(buf, extra...)
+	buf = append(buf, contents...)
+
+	buf = le.AppendUint32(buf, ZipDataDescriptorHeader)
+	buf = le.AppendUint32(buf, 0) // CRC-32
+	if zip64Desc {
+		buf = le.AppendUint64(buf, uint64(len(contents)))
+		buf = le.AppendUint64(buf, uint64(len(contents)))
+	} else {
+		buf = le.AppendUint32(buf, uint32(len(contents)))
+		buf = le.AppendUint32(buf, uint32(len(contents)))
+	}
+
+	cdOffset := len(buf)
+	buf = le.AppendUint32(buf, ZipCentralDirHeader)
+	buf = append(buf, make([]byte, 24)...)
+	buf = le.AppendUint16(buf, uint16(len(name)))
+	buf = append(buf, make([]byte, 12)...)
+	buf = le.AppendUint32(buf, 0) // offset of the local file header
+	buf = append(buf, name...)
+	cdSize := len(buf) - cdOffset
+
+	buf = le.AppendUint32(buf, ZipEndCentralDirHeader)
+	buf = append(buf, make([]byte, 4)...)
+	buf = le.AppendUint16(buf, 1)
+	buf = le.AppendUint16(buf, 1)
+	buf = le.AppendUint32(buf, uint32(cdSize))
+	buf = le.AppendUint32(buf, uint32(cdOffset))
+	buf = le.AppendUint16(buf, 0)
+
+	size := len(buf)
+	return append(buf, make([]byte, 64)...), size
+}
+
+func TestScanZIPDataDescriptor(t *testing.T) {
+	tests := []struct {
+		name       string
+		version    uint16
+		zip64Extra bool
+		zip64Desc  bool
+	}{
+		{"ZIP64 extra field", 20, true, true},
+		{"ZIP64 version without extra field", zip64Version, false, true},
+		{"deflate", 20, false, false},
+		{"bzip2", 46, false, false},
+		{"LZMA", 63, false, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, size := streamedZIPArchive(tt.version, tt.zip64Extra, tt.zip64Desc)
+
+			for _, opts := range []ZIPOptions{{}, {VerifyCentralDir: true}} {
+				res, err := scanZIP(newBytesReader(data), opts)
+				if err != nil {
+					t.Fatalf("%+v: unexpected error: %v", opts, err)
+				}
+				if res.Size != uint64(size) {
+					t.Errorf("%+v: expected size %d, got %d", opts, size, res.Size)
+				}
+			}
+		})
+	}
+}
+
+// docxArchive returns a DOCX document with the given title, whose properties are stored
+// with sizes in their local header, as office suites do, and the size of the archive.
+func docxArchive(t *testing.T, title string) ([]byte, int) {
+	var buf bytes.Buffer
+
+	w := zip.NewWriter(&buf)
+	for _, name := range []string{"[Content_Types].xml", "_rels/.rels", "word/document.xml"} {
+		if _, err := w.Create(name); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	props := []byte(`<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<cp:coreProperties xmlns:cp="http://schemas.openxmlformats.org/package/2006/metadata/core-properties" xmlns:dc="http://purl.org/dc/elements/1.1/">
+<dc:title>` + title + `</dc:title><dc:creator>someone</dc:creator></cp:coreProperties>`)
+
+	var compressed bytes.Buffer
+	fw, _ := flate.NewWriter(&compressed, flate.DefaultCompression)
+	fw.Write(props)
+	fw.Close()
+
+	f, err := w.CreateRaw(&zip.FileHeader{
+		Name:               zipCorePropsName,
+		Method:             zip.Deflate,
+		CRC32:              crc32.ChecksumIEEE(props),
+		CompressedSize64:   uint64(compressed.Len()),
+		UncompressedSize64: uint64(len(props)),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.Write(compressed.Bytes()); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	size := buf.Len()
+	buf.Write(make([]byte, 64))
+	return buf.Bytes(), size
+}
+
+func TestScanZIPName(t *testing.T) {
+	tests := []struct {
+		name  string
+		names []string
+		want  string
+	}{
+		{"root directory", []string{"photos/a.jpg", "photos/2024/b.jpg", "__MACOSX/photos/._a.jpg"}, "photos.zip"},
+		{"files and directories", []string{"docs/a.txt", "report.txt"}, ""},
+		{"single file", []string{"report.txt", ".DS_Store"}, "report.zip"},
+		{"several files", []string{"a.txt", "b.txt"}, ""},
+		{"several directories", []string{"a/1.txt", "b/2.txt"}, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, size := zipArchive(t, tt.names...)
+
+			res, err := ScanZIP(newBytesReader(data))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if res.Size != uint64(size) {
+				t.Errorf("expected size %d, got %d", size, res.Size)
+			}
+			if res.Name != tt.want {
+				t.Errorf("expected name %q, got %q", tt.want, res.Name)
+			}
+		})
+	}
+}
+
+func TestScanZIPDocumentTitle(t *testing.T) {
+	data, size := docxArchive(t, "Quarterly report: Q3/2024 &amp; outlook")
+
+	for _, opts := range []ZIPOptions{{}, {VerifyCentralDir: true}} {
+		res, err := scanZIP(newBytesReader(data), opts)
+		if err != nil {
+			t.Fatalf("%+v: unexpected error: %v", opts, err)
+		}
+		if res.Size != uint64(size) || res.Ext != "docx" {
+			t.Errorf("%+v: expected size %d and ext docx, got %d and %s", opts, size, res.Size, res.Ext)
+		}
+		if want := "Quarterly report_ Q3_2024 & outlook.docx"; res.Name != want {
+			t.Errorf("%+v: expected name %q, got %q", opts, want, res.Name)
+		}
+	}
+}
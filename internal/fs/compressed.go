@@ -0,0 +1,172 @@
+// Copyright (c) 2025 Stefano Scafiti
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package fs
+
+import (
+	"bytes"
+	"compress/bzip2"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+
+	"github.com/ulikunitz/xz"
+)
+
+// compression describes a compression format of image files.
+type compression struct {
+	name      string
+	magic     []byte
+	newReader func(r io.Reader) (io.Reader, error)
+}
+
+var compressions = []compression{
+	{
+		name:  "gzip",
+		magic: []byte{0x1f, 0x8b},
+		newReader: func(r io.Reader) (io.Reader, error) {
+			return gzip.NewReader(r)
+		},
+	},
+	{
+		name:  "bzip2",
+		magic: []byte("BZh"),
+		newReader: func(r io.Reader) (io.Reader, error) {
+			return bzip2.NewReader(r), nil
+		},
+	},
+	{
+		name:  "xz",
+		magic: []byte{0xfd, '7', 'z', 'X', 'Z', 0x00},
+		newReader: func(r io.Reader) (io.Reader, error) {
+			return xz.NewReader(r)
+		},
+	},
+}
+
+var (
+	decompressedMu sync.Mutex
+	decompressed   = map[string]string{} // path of a compressed image (or StdinPath) -> path of its temporary copy
+)
+
+// resolveCompressed returns the path of the decompressed copy of the image at path,
+// if it is a compressed regular file, or path itself otherwise. Since compressed
+// streams are not seekable, the image is decompressed once to a temporary file,
+// which is reused by later calls and deleted by RemoveDecompressed. The standard
+// input is copied first (see resolveStdin), so compressed streams are supported too.
+func resolveCompressed(path string) (string, error) {
+	path, err := resolveStdin(path)
+	if err != nil {
+		return "", err
+	}
+
+	finfo, err := os.Stat(path)
+	if err != nil || !finfo.Mode().IsRegular() {
+		return path, nil // let the caller report errors, and never decompress raw devices
+	}
+
+	decompressedMu.Lock()
+	defer decompressedMu.Unlock()
+
+	if tmpPath, ok := decompressed[path]; ok {
+		return tmpPath, nil
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return "", newOpenError(path, err)
+	}
+	defer f.Close()
+
+	var magic [8]byte
+	n, err := io.ReadFull(f, magic[:])
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return "", err
+	}
+
+	c := detectCompression(magic[:n])
+	if c == nil {
+		return path, nil
+	}
+
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return "", err
+	}
+
+	tmpPath, err := decompressTo(f, c)
+	if err != nil {
+		return "", fmt.Errorf("unable to decompress %s image %q: %w (decompress it manually, or set TMPDIR to a directory with enough free space)", c.name, path, err)
+	}
+
+	decompressed[path] = tmpPath
+	return tmpPath, nil
+}
+
+func detectCompression(magic []byte) *compression {
+	for i := range compressions {
+		if bytes.HasPrefix(magic, compressions[i].magic) {
+			return &compressions[i]
+		}
+	}
+	return nil
+}
+
+// decompressTo decompresses r to a new temporary file, and returns its path.
+func decompressTo(r io.Reader, c *compression) (string, error) {
+	zr, err := c.newReader(r)
+	if err != nil {
+		return "", err
+	}
+	return copyToTemp(zr)
+}
+
+// copyToTemp copies r to a new temporary file, and returns its path.
+func copyToTemp(r io.Reader) (string, error) {
+	tmp, err := os.CreateTemp("", "digler-*.img")
+	if err != nil {
+		return "", err
+	}
+
+	_, err = io.Copy(tmp, r)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		if isNoSpaceError(err) {
+			return "", fmt.Errorf("not enough free space in the temporary directory %q: %w", os.TempDir(), err)
+		}
+		return "", err
+	}
+	return tmp.Name(), nil
+}
+
+// RemoveDecompressed deletes the temporary files holding decompressed images
+// and the copy of the standard input.
+func RemoveDecompressed() {
+	decompressedMu.Lock()
+	defer decompressedMu.Unlock()
+
+	for path, tmpPath := range decompressed {
+		os.Remove(tmpPath)
+		delete(decompressed, path)
+	}
+}
@@ -0,0 +1,100 @@
+package fs
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ulikunitz/xz"
+)
+
+func TestOpenCompressed(t *testing.T) {
+	tests := []struct {
+		name      string
+		newWriter func(w io.Writer) (io.WriteCloser, error)
+	}{
+		{"gzip", func(w io.Writer) (io.WriteCloser, error) { return gzip.NewWriter(w), nil }},
+		{"xz", func(w io.Writer) (io.WriteCloser, error) { return xz.NewWriter(w) }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			testOpenCompressed(t, tt.newWriter)
+		})
+	}
+}
+
+func testOpenCompressed(t *testing.T, newWriter func(w io.Writer) (io.WriteCloser, error)) {
+	data := bytes.Repeat([]byte("digler"), 10000)
+
+	var buf bytes.Buffer
+	zw, err := newWriter(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	zw.Write(data)
+	zw.Close()
+
+	path := filepath.Join(t.TempDir(), "disk.img.z")
+	if err := os.WriteFile(path, buf.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+	defer RemoveDecompressed()
+
+	for _, open := range []func(string) (File, error){Open, OpenMmap} {
+		f, err := open(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		finfo, err := f.Stat()
+		if err != nil || finfo.Size() != int64(len(data)) {
+			t.Fatalf("Stat() = %v, %v, want size %d", finfo, err, len(data))
+		}
+
+		got := make([]byte, 100)
+		if _, err := f.ReadAt(got, 5000); err != nil || !bytes.Equal(got, data[5000:5100]) {
+			t.Fatalf("ReadAt returned %q, %v", got, err)
+		}
+		f.Close()
+	}
+
+	tmpPath := decompressed[path]
+
+	RemoveDecompressed()
+	if _, err := os.Stat(tmpPath); !os.IsNotExist(err) {
+		t.Fatalf("decompressed image %q was not removed", tmpPath)
+	}
+}
+
+func TestOpenUncompressed(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "disk.img")
+	if err := os.WriteFile(path, []byte("plain image"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	data, err := io.ReadAll(f)
+	if err != nil || string(data) != "plain image" {
+		t.Fatalf("ReadAll() = %q, %v", data, err)
+	}
+}
+
+func TestOpenCorruptCompression(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "disk.img.xz")
+	if err := os.WriteFile(path, []byte{0xfd, '7', 'z', 'X', 'Z', 0x00, 0, 0}, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := Open(path); err == nil {
+		t.Fatal("expected an error for a corrupt xz-compressed image")
+	}
+}
@@ -0,0 +1,91 @@
+// Copyright (c) 2025 Stefano Scafiti
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package fs
+
+import (
+	"errors"
+	"fmt"
+	iofs "io/fs"
+	"os"
+)
+
+// Errors returned by Open and OpenMmap, which wrap them in an *OpenError, so that
+// callers can tell the causes apart with errors.Is, on every platform.
+var (
+	// ErrDeviceBusy is returned when the device is in use, e.g. locked by another program.
+	ErrDeviceBusy = errors.New("device or resource busy")
+
+	// ErrNoMedium is returned when a removable drive, such as a card reader, holds no medium.
+	ErrNoMedium = errors.New("no medium found")
+
+	// ErrPermission is returned when the user is not allowed to read the image or device.
+	ErrPermission = errors.New("permission denied")
+
+	// ErrNotADevice is returned when the path names neither a disk image nor a device,
+	// e.g. a directory.
+	ErrNotADevice = errors.New("not a disk image or device")
+)
+
+// OpenError records the failure to open a disk image or device.
+type OpenError struct {
+	Path string
+	Kind error // One of the errors above, or nil if the cause is not classified.
+	Err  error // The underlying error.
+}
+
+func (e *OpenError) Error() string {
+	cause := e.Err
+
+	// The path is already part of the message.
+	var pathErr *os.PathError
+	if errors.As(cause, &pathErr) {
+		cause = pathErr.Err
+	}
+	return fmt.Sprintf("failed to open %q: %v", e.Path, cause)
+}
+
+func (e *OpenError) Unwrap() []error {
+	if e.Kind == nil {
+		return []error{e.Err}
+	}
+	return []error{e.Kind, e.Err}
+}
+
+// newOpenError returns the error of opening the image or device at path, classifying err.
+func newOpenError(path string, err error) *OpenError {
+	kind := platformErrorKind(err)
+	if kind == nil && errors.Is(err, iofs.ErrPermission) {
+		kind = ErrPermission
+	}
+	return &OpenError{Path: path, Kind: kind, Err: err}
+}
+
+// checkFileMode returns an error if finfo describes a file which cannot be scanned:
+// a directory, a named pipe or a socket.
+func checkFileMode(path string, finfo os.FileInfo) error {
+	mode := finfo.Mode()
+	switch {
+	case mode.IsDir():
+		return &OpenError{Path: path, Kind: ErrNotADevice, Err: errors.New("is a directory")}
+	case mode&(os.ModeNamedPipe|os.ModeSocket) != 0:
+		return &OpenError{Path: path, Kind: ErrNotADevice, Err: errors.New("is neither a regular file nor a device")}
+	}
+	return nil
+}
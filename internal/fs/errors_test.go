@@ -0,0 +1,53 @@
+//go:build !windows
+
+package fs
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+)
+
+func TestOpenErrors(t *testing.T) {
+	dir := t.TempDir()
+
+	_, err := Open(dir)
+	if !errors.Is(err, ErrNotADevice) {
+		t.Errorf("expected ErrNotADevice for a directory, got %v", err)
+	}
+
+	_, err = Open(filepath.Join(dir, "missing.img"))
+	var openErr *OpenError
+	if !errors.As(err, &openErr) || openErr.Kind != nil || !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("expected an unclassified error wrapping os.ErrNotExist, got %v", err)
+	}
+}
+
+func TestNewOpenError(t *testing.T) {
+	tests := []struct {
+		errno syscall.Errno
+		want  error
+	}{
+		{syscall.EBUSY, ErrDeviceBusy},
+		{syscall.ENXIO, ErrNoMedium},
+		{syscall.EACCES, ErrPermission},
+		{syscall.EPERM, ErrPermission},
+		{syscall.EISDIR, ErrNotADevice},
+		{syscall.EIO, nil},
+	}
+
+	for _, tt := range tests {
+		err := newOpenError("/dev/sdz", &os.PathError{Op: "open", Path: "/dev/sdz", Err: tt.errno})
+		if err.Kind != tt.want {
+			t.Errorf("%v: got kind %v, want %v", tt.errno, err.Kind, tt.want)
+		}
+		if !errors.Is(err, tt.errno) {
+			t.Errorf("%v: the error does not wrap its cause", tt.errno)
+		}
+		if want := `failed to open "/dev/sdz": ` + tt.errno.Error(); err.Error() != want {
+			t.Errorf("got message %q, want %q", err.Error(), want)
+		}
+	}
+}
@@ -29,3 +29,10 @@ type File interface {
 	io.ReaderAt
 	Stat() (os.FileInfo, error)
 }
+
+// Drive describes a physical drive attached to the system.
+type Drive struct {
+	Path       string // Path to pass to Open
+	Size       uint64 // Size in bytes
+	SectorSize uint32 // Logical sector size in bytes
+}
@@ -0,0 +1,130 @@
+// Copyright (c) 2025 Stefano Scafiti
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package fs
+
+import (
+	"io"
+	"os"
+	"sync"
+
+	"github.com/ostafen/digler/internal/mmap"
+)
+
+// mmapWindowSize is the maximum size of the file region mapped in memory at a time, a
+// multiple of 64KiB, the offsets of mappings being aligned to it on Windows. Mapping
+// bounded windows allows to handle files larger than the addressable space.
+var mmapWindowSize int64 = 256 * 1024 * 1024
+
+// mmapFile is a File whose ReadAt is served from a memory mapped window of the underlying file.
+type mmapFile struct {
+	*os.File
+
+	size int64
+
+	mtx    sync.Mutex
+	window *mmap.MmapFile
+}
+
+// OpenMmap opens the file at path, serving random reads through memory mapping.
+// If the path does not refer to a regular file (e.g. a raw device), mapping is
+// not appropriate and a File backed by normal reads is returned instead.
+// Compressed image files are decompressed, and their decompressed copy is mapped.
+func OpenMmap(path string) (File, error) {
+	path, err := resolveCompressed(path)
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, newOpenError(path, err)
+	}
+
+	finfo, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	if !finfo.Mode().IsRegular() {
+		f.Close()
+		return Open(path)
+	}
+
+	return &mmapFile{
+		File: f,
+		size: finfo.Size(),
+	}, nil
+}
+
+func (m *mmapFile) ReadAt(p []byte, off int64) (int, error) {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+
+	n := 0
+	for n < len(p) {
+		pos := off + int64(n)
+		if pos >= m.size {
+			return n, io.EOF
+		}
+
+		if err := m.mapWindow(pos); err != nil {
+			return n, err
+		}
+		n += copy(p[n:], m.window.Data[pos-m.window.Offset:])
+	}
+	return n, nil
+}
+
+// mapWindow ensures that the currently mapped window contains the byte at offset pos.
+func (m *mmapFile) mapWindow(pos int64) error {
+	w := m.window
+	if w != nil && pos >= w.Offset && pos < w.Offset+int64(len(w.Data)) {
+		return nil
+	}
+
+	if w != nil {
+		if err := w.Close(); err != nil {
+			return err
+		}
+		m.window = nil
+	}
+
+	offset := pos - pos%mmapWindowSize
+	size := min(mmapWindowSize, m.size-offset)
+
+	w, err := mmap.NewMmapFileRegion(m.File, offset, int(size))
+	if err != nil {
+		return err
+	}
+	m.window = w
+	return nil
+}
+
+func (m *mmapFile) Close() error {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+
+	if m.window != nil {
+		_ = m.window.Close()
+		m.window = nil
+	}
+	return m.File.Close()
+}
@@ -0,0 +1,94 @@
+package fs
+
+import (
+	"bytes"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestMmapReadAt(t *testing.T) {
+	defer func(size int64) { mmapWindowSize = size }(mmapWindowSize)
+	mmapWindowSize = 64 * 1024
+
+	data := make([]byte, 3*mmapWindowSize+100)
+	rand.New(rand.NewSource(1)).Read(data)
+
+	path := filepath.Join(t.TempDir(), "disk.img")
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	m, err := OpenMmap(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer m.Close()
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	size := int64(len(data))
+	tests := []struct {
+		name string
+		off  int64
+		n    int
+	}{
+		{"within a window", 10, 100},
+		{"across a window boundary", mmapWindowSize - 10, 20},
+		{"across several windows", 10, int(2*mmapWindowSize + 50)},
+		{"back to the first window", 0, 10},
+		{"up to EOF", size - 10, 10},
+		{"short at EOF", size - 5, 10},
+		{"at EOF", size, 10},
+		{"past EOF", size + 100, 10},
+		{"empty", 20, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			want := make([]byte, tt.n)
+			wantN, wantErr := f.ReadAt(want, tt.off)
+
+			got := make([]byte, tt.n)
+			n, err := m.ReadAt(got, tt.off)
+
+			if n != wantN || err != wantErr {
+				t.Fatalf("ReadAt() = %d, %v, want %d, %v", n, err, wantN, wantErr)
+			}
+			if !bytes.Equal(got[:n], want[:wantN]) {
+				t.Errorf("ReadAt() returned different data")
+			}
+		})
+	}
+}
+
+func TestMmapEmptyFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "empty.img")
+	if err := os.WriteFile(path, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	m, err := OpenMmap(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer m.Close()
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	for _, off := range []int64{0, 10} {
+		wantN, wantErr := f.ReadAt(make([]byte, 10), off)
+		if n, err := m.ReadAt(make([]byte, 10), off); n != wantN || err != wantErr {
+			t.Errorf("ReadAt(%d) = %d, %v, want %d, %v", off, n, err, wantN, wantErr)
+		}
+	}
+}
@@ -23,8 +23,65 @@
 
 package fs
 
-import "os"
+import (
+	"errors"
+	"os"
+	"slices"
+	"syscall"
+)
 
+// Open opens the image file or device at path for reading.
+// Compressed image files are transparently decompressed (see resolveCompressed).
 func Open(path string) (File, error) {
-	return os.Open(path)
+	path, err := resolveCompressed(path)
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, newOpenError(path, err)
+	}
+
+	finfo, err := f.Stat()
+	if err == nil {
+		err = checkFileMode(path, finfo)
+	}
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	return f, nil
+}
+
+// platformErrorKind returns the kind of the error of opening an image or device
+// (see OpenError), or nil if it is not classified.
+func platformErrorKind(err error) error {
+	var errno syscall.Errno
+	if !errors.As(err, &errno) {
+		return nil
+	}
+
+	switch {
+	case errno == syscall.EBUSY:
+		return ErrDeviceBusy
+	case slices.Contains(noMediumErrnos, errno):
+		return ErrNoMedium
+	case errno == syscall.EACCES || errno == syscall.EPERM:
+		return ErrPermission
+	case errno == syscall.EISDIR:
+		return ErrNotADevice
+	}
+	return nil
+}
+
+// isNoSpaceError reports whether err is caused by a full disk.
+func isNoSpaceError(err error) bool {
+	return errors.Is(err, syscall.ENOSPC)
+}
+
+// PhysicalDrives returns the physical drives attached to the system.
+// It is only supported on Windows: elsewhere, devices can be found under /dev.
+func PhysicalDrives() ([]Drive, error) {
+	return nil, errors.New("listing physical drives is only supported on Windows")
 }
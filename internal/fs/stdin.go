@@ -0,0 +1,57 @@
+// Copyright (c) 2025 Stefano Scafiti
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package fs
+
+import (
+	"fmt"
+	"io"
+	"os"
+)
+
+// StdinPath is the image path standing for the standard input.
+const StdinPath = "-"
+
+// stdin is the source of the image at StdinPath.
+var stdin io.Reader = os.Stdin
+
+// resolveStdin returns the path of a copy of the standard input if path is StdinPath,
+// or path itself otherwise. Since a stream can only be read once, and not at random
+// offsets, it is copied as a whole to a temporary file, which is reused by later calls
+// and deleted by RemoveDecompressed.
+func resolveStdin(path string) (string, error) {
+	if path != StdinPath {
+		return path, nil
+	}
+
+	decompressedMu.Lock()
+	defer decompressedMu.Unlock()
+
+	if tmpPath, ok := decompressed[path]; ok {
+		return tmpPath, nil
+	}
+
+	tmpPath, err := copyToTemp(stdin)
+	if err != nil {
+		return "", fmt.Errorf("unable to copy the standard input: %w (set TMPDIR to a directory with enough free space)", err)
+	}
+
+	decompressed[path] = tmpPath
+	return tmpPath, nil
+}
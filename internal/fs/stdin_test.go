@@ -0,0 +1,49 @@
+package fs
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"testing"
+)
+
+func TestOpenStdin(t *testing.T) {
+	data := bytes.Repeat([]byte("digler"), 10000)
+
+	var gz bytes.Buffer
+	zw := gzip.NewWriter(&gz)
+	zw.Write(data)
+	zw.Close()
+
+	tests := []struct {
+		name  string
+		input []byte
+	}{
+		{name: "raw stream", input: data},
+		{name: "gzip stream", input: gz.Bytes()},
+	}
+
+	defer func(r io.Reader) { stdin = r }(stdin)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer RemoveDecompressed()
+
+			stdin = bytes.NewReader(tt.input)
+
+			// The stream is read once, and later opens reuse its copy.
+			for i := 0; i < 2; i++ {
+				f, err := Open(StdinPath)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				got := make([]byte, 100)
+				if _, err := f.ReadAt(got, 5000); err != nil || !bytes.Equal(got, data[5000:5100]) {
+					t.Fatalf("ReadAt returned %q, %v", got, err)
+				}
+				f.Close()
+			}
+		})
+	}
+}
@@ -23,7 +23,9 @@
 package fs
 
 import (
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"syscall"
 	"time"
@@ -33,10 +35,15 @@ import (
 )
 
 type WindowsDiskFile struct {
-	handle windows.Handle
-	offset int64 // used for io.Reader
+	handle     windows.Handle
+	offset     int64 // used for io.Reader
+	sectorSize int64 // logical sector size, to which raw reads must be aligned
+	size       int64 // size of the device in bytes, or -1 if unknown
 }
 
+// defaultSectorSize is the sector size assumed when the drive geometry cannot be queried.
+const defaultSectorSize = 512
+
 type diskFileInfo struct {
 	name    string
 	size    int64
@@ -52,8 +59,14 @@ func (fi *diskFileInfo) ModTime() time.Time { return fi.modTime }
 func (fi *diskFileInfo) IsDir() bool        { return fi.mode.IsDir() }
 func (fi *diskFileInfo) Sys() interface{}   { return fi.sys }
 
-// OpenWindowsDisk opens a disk/volume for raw reading
+// Open opens a disk/volume for raw reading.
+// Compressed image files are transparently decompressed (see resolveCompressed).
 func Open(path string) (File, error) {
+	path, err := resolveCompressed(path)
+	if err != nil {
+		return nil, err
+	}
+
 	handle, err := windows.CreateFile(
 		windows.StringToUTF16Ptr(path),
 		windows.GENERIC_READ,
@@ -64,9 +77,78 @@ func Open(path string) (File, error) {
 		0,
 	)
 	if err != nil {
-		return nil, fmt.Errorf("failed to open %q: %w", path, err)
+		return nil, newOpenError(path, err)
+	}
+
+	sectorSize := int64(defaultSectorSize)
+	if geometry, err := getDriveGeometry(handle); err == nil && geometry.BytesPerSector > 0 {
+		sectorSize = int64(geometry.BytesPerSector)
+	}
+
+	d := &WindowsDiskFile{handle: handle, sectorSize: sectorSize, size: -1}
+	if finfo, err := d.Stat(); err == nil {
+		d.size = finfo.Size()
+	}
+	return d, nil
+}
+
+// isNoSpaceError reports whether err is caused by a full disk.
+func isNoSpaceError(err error) bool {
+	return errors.Is(err, windows.ERROR_DISK_FULL) || errors.Is(err, windows.ERROR_HANDLE_DISK_FULL)
+}
+
+// platformErrorKind returns the kind of the error of opening an image or device
+// (see OpenError), or nil if it is not classified.
+func platformErrorKind(err error) error {
+	var errno windows.Errno
+	if !errors.As(err, &errno) {
+		return nil
 	}
-	return &WindowsDiskFile{handle: handle}, nil
+
+	switch errno {
+	case windows.ERROR_SHARING_VIOLATION, windows.ERROR_LOCK_VIOLATION:
+		return ErrDeviceBusy
+	case windows.ERROR_NOT_READY, windows.ERROR_NO_MEDIA_IN_DRIVE:
+		return ErrNoMedium
+	case windows.ERROR_ACCESS_DENIED, windows.ERROR_PRIVILEGE_NOT_HELD:
+		return ErrPermission
+	case windows.ERROR_DIRECTORY, windows.ERROR_INVALID_FUNCTION:
+		return ErrNotADevice
+	}
+	return nil
+}
+
+// maxPhysicalDrives is the number of \\.\PhysicalDriveN paths probed by PhysicalDrives.
+const maxPhysicalDrives = 64
+
+// PhysicalDrives returns the physical drives attached to the system.
+// Drives which cannot be opened, e.g. because of missing privileges, are not returned.
+func PhysicalDrives() ([]Drive, error) {
+	var drives []Drive
+	for i := 0; i < maxPhysicalDrives; i++ {
+		path := fmt.Sprintf(`\\.\PhysicalDrive%d`, i)
+
+		f, err := Open(path)
+		if err != nil {
+			continue
+		}
+
+		d := f.(*WindowsDiskFile)
+		if finfo, err := d.Stat(); err == nil {
+			drives = append(drives, Drive{
+				Path:       path,
+				Size:       uint64(finfo.Size()),
+				SectorSize: d.SectorSize(),
+			})
+		}
+		d.Close()
+	}
+	return drives, nil
+}
+
+// SectorSize returns the logical sector size of the drive.
+func (d *WindowsDiskFile) SectorSize() uint32 {
+	return uint32(d.sectorSize)
 }
 
 // Read reads from the current offset (for io.Reader)
@@ -80,15 +162,26 @@ func (d *WindowsDiskFile) Read(p []byte) (int, error) {
 	return int(bytesRead), nil
 }
 
+// ReadAt reads len(p) bytes at offset off, reading whole sectors from the device.
+// Reads spanning past the end of the device return the bytes up to the end and io.EOF.
 func (d *WindowsDiskFile) ReadAt(p []byte, off int64) (int, error) {
-	const sectorSize = 512
+	sectorSize := d.sectorSize
+
+	if d.size >= 0 && off >= d.size {
+		return 0, io.EOF
+	}
 
 	// Calculate aligned offset and size
 	alignedOffset := off / sectorSize * sectorSize
-	alignmentDiff := int(off - alignedOffset)
+	alignmentDiff := off - alignedOffset
 
 	// Calculate aligned read size (must fully cover p)
-	alignedSize := ((len(p) + alignmentDiff + sectorSize - 1) / sectorSize) * sectorSize
+	alignedSize := ((int64(len(p)) + alignmentDiff + sectorSize - 1) / sectorSize) * sectorSize
+
+	// Do not read past the end of the device, which fails the whole read
+	if d.size >= 0 && alignedOffset+alignedSize > d.size {
+		alignedSize = ((d.size - alignedOffset + sectorSize - 1) / sectorSize) * sectorSize
+	}
 
 	// Allocate aligned buffer
 	buf := make([]byte, alignedSize)
@@ -104,13 +197,23 @@ func (d *WindowsDiskFile) ReadAt(p []byte, off int64) (int, error) {
 		if err == syscall.ERROR_IO_PENDING {
 			err = windows.GetOverlappedResult(d.handle, ov, &bytesRead, true)
 		}
+		if err == windows.ERROR_HANDLE_EOF {
+			return 0, io.EOF
+		}
 		if err != nil {
 			return 0, fmt.Errorf("aligned read failed: %w", err)
 		}
 	}
 
+	if int64(bytesRead) <= alignmentDiff {
+		return 0, io.EOF
+	}
+
 	// Copy only requested portion
-	n := copy(p, buf[alignmentDiff:])
+	n := copy(p, buf[alignmentDiff:bytesRead])
+	if n < len(p) {
+		return n, io.EOF
+	}
 	return n, nil
 }
 
@@ -122,14 +225,40 @@ type DISK_GEOMETRY struct {
 	BytesPerSector    uint32
 }
 
-const IOCTL_DISK_GET_DRIVE_GEOMETRY = 0x70000
+const (
+	IOCTL_DISK_GET_DRIVE_GEOMETRY = 0x70000
+	IOCTL_DISK_GET_LENGTH_INFO    = 0x7405C
+)
 
 func (d *WindowsDiskFile) Stat() (os.FileInfo, error) {
+	geometry, err := getDriveGeometry(d.handle)
+	if err != nil {
+		return nil, err
+	}
+
+	// The size computed from the geometry excludes the sectors past the last whole cylinder.
+	size, err := getDeviceLength(d.handle)
+	if err != nil {
+		size = geometry.Cylinders * int64(geometry.TracksPerCylinder) * int64(geometry.SectorsPerTrack) * int64(geometry.BytesPerSector)
+	}
+
+	// Build a minimal FileInfo-like struct
+	return &diskFileInfo{
+		name:    "", // no name
+		size:    size,
+		mode:    0,
+		modTime: time.Time{},
+		sys:     geometry,
+	}, nil
+}
+
+// getDriveGeometry queries the geometry of the drive opened with the given handle.
+func getDriveGeometry(handle windows.Handle) (DISK_GEOMETRY, error) {
 	var geometry DISK_GEOMETRY
 	var bytesReturned uint32
 
 	err := windows.DeviceIoControl(
-		d.handle,
+		handle,
 		IOCTL_DISK_GET_DRIVE_GEOMETRY,
 		nil,
 		0,
@@ -139,19 +268,30 @@ func (d *WindowsDiskFile) Stat() (os.FileInfo, error) {
 		nil,
 	)
 	if err != nil {
-		return nil, fmt.Errorf("DeviceIoControl(IOCTL_DISK_GET_DRIVE_GEOMETRY) failed: %w", err)
+		return geometry, fmt.Errorf("DeviceIoControl(IOCTL_DISK_GET_DRIVE_GEOMETRY) failed: %w", err)
 	}
+	return geometry, nil
+}
 
-	size := geometry.Cylinders * int64(geometry.TracksPerCylinder) * int64(geometry.SectorsPerTrack) * int64(geometry.BytesPerSector)
+// getDeviceLength queries the size in bytes of the device opened with the given handle.
+func getDeviceLength(handle windows.Handle) (int64, error) {
+	var length int64
+	var bytesReturned uint32
 
-	// Build a minimal FileInfo-like struct
-	return &diskFileInfo{
-		name:    "", // no name
-		size:    size,
-		mode:    0,
-		modTime: time.Time{},
-		sys:     geometry,
-	}, nil
+	err := windows.DeviceIoControl(
+		handle,
+		IOCTL_DISK_GET_LENGTH_INFO,
+		nil,
+		0,
+		(*byte)(unsafe.Pointer(&length)),
+		uint32(unsafe.Sizeof(length)),
+		&bytesReturned,
+		nil,
+	)
+	if err != nil {
+		return 0, fmt.Errorf("DeviceIoControl(IOCTL_DISK_GET_LENGTH_INFO) failed: %w", err)
+	}
+	return length, nil
 }
 
 // Close closes the underlying handle
@@ -32,12 +32,70 @@ import (
 
 	"bazil.org/fuse"
 	"bazil.org/fuse/fs"
+	"github.com/ostafen/digler/internal/format"
 )
 
 type FileEntry struct {
 	Name   string
 	Offset uint64
 	Size   uint64
+
+	// Runs lists the byte ranges of fragmented files, in file order.
+	// It is empty for contiguous files.
+	Runs []format.ByteRun
+}
+
+// reader returns a reader for the contents of the file stored in r.
+func (e FileEntry) reader(r io.ReaderAt) io.ReaderAt {
+	if len(e.Runs) == 0 {
+		return io.NewSectionReader(r, int64(e.Offset), int64(e.Size))
+	}
+	return &runsReaderAt{r: r, runs: e.Runs}
+}
+
+// runsReaderAt reads the concatenation of a sequence of byte runs of an underlying reader.
+type runsReaderAt struct {
+	r    io.ReaderAt
+	runs []format.ByteRun
+}
+
+func (rr *runsReaderAt) ReadAt(p []byte, off int64) (int, error) {
+	if off < 0 {
+		return 0, os.ErrInvalid
+	}
+
+	n := 0
+	pos := uint64(off)
+	for _, run := range rr.runs {
+		if len(p) == 0 {
+			return n, nil
+		}
+		if pos >= run.Size {
+			pos -= run.Size
+			continue
+		}
+
+		chunk := p
+		if uint64(len(chunk)) > run.Size-pos {
+			chunk = chunk[:run.Size-pos]
+		}
+
+		m, err := rr.r.ReadAt(chunk, int64(run.Offset+pos))
+		n += m
+		if m < len(chunk) {
+			if err == nil {
+				err = io.ErrUnexpectedEOF
+			}
+			return n, err
+		}
+		p = p[m:]
+		pos = 0
+	}
+
+	if len(p) > 0 {
+		return n, io.EOF
+	}
+	return n, nil
 }
 
 type RecoverFS struct {
@@ -68,7 +126,7 @@ func (*Dir) Attr(ctx context.Context, a *fuse.Attr) error {
 func (d *Dir) Lookup(ctx context.Context, name string) (fs.Node, error) {
 	if e, ok := d.fs.entries[name]; ok {
 		return File{
-			r:    io.NewSectionReader(d.fs.r, int64(e.Offset), int64(e.Size)),
+			r:    e.reader(d.fs.r),
 			size: e.Size,
 		}, nil
 	}
@@ -23,12 +23,19 @@
 package fuse
 
 import (
-	"fmt"
+	"errors"
 	"io"
 
 	"github.com/ostafen/digler/internal/format"
 )
 
+var errUnsupported = errors.New("FUSE mount is only supported on Linux")
+
+// Available returns an error, as FUSE file systems can only be mounted on Linux.
+func Available() error {
+	return errUnsupported
+}
+
 func Mount(mountpoint string, r io.ReaderAt, entries []format.FileInfo) error {
-	return fmt.Errorf("FUSE mount is only supported on Linux")
+	return errUnsupported
 }
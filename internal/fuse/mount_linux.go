@@ -23,9 +23,11 @@
 package fuse
 
 import (
+	"fmt"
 	"io"
 	"log"
 	"os"
+	"os/exec"
 	"os/signal"
 	"syscall"
 
@@ -35,6 +37,21 @@ import (
 	osutils "github.com/ostafen/digler/pkg/util/os"
 )
 
+// fusermount is the helper used to mount FUSE file systems without privileges.
+const fusermount = "fusermount3"
+
+// Available returns an error if FUSE file systems cannot be mounted: this requires
+// the FUSE device, provided by the fuse kernel module, and the fusermount3 helper.
+func Available() error {
+	if _, err := os.Stat("/dev/fuse"); err != nil {
+		return fmt.Errorf("FUSE device not available (is the fuse kernel module loaded?): %w", err)
+	}
+	if _, err := exec.LookPath(fusermount); err != nil {
+		return fmt.Errorf("%s not found (is fuse3 installed?): %w", fusermount, err)
+	}
+	return nil
+}
+
 func Mount(mountpoint string, r io.ReaderAt, finfos []format.FileInfo) error {
 	created, err := osutils.EnsureDir(mountpoint, true)
 	if err != nil {
@@ -56,6 +73,7 @@ func Mount(mountpoint string, r io.ReaderAt, finfos []format.FileInfo) error {
 			Name:   e.Name,
 			Offset: e.Offset,
 			Size:   e.Size,
+			Runs:   e.Runs,
 		}
 	}
 
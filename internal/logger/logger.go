@@ -22,9 +22,21 @@ package logger
 import (
 	"fmt"
 	"io"
+	"os"
 	"sync"
 )
 
+// ANSI escape codes of the colors of log levels
+const (
+	colorRed    = "\033[31m"
+	colorYellow = "\033[33m"
+	colorReset  = "\033[0m"
+)
+
+// colors enables colorized log levels on terminals. It is disabled by
+// a non-empty NO_COLOR environment variable (see https://no-color.org).
+var colors = os.Getenv("NO_COLOR") == ""
+
 // Level type for log levels
 type Level int
 
@@ -64,31 +76,72 @@ func (l Level) String() string {
 	}
 }
 
+// color returns the ANSI escape code of the color of the level, if any
+func (l Level) color() string {
+	switch l {
+	case WarnLevel:
+		return colorYellow
+	case ErrorLevel:
+		return colorRed
+	default:
+		return ""
+	}
+}
+
+// SetColors enables or disables colorized log levels for the loggers created afterwards.
+// Even when enabled, colors are only used for outputs which are terminals.
+func SetColors(enabled bool) {
+	colors = enabled && os.Getenv("NO_COLOR") == ""
+}
+
 // Logger defines the logging structure
 type Logger struct {
-	mu    sync.Mutex
-	out   io.Writer
-	level Level
+	mu      sync.Mutex
+	outputs []Output
+}
+
+// Output is a destination of log messages, with its own minimum log level
+type Output struct {
+	W     io.Writer
+	Level Level
+
+	color bool // whether levels are colorized, computed at logger creation
 }
 
 // New creates a new logger writing to a writer with minimum log level
 func New(w io.Writer, level Level) *Logger {
+	return NewMulti(Output{W: w, Level: level})
+}
+
+// NewMulti creates a new logger writing each message to the outputs whose
+// minimum log level it reaches
+func NewMulti(outputs ...Output) *Logger {
+	outputs = append([]Output(nil), outputs...)
+	for i := range outputs {
+		outputs[i].color = colors && isTerminal(outputs[i].W)
+	}
+
 	return &Logger{
-		out:   w,
-		level: level,
+		outputs: outputs,
 	}
 }
 
 // log is the internal formatter
 func (l *Logger) log(level Level, msg string) {
-	if level < l.level {
-		return
-	}
-
 	l.mu.Lock()
 	defer l.mu.Unlock()
 
-	fmt.Fprintf(l.out, "[%s] %s\n", level.String(), msg)
+	for _, out := range l.outputs {
+		if level < out.Level {
+			continue
+		}
+
+		if color := level.color(); out.color && color != "" {
+			fmt.Fprintf(out.W, "[%s%s%s] %s\n", color, level.String(), colorReset, msg)
+		} else {
+			fmt.Fprintf(out.W, "[%s] %s\n", level.String(), msg)
+		}
+	}
 }
 
 // --- Logging Methods ---
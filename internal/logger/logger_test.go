@@ -0,0 +1,29 @@
+package logger
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestLogColors(t *testing.T) {
+	var plain, colored bytes.Buffer
+
+	l := NewMulti(Output{W: &plain, Level: DebugLevel}, Output{W: &colored, Level: DebugLevel})
+	if l.outputs[0].color {
+		t.Fatal("colors enabled for an output which is not a terminal")
+	}
+	l.outputs[1].color = true
+
+	l.Info("scanning")
+	l.Warn("bad block")
+	l.Error("read failed")
+
+	if want := "[INFO] scanning\n[WARN] bad block\n[ERROR] read failed\n"; plain.String() != want {
+		t.Errorf("plain output = %q, want %q", plain.String(), want)
+	}
+
+	want := "[INFO] scanning\n[\033[33mWARN\033[0m] bad block\n[\033[31mERROR\033[0m] read failed\n"
+	if colored.String() != want {
+		t.Errorf("colored output = %q, want %q", colored.String(), want)
+	}
+}
@@ -0,0 +1,77 @@
+// Copyright (c) 2025 Stefano Scafiti
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package mmap
+
+import (
+	"fmt"
+	"os"
+)
+
+// MmapFile is a read-only memory mapping of a region of a file.
+type MmapFile struct {
+	Data   []byte // Data holds the mapped bytes of the region.
+	Offset int64  // Offset is the offset of the region within the file.
+
+	unmap func() error
+}
+
+// NewMmapFile maps the whole content of f into memory.
+func NewMmapFile(f *os.File) (*MmapFile, error) {
+	finfo, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	return NewMmapFileRegion(f, 0, int(finfo.Size()))
+}
+
+// NewMmapFileRegion maps size bytes of f, starting at offset, into memory.
+// The offset does not need to be aligned, since the mapping is internally
+// extended to start at the closest preceding aligned offset.
+func NewMmapFileRegion(f *os.File, offset int64, size int) (*MmapFile, error) {
+	if offset < 0 || size <= 0 {
+		return nil, fmt.Errorf("invalid mmap region: offset=%d, size=%d", offset, size)
+	}
+
+	alignedOffset := offset - offset%int64(alignment())
+	shift := int(offset - alignedOffset)
+
+	data, unmap, err := mmap(f, alignedOffset, size+shift)
+	if err != nil {
+		return nil, fmt.Errorf("failed to mmap region [%d, %d) of %s: %w", offset, offset+int64(size), f.Name(), err)
+	}
+
+	return &MmapFile{
+		Data:   data[shift:],
+		Offset: offset,
+		unmap:  unmap,
+	}, nil
+}
+
+// Close unmaps the region. Data must not be accessed after Close returns.
+func (m *MmapFile) Close() error {
+	if m.unmap == nil {
+		return nil
+	}
+
+	err := m.unmap()
+	m.Data = nil
+	m.unmap = nil
+	return err
+}
@@ -0,0 +1,80 @@
+// Copyright (c) 2025 Stefano Scafiti
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package scan
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"io"
+
+	"github.com/ostafen/digler/internal/logger"
+	"github.com/ostafen/digler/pkg/dfxml"
+)
+
+// dedupeSet tracks the contents of the recovered files, to dump only the first copy of each.
+type dedupeSet struct {
+	originals  map[[sha1.Size]byte]string // Names of the first files with each digest.
+	duplicates int
+}
+
+func newDedupeSet() *dedupeSet {
+	return &dedupeSet{originals: make(map[[sha1.Size]byte]string)}
+}
+
+// check records the file made of the concatenation of the given readers, and returns the name
+// of the first file with the same contents, or an empty string if it is the first one.
+// The file is hashed unless its SHA-1 is among the given digests, which are returned together
+// with the computed ones. A nil set does not track any file.
+func (s *dedupeSet) check(logger *logger.Logger, name string, digests []dfxml.HashDigest, readers ...io.Reader) ([]dfxml.HashDigest, string) {
+	if s == nil {
+		return digests, ""
+	}
+
+	sum, ok := sha1Digest(digests)
+	if !ok {
+		var err error
+		if sum, err = hashFile(readers...); err != nil {
+			logger.Errorf("unable to hash file %s: %s", name, err)
+			return digests, ""
+		}
+		digests = append(digests, dfxml.HashDigest{Type: "sha1", Value: hex.EncodeToString(sum[:])})
+	}
+
+	if original, ok := s.originals[sum]; ok {
+		s.duplicates++
+		return digests, original
+	}
+	s.originals[sum] = name
+	return digests, ""
+}
+
+// sha1Digest returns the SHA-1 among the given digests, if any.
+func sha1Digest(digests []dfxml.HashDigest) ([sha1.Size]byte, bool) {
+	var sum [sha1.Size]byte
+	for _, d := range digests {
+		if d.Type != "sha1" || hex.DecodedLen(len(d.Value)) != sha1.Size {
+			continue
+		}
+		if _, err := hex.Decode(sum[:], []byte(d.Value)); err == nil {
+			return sum, true
+		}
+	}
+	return sum, false
+}
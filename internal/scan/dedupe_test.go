@@ -0,0 +1,43 @@
+package scan
+
+import (
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/ostafen/digler/internal/logger"
+	"github.com/ostafen/digler/pkg/dfxml"
+)
+
+func TestDedupeSet(t *testing.T) {
+	log := logger.New(io.Discard, logger.ErrorLevel)
+	seen := newDedupeSet()
+
+	digests, original := seen.check(log, "a.txt", nil, strings.NewReader("ab"), strings.NewReader("c"))
+	if original != "" {
+		t.Errorf("check() of the first file = %q, want no original", original)
+	}
+	if len(digests) != 1 || digests[0].Value != "a9993e364706816aba3e25717850c26c9cd0d89d" {
+		t.Errorf("check() digests = %v, want the SHA-1 of %q", digests, "abc")
+	}
+
+	// The given digest is used instead of hashing the file again.
+	_, original = seen.check(log, "b.txt", digests, strings.NewReader("unread"))
+	if original != "a.txt" {
+		t.Errorf("check() of a copy = %q, want %q", original, "a.txt")
+	}
+
+	if _, original = seen.check(log, "c.txt", nil, strings.NewReader("abd")); original != "" {
+		t.Errorf("check() of a different file = %q, want no original", original)
+	}
+
+	if seen.duplicates != 1 {
+		t.Errorf("duplicates = %d, want 1", seen.duplicates)
+	}
+
+	var disabled *dedupeSet
+	given := []dfxml.HashDigest{{Type: "sha1", Value: "x"}}
+	if digests, original := disabled.check(log, "a.txt", given, strings.NewReader("abc")); original != "" || len(digests) != 1 {
+		t.Errorf("check() on a nil set = %v, %q, want the given digests", digests, original)
+	}
+}
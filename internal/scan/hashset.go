@@ -0,0 +1,107 @@
+// Copyright (c) 2025 Stefano Scafiti
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package scan
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// HashSet is a set of SHA-1 digests of known files.
+type HashSet map[[sha1.Size]byte]struct{}
+
+// LoadHashSet reads a hash set from the file at path, which lists one SHA-1 digest per line, in hex.
+// Lines in the NSRL CSV format are also accepted, since they start with the SHA-1 of the file.
+// Empty lines, comments starting with '#' and CSV headers are ignored.
+func LoadHashSet(path string) (HashSet, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return ReadHashSet(f)
+}
+
+// ReadHashSet reads a hash set from r. See LoadHashSet for the accepted format.
+func ReadHashSet(r io.Reader) (HashSet, error) {
+	set := make(HashSet)
+
+	s := bufio.NewScanner(r)
+	for lineNum := 1; s.Scan(); lineNum++ {
+		line := strings.TrimSpace(s.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		field, _, _ := strings.Cut(line, ",")
+		field = strings.Trim(field, `"`)
+		if strings.EqualFold(field, "SHA-1") {
+			continue
+		}
+
+		var sum [sha1.Size]byte
+		if len(field) != hex.EncodedLen(sha1.Size) {
+			return nil, fmt.Errorf("line %d: invalid SHA-1 digest %q", lineNum, field)
+		}
+		if _, err := hex.Decode(sum[:], []byte(field)); err != nil {
+			return nil, fmt.Errorf("line %d: invalid SHA-1 digest %q: %w", lineNum, field, err)
+		}
+		set[sum] = struct{}{}
+	}
+
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+	return set, nil
+}
+
+// Contains reports whether the given digest belongs to the set.
+func (s HashSet) Contains(sum [sha1.Size]byte) bool {
+	_, ok := s[sum]
+	return ok
+}
+
+// checkKnown computes the SHA-1 digest of the file made of the concatenation of the given
+// readers, and reports whether it belongs to the set. The digest is returned in hex.
+func (s HashSet) checkKnown(readers ...io.Reader) (string, bool, error) {
+	sum, err := hashFile(readers...)
+	if err != nil {
+		return "", false, err
+	}
+	return hex.EncodeToString(sum[:]), s.Contains(sum), nil
+}
+
+// hashFile computes the SHA-1 digest of the concatenation of the given readers.
+func hashFile(readers ...io.Reader) ([sha1.Size]byte, error) {
+	var sum [sha1.Size]byte
+
+	h := sha1.New()
+	if _, err := io.Copy(h, io.MultiReader(readers...)); err != nil {
+		return sum, err
+	}
+	copy(sum[:], h.Sum(nil))
+	return sum, nil
+}
@@ -0,0 +1,52 @@
+package scan
+
+import (
+	"crypto/sha1"
+	"strings"
+	"testing"
+)
+
+func TestReadHashSet(t *testing.T) {
+	input := `# known files
+"SHA-1","MD5","CRC32","FileName","FileSize","ProductCode","OpSystemCode","SpecialCode"
+"000000206738748EDD92C4E3D2E823896700F849","392126E756571EBF112CB1C1CDEDF926","EBD105A0","I05002T2.PFB",98865,3095,"WIN",""
+
+a9993e364706816aba3e25717850c26c9cd0d89d
+`
+
+	set, err := ReadHashSet(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("ReadHashSet() error = %v", err)
+	}
+
+	if len(set) != 2 {
+		t.Errorf("len(set) = %d, want 2", len(set))
+	}
+
+	if !set.Contains(sha1.Sum([]byte("abc"))) {
+		t.Errorf("set does not contain the SHA-1 of %q", "abc")
+	}
+
+	digest, known, err := set.checkKnown(strings.NewReader("ab"), strings.NewReader("c"))
+	if err != nil || !known || digest != "a9993e364706816aba3e25717850c26c9cd0d89d" {
+		t.Errorf("checkKnown() = %q, %v, %v, want known digest of %q", digest, known, err, "abc")
+	}
+
+	if _, known, _ := set.checkKnown(strings.NewReader("abd")); known {
+		t.Errorf("checkKnown() reported an unknown file as known")
+	}
+}
+
+func TestReadHashSetInvalid(t *testing.T) {
+	tests := []string{
+		"a9993e364706816aba3e25717850c26c9cd0d89",
+		"a9993e364706816aba3e25717850c26c9cd0d89d00",
+		"z9993e364706816aba3e25717850c26c9cd0d89d",
+	}
+
+	for _, input := range tests {
+		if _, err := ReadHashSet(strings.NewReader(input)); err == nil {
+			t.Errorf("ReadHashSet(%q) error = nil, want error", input)
+		}
+	}
+}
@@ -21,10 +21,15 @@ package scan
 
 import (
 	"encoding/binary"
+	"encoding/hex"
+	"errors"
 	"fmt"
 	"io"
+	"math"
 	"os"
 	"path/filepath"
+	"reflect"
+	"sort"
 	"strings"
 	"time"
 
@@ -34,21 +39,108 @@ import (
 	"github.com/ostafen/digler/internal/fs"
 	"github.com/ostafen/digler/internal/logger"
 	"github.com/ostafen/digler/pkg/dfxml"
+	"github.com/ostafen/digler/pkg/sysinfo"
 	fmtutil "github.com/ostafen/digler/pkg/util/format"
 	ioutil "github.com/ostafen/digler/pkg/util/io"
 )
 
+const (
+	// DefaultScanBufferSize is the scan buffer size used when the amount of memory cannot be determined.
+	DefaultScanBufferSize = 4 * fmtutil.MiB
+	// MaxAutoScanBufferSize caps the size of an automatically sized scan buffer.
+	MaxAutoScanBufferSize = 256 * fmtutil.MiB
+
+	// autoScanBufferRAMFraction is the fraction (1/n) of the total RAM used for an automatically sized scan buffer.
+	autoScanBufferRAMFraction = 64
+)
+
 type Options struct {
 	DumpDir        string       // DumpDir is the directory where carved files will be dumped. If empty, files will not be dumped.
 	ReportFile     string       // ReportFile is the path to the report file. If empty, a default name will be used.
+	ReportDir      string       // ReportDir is the directory of the report file with the default name. If empty, DumpDir is used.
 	MaxScanSize    uint64       // MaxScanSize is the maximum number of bytes to scan. If 0, the entire partition will be scanned.
-	ScanBufferSize uint64       // ScanBufferSize is the size of the buffer to use during scanning. If 0, a default size is used.
+	Offset         uint64       // Offset is the offset within the partition where the scan starts. It must be a multiple of the block size.
+	Length         uint64       // Length is the number of bytes to scan from Offset. If 0, the partition is scanned up to its end.
+	ScanBufferSize uint64       // ScanBufferSize is the size of the buffer to use during scanning. If 0, the size is chosen based on the available memory.
+	BufferOverlap  uint64       // BufferOverlap is the number of bytes after the scan buffer searched for signatures with it. If 0, the length of the longest signature is used.
 	BlockSize      uint64       // BlockSize is the size of a block to read from the disk. If 0, the default block size is used.
 	MaxFileSize    uint64       // MaxFileSize is the maximum size of a carved file. If 0, no limit is applied.
+	MaxCarveBytes  uint64       // MaxCarveBytes is the maximum number of bytes a file scanner may consume to validate a file, which is otherwise abandoned. If 0, no limit is applied.
 	DisableLog     bool         // DisableLog disables logging to a file. If true, no log file will be created.
+	Quiet          bool         // Quiet prints only warnings and errors to stdout. The log file, if any, is unaffected.
+	Mmap           bool         // Mmap enables memory mapping of image files. Raw devices are always read normally.
+	SkipErrors     bool         // SkipErrors zero-fills and skips the blocks which cannot be read, instead of stopping the scan.
+	FatalPanics    bool         // FatalPanics stops the program when a file scanner panics, instead of logging the panic and going on.
+	DryRun         bool         // DryRun scans and writes the report without dumping any file, even if DumpDir is set.
+	HeaderOnly     bool         // HeaderOnly reports each signature match as a file of one block, without validating nor sizing it, and dumps no file.
+	NameTemplate   string       // NameTemplate is the template of the names of carved files (see format.ParseNameTemplate). If empty, format.DefaultNameTemplate is used.
+	IgnoreHashes   string       // IgnoreHashes is the path to a list of SHA-1 digests of known files, which are neither dumped nor reported.
+	Dedupe         bool         // Dedupe dumps only the first of the files with identical contents; the others are reported as duplicates of it.
+	FATMetadata    bool         // FATMetadata recovers the files listed in the directories of FAT partitions, with their original names, before carving.
+	MaxDepth       int          // MaxDepth is the maximum depth of recursive carving of embedded files. If 0, embedded files are not carved.
+	Partitions     []int        // numbers of the partitions to scan. If empty, all the partitions are scanned.
 	FileExt        []string     // file extensions to parse, e.g. "jpg,png,txt"
+	ExcludeExt     []string     // file extensions to skip, removed from the ones selected by FileExt
 	Plugins        []string     // paths to plugin .so files or directories containing plugins
 	LogLevel       logger.Level // LogLevel specifies the minimum log level to write to the log file.
+
+	// SkipPartitionMetadata excludes boot sectors and partition tables from carving.
+	SkipPartitionMetadata bool
+
+	// SkipRegions are byte ranges of each scanned partition which are not searched for files,
+	// e.g. because they were already recovered. They are not read at all, when possible.
+	SkipRegions []disk.Region
+
+	// MinConfidence is the minimum confidence of carved files: files validated less
+	// thoroughly by their scanner are neither dumped nor reported.
+	MinConfidence format.Confidence
+
+	// EntropyFilter rejects carved files whose entropy does not match their format,
+	// e.g. ZIP archives holding uncompressed data (see format.EntropyClass).
+	EntropyFilter bool
+
+	// IncludeGaps also scans the unallocated space of partitioned disks, before, between
+	// and after the partitions, as additional partitions (see DiscoverGaps).
+	IncludeGaps bool
+
+	// SkipInvalidPartitions skips the partitions whose partition table entry is invalid,
+	// e.g. because it overlaps another entry or extends past the end of the disk.
+	SkipInvalidPartitions bool
+
+	// PartitionBlockSizes overrides the block size of the partitions with the given
+	// numbers, taking precedence over BlockSize.
+	PartitionBlockSizes map[int]uint64
+
+	// LogOutput is where the scan logs are printed, besides the log file. If nil, os.Stdout is used.
+	LogOutput io.Writer
+
+	// Scanners are additional scanners, e.g. of formats defined by programs embedding the scan engine.
+	// Their extensions must differ from those of the built-in scanners.
+	Scanners []format.FileScanner
+
+	// Progress, if set, is called periodically with the progress of the scan. Scan reports
+	// the progress across all the scanned partitions, and the number of files found in them.
+	Progress format.ProgressFunc
+
+	// SingleReport writes one report for all the partitions scanned by Scan, in which the
+	// files of each partition are grouped in a volume element, instead of one per partition.
+	SingleReport bool
+
+	// OnSummary, if set, is called with the summary of the scan of each partition, once it completes.
+	OnSummary func(Summary)
+
+	// JPEG controls how the end of JPEG files is determined.
+	JPEG format.JPEGOptions
+	// GIF controls how strictly GIF files are validated.
+	GIF format.GIFOptions
+	// ZIP controls how thoroughly ZIP archives and OOXML documents are validated.
+	ZIP format.ZIPOptions
+	// SQLite controls how SQLite databases are carved.
+	SQLite format.SQLiteOptions
+
+	// dumpNames holds the names of the files dumped to DumpDir, shared by the partitions
+	// scanned by Scan, so that their files do not overwrite each other.
+	dumpNames *format.NameSet
 }
 
 func Scan(filePath string, opts Options) error {
@@ -57,19 +149,82 @@ func Scan(filePath string, opts Options) error {
 		return err
 	}
 
-	scanAllPartitions := true
+	if opts.IncludeGaps {
+		gaps, err := DiscoverGaps(filePath, partitions)
+		if err != nil {
+			return err
+		}
+		partitions = append(partitions, gaps...)
+	}
+
+	scanAllPartitions := len(opts.Partitions) == 0
 	partitionsToScan := map[int]bool{}
 
+	for _, num := range opts.Partitions {
+		if !hasPartition(partitions, num) {
+			return fmt.Errorf("partition %d not found in %q", num, filePath)
+		}
+		partitionsToScan[num] = true
+	}
+
+	var selected []disk.Partition
 	for _, p := range partitions {
 		if scanAllPartitions || partitionsToScan[p.Num] {
-			if err := ScanPartition(&p, filePath, opts); err != nil {
-				return err
+			if p.Err != nil && opts.SkipInvalidPartitions {
+				logger.New(logOutput(opts), opts.LogLevel).Warnf("Skipping invalid partition %d: %s", p.Num, p.Err)
+				continue
 			}
+			selected = append(selected, p)
 		}
 	}
+
+	var report *sharedReport
+	if opts.SingleReport && len(selected) > 0 {
+		report, err = createSharedReport(filePath, selected, opts)
+		if err != nil {
+			return err
+		}
+		defer report.close()
+	}
+
+	// The progress of each partition is shifted by the bytes and files of the previous ones.
+	var total, scanned uint64
+	for _, p := range selected {
+		total += scanSize(&p, opts)
+	}
+	filesFound := 0
+
+	if opts.dumpNames == nil {
+		opts.dumpNames = format.NewNameSet()
+	}
+
+	for _, p := range selected {
+		popts := opts
+		if opts.Progress != nil {
+			offset, files := scanned, filesFound
+			popts.Progress = func(processed, _ int64, n int) {
+				filesFound = files + n
+				opts.Progress(int64(offset)+processed, int64(total), filesFound)
+			}
+		}
+
+		if err := scanPartition(&p, filePath, popts, report); err != nil {
+			return err
+		}
+		scanned += scanSize(&p, opts)
+	}
 	return nil
 }
 
+func hasPartition(partitions []disk.Partition, num int) bool {
+	for _, p := range partitions {
+		if p.Num == num {
+			return true
+		}
+	}
+	return false
+}
+
 func absPath(path string) string {
 	absPath, err := filepath.Abs(path)
 	if err != nil {
@@ -78,8 +233,24 @@ func absPath(path string) string {
 	return absPath
 }
 
+// resolvedPath returns the canonical path of the image to record in the report,
+// or an empty string if it is the path given.
+func resolvedPath(path string) string {
+	if resolved := disk.ResolvePath(path); resolved != path {
+		return resolved
+	}
+	return ""
+}
+
+// ScanPartition scans a partition of the image or device at filePath, writing its own report.
 func ScanPartition(p *disk.Partition, filePath string, opts Options) error {
-	f, err := fs.Open(filePath)
+	return scanPartition(p, filePath, opts, nil)
+}
+
+// scanPartition scans a partition, writing its files to the given shared report,
+// or to a report of its own if it is nil.
+func scanPartition(p *disk.Partition, filePath string, opts Options, report *sharedReport) error {
+	f, err := openImage(filePath, opts.Mmap)
 	if err != nil {
 		return err
 	}
@@ -90,43 +261,79 @@ func ScanPartition(p *disk.Partition, filePath string, opts Options) error {
 		return err
 	}
 
-	scanID := GetScanID()
+	var knownHashes HashSet
+	if opts.IgnoreHashes != "" {
+		knownHashes, err = LoadHashSet(opts.IgnoreHashes)
+		if err != nil {
+			return fmt.Errorf("unable to load hash set %q: %w", opts.IgnoreHashes, err)
+		}
+	}
 
-	var reportFileName string
-	if opts.ReportFile == "" {
-		reportFileName = fmt.Sprintf("report_%s.xml", scanID)
+	nameTemplate := format.DefaultNameTemplate
+	if opts.NameTemplate != "" {
+		nameTemplate = opts.NameTemplate
 	}
 
-	outFile, err := os.Create(reportFileName)
+	names, err := format.ParseNameTemplate(nameTemplate)
 	if err != nil {
 		return err
 	}
-	defer outFile.Close()
 
-	blockSize := p.BlockSize
-	if opts.BlockSize != 0 {
-		blockSize = uint32(opts.BlockSize)
+	partitionBlockSize := blockSizeOf(p, opts)
+
+	if err := validateBufferSizes(partitionBlockSize, opts.ScanBufferSize, opts.BufferOverlap); err != nil {
+		return fmt.Errorf("partition %d: %w", p.Num, err)
 	}
+	blockSize := uint32(partitionBlockSize)
 
-	reportFileWriter := dfxml.NewDFXMLWriter(outFile)
-	defer reportFileWriter.Close()
+	if opts.Offset >= p.Size {
+		return fmt.Errorf("scan offset %d is beyond the end of partition %d (%d bytes)", opts.Offset, p.Num, p.Size)
+	}
 
-	err = reportFileWriter.WriteHeader(dfxml.DFXMLHeader{
-		XmlOutput: dfxml.XmlOutputVersion,
-		Metadata:  dfxml.DefaultMetadata,
-		Creator: dfxml.Creator{
-			Package:              env.AppName,
-			Version:              env.Version,
-			ExecutionEnvironment: dfxml.GetExecEnv(),
-		},
-		Source: dfxml.Source{
-			ImageFilename: filePath,
-			SectorSize:    int(blockSize),
-			ImageSize:     uint64(imgInfo.Size()),
-		},
-	})
-	if err != nil {
-		return err
+	if opts.Offset%uint64(blockSize) != 0 {
+		return fmt.Errorf("scan offset %d is not a multiple of the block size (%d)", opts.Offset, blockSize)
+	}
+
+	scanID := GetScanID()
+
+	var reportFileName string
+	var reportFileWriter *dfxml.DFXMLWriter
+	if report != nil {
+		reportFileName, reportFileWriter = report.path, report.w
+
+		err = reportFileWriter.StartVolume(dfxml.Volume{
+			Offset:     p.Offset,
+			Partition:  p.Num,
+			SectorSize: int(blockSize),
+			Filesystem: p.FSType.String(),
+		})
+		if err != nil {
+			return err
+		}
+		defer reportFileWriter.EndVolume()
+	} else {
+		reportFileName, err = reportPath(opts, scanID)
+		if err != nil {
+			return err
+		}
+
+		outFile, err := os.Create(reportFileName)
+		if err != nil {
+			return err
+		}
+		defer outFile.Close()
+
+		reportFileWriter = dfxml.NewDFXMLWriter(outFile)
+		defer reportFileWriter.Close()
+
+		err = reportFileWriter.WriteHeader(reportHeader(filePath, opts, dfxml.Source{
+			SectorSize: int(blockSize),
+			ImageSize:  uint64(imgInfo.Size()),
+			Filesystem: p.FSType.String(),
+		}))
+		if err != nil {
+			return err
+		}
 	}
 
 	var logFilePath string
@@ -139,15 +346,32 @@ func ScanPartition(p *disk.Partition, filePath string, opts Options) error {
 		return err
 	}
 
+	scanners, err = excludeScanners(scanners, opts.ExcludeExt)
+	if err != nil {
+		return err
+	}
+
+	configureScanners(scanners, opts)
+
+	builtinScanners := scanners
+
 	var pluginScanners []format.FileScanner
 	if len(opts.Plugins) > 0 {
 		pluginScanners, err = format.LoadPlugins(opts.Plugins...)
 		if err != nil {
 			return err
 		}
+		defer format.ClosePlugins(pluginScanners)
 		scanners = append(scanners, pluginScanners...)
 	}
 
+	if len(opts.Scanners) > 0 {
+		if err := validateCustomScanners(builtinScanners, opts.Scanners); err != nil {
+			return err
+		}
+		scanners = append(scanners, opts.Scanners...)
+	}
+
 	registry := format.BuildFileRegistry(scanners...)
 
 	fileExts := make([]string, len(scanners))
@@ -155,7 +379,7 @@ func ScanPartition(p *disk.Partition, filePath string, opts Options) error {
 		fileExts[i] = scanners[i].Ext()
 	}
 
-	logger, logFile, err := setupLogger(logFilePath, opts.LogLevel)
+	logger, logFile, err := setupLogger(logOutput(opts), logFilePath, opts.LogLevel, opts.Quiet)
 	if err != nil {
 		return err
 	}
@@ -164,11 +388,18 @@ func ScanPartition(p *disk.Partition, filePath string, opts Options) error {
 	}
 
 	logger.Info("Starting scanning operation...")
-	logger.Infof("Source: \t%s", absPath(filePath))
+	if filePath == fs.StdinPath {
+		logger.Info("Source: \tstandard input")
+	} else if resolved := disk.ResolvePath(filePath); resolved != absPath(filePath) {
+		logger.Infof("Source: \t%s (%s)", absPath(filePath), resolved)
+	} else {
+		logger.Infof("Source: \t%s", absPath(filePath))
+	}
 	logger.Infof("File Types: \t%s", strings.Join(fileExts, ","))
 
 	if len(pluginScanners) > 0 {
 		logger.Infof("Loaded %d plugins(s): \t%s", len(pluginScanners), strings.Join(opts.Plugins, ","))
+		logSignatureOverlaps(logger, builtinScanners, pluginScanners)
 	} else {
 		logger.Infof("No plugin loaded")
 	}
@@ -182,17 +413,58 @@ func ScanPartition(p *disk.Partition, filePath string, opts Options) error {
 		outLog = logFilePath
 	}
 	logger.Infof("Output Log: \t%s", outLog)
+	if opts.MaxDepth > 0 {
+		logger.Infof("Recursive carving: \tenabled (max depth %d)", opts.MaxDepth)
+	}
+	if knownHashes != nil {
+		logger.Infof("Known files: \t%d hashes from %s", len(knownHashes), absPath(opts.IgnoreHashes))
+	}
+	if opts.Dedupe {
+		logger.Infof("Deduplication: \tenabled")
+	}
 	logger.Infof("Scanning for %d signatures...", registry.Signatures())
 
-	size := min(opts.MaxScanSize, p.Size)
-	r := io.NewSectionReader(f, int64(p.Offset), int64(size))
+	if p.Err != nil {
+		logger.Warnf("Partition %d is invalid: %s", p.Num, p.Err)
+	}
 
-	if opts.DumpDir != "" {
-		if err := os.MkdirAll(opts.DumpDir, 0755); err != nil {
+	size := scanSize(p, opts)
+
+	// Offset of the scanned range within the image, to which carved file offsets are relative.
+	rangeOffset := p.Offset + opts.Offset
+	r := io.NewSectionReader(f, int64(rangeOffset), int64(size))
+
+	if opts.Offset != 0 || size < p.Size {
+		logger.Infof("Byte range: \t%d-%d (%s)", rangeOffset, rangeOffset+size, fmtutil.FormatBytes(int64(size)))
+	}
+
+	scanBufferSize := opts.ScanBufferSize
+	if scanBufferSize == 0 {
+		scanBufferSize = AutoScanBufferSize()
+		logger.Infof("Scan buffer: \t%s (auto)", fmtutil.FormatBytes(int64(scanBufferSize)))
+	}
+
+	dumpDir := opts.DumpDir
+	if opts.DryRun {
+		dumpDir = ""
+		logger.Infof("Dry run: \tno file will be dumped")
+	}
+	if opts.HeaderOnly {
+		dumpDir = ""
+		logger.Infof("Header only: \tfiles are neither validated nor sized, and no file will be dumped")
+	}
+
+	if dumpDir != "" {
+		if err := os.MkdirAll(dumpDir, 0755); err != nil {
 			return err
 		}
 	}
 
+	maxFileSize := opts.MaxFileSize
+	if maxFileSize == 0 {
+		maxFileSize = math.MaxUint64
+	}
+
 	start := time.Now()
 	filesFound := 0
 	var totalDataSize uint64 = 0
@@ -200,59 +472,639 @@ func ScanPartition(p *disk.Partition, filePath string, opts Options) error {
 	sc := format.NewScanner(
 		logger,
 		registry,
-		int(opts.ScanBufferSize),
+		int(scanBufferSize),
 		int(blockSize),
-		opts.MaxFileSize,
+		maxFileSize,
 	)
+	sc.SetMaxDepth(opts.MaxDepth)
+	if opts.NameTemplate != "" {
+		sc.SetNameTemplate(names)
+	}
+	dumpNames := opts.dumpNames
+	if dumpNames == nil {
+		dumpNames = format.NewNameSet()
+	}
+	sc.SetNameSet(dumpNames)
+	sc.SetSkipErrors(opts.SkipErrors)
+	sc.SetFatalPanics(opts.FatalPanics)
+	sc.SetHeaderOnly(opts.HeaderOnly)
+	sc.SetEntropyFilter(opts.EntropyFilter)
+	sc.SetScanBudget(opts.MaxCarveBytes)
+	if opts.BufferOverlap > 0 {
+		sc.SetBufferOverlap(int(opts.BufferOverlap))
+	}
+	sc.SetBaseOffset(opts.Offset)
+	sc.SetProgress(opts.Progress)
+
+	if opts.SkipPartitionMetadata {
+		for _, region := range partitionMetadata(p) {
+			sc.SkipRegion(region.Offset, region.Size)
+		}
+	}
+
+	for _, region := range opts.SkipRegions {
+		sc.SkipRegion(region.Offset, region.Size)
+	}
+
+	var seen *dedupeSet
+	if opts.Dedupe {
+		seen = newDedupeSet()
+	}
+
+	var fatFiles, knownFiles, lowConfidenceFiles int
+	if opts.FATMetadata {
+		if isFAT(p.FSType) {
+			pr := io.NewSectionReader(f, int64(p.Offset), int64(p.Size))
+
+			fatFiles, knownFiles, err = recoverFATFiles(pr, p.Offset, dumpDir, dumpNames, knownHashes, seen, sc, reportFileWriter, logger)
+			if err != nil {
+				logger.Errorf("unable to read FAT directories: %s", err)
+			}
+		} else {
+			logger.Warnf("Partition %d is not a FAT volume (%s): no file recovered from metadata", p.Num, p.FSType)
+		}
+	}
+
+	formatStats := make(map[string]*FormatSummary)
+
 	for finfo := range sc.Scan(r, size) {
+		if finfo.Confidence < opts.MinConfidence {
+			lowConfidenceFiles++
+			continue
+		}
+
+		digests, known := checkKnownFile(knownHashes, logger, finfo.Name,
+			io.NewSectionReader(r, int64(finfo.Offset), int64(finfo.Size)))
+		if known {
+			knownFiles++
+			continue
+		}
+
+		digests, duplicateOf := seen.check(logger, finfo.Name, digests,
+			io.NewSectionReader(r, int64(finfo.Offset), int64(finfo.Size)))
+
 		filesFound++
 		totalDataSize += finfo.Size
 
-		if opts.DumpDir != "" {
-			if err := DumpFile(r, opts.DumpDir, &finfo); err != nil {
+		stats := formatStats[finfo.Ext]
+		if stats == nil {
+			stats = &FormatSummary{}
+			formatStats[finfo.Ext] = stats
+		}
+		stats.Files++
+		stats.Bytes += finfo.Size
+
+		if dumpDir != "" && duplicateOf == "" {
+			if err := DumpFile(r, dumpDir, &finfo); err != nil {
 				logger.Errorf("unable to dump file %s: %s", finfo.Name, err)
 			}
 		}
 
+		if finfo.Truncated {
+			logger.Warnf("file %s at offset %d was truncated to %s",
+				finfo.Name, finfo.Offset, fmtutil.FormatBytes(int64(finfo.Size)))
+		}
+
 		err := reportFileWriter.WriteFileObject(dfxml.FileObject{
-			Filename: finfo.Name,
-			FileSize: uint64(finfo.Size),
-			ByteRuns: dfxml.ByteRuns{
-				Runs: []dfxml.ByteRun{{
-					Offset:    uint64(finfo.Offset),
-					ImgOffset: uint64(finfo.Offset),
-					Length:    uint64(finfo.Size),
-				}},
-			},
+			Filename:    finfo.Name,
+			FileSize:    uint64(finfo.Size),
+			Truncated:   finfo.Truncated,
+			Confidence:  finfo.Confidence.String(),
+			Version:     finfo.Version,
+			HashDigests: digests,
+			DuplicateOf: duplicateOf,
+			Parent:      parentObject(finfo.Parent),
+			Detection:   detectionObject(finfo.Detection),
+			ByteRuns:    dfxml.ByteRuns{Runs: byteRunObjects(finfo.ByteRuns(), rangeOffset)},
 		})
 		if err != nil {
 			logger.Errorf("unable to write index entry: %s", err)
 		}
 	}
 
+	if err := sc.Err(); err != nil {
+		return err
+	}
+
+	if opts.SkipErrors {
+		if err := reportFileWriter.WriteScanStats(dfxml.ScanStats{BadBlocks: sc.BadBlocks()}); err != nil {
+			logger.Errorf("unable to write scan stats: %s", err)
+		}
+	}
+
 	logger.Infof("Scan completed!")
 	logger.Infof("Signatures found: \t%d", sc.FoundSignatures())
 	logger.Infof("Files found: \t\t%d", filesFound)
+	if opts.FATMetadata {
+		logger.Infof("Files from FAT: \t%d", fatFiles)
+	}
+	if knownHashes != nil {
+		logger.Infof("Known files skipped: \t%d", knownFiles)
+	}
+	if seen != nil {
+		logger.Infof("Duplicates: \t\t%d (not dumped)", seen.duplicates)
+	}
+	if lowConfidenceFiles > 0 {
+		logger.Infof("Low confidence skipped: \t%d", lowConfidenceFiles)
+	}
+	if opts.SkipErrors {
+		logger.Infof("Bad blocks: \t\t%d", sc.BadBlocks())
+	}
+	if sc.BudgetExceeded() > 0 {
+		logger.Infof("Over scan budget: \t%d", sc.BudgetExceeded())
+	}
+	if opts.EntropyFilter {
+		logger.Infof("Entropy rejected: \t%d", sc.EntropyRejected())
+	}
+	if sc.Panics() > 0 {
+		logger.Warnf("Scanner panics: \t%d (see the log for details)", sc.Panics())
+	}
 	logger.Infof("Total data: \t\t%s", fmtutil.FormatBytes(int64(size)))
 	logger.Infof("Duration: \t\t%s", FormatDurationHMS(time.Since(start)))
 	logger.Infof("Report saved to: \t%s", absPath(reportFileName))
 
+	if opts.DryRun {
+		logFormatSummary(logger, formatStats)
+	}
+
 	if !opts.DisableLog {
 		logger.Infof("Detailed scan log: \t%s", logFilePath)
 	}
+
+	if opts.OnSummary != nil {
+		summary := Summary{
+			Partition:    p.Num,
+			FilesFound:   filesFound,
+			FATFiles:     fatFiles,
+			BytesScanned: size,
+			Duration:     time.Since(start).Milliseconds(),
+			ReportPath:   absPath(reportFileName),
+			PerExt:       make(map[string]FormatSummary, len(formatStats)),
+		}
+		if seen != nil {
+			summary.Duplicates = seen.duplicates
+		}
+		for ext, stats := range formatStats {
+			summary.PerExt[ext] = *stats
+		}
+		opts.OnSummary(summary)
+	}
 	return nil
 }
 
+// blockSizeOf returns the block size used to scan the given partition.
+func blockSizeOf(p *disk.Partition, opts Options) uint64 {
+	if size := opts.PartitionBlockSizes[p.Num]; size != 0 {
+		return size
+	}
+	if opts.BlockSize != 0 {
+		return opts.BlockSize
+	}
+	return uint64(p.BlockSize)
+}
+
+// scanSize returns the number of bytes of the given partition which are scanned.
+func scanSize(p *disk.Partition, opts Options) uint64 {
+	if opts.Offset >= p.Size {
+		return 0
+	}
+
+	size := p.Size - opts.Offset
+	if opts.MaxScanSize != 0 {
+		size = min(size, opts.MaxScanSize)
+	}
+	if opts.Length != 0 {
+		size = min(size, opts.Length)
+	}
+	return size
+}
+
+// reportPath returns the path of the report of the scan with the given ID, creating
+// its directory if needed.
+func reportPath(opts Options, scanID string) (string, error) {
+	if opts.ReportFile != "" {
+		return opts.ReportFile, nil
+	}
+
+	reportDir := opts.ReportDir
+	if reportDir == "" {
+		// Next to the log file.
+		reportDir = opts.DumpDir
+	}
+
+	if reportDir != "" {
+		if err := os.MkdirAll(reportDir, 0755); err != nil {
+			return "", err
+		}
+	}
+	return filepath.Join(reportDir, fmt.Sprintf("report_%s.xml", scanID)), nil
+}
+
+// reportHeader returns the header of the report of a scan of the image at filePath,
+// completing the given source with the image paths.
+func reportHeader(filePath string, opts Options, src dfxml.Source) dfxml.DFXMLHeader {
+	src.ImageFilename = filePath
+	src.ResolvedPath = resolvedPath(filePath)
+
+	return dfxml.DFXMLHeader{
+		XmlOutput: dfxml.XmlOutputVersion,
+		Metadata:  dfxml.DefaultMetadata,
+		Creator: dfxml.Creator{
+			Package:              env.AppName,
+			Version:              env.Version,
+			ExecutionEnvironment: dfxml.GetExecEnv(),
+			Options:              reportOptions(opts),
+		},
+		Source: src,
+	}
+}
+
+// sharedReport is a report written by Scan for all the scanned partitions.
+type sharedReport struct {
+	path string
+	f    *os.File
+	w    *dfxml.DFXMLWriter
+}
+
+// createSharedReport creates the report of the scan of the given partitions of the image
+// at filePath and writes its header. Its sector size is that of the first partition.
+func createSharedReport(filePath string, partitions []disk.Partition, opts Options) (*sharedReport, error) {
+	img, err := fs.Open(filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer img.Close()
+
+	imgInfo, err := img.Stat()
+	if err != nil {
+		return nil, err
+	}
+
+	path, err := reportPath(opts, GetScanID())
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+
+	w := dfxml.NewDFXMLWriter(f)
+	err = w.WriteHeader(reportHeader(filePath, opts, dfxml.Source{
+		SectorSize: int(blockSizeOf(&partitions[0], opts)),
+		ImageSize:  uint64(imgInfo.Size()),
+	}))
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	return &sharedReport{path: path, f: f, w: w}, nil
+}
+
+func (r *sharedReport) close() error {
+	err := r.w.Close()
+	if cerr := r.f.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+func isFAT(fsType disk.FSType) bool {
+	return fsType == disk.FSTypeFAT12 || fsType == disk.FSTypeFAT16 || fsType == disk.FSTypeFAT32
+}
+
+// recoverFATFiles recovers the files listed in the directories of the FAT volume read by r,
+// which starts at imgOffset within the image, writing them to the report and dumping them to
+// dumpDir, if not empty, under names made unique within dumpNames. The contents of the
+// recovered files are excluded from carving.
+// Files belonging to the known hashes are skipped, and the copies of files already recorded
+// in seen, if not nil, are reported but not dumped.
+// It returns the number of recovered and of skipped files.
+func recoverFATFiles(
+	r io.ReaderAt,
+	imgOffset uint64,
+	dumpDir string,
+	dumpNames *format.NameSet,
+	knownHashes HashSet,
+	seen *dedupeSet,
+	sc *format.Scanner,
+	reportFileWriter *dfxml.DFXMLWriter,
+	logger *logger.Logger,
+) (int, int, error) {
+	vol, err := disk.OpenFATVolume(r)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	n, known := 0, 0
+	err = vol.Walk(func(file disk.FATFile) bool {
+		finfo := fatFileInfo(file)
+
+		digests, isKnown := checkKnownFile(knownHashes, logger, file.Path, runReaders(r, finfo.Runs)...)
+		if isKnown {
+			for _, run := range finfo.Runs {
+				sc.SkipRegion(run.Offset, run.Size)
+			}
+			known++
+			return true
+		}
+
+		n++
+
+		digests, duplicateOf := seen.check(logger, file.Path, digests, runReaders(r, finfo.Runs)...)
+
+		for _, run := range finfo.Runs {
+			sc.SkipRegion(run.Offset, run.Size)
+		}
+
+		if dumpDir != "" && duplicateOf == "" {
+			relPath, err := sanitizeFilePath(file.Path)
+			if err == nil {
+				filePath := filepath.Join(dumpDir, dumpNames.Unique(relPath))
+
+				err = DumpRuns(r, filePath, finfo.Runs)
+				if err == nil {
+					err = setModTime(filePath, file.ModTime)
+				}
+			}
+			if err != nil {
+				logger.Errorf("unable to dump file %s: %s", file.Path, err)
+			}
+		}
+
+		var modTime *time.Time
+		if !file.ModTime.IsZero() {
+			modTime = &file.ModTime
+		}
+
+		err := reportFileWriter.WriteFileObject(dfxml.FileObject{
+			Filename:    file.Path,
+			FileSize:    file.Size,
+			Unallocated: file.Deleted,
+			ModTime:     modTime,
+			HashDigests: digests,
+			DuplicateOf: duplicateOf,
+			ByteRuns:    dfxml.ByteRuns{Runs: byteRunObjects(finfo.Runs, imgOffset)},
+		})
+		if err != nil {
+			logger.Errorf("unable to write index entry: %s", err)
+		}
+		return true
+	})
+	return n, known, err
+}
+
+// fatFileInfo returns the information of a file listed in a FAT directory, whose contents
+// are held by the clusters of its chain. Empty files have no byte runs.
+func fatFileInfo(file disk.FATFile) format.FileInfo {
+	runs := make([]format.ByteRun, len(file.Runs))
+	for i, run := range file.Runs {
+		runs[i] = format.ByteRun{Offset: run.Offset, Size: run.Size}
+	}
+
+	return format.FileInfo{
+		Name:    file.Path,
+		Size:    file.Size,
+		ModTime: file.ModTime,
+		Runs:    runs,
+	}
+}
+
+// byteRunObjects returns the report entries of the given byte runs of a file, whose
+// offsets are relative to the source starting at imgOffset within the image.
+func byteRunObjects(runs []format.ByteRun, imgOffset uint64) []dfxml.ByteRun {
+	objs := make([]dfxml.ByteRun, len(runs))
+
+	var fileOffset uint64
+	for i, run := range runs {
+		objs[i] = dfxml.ByteRun{
+			Offset:    fileOffset,
+			ImgOffset: imgOffset + run.Offset,
+			Length:    run.Size,
+		}
+		fileOffset += run.Size
+	}
+	return objs
+}
+
+// parentObject returns the report entry referring to the file with the given name,
+// or nil if the name is empty.
+func parentObject(name string) *dfxml.ParentObject {
+	if name == "" {
+		return nil
+	}
+	return &dfxml.ParentObject{Filename: name}
+}
+
+// detectionObject returns the report entry describing the given detection, or nil
+// if the file was not detected by a signature.
+func detectionObject(d format.Detection) *dfxml.Detection {
+	if d.Scanner == "" {
+		return nil
+	}
+	return &dfxml.Detection{
+		Scanner:         d.Scanner,
+		Description:     d.Description,
+		Signature:       hex.EncodeToString(d.Signature),
+		SignatureOffset: d.SignatureOffset,
+	}
+}
+
+// checkKnownFile hashes the file made of the concatenation of the given readers, when known
+// hashes are given. It returns the digests to report, and whether the file is a known one.
+func checkKnownFile(knownHashes HashSet, logger *logger.Logger, name string, readers ...io.Reader) ([]dfxml.HashDigest, bool) {
+	if knownHashes == nil {
+		return nil, false
+	}
+
+	digest, known, err := knownHashes.checkKnown(readers...)
+	if err != nil {
+		logger.Errorf("unable to hash file %s: %s", name, err)
+		return nil, false
+	}
+	return []dfxml.HashDigest{{Type: "sha1", Value: digest}}, known
+}
+
+// Summary is the outcome of the scan of a partition, passed to Options.OnSummary.
+// It is meant to be encoded as JSON for scripts.
+type Summary struct {
+	Partition    int                      `json:"partition"`
+	FilesFound   int                      `json:"files_found"`          // Number of carved files.
+	FATFiles     int                      `json:"fat_files,omitempty"`  // Number of files recovered from FAT directories.
+	Duplicates   int                      `json:"duplicates,omitempty"` // Number of files not dumped, as copies of other files.
+	BytesScanned uint64                   `json:"bytes_scanned"`
+	Duration     int64                    `json:"duration_ms"` // Duration of the scan in milliseconds.
+	ReportPath   string                   `json:"report_path"`
+	PerExt       map[string]FormatSummary `json:"per_ext"` // Carved files by extension.
+}
+
+// FormatSummary holds the number and total size of the files carved for a format.
+type FormatSummary struct {
+	Files int    `json:"files"`
+	Bytes uint64 `json:"bytes"`
+}
+
+// logFormatSummary logs the number and total size of the files found for each format.
+func logFormatSummary(logger *logger.Logger, stats map[string]*FormatSummary) {
+	exts := make([]string, 0, len(stats))
+	for ext := range stats {
+		exts = append(exts, ext)
+	}
+	sort.Strings(exts)
+
+	logger.Infof("Files by format:")
+	for _, ext := range exts {
+		logger.Infof("  %s: \t%d file(s), %s", ext, stats[ext].Files, fmtutil.FormatBytes(int64(stats[ext].Bytes)))
+	}
+}
+
+// logSignatureOverlaps warns about plugin signatures conflicting with the signature of
+// a built-in scanner at the same offset (see format.FindSignatureConflicts): the scanner
+// with the shorter signature is tried first.
+func logSignatureOverlaps(logger *logger.Logger, builtins, plugins []format.FileScanner) {
+	isPlugin := make(map[string]bool, len(plugins))
+	for _, sc := range plugins {
+		isPlugin[sc.Ext()] = true
+	}
+
+	scanners := append(append([]format.FileScanner(nil), builtins...), plugins...)
+	for _, c := range format.FindSignatureConflicts(scanners...) {
+		if isPlugin[c.Ext] || isPlugin[c.PrefixExt] {
+			logger.Warnf("%s signature %x at offset %d is shadowed by %s signature %x: the latter is tried first",
+				c.Ext, c.Signature, c.Offset, c.PrefixExt, c.Prefix)
+		}
+	}
+}
+
+// AutoScanBufferSize returns a scan buffer size proportional to the total
+// physical memory, bounded by DefaultScanBufferSize and MaxAutoScanBufferSize.
+func AutoScanBufferSize() uint64 {
+	sinfo, err := sysinfo.Stat()
+	if err != nil || sinfo.TotalRAM == 0 {
+		return DefaultScanBufferSize
+	}
+
+	size := sinfo.TotalRAM / autoScanBufferRAMFraction
+	return min(max(size, DefaultScanBufferSize), MaxAutoScanBufferSize)
+}
+
+// validateBufferSizes checks the block size of a scan, which must be positive and fit in
+// 32 bits, the size of the scan buffer, which, if set, must hold at least a block, and
+// the buffer overlap, which must not exceed the scan buffer.
+func validateBufferSizes(blockSize, scanBufferSize, overlap uint64) error {
+	if blockSize == 0 {
+		return errors.New("block size must be greater than 0")
+	}
+	if blockSize > math.MaxUint32 {
+		return fmt.Errorf("block size %s is too large", fmtutil.FormatBytes(int64(blockSize)))
+	}
+	if scanBufferSize != 0 && scanBufferSize < blockSize {
+		return fmt.Errorf("scan buffer size (%s) must not be smaller than the block size (%s)",
+			fmtutil.FormatBytes(int64(scanBufferSize)), fmtutil.FormatBytes(int64(blockSize)))
+	}
+
+	maxOverlap := scanBufferSize
+	if maxOverlap == 0 {
+		maxOverlap = MaxAutoScanBufferSize
+	}
+	if overlap > maxOverlap {
+		return fmt.Errorf("buffer overlap (%s) must not be larger than the scan buffer",
+			fmtutil.FormatBytes(int64(overlap)))
+	}
+	return nil
+}
+
+func openImage(path string, useMmap bool) (fs.File, error) {
+	if useMmap {
+		return fs.OpenMmap(path)
+	}
+	return fs.Open(path)
+}
+
+// DumpFile writes the bytes of the file described by finfo to outDir, concatenating
+// its byte runs if it is fragmented. The name of the file is sanitized, so that the
+// file is always written inside outDir.
 func DumpFile(r io.ReaderAt, outDir string, finfo *format.FileInfo) error {
-	fileReader := io.NewSectionReader(r, int64(finfo.Offset), int64(finfo.Size))
+	name, err := sanitizeFileName(finfo.Name)
+	if err != nil {
+		return err
+	}
+
+	filePath := filepath.Join(outDir, name)
+	if err := DumpRuns(r, filePath, finfo.ByteRuns()); err != nil {
+		return err
+	}
+	return setModTime(filePath, finfo.ModTime)
+}
+
+// sanitizeFileName makes a file name, which may come from a plugin or from recovered
+// metadata, safe to be joined with an output directory: directory components are stripped
+// and characters which are not allowed in file names on some platforms are replaced by '_'.
+// Names which are empty or refer to a directory, like "..", are rejected.
+func sanitizeFileName(name string) (string, error) {
+	if i := strings.LastIndexAny(name, `/\`); i >= 0 {
+		name = name[i+1:]
+	}
+
+	name = strings.Map(func(r rune) rune {
+		if r < 0x20 || r == 0x7f || strings.ContainsRune(`<>:"|?*`, r) {
+			return '_'
+		}
+		return r
+	}, name)
+
+	if name == "" || name == "." || name == ".." {
+		return "", fmt.Errorf("invalid file name %q", name)
+	}
+	return name, nil
+}
+
+// sanitizeFilePath sanitizes each component of a slash-separated relative path
+// (see sanitizeFileName) and joins them with the separator of the OS.
+func sanitizeFilePath(path string) (string, error) {
+	components := strings.Split(strings.Trim(path, "/"), "/")
+	for i, c := range components {
+		name, err := sanitizeFileName(c)
+		if err != nil {
+			return "", fmt.Errorf("invalid file path %q: %w", path, err)
+		}
+		components[i] = name
+	}
+	return filepath.Join(components...), nil
+}
+
+// setModTime sets the access and modification times of the file at filePath to modTime.
+// Nothing is done if modTime is the zero time.
+func setModTime(filePath string, modTime time.Time) error {
+	if modTime.IsZero() {
+		return nil
+	}
+	return os.Chtimes(filePath, modTime, modTime)
+}
+
+// DumpRuns writes the concatenation of the given byte ranges of r to the file at filePath,
+// creating its parent directories.
+func DumpRuns(r io.ReaderAt, filePath string, runs []format.ByteRun) error {
+	if err := os.MkdirAll(filepath.Dir(filePath), 0755); err != nil {
+		return err
+	}
 
-	return ioutil.CopyFile(filepath.Join(outDir, finfo.Name), fileReader)
+	return ioutil.CopyFile(filePath, io.MultiReader(runReaders(r, runs)...))
+}
+
+func runReaders(r io.ReaderAt, runs []format.ByteRun) []io.Reader {
+	readers := make([]io.Reader, len(runs))
+	for i, run := range runs {
+		readers[i] = io.NewSectionReader(r, int64(run.Offset), int64(run.Size))
+	}
+	return readers
 }
 
 func DiscoverPartitions(path string) ([]disk.Partition, error) {
 	imgFile, err := fs.Open(path)
 	if err != nil {
-		return nil, fmt.Errorf("failed to open image file %q: %w", path, err)
+		return nil, err
 	}
 	defer imgFile.Close()
 
@@ -280,13 +1132,99 @@ func DiscoverPartitions(path string) ([]disk.Partition, error) {
 	}
 
 	return []disk.Partition{
-		fullDiskPartition(uint64(finfo.Size())),
+		fullDiskPartition(uint64(finfo.Size()), disk.DetectFSType(imgFile, 0)),
 	}, nil
 }
 
-func fullDiskPartition(diskSize uint64) disk.Partition {
+// DiscoverGaps returns synthetic partitions covering the unallocated space of the image
+// or device at path, i.e. the byte ranges before, between and after the given partitions,
+// which may hold deleted partitions or hidden data. They are numbered after the last
+// partition, and their filesystem is detected, e.g. to find the ones of former partitions.
+// Gaps smaller than a sector are ignored. A disk which is not partitioned has no gaps.
+func DiscoverGaps(path string, partitions []disk.Partition) ([]disk.Partition, error) {
+	if len(partitions) == 0 || partitions[0].Type == disk.PartitionTypeEmpty {
+		return nil, nil
+	}
+
+	imgFile, err := fs.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer imgFile.Close()
+
+	finfo, err := imgFile.Stat()
+	if err != nil {
+		return nil, err
+	}
+
+	var gaps []disk.Partition
+	for _, region := range unallocatedRegions(partitions, uint64(finfo.Size())) {
+		gap := disk.Partition{
+			FSType:      disk.DetectFSType(imgFile, region.Offset),
+			Type:        disk.PartitionTypeEmpty,
+			Offset:      region.Offset,
+			Size:        region.Size,
+			BlockSize:   disk.DefaultBlocksize,
+			Unallocated: true,
+		}
+		if region.Offset == 0 {
+			// The partition table.
+			gap.Metadata = []disk.Region{{Offset: 0, Size: disk.DefaultBlocksize}}
+		}
+		gaps = append(gaps, gap)
+	}
+
+	num := 0
+	for _, p := range partitions {
+		num = max(num, p.Num+1)
+	}
+	for i := range gaps {
+		gaps[i].Num = num + i
+	}
+	return gaps, nil
+}
+
+// unallocatedRegions returns the byte ranges of a disk of diskSize bytes which are not
+// covered by any of the given partitions, aligned to sectors.
+func unallocatedRegions(partitions []disk.Partition, diskSize uint64) []disk.Region {
+	sorted := append([]disk.Partition(nil), partitions...)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Offset < sorted[j].Offset
+	})
+
+	var regions []disk.Region
+	addRegion := func(start, end uint64) {
+		start = roundUp(start, disk.DefaultBlocksize)
+		end -= end % disk.DefaultBlocksize
+		if end > start {
+			regions = append(regions, disk.Region{Offset: start, Size: end - start})
+		}
+	}
+
+	var pos uint64
+	for _, p := range sorted {
+		start := min(p.Offset, diskSize)
+		if start > pos {
+			addRegion(pos, start)
+		}
+		pos = max(pos, min(p.Offset+p.Size, diskSize))
+	}
+	addRegion(pos, diskSize)
+	return regions
+}
+
+func roundUp(n, m uint64) uint64 {
+	return (n + m - 1) / m * m
+}
+
+// fullDiskPartition returns a partition spanning the whole disk, for disks without a partition table.
+func fullDiskPartition(diskSize uint64, fsType disk.FSType) disk.Partition {
+	if fsType == disk.FSTypeUnknown {
+		fsType = disk.FSTypeRaw
+	}
+
 	return disk.Partition{
-		FSType:    1,
+		FSType:    fsType,
 		Num:       0,
 		Offset:    0,
 		Size:      diskSize,
@@ -297,21 +1235,43 @@ func fullDiskPartition(diskSize uint64) disk.Partition {
 func GetMBRPartitions(imgFile fs.File, mbr *disk.MBR) ([]disk.Partition, error) {
 	// protective MBR for GPT disks
 	if p := mbr.PartitionEntries[0]; p.PartitionType == disk.PartitionTypeGPT {
-		offset := int64(p.ReadStartLBA()) * disk.DefaultBlocksize
-		size := uint64(binary.LittleEndian.Uint32(p.TotalSectors[:])) * uint64(disk.DefaultBlocksize)
+		hdr, sectorSize, err := disk.ReadGPTHeader(imgFile)
+		if err == nil {
+			if partitions, err := getGPTPartitions(imgFile, hdr, sectorSize); err == nil && len(partitions) > 0 {
+				return partitions, nil
+			}
+		} else {
+			sectorSize = disk.DefaultBlocksize
+		}
+
+		// The partition table cannot be read: scan the whole protective partition.
+		offset := uint64(p.ReadStartLBA()) * uint64(sectorSize)
+		size := uint64(binary.LittleEndian.Uint32(p.TotalSectors[:])) * uint64(sectorSize)
+
+		var metadata []disk.Region
+		if hdr != nil {
+			metadata = gptMetadataRegions(hdr, sectorSize, offset, size)
+		}
 
-		// TODO: discover sector size
 		return []disk.Partition{
 			{
-				FSType:    0,
+				FSType:    disk.FSTypeUnknown,
+				Type:      p.PartitionType,
 				Num:       0,
-				Offset:    uint64(offset),
-				BlockSize: disk.DefaultBlocksize,
+				Offset:    offset,
+				BlockSize: sectorSize,
 				Size:      size,
+				Metadata:  metadata,
 			},
 		}, nil
 	}
 
+	finfo, err := imgFile.Stat()
+	if err != nil {
+		return nil, err
+	}
+	entryErrs := mbr.Validate(uint64(finfo.Size()) / disk.DefaultBlocksize)
+
 	partitions := make([]disk.Partition, 0, len(mbr.PartitionEntries))
 	for n, p := range mbr.PartitionEntries {
 		switch p.PartitionType {
@@ -322,22 +1282,21 @@ func GetMBRPartitions(imgFile fs.File, mbr *disk.MBR) ([]disk.Partition, error)
 			disk.PartitionTypeFAT32LBA,
 			disk.PartitionTypeFAT32CHS:
 
-			offset := int64(p.ReadStartLBA()) * disk.DefaultBlocksize
-
-			var buf [512]byte
-			_, err := imgFile.ReadAt(buf[:], offset)
-			if err != nil {
-				continue
-			}
-
-			fatSector, err := disk.ReadFatBootSectorFrom(buf[:])
+			fatSector, sectorSize, err := disk.ReadFatBootSector(imgFile, p.ReadStartLBA())
 			if err == nil {
+				offset := uint64(p.ReadStartLBA()) * uint64(sectorSize)
 				partitions = append(partitions, disk.Partition{
-					FSType:    0,
+					FSType:    disk.DetectFSType(imgFile, offset),
+					Type:      p.PartitionType,
 					Num:       n,
-					Offset:    uint64(offset),
+					Offset:    offset,
 					BlockSize: uint32(fatSector.SectorSize),
-					Size:      uint64(binary.LittleEndian.Uint32(p.TotalSectors[:])) * uint64(fatSector.SectorSize),
+					Size:      uint64(binary.LittleEndian.Uint32(p.TotalSectors[:])) * uint64(sectorSize),
+					Metadata: []disk.Region{
+						// Boot sector, FS information sector and backup boot sector.
+						{Offset: 0, Size: uint64(fatSector.Reserved) * uint64(fatSector.SectorSize)},
+					},
+					Err: entryErrs[n],
 				})
 			}
 		}
@@ -345,6 +1304,86 @@ func GetMBRPartitions(imgFile fs.File, mbr *disk.MBR) ([]disk.Partition, error)
 	return partitions, nil
 }
 
+// maxGPTPartitionEntriesSize bounds the size of the GPT partition entries array which is read.
+const maxGPTPartitionEntriesSize = 1024 * 1024
+
+// getGPTPartitions returns the partitions listed in the GUID Partition Table of the disk,
+// described by the given header, whose LBAs are in units of sectorSize bytes.
+// The block size of HFS+ and APFS volumes is read from their superblock.
+func getGPTPartitions(imgFile fs.File, hdr *disk.GPTHeader, sectorSize uint32) ([]disk.Partition, error) {
+	if hdr.PartitionEntriesSize() > maxGPTPartitionEntriesSize {
+		return nil, fmt.Errorf("GPT partition entries array too large: %d bytes", hdr.PartitionEntriesSize())
+	}
+
+	data := make([]byte, hdr.PartitionEntriesSize())
+	if _, err := imgFile.ReadAt(data, int64(hdr.PartitionEntriesLBA)*int64(sectorSize)); err != nil {
+		return nil, err
+	}
+
+	entries, err := disk.ParseGPTPartitionEntries(hdr, data)
+	if err != nil {
+		return nil, err
+	}
+
+	partitions := make([]disk.Partition, 0, len(entries))
+	for _, e := range entries {
+		offset := e.FirstLBA * uint64(sectorSize)
+		fsType := disk.DetectFSType(imgFile, offset)
+
+		blockSize := disk.FSBlockSize(imgFile, offset, fsType)
+		if blockSize < sectorSize || blockSize&(blockSize-1) != 0 {
+			blockSize = sectorSize
+		}
+
+		partitions = append(partitions, disk.Partition{
+			FSType:    fsType,
+			Type:      disk.PartitionTypeGPT,
+			Num:       int(e.Index),
+			Offset:    offset,
+			Size:      (e.LastLBA - e.FirstLBA + 1) * uint64(sectorSize),
+			BlockSize: blockSize,
+		})
+	}
+	return partitions, nil
+}
+
+// partitionMetadata returns the metadata regions of the partition. When none is known,
+// the first sector of the partition is assumed to be a boot sector.
+func partitionMetadata(p *disk.Partition) []disk.Region {
+	if len(p.Metadata) > 0 {
+		return p.Metadata
+	}
+
+	blockSize := uint64(p.BlockSize)
+	if blockSize == 0 {
+		blockSize = disk.DefaultBlocksize
+	}
+	return []disk.Region{{Offset: 0, Size: blockSize}}
+}
+
+// gptMetadataRegions returns the regions of the GPT protective partition holding the primary
+// and backup GPT headers and partition entries. The offsets of GPT structures are absolute,
+// so they are clipped to the partition at the given offset.
+func gptMetadataRegions(hdr *disk.GPTHeader, sectorSize uint32, offset, size uint64) []disk.Region {
+	var regions []disk.Region
+
+	addRegion := func(start, end uint64) {
+		start = max(start, offset)
+		end = min(end, offset+size)
+		if start < end {
+			regions = append(regions, disk.Region{Offset: start - offset, Size: end - start})
+		}
+	}
+
+	sector := uint64(sectorSize)
+
+	// Primary header and partition entries, up to the first usable LBA.
+	addRegion(hdr.CurrentLBA*sector, hdr.FirstUsableLBA*sector)
+	// Backup partition entries and header, after the last usable LBA.
+	addRegion((hdr.LastUsableLBA+1)*sector, (hdr.BackupLBA+1)*sector)
+	return regions
+}
+
 // GetScanID creates a unique file name for a scan session.
 // The format is "scan_YYYYMMDD_HHMMSS".
 func GetScanID() string {
@@ -375,13 +1414,27 @@ func FormatDurationHMS(d time.Duration) string {
 	return fmt.Sprintf("%02d:%02d:%02d", hours, minutes, seconds)
 }
 
+// logOutput returns the writer the scan logs are printed to.
+func logOutput(opts Options) io.Writer {
+	if opts.LogOutput != nil {
+		return opts.LogOutput
+	}
+	return os.Stdout
+}
+
 // setupLogger initializes a new slog.Logger that writes to a specified file or discards output.
+// - w: The writer of the console output.
 // - logFilePath: The full path to the log file. If empty, logs will be discarded (file logging disabled).
 // - minLevel: The minimum log level to write.
+// - quiet: Whether to print only warnings and errors to w. The log file still receives messages from minLevel.
 // It returns the logger instance and the *os.File, which will be nil if logging to file is disabled.
 // The returned *os.File (if not nil) should be closed by the caller.
-func setupLogger(logFilePath string, minLevel logger.Level) (*logger.Logger, *os.File, error) {
-	var w io.Writer = os.Stdout
+func setupLogger(w io.Writer, logFilePath string, minLevel logger.Level, quiet bool) (*logger.Logger, *os.File, error) {
+	outputs := []logger.Output{{W: w, Level: minLevel}}
+	if quiet {
+		outputs[0].Level = max(minLevel, logger.WarnLevel)
+	}
+
 	var file *os.File
 
 	if logFilePath != "" {
@@ -395,10 +1448,127 @@ func setupLogger(logFilePath string, minLevel logger.Level) (*logger.Logger, *os
 			return nil, nil, fmt.Errorf("failed to open log file %q: %w", logFilePath, err)
 		}
 
-		w = io.MultiWriter(os.Stdout, f)
+		outputs = append(outputs, logger.Output{W: f, Level: minLevel})
 		file = f
 	}
 
-	logger := logger.New(w, logger.Level(minLevel))
-	return logger, file, nil
+	return logger.NewMulti(outputs...), file, nil
+}
+
+// configureScanners replaces the built-in scanners having non-default options
+// with scanners configured accordingly.
+func configureScanners(scanners []format.FileScanner, opts Options) {
+	for i, sc := range scanners {
+		switch {
+		case sc.Ext() == "jpeg" && opts.JPEG != (format.JPEGOptions{}):
+			scanners[i] = format.NewJPEGScanner(opts.JPEG)
+		case sc.Ext() == "gif" && opts.GIF != (format.GIFOptions{}):
+			scanners[i] = format.NewGIFScanner(opts.GIF)
+		case sc.Ext() == "zip" && opts.ZIP != (format.ZIPOptions{}):
+			scanners[i] = format.NewZIPScanner(opts.ZIP)
+		case sc.Ext() == "sqlite" && opts.SQLite != (format.SQLiteOptions{}):
+			scanners[i] = format.NewSQLiteScanner(opts.SQLite)
+		}
+	}
+}
+
+// validateCustomScanners checks the scanners passed in Options.Scanners, whose extensions
+// must differ from those of the built-in scanners and from each other.
+func validateCustomScanners(builtins, custom []format.FileScanner) error {
+	exts := make(map[string]bool)
+	for _, sc := range builtins {
+		exts[sc.Ext()] = true
+	}
+
+	for _, sc := range custom {
+		if err := format.ValidateScanner(sc); err != nil {
+			return err
+		}
+
+		if exts[sc.Ext()] {
+			return fmt.Errorf("%w: a scanner is already registered for %q", format.ErrDuplicateExtension, sc.Ext())
+		}
+		exts[sc.Ext()] = true
+	}
+	return nil
+}
+
+// excludeScanners removes the scanners of the given extensions from scanners.
+// Excluding an unknown extension is reported as an error.
+func excludeScanners(scanners []format.FileScanner, exts []string) ([]format.FileScanner, error) {
+	if len(exts) == 0 {
+		return scanners, nil
+	}
+
+	if _, err := format.GetFileScanners(exts...); err != nil {
+		return nil, err
+	}
+
+	excluded := make(map[string]bool, len(exts))
+	for _, e := range exts {
+		excluded[e] = true
+	}
+
+	filtered := make([]format.FileScanner, 0, len(scanners))
+	for _, sc := range scanners {
+		if !excluded[sc.Ext()] {
+			filtered = append(filtered, sc)
+		}
+	}
+
+	if len(filtered) == 0 {
+		return nil, fmt.Errorf("no file formats left to scan after excluding %s", strings.Join(exts, ", "))
+	}
+	return filtered, nil
+}
+
+// reportOptions lists the options of the scan which are set, to record them in the report.
+// Fields of nested structs are named after the struct field, e.g. "JPEG.FollowConcatenated".
+// Callbacks and custom scanners are omitted, since they have no textual representation.
+func reportOptions(opts Options) []dfxml.Option {
+	return appendOptions(nil, "", reflect.ValueOf(opts))
+}
+
+func appendOptions(options []dfxml.Option, prefix string, v reflect.Value) []dfxml.Option {
+	for i := 0; i < v.NumField(); i++ {
+		field, value := v.Type().Field(i), v.Field(i)
+		if !field.IsExported() || value.IsZero() {
+			continue
+		}
+		if (value.Kind() == reflect.Slice || value.Kind() == reflect.Map) && value.Len() == 0 {
+			continue
+		}
+
+		name := prefix + field.Name
+		switch {
+		case value.Kind() == reflect.Func, value.Kind() == reflect.Interface:
+		case value.Kind() == reflect.Slice && value.Type().Elem().Kind() == reflect.Interface:
+		case value.Kind() == reflect.Struct:
+			options = appendOptions(options, name+".", value)
+		default:
+			options = append(options, dfxml.Option{Name: name, Value: formatOption(value)})
+		}
+	}
+	return options
+}
+
+// formatOption formats the value of an option, listing the elements of slices and the
+// entries of maps, sorted by key, separated by commas.
+func formatOption(v reflect.Value) string {
+	switch v.Kind() {
+	case reflect.Slice:
+		elems := make([]string, v.Len())
+		for i := range elems {
+			elems[i] = fmt.Sprint(v.Index(i).Interface())
+		}
+		return strings.Join(elems, ",")
+	case reflect.Map:
+		entries := make([]string, 0, v.Len())
+		for it := v.MapRange(); it.Next(); {
+			entries = append(entries, fmt.Sprintf("%v=%v", it.Key().Interface(), it.Value().Interface()))
+		}
+		sort.Strings(entries)
+		return strings.Join(entries, ",")
+	}
+	return fmt.Sprint(v.Interface())
 }
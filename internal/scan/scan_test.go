@@ -0,0 +1,384 @@
+package scan
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/png"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/ostafen/digler/internal/disk"
+	"github.com/ostafen/digler/internal/format"
+	"github.com/ostafen/digler/internal/logger"
+	"github.com/ostafen/digler/pkg/dfxml"
+)
+
+func TestSanitizeFileName(t *testing.T) {
+	tests := []struct {
+		name    string
+		want    string
+		wantErr bool
+	}{
+		{name: "f1024.jpg", want: "f1024.jpg"},
+		{name: "../../etc/passwd", want: "passwd"},
+		{name: `..\..\windows\win.ini`, want: "win.ini"},
+		{name: "/abs/path.png", want: "path.png"},
+		{name: "a:b*c?.txt", want: "a_b_c_.txt"},
+		{name: "tab\there", want: "tab_here"},
+		{name: "..", wantErr: true},
+		{name: "dir/..", wantErr: true},
+		{name: "dir/", wantErr: true},
+		{name: "", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := sanitizeFileName(tt.name)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("sanitizeFileName(%q) error = %v, wantErr %v", tt.name, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("sanitizeFileName(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestSanitizeFilePath(t *testing.T) {
+	tests := []struct {
+		path    string
+		want    string
+		wantErr bool
+	}{
+		{path: "DCIM/100CANON/IMG_0001.JPG", want: filepath.Join("DCIM", "100CANON", "IMG_0001.JPG")},
+		{path: "/DOCS/A.TXT", want: filepath.Join("DOCS", "A.TXT")},
+		{path: "DOCS/../../A.TXT", wantErr: true},
+		{path: "DOCS//A.TXT", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := sanitizeFilePath(tt.path)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("sanitizeFilePath(%q) error = %v, wantErr %v", tt.path, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("sanitizeFilePath(%q) = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestReportOptions(t *testing.T) {
+	opts := Options{
+		DumpDir:             "out",
+		MaxFileSize:         1024,
+		Dedupe:              true,
+		FileExt:             []string{"jpg", "png"},
+		MinConfidence:       format.ConfidenceStructural,
+		PartitionBlockSizes: map[int]uint64{2: 4096, 1: 512},
+		Progress:            func(processed, total int64, filesFound int) {},
+		JPEG:                format.JPEGOptions{FollowConcatenated: true},
+	}
+
+	want := []dfxml.Option{
+		{Name: "DumpDir", Value: "out"},
+		{Name: "MaxFileSize", Value: "1024"},
+		{Name: "Dedupe", Value: "true"},
+		{Name: "FileExt", Value: "jpg,png"},
+		{Name: "MinConfidence", Value: "structural"},
+		{Name: "PartitionBlockSizes", Value: "1=512,2=4096"},
+		{Name: "JPEG.FollowConcatenated", Value: "true"},
+	}
+
+	if got := reportOptions(opts); !reflect.DeepEqual(got, want) {
+		t.Errorf("reportOptions() = %v, want %v", got, want)
+	}
+}
+
+func TestReportOptionsEmpty(t *testing.T) {
+	opts := Options{
+		DumpDir:             "out",
+		Partitions:          []int{},
+		FileExt:             []string{},
+		ExcludeExt:          []string{},
+		SkipRegions:         []disk.Region{},
+		PartitionBlockSizes: map[int]uint64{},
+	}
+
+	want := []dfxml.Option{{Name: "DumpDir", Value: "out"}}
+
+	if got := reportOptions(opts); !reflect.DeepEqual(got, want) {
+		t.Errorf("reportOptions() = %v, want %v", got, want)
+	}
+}
+
+func TestValidateBufferSizes(t *testing.T) {
+	tests := []struct {
+		blockSize      uint64
+		scanBufferSize uint64
+		overlap        uint64
+		wantErr        bool
+	}{
+		{blockSize: 512, scanBufferSize: 4096},
+		{blockSize: 4096, scanBufferSize: 4096},
+		{blockSize: 4096, scanBufferSize: 0},
+		{blockSize: 0, scanBufferSize: 4096, wantErr: true},
+		{blockSize: 4096, scanBufferSize: 512, wantErr: true},
+		{blockSize: 1 << 32, scanBufferSize: 0, wantErr: true},
+		{blockSize: 512, scanBufferSize: 4096, overlap: 4096},
+		{blockSize: 512, scanBufferSize: 4096, overlap: 4097, wantErr: true},
+		{blockSize: 512, scanBufferSize: 0, overlap: 1 << 40, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		err := validateBufferSizes(tt.blockSize, tt.scanBufferSize, tt.overlap)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("validateBufferSizes(%d, %d, %d) error = %v, wantErr %v", tt.blockSize, tt.scanBufferSize, tt.overlap, err, tt.wantErr)
+		}
+	}
+}
+
+func TestDumpFileRuns(t *testing.T) {
+	img := []byte("0123456789abcdefghij")
+	finfo := &format.FileInfo{
+		Name: "fragmented.txt",
+		Size: 8,
+		Runs: []format.ByteRun{
+			{Offset: 10, Size: 4},
+			{Offset: 2, Size: 4},
+		},
+	}
+
+	dir := t.TempDir()
+	if err := DumpFile(bytes.NewReader(img), dir, finfo); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, finfo.Name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "abcd2345" {
+		t.Errorf("got %q, want %q", data, "abcd2345")
+	}
+}
+
+// twoPartitionImage returns a disk image with an MBR listing two FAT partitions
+// of partSize bytes, at 1MiB and 2MiB.
+func twoPartitionImage(partSize uint32) []byte {
+	img := make([]byte, 3<<20)
+	for i, lba := range []uint32{2048, 4096} {
+		entry := img[446+16*i:]
+		entry[4] = byte(disk.PartitionTypeFAT32LBA)
+		binary.LittleEndian.PutUint32(entry[8:], lba)
+		binary.LittleEndian.PutUint32(entry[12:], partSize/512)
+
+		bs := img[lba*512:]
+		copy(bs, "\xEB\x3C\x90MSDOS5.0")
+		binary.LittleEndian.PutUint16(bs[11:], 512) // bytes per sector
+		bs[13] = 1                                  // sectors per cluster
+		binary.LittleEndian.PutUint16(bs[14:], 1)   // reserved sectors
+		bs[16] = 2                                  // FATs
+		binary.LittleEndian.PutUint16(bs[17:], 512) // root entries
+		binary.LittleEndian.PutUint16(bs[19:], uint16(partSize/512))
+		bs[21] = 0xF8
+		binary.LittleEndian.PutUint16(bs[22:], 8) // sectors per FAT
+		bs[510], bs[511] = 0x55, 0xAA
+	}
+	img[510], img[511] = 0x55, 0xAA
+	return img
+}
+
+func TestScanSingleReport(t *testing.T) {
+	dir := t.TempDir()
+	imgPath := filepath.Join(dir, "disk.img")
+	if err := os.WriteFile(imgPath, twoPartitionImage(512<<10), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var lastProcessed, lastTotal int64
+	opts := Options{
+		ReportFile:     filepath.Join(dir, "report.xml"),
+		ScanBufferSize: 64 << 10,
+		DisableLog:     true,
+		Quiet:          true,
+		SingleReport:   true,
+		Progress: func(processed, total int64, filesFound int) {
+			if processed < lastProcessed {
+				t.Errorf("progress went back from %d to %d", lastProcessed, processed)
+			}
+			lastProcessed, lastTotal = processed, total
+		},
+	}
+	if err := Scan(imgPath, opts); err != nil {
+		t.Fatal(err)
+	}
+
+	if lastProcessed != 1<<20 || lastTotal != 1<<20 {
+		t.Errorf("expected the progress to end at %d of %d bytes, got %d of %d", 1<<20, 1<<20, lastProcessed, lastTotal)
+	}
+
+	report, err := os.ReadFile(opts.ReportFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, volume := range []string{`<volume offset="1048576" partition="0">`, `<volume offset="2097152" partition="1">`} {
+		if !bytes.Contains(report, []byte(volume)) {
+			t.Errorf("expected the report to contain %s:\n%s", volume, report)
+		}
+	}
+	if n := bytes.Count(report, []byte("<dfxml ")); n != 1 {
+		t.Errorf("expected one report header, got %d", n)
+	}
+}
+
+func TestScanDumpNamesAcrossPartitions(t *testing.T) {
+	var pngData bytes.Buffer
+	if err := png.Encode(&pngData, image.NewGray(image.Rect(0, 0, 4, 4))); err != nil {
+		t.Fatal(err)
+	}
+
+	// The same image at the same offset of both partitions gets the same template name.
+	img := twoPartitionImage(512 << 10)
+	for _, lba := range []int{2048, 4096} {
+		copy(img[lba*512+64<<10:], pngData.Bytes())
+	}
+
+	dir := t.TempDir()
+	imgPath := filepath.Join(dir, "disk.img")
+	if err := os.WriteFile(imgPath, img, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	dumpDir := filepath.Join(dir, "dump")
+	opts := Options{
+		DumpDir:        dumpDir,
+		ReportDir:      dir,
+		ScanBufferSize: 64 << 10,
+		FileExt:        []string{"png"},
+		DisableLog:     true,
+		Quiet:          true,
+		SingleReport:   true,
+	}
+	if err := Scan(imgPath, opts); err != nil {
+		t.Fatal(err)
+	}
+
+	entries, err := os.ReadDir(dumpDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var names []string
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+	if want := []string{"f128 (2).png", "f128.png"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("dumped files = %v, want %v", names, want)
+	}
+}
+
+func TestUnallocatedRegions(t *testing.T) {
+	tests := []struct {
+		name       string
+		partitions []disk.Partition
+		want       []disk.Region
+	}{
+		{
+			name:       "leading and trailing gaps",
+			partitions: []disk.Partition{{Offset: 1024, Size: 2048}},
+			want:       []disk.Region{{Offset: 0, Size: 1024}, {Offset: 3072, Size: 1024}},
+		},
+		{
+			name:       "unsorted partitions",
+			partitions: []disk.Partition{{Offset: 2048, Size: 2048}, {Offset: 0, Size: 1024}},
+			want:       []disk.Region{{Offset: 1024, Size: 1024}},
+		},
+		{
+			name:       "overlapping partitions",
+			partitions: []disk.Partition{{Offset: 0, Size: 3072}, {Offset: 1024, Size: 1024}},
+			want:       []disk.Region{{Offset: 3072, Size: 1024}},
+		},
+		{
+			name:       "partition past the end of the disk",
+			partitions: []disk.Partition{{Offset: 512, Size: 8192}},
+			want:       []disk.Region{{Offset: 0, Size: 512}},
+		},
+		{
+			name:       "gaps smaller than a sector",
+			partitions: []disk.Partition{{Offset: 100, Size: 900}, {Offset: 1100, Size: 2996}},
+			want:       nil,
+		},
+	}
+
+	for _, tt := range tests {
+		if got := unallocatedRegions(tt.partitions, 4096); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: unallocatedRegions() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestDiscoverGaps(t *testing.T) {
+	imgPath := filepath.Join(t.TempDir(), "disk.img")
+	if err := os.WriteFile(imgPath, twoPartitionImage(512<<10), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	partitions, err := DiscoverPartitions(imgPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	gaps, err := DiscoverGaps(imgPath, partitions)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []struct {
+		num          int
+		offset, size uint64
+	}{
+		{2, 0, 1 << 20},
+		{3, 1536 << 10, 512 << 10},
+		{4, 2560 << 10, 512 << 10},
+	}
+	if len(gaps) != len(want) {
+		t.Fatalf("expected %d gaps, got %+v", len(want), gaps)
+	}
+	for i, w := range want {
+		g := gaps[i]
+		if g.Num != w.num || g.Offset != w.offset || g.Size != w.size || !g.Unallocated {
+			t.Errorf("gap %d = %+v, want number %d at %d of %d bytes", i, g, w.num, w.offset, w.size)
+		}
+	}
+}
+
+func TestLogSignatureOverlaps(t *testing.T) {
+	builtin := format.NewFileScanner(format.FileHeader{Ext: "bin", Signatures: [][]byte{[]byte("ABCD")}})
+
+	tests := []struct {
+		name   string
+		plugin format.FileHeader
+		warns  bool
+	}{
+		{"prefix", format.FileHeader{Ext: "plg", Signatures: [][]byte{[]byte("AB")}}, true},
+		{"extension", format.FileHeader{Ext: "plg", Signatures: [][]byte{[]byte("ABCDEF")}}, true},
+		{"other offset", format.FileHeader{Ext: "plg", Signatures: [][]byte{[]byte("AB")}, SignatureOffset: 4}, false},
+		{"unrelated", format.FileHeader{Ext: "plg", Signatures: [][]byte{[]byte("XY")}}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			log := logger.New(&buf, logger.WarnLevel)
+
+			logSignatureOverlaps(log, []format.FileScanner{builtin}, []format.FileScanner{format.NewFileScanner(tt.plugin)})
+
+			if warns := buf.Len() > 0; warns != tt.warns {
+				t.Errorf("expected warning %v, got %q", tt.warns, buf.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,194 @@
+// Copyright (c) 2025 Stefano Scafiti
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package carve exposes the file carving engine of digler to other Go programs.
+//
+// A scan searches a source, block by block, for the signatures of the registered
+// file formats, and yields the files found in it:
+//
+//	for finfo := range carve.Scan(ctx, img, size, carve.Options{}) {
+//		fmt.Println(finfo.Name, finfo.Offset, finfo.Size)
+//	}
+//
+// Additional formats are supported by implementing FileScanner, or by
+// wrapping a FileHeader with NewFileScanner.
+package carve
+
+import (
+	"context"
+	"io"
+	"iter"
+	"math"
+
+	"github.com/ostafen/digler/internal/disk"
+	"github.com/ostafen/digler/internal/format"
+	"github.com/ostafen/digler/internal/logger"
+)
+
+const (
+	// DefaultBlockSize is the block size used when Options.BlockSize is 0.
+	DefaultBlockSize = disk.DefaultBlocksize
+	// DefaultBufferSize is the scan buffer size used when Options.BufferSize is 0.
+	DefaultBufferSize = 4 * 1024 * 1024
+)
+
+type (
+	// FileScanner recognizes a file format: Signatures lists the magic bytes at the start
+	// of its files, and ScanFile returns the size of the file starting at the reader.
+	FileScanner = format.FileScanner
+	// FileHeader describes a file format by its signatures and scan function.
+	FileHeader = format.FileHeader
+	// Reader is the reader passed to FileScanner.ScanFile, which is positioned at the
+	// start of the candidate file and does not go past the maximum file size.
+	Reader = format.Reader
+	// ScanResult is returned by FileScanner.ScanFile.
+	ScanResult = format.ScanResult
+	// FileInfo describes a carved file. Offsets are relative to the scanned source.
+	FileInfo = format.FileInfo
+	// Detection describes the signature match which led to carving a file.
+	Detection = format.Detection
+	// ByteRun is a byte range holding a part of a fragmented file (see FileInfo.Runs).
+	ByteRun = format.ByteRun
+	// Category groups related file formats, e.g., audio or image formats.
+	Category = format.Category
+	// ProgressFunc is called periodically with the progress of a scan.
+	ProgressFunc = format.ProgressFunc
+	// Confidence is how thoroughly a scanner validated a carved file.
+	Confidence = format.Confidence
+)
+
+// Confidence levels, set by scanners in ScanResult.Confidence, in increasing order.
+const (
+	ConfidenceHeaderOnly     = format.ConfidenceHeaderOnly
+	ConfidenceStructural     = format.ConfidenceStructural
+	ConfidenceFullyValidated = format.ConfidenceFullyValidated
+)
+
+// Options controls a scan. The zero value scans for all the built-in formats.
+type Options struct {
+	BlockSize   int           // BlockSize is the alignment of the files searched for. If 0, DefaultBlockSize is used.
+	BufferSize  int           // BufferSize is the size of the scan buffer. If 0, DefaultBufferSize is used.
+	MaxFileSize uint64        // MaxFileSize is the maximum size of a carved file. If 0, no limit is applied.
+	MaxDepth    int           // MaxDepth is the maximum depth of recursive carving of embedded files. If 0, embedded files are not carved.
+	SkipErrors  bool          // SkipErrors zero-fills and skips the blocks which cannot be read, instead of stopping the scan.
+	HeaderOnly  bool          // HeaderOnly yields each signature match as a file of one block, without running the scanners, for fast indexing.
+	Scanners    []FileScanner // Scanners are the formats searched for. If empty, all the built-in formats are searched for.
+	Progress    ProgressFunc  // Progress, if set, is called periodically with the progress of the scan.
+
+	// OnError, if set, is called with the error which stopped the scan, if any,
+	// such as a read error or the cancellation of the context.
+	OnError func(err error)
+}
+
+// Scanners returns the built-in scanners of the given file extensions, or all of them
+// if no extension is given. An error is returned for unknown extensions.
+func Scanners(ext ...string) ([]FileScanner, error) {
+	return format.GetFileScanners(ext...)
+}
+
+// SupportedExtensions returns the extensions of the built-in and the registered formats.
+func SupportedExtensions() []string {
+	return format.SupportedExtensions()
+}
+
+// Lookup returns the description of the built-in or registered format with the given
+// extension, such as its category and signatures, and reports whether it is supported.
+func Lookup(ext string) (FileHeader, bool) {
+	return format.Lookup(ext)
+}
+
+// RegisterScanner adds a scanner to the built-in ones, which are searched for when
+// Options.Scanners is empty. An error is returned if a scanner is already registered
+// for the same extension.
+func RegisterScanner(sc FileScanner) error {
+	return format.RegisterScanner(sc)
+}
+
+// NewFileScanner returns a FileScanner for the format described by hdr.
+func NewFileScanner(hdr FileHeader) FileScanner {
+	return format.NewFileScanner(hdr)
+}
+
+// Scan carves the first size bytes of r, yielding the files found in order of offset.
+// The scan stops when the context is canceled, or when the loop over the sequence ends.
+func Scan(ctx context.Context, r io.ReaderAt, size uint64, opts Options) iter.Seq[FileInfo] {
+	return func(yield func(FileInfo) bool) {
+		scanners := opts.Scanners
+		if len(scanners) == 0 {
+			scanners = format.GetAllFileScanners()
+		}
+
+		blockSize := opts.BlockSize
+		if blockSize == 0 {
+			blockSize = DefaultBlockSize
+		}
+
+		bufferSize := opts.BufferSize
+		if bufferSize == 0 {
+			bufferSize = DefaultBufferSize
+		}
+
+		maxFileSize := opts.MaxFileSize
+		if maxFileSize == 0 {
+			maxFileSize = math.MaxUint64
+		}
+
+		sc := format.NewScanner(
+			logger.New(io.Discard, logger.ErrorLevel),
+			format.BuildFileRegistry(scanners...),
+			bufferSize,
+			blockSize,
+			maxFileSize,
+		)
+		sc.SetMaxDepth(opts.MaxDepth)
+		sc.SetSkipErrors(opts.SkipErrors)
+		sc.SetHeaderOnly(opts.HeaderOnly)
+		sc.SetProgress(opts.Progress)
+
+		for finfo := range sc.Scan(&contextReaderAt{ctx: ctx, r: r}, size) {
+			if ctx.Err() != nil || !yield(finfo) {
+				break
+			}
+		}
+
+		err := sc.Err()
+		if err == nil {
+			err = ctx.Err()
+		}
+
+		if err != nil && opts.OnError != nil {
+			opts.OnError(err)
+		}
+	}
+}
+
+// contextReaderAt reports the end of the data once its context is canceled, which stops
+// the scan even when no file is found for a long time, or when read errors are skipped.
+type contextReaderAt struct {
+	ctx context.Context
+	r   io.ReaderAt
+}
+
+func (r *contextReaderAt) ReadAt(p []byte, off int64) (int, error) {
+	if r.ctx.Err() != nil {
+		return 0, io.EOF
+	}
+	return r.r.ReadAt(p, off)
+}
@@ -0,0 +1,161 @@
+package carve
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"image"
+	"image/png"
+	"testing"
+)
+
+func newTestImage(t *testing.T, offsets ...int) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewGray(image.Rect(0, 0, 4, 4))); err != nil {
+		t.Fatal(err)
+	}
+
+	data := make([]byte, 64*1024)
+	for _, off := range offsets {
+		copy(data[off:], buf.Bytes())
+	}
+	return data
+}
+
+func TestScan(t *testing.T) {
+	data := newTestImage(t, 1024, 8192)
+
+	var found []FileInfo
+	for finfo := range Scan(context.Background(), bytes.NewReader(data), uint64(len(data)), Options{}) {
+		found = append(found, finfo)
+	}
+
+	if len(found) != 2 {
+		t.Fatalf("expected 2 files, got %d", len(found))
+	}
+
+	for i, offset := range []uint64{1024, 8192} {
+		if found[i].Offset != offset || found[i].Ext != "png" {
+			t.Errorf("unexpected file %+v", found[i])
+		}
+	}
+}
+
+func TestScanSize(t *testing.T) {
+	data := newTestImage(t, 1024, 8192)
+
+	var found []FileInfo
+	for finfo := range Scan(context.Background(), bytes.NewReader(data), 4096, Options{}) {
+		found = append(found, finfo)
+	}
+
+	if len(found) != 1 || found[0].Offset != 1024 {
+		t.Fatalf("expected only the file at offset 1024, got %+v", found)
+	}
+}
+
+func TestScanCustomScanner(t *testing.T) {
+	data := newTestImage(t)
+	copy(data[2048:], "MAGIC")
+
+	sc := NewFileScanner(FileHeader{
+		Ext:        "magic",
+		Signatures: [][]byte{[]byte("MAGIC")},
+		ScanFile: func(r *Reader) (*ScanResult, error) {
+			return &ScanResult{Size: 5}, nil
+		},
+	})
+
+	var found []FileInfo
+	for finfo := range Scan(context.Background(), bytes.NewReader(data), uint64(len(data)), Options{Scanners: []FileScanner{sc}}) {
+		found = append(found, finfo)
+	}
+
+	if len(found) != 1 || found[0].Offset != 2048 || found[0].Size != 5 {
+		t.Fatalf("unexpected files %+v", found)
+	}
+}
+
+func TestScanScannerPanic(t *testing.T) {
+	data := newTestImage(t, 8192)
+	copy(data[2048:], "MAGIC")
+
+	sc := NewFileScanner(FileHeader{
+		Ext:        "magic",
+		Signatures: [][]byte{[]byte("MAGIC")},
+		ScanFile: func(r *Reader) (*ScanResult, error) {
+			panic("buggy scanner")
+		},
+	})
+
+	pngScanners, err := Scanners("png")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var found []FileInfo
+	for finfo := range Scan(context.Background(), bytes.NewReader(data), uint64(len(data)), Options{Scanners: append(pngScanners, sc)}) {
+		found = append(found, finfo)
+	}
+
+	if len(found) != 1 || found[0].Offset != 8192 {
+		t.Fatalf("expected the scan to go on after the panic, got %+v", found)
+	}
+}
+
+func TestScanHeaderOnly(t *testing.T) {
+	data := newTestImage(t)
+	copy(data[2048:], "MAGIC")
+
+	sc := NewFileScanner(FileHeader{
+		Ext:        "magic",
+		Signatures: [][]byte{[]byte("MAGIC")},
+		ScanFile: func(r *Reader) (*ScanResult, error) {
+			t.Errorf("ScanFile called in header-only mode")
+			return nil, errors.New("not a file")
+		},
+	})
+
+	var found []FileInfo
+	for finfo := range Scan(context.Background(), bytes.NewReader(data), uint64(len(data)), Options{
+		BlockSize:  512,
+		Scanners:   []FileScanner{sc},
+		HeaderOnly: true,
+	}) {
+		found = append(found, finfo)
+	}
+
+	if len(found) != 1 || found[0].Offset != 2048 || found[0].Size != 512 || found[0].Confidence != ConfidenceHeaderOnly {
+		t.Fatalf("expected a one-block file at offset 2048, got %+v", found)
+	}
+}
+
+func TestScanCanceled(t *testing.T) {
+	data := newTestImage(t, 1024, 8192)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var (
+		found   int
+		scanErr error
+	)
+	opts := Options{
+		BufferSize: 4096,
+		OnError:    func(err error) { scanErr = err },
+	}
+	for range Scan(ctx, bytes.NewReader(data), uint64(len(data)), opts) {
+		found++
+		cancel()
+	}
+
+	if found != 1 {
+		t.Errorf("expected 1 file, got %d", found)
+	}
+
+	if !errors.Is(scanErr, context.Canceled) {
+		t.Errorf("expected context.Canceled, got %v", scanErr)
+	}
+}
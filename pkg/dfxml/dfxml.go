@@ -25,6 +25,7 @@ import (
 	"os/user"
 	"runtime"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/ostafen/digler/pkg/sysinfo"
@@ -58,27 +59,54 @@ type Metadata struct {
 
 // Creator describes the software and environment used to generate the DFXML.
 type Creator struct {
-	Package              string  `xml:"package"`               // The name of the software package.
-	Version              string  `xml:"version"`               // The version of the software package.
-	ExecutionEnvironment ExecEnv `xml:"execution_environment"` // Details about the execution environment.
+	Package              string   `xml:"package"`               // The name of the software package.
+	Version              string   `xml:"version"`               // The version of the software package.
+	ExecutionEnvironment ExecEnv  `xml:"execution_environment"` // Details about the execution environment.
+	Options              []Option `xml:"options>option"`        // The options the report was produced with.
+}
+
+// Option is a named setting of the software which produced the DFXML.
+type Option struct {
+	Name  string `xml:"name,attr"` // The name of the option.
+	Value string `xml:",chardata"` // The value of the option.
 }
 
 // ExecEnv provides information about the operating system and host where the DFXML was created.
 type ExecEnv struct {
-	OS      string `xml:"os_sysname"` // Operating system name (e.g., "Linux", "Windows").
-	Release string `xml:"os_release"` // Operating system release version.
-	Version string `xml:"os_version"` // Operating system kernel version.
-	Host    string `xml:"host"`       // Hostname of the machine.
-	Arch    string `xml:"arch"`       // Architecture of the machine (e.g., "x86_64").
-	UID     int    `xml:"uid"`        // User ID under which the process ran.
-	Start   string `xml:"start_time"` // Start time of the DFXML generation.
+	OS       string `xml:"os_sysname"`          // Operating system name (e.g., "Linux", "Windows").
+	Release  string `xml:"os_release"`          // Operating system release version.
+	Version  string `xml:"os_version"`          // Operating system kernel version.
+	Host     string `xml:"host"`                // Hostname of the machine.
+	Arch     string `xml:"arch"`                // Architecture of the machine (e.g., "x86_64").
+	UID      int    `xml:"uid"`                 // User ID under which the process ran.
+	Start    string `xml:"start_time"`          // Start time of the DFXML generation.
+	TotalRAM uint64 `xml:"total_ram,omitempty"` // Total physical memory of the machine in bytes.
+	NumCPU   int    `xml:"num_cpu,omitempty"`   // Number of logical CPUs of the machine.
+	Command  string `xml:"command_line"`        // Command line of the process, with shell quoting.
 }
 
 // Source describes the original forensic image or data source.
 type Source struct {
-	ImageFilename string `xml:"image_filename"` // The filename of the forensic image.
-	SectorSize    int    `xml:"sectorsize"`     // The size of a sector in bytes.
-	ImageSize     uint64 `xml:"image_size"`     // The total size of the image in bytes.
+	ImageFilename string `xml:"image_filename"`          // The filename of the forensic image.
+	ResolvedPath  string `xml:"resolved_path,omitempty"` // The canonical path of the image, if its filename is a symbolic link or a relative path.
+	SectorSize    int    `xml:"sectorsize"`              // The size of a sector in bytes.
+	ImageSize     uint64 `xml:"image_size"`              // The total size of the image in bytes.
+	Filesystem    string `xml:"filesystem,omitempty"`    // The filesystem of the scanned partition, if known.
+}
+
+// Volume describes a partition of the source, whose file objects are grouped in a
+// <volume> element when a report covers several partitions.
+type Volume struct {
+	Offset     uint64 // The offset of the partition within the image, an attribute.
+	Partition  int    // The number of the partition, an attribute.
+	SectorSize int    // The size of a sector of the partition in bytes.
+	Filesystem string // The filesystem of the partition, if known.
+}
+
+// ScanStats reports statistics about the scan, written after all the file objects.
+type ScanStats struct {
+	XMLName   xml.Name `xml:"scan_stats"` // Specifies the XML element name as "scan_stats".
+	BadBlocks int      `xml:"bad_blocks"` // Number of blocks which could not be read.
 }
 
 // --- FileObject Struct ---
@@ -89,6 +117,35 @@ type FileObject struct {
 	Filename string   `xml:"filename"`   // The name of the file.
 	FileSize uint64   `xml:"filesize"`   // The size of the file in bytes.
 	ByteRuns ByteRuns `xml:"byte_runs"`  // Contains information about the physical location of file data.
+
+	Truncated   bool          `xml:"truncated,omitempty"`      // Whether the file was carved only partially.
+	Confidence  string        `xml:"confidence,omitempty"`     // How thoroughly a carved file was validated.
+	Version     string        `xml:"format_version,omitempty"` // The version of the format of a carved file, if known.
+	Unallocated bool          `xml:"unalloc,omitempty"`        // Whether the file was recovered from a deleted directory entry.
+	ModTime     *time.Time    `xml:"mtime,omitempty"`          // The original modification time of the file, if known.
+	HashDigests []HashDigest  `xml:"hashdigest,omitempty"`     // Digests of the file contents, if computed.
+	DuplicateOf string        `xml:"duplicate_of,omitempty"`   // The name of the first file with the same contents, if deduplicated.
+	Parent      *ParentObject `xml:"parent_object,omitempty"`  // The file which this one belongs with, e.g. the database of a write-ahead log.
+	Detection   *Detection    `xml:"detection,omitempty"`      // How a carved file was detected.
+}
+
+// Detection describes the signature match which led to carving a file.
+type Detection struct {
+	Scanner         string `xml:"scanner,attr"`                    // The extension of the file scanner which carved the file.
+	Description     string `xml:"description,omitempty"`           // The description of the format of the file scanner.
+	Signature       string `xml:"signature,omitempty"`             // The matched signature, in hex.
+	SignatureOffset int    `xml:"signature_offset,attr,omitempty"` // The offset of the signature from the start of the file.
+}
+
+// ParentObject refers to the file which a file object belongs with.
+type ParentObject struct {
+	Filename string `xml:"filename"` // The name of the file.
+}
+
+// HashDigest is a digest of the contents of a file.
+type HashDigest struct {
+	Type  string `xml:"type,attr"` // The hash function, e.g. "sha1".
+	Value string `xml:",chardata"` // The digest, in hex.
 }
 
 // ByteRuns is a collection of ByteRun entries.
@@ -139,12 +196,40 @@ func GetExecEnv() ExecEnv {
 	startTime := time.Now().UTC().Format("2006-01-02T15:04:05Z") // YYYY-MM-DDTHH:MM:SSZ
 
 	return ExecEnv{
-		OS:      sinfo.Name,
-		Release: sinfo.Release,
-		Version: sinfo.Version,
-		Host:    host,
-		Arch:    arch,
-		UID:     uid,
-		Start:   startTime,
+		OS:       sinfo.Name,
+		Release:  sinfo.Release,
+		Version:  sinfo.Version,
+		Host:     host,
+		Arch:     arch,
+		UID:      uid,
+		Start:    startTime,
+		TotalRAM: sinfo.TotalRAM,
+		NumCPU:   sinfo.NumCPU,
+		Command:  CommandLine(os.Args),
+	}
+}
+
+// CommandLine joins args into a command line, quoting the arguments which a POSIX
+// shell would otherwise split or expand.
+func CommandLine(args []string) string {
+	quoted := make([]string, len(args))
+	for i, arg := range args {
+		quoted[i] = quoteArg(arg)
+	}
+	return strings.Join(quoted, " ")
+}
+
+func quoteArg(arg string) string {
+	if arg != "" && !strings.ContainsFunc(arg, needsQuoting) {
+		return arg
+	}
+	return "'" + strings.ReplaceAll(arg, "'", `'\''`) + "'"
+}
+
+func needsQuoting(r rune) bool {
+	switch {
+	case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+		return false
 	}
+	return !strings.ContainsRune("-_./:,=+@%", r)
 }
@@ -22,6 +22,7 @@ package dfxml
 import (
 	"encoding/xml"
 	"io"
+	"strconv"
 )
 
 // DFXMLWriter provides methods for writing DFXML elements to an io.Writer.
@@ -77,6 +78,39 @@ func (w *DFXMLWriter) WriteFileObject(obj FileObject) error {
 	return w.enc.Encode(obj)
 }
 
+// StartVolume writes the opening <volume> tag of the given partition, followed by its
+// properties. The file objects written next belong to the volume, until EndVolume is called.
+func (w *DFXMLWriter) StartVolume(v Volume) error {
+	start := xml.StartElement{
+		Name: xml.Name{Local: "volume"},
+		Attr: []xml.Attr{
+			{Name: xml.Name{Local: "offset"}, Value: strconv.FormatUint(v.Offset, 10)},
+			{Name: xml.Name{Local: "partition"}, Value: strconv.Itoa(v.Partition)},
+		},
+	}
+	if err := w.enc.EncodeToken(start); err != nil {
+		return err
+	}
+
+	if err := w.enc.EncodeElement(v.SectorSize, xml.StartElement{Name: xml.Name{Local: "sectorsize"}}); err != nil {
+		return err
+	}
+	if v.Filesystem != "" {
+		return w.enc.EncodeElement(v.Filesystem, xml.StartElement{Name: xml.Name{Local: "filesystem"}})
+	}
+	return nil
+}
+
+// EndVolume writes the closing </volume> tag of the volume started by StartVolume.
+func (w *DFXMLWriter) EndVolume() error {
+	return w.enc.EncodeToken(xml.EndElement{Name: xml.Name{Local: "volume"}})
+}
+
+// WriteScanStats encodes and writes a ScanStats struct as an XML element.
+func (w *DFXMLWriter) WriteScanStats(stats ScanStats) error {
+	return w.enc.Encode(stats)
+}
+
 // Close closes the DFXML document by writing the closing </dfxml> tag and flushing the encoder.
 func (w *DFXMLWriter) Close() error {
 	// Write the closing </dfxml> tag.
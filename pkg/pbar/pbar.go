@@ -21,6 +21,7 @@ package pbar
 
 import (
 	"fmt"
+	"io"
 	"os"
 	"strings"
 	"time"
@@ -38,6 +39,7 @@ type ProgressBarState struct {
 	StartTime          time.Time
 	LastUpdateTime     time.Time
 	LastProcessedBytes int64
+	Output             io.Writer // Output is where the bar is rendered. If nil, os.Stdout is used.
 }
 
 // NewProgressBarState initializes a new ProgressBarState
@@ -92,7 +94,7 @@ func (pbs *ProgressBarState) Render(force bool) {
 	// Clear the current line and print the new progress
 	// \r moves the cursor to the beginning of the line
 	// We print spaces to clear any leftover characters from a previous longer line
-	fmt.Fprintf(os.Stdout, "\r[INFO] Progress: [%s] %3.0f%% (%s/%s) | Files Found: %d | @ %.2fMB/s [%s]    ",
+	fmt.Fprintf(pbs.output(), "\r[INFO] Progress: [%s] %3.0f%% (%s/%s) | Files Found: %d | @ %.2fMB/s [%s]    ",
 		bar,
 		percentage,
 		format.FormatBytes(pbs.ProcessedBytes),
@@ -102,10 +104,62 @@ func (pbs *ProgressBarState) Render(force bool) {
 		etaStr)
 
 	// Ensure the buffer is flushed to the terminal immediately
-	os.Stdout.Sync()
+	if f, ok := pbs.output().(*os.File); ok {
+		f.Sync()
+	}
 }
 
 // ClearLine prints a newline, effectively finishing the progress bar output
 func (pbs *ProgressBarState) Finish() {
-	fmt.Println() // Move to the next line after the bar is done
+	fmt.Fprintln(pbs.output()) // Move to the next line after the bar is done
+}
+
+func (pbs *ProgressBarState) output() io.Writer {
+	if pbs.Output != nil {
+		return pbs.Output
+	}
+	return os.Stdout
+}
+
+// Reporter renders the progress reported by a scan as a progress bar. Since scans report
+// their progress at a limited rate, every update is rendered. A new bar is started after
+// the previous one completes, so a reporter can be reused across consecutive scans.
+type Reporter struct {
+	w     io.Writer
+	state *ProgressBarState
+}
+
+// NewReporter returns a Reporter rendering to stdout.
+func NewReporter() *Reporter {
+	return NewReporterTo(os.Stdout)
+}
+
+// NewReporterTo returns a Reporter rendering to w.
+func NewReporterTo(w io.Writer) *Reporter {
+	return &Reporter{w: w}
+}
+
+// Update renders the progress of the current scan, completing the bar when processed reaches total.
+func (r *Reporter) Update(processed, total int64, filesFound int) {
+	if r.state == nil {
+		r.state = NewProgressBarState(total)
+		r.state.Output = r.w
+	}
+
+	r.state.TotalBytes = total
+	r.state.ProcessedBytes = processed
+	r.state.FilesFound = filesFound
+	r.state.Render(true)
+
+	if processed >= total {
+		r.Finish()
+	}
+}
+
+// Finish terminates the current bar, if any, e.g. when a scan stops with an error.
+func (r *Reporter) Finish() {
+	if r.state != nil {
+		r.state.Finish()
+		r.state = nil
+	}
 }
@@ -109,16 +109,25 @@ func (b *BufferedReadSeeker) Seek(offset int64, whence int) (int64, error) {
 	return newOffset, nil
 }
 
+// Peek returns the next n bytes without advancing the reader.
+// The underlying reader is read as many times as needed, so fewer than n bytes
+// are returned, together with io.EOF, only when the end of the data is reached
+// (or a read returns no data). The returned slice is only valid until the next call
+// to Read, Seek or Peek. Peek fails if n exceeds the buffer size.
 func (b *BufferedReadSeeker) Peek(n int) ([]byte, error) {
 	if n > len(b.buf) {
 		return nil, errors.New("peek size exceeds buffer capacity")
 	}
 
-	// Fill the buffer if there's not enough data available
-	if b.off+n > b.size {
+	// Fill the buffer until there's enough data available
+	for b.off+n > b.size {
+		available := b.size - b.off
 		if err := b.fillBuffer(); err != nil {
 			return nil, err
 		}
+		if b.size == available {
+			break // end of data
+		}
 	}
 
 	available := b.size - b.off
@@ -128,6 +137,12 @@ func (b *BufferedReadSeeker) Peek(n int) ([]byte, error) {
 	return b.buf[b.off : b.off+n], nil
 }
 
+// Offset returns the absolute position of the next byte to be read.
+// Unlike Seek(0, io.SeekCurrent), it never touches the underlying reader.
+func (b *BufferedReadSeeker) Offset() int64 {
+	return b.currPos + int64(b.off)
+}
+
 func (b *BufferedReadSeeker) Reset(r io.ReadSeeker) {
 	b.src = r
 	b.off = 0
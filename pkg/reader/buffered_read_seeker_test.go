@@ -0,0 +1,71 @@
+package reader
+
+import (
+	"bytes"
+	"io"
+	"testing"
+)
+
+// shortReadSeeker returns at most chunk bytes per Read.
+type shortReadSeeker struct {
+	*bytes.Reader
+	chunk int
+}
+
+func (r *shortReadSeeker) Read(p []byte) (int, error) {
+	if len(p) > r.chunk {
+		p = p[:r.chunk]
+	}
+	return r.Reader.Read(p)
+}
+
+func TestBufferedReadSeekerPeek(t *testing.T) {
+	data := GenerateRandomBuffer(1000)
+
+	tests := []struct {
+		name    string
+		chunk   int // maximum size of a read of the underlying reader
+		skip    int // bytes read before peeking
+		n       int
+		want    []byte
+		wantEOF bool
+	}{
+		{name: "single read", chunk: 1000, n: 64, want: data[:64]},
+		{name: "short reads", chunk: 10, n: 64, want: data[:64]},
+		{name: "across refill", chunk: 10, skip: 95, n: 64, want: data[95:159]},
+		{name: "whole buffer", chunk: 7, skip: 3, n: 128, want: data[3:131]},
+		{name: "at end", chunk: 10, skip: 980, n: 64, want: data[980:], wantEOF: true},
+		{name: "past end", chunk: 10, skip: 1000, n: 1, want: []byte{}, wantEOF: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := NewBufferedReadSeeker(&shortReadSeeker{bytes.NewReader(data), tt.chunk}, 128)
+
+			if _, err := io.ReadFull(b, make([]byte, tt.skip)); err != nil {
+				t.Fatalf("Read: %v", err)
+			}
+
+			got, err := b.Peek(tt.n)
+			if (err == io.EOF) != tt.wantEOF || (err != nil && err != io.EOF) {
+				t.Fatalf("Peek(%d) error = %v, want EOF %v", tt.n, err, tt.wantEOF)
+			}
+			if !bytes.Equal(got, tt.want) {
+				t.Fatalf("Peek(%d) = %d bytes, want %d", tt.n, len(got), len(tt.want))
+			}
+
+			// Peeking does not advance the reader
+			next := make([]byte, len(tt.want))
+			if _, err := io.ReadFull(b, next); err != nil || !bytes.Equal(next, tt.want) {
+				t.Fatalf("Read after Peek: %v", err)
+			}
+		})
+	}
+}
+
+func TestBufferedReadSeekerPeekTooLarge(t *testing.T) {
+	b := NewBufferedReadSeeker(bytes.NewReader(nil), 16)
+	if _, err := b.Peek(17); err == nil {
+		t.Fatal("Peek beyond the buffer size must fail")
+	}
+}
@@ -25,6 +25,7 @@ import (
 	"os"
 	"os/exec"
 	"runtime"
+	"strconv"
 	"strings"
 )
 
@@ -33,13 +34,16 @@ var SysUnknown = SysInfo{
 	Name:    runtime.GOOS,
 	Release: "unknown",
 	Version: "unknown",
+	NumCPU:  runtime.NumCPU(),
 }
 
 // SysInfo holds the basic operating system details.
 type SysInfo struct {
-	Name    string // The name of the operating system (e.g., "linux", "darwin", "windows").
-	Release string // The marketing name or release version of the OS (e.g., "Ubuntu", "macOS Sonoma", "Windows 11").
-	Version string // The specific build or kernel version of the OS.
+	Name     string // The name of the operating system (e.g., "linux", "darwin", "windows").
+	Release  string // The marketing name or release version of the OS (e.g., "Ubuntu", "macOS Sonoma", "Windows 11").
+	Version  string // The specific build or kernel version of the OS.
+	TotalRAM uint64 // The total physical memory in bytes, or 0 if it cannot be determined.
+	NumCPU   int    // The number of logical CPUs usable by the current process.
 }
 
 // Stat gathers and returns detailed operating system information.
@@ -50,50 +54,102 @@ func Stat() (*SysInfo, error) {
 	osRelease := ""
 	osVersion := ""
 
+	var totalRAM uint64
+
 	switch osSysname {
 	case "linux":
 		osRelease, osVersion = getLinuxInfo()
+		totalRAM = getLinuxTotalRAM()
 	case "darwin":
 		osRelease, osVersion = getDarwinInfo()
+		totalRAM = getDarwinTotalRAM()
 	case "windows":
 		osRelease, osVersion = getWindowsInfo()
+		totalRAM = getWindowsTotalRAM()
 	default:
 		osRelease, osVersion = "unknown", "unknown"
 	}
 
 	return &SysInfo{
-		Name:    osSysname,
-		Release: osRelease,
-		Version: osVersion,
+		Name:     osSysname,
+		Release:  osRelease,
+		Version:  osVersion,
+		TotalRAM: totalRAM,
+		NumCPU:   runtime.NumCPU(),
 	}, nil
 }
 
+// osReleasePaths are the locations of the os-release file, in order of precedence.
+// Minimal container images often ship only the second one.
+var osReleasePaths = []string{"/etc/os-release", "/usr/lib/os-release"}
+
 // getLinuxInfo retrieves OS release and version information for Linux systems.
-// It attempts to read and parse the /etc/os-release file, which is a common
-// standard for distributing OS identification data.
+// It attempts to read and parse the os-release file, which is a common
+// standard for distributing OS identification data. When the file is missing,
+// or it does not report a version, the kernel version is used instead.
 func getLinuxInfo() (string, string) {
-	f, err := os.Open("/etc/os-release")
-	if err != nil {
-		return "unknown", "unknown"
+	var name, version string
+	for _, path := range osReleasePaths {
+		data, err := os.ReadFile(path)
+		if err == nil {
+			name, version = parseOSRelease(data)
+			break
+		}
 	}
-	defer f.Close()
 
-	var name, version string
-	scanner := bufio.NewScanner(f)
+	if name == "" {
+		name = "Linux"
+	}
+	if version == "" {
+		version = getLinuxKernelVersion()
+	}
+	return name, version
+}
+
+// parseOSRelease returns the name and version of the OS from the contents of an
+// os-release file. VERSION_ID is used when VERSION is not set, as on Alpine.
+func parseOSRelease(data []byte) (string, string) {
+	var name, version, versionID string
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
 	for scanner.Scan() {
-		line := scanner.Text()
-		if strings.HasPrefix(line, "NAME=") {
-			// Trim "NAME=" prefix and quotes from the value.
-			name = strings.Trim(line[5:], `"`)
+		key, value, ok := strings.Cut(scanner.Text(), "=")
+		if !ok {
+			continue
 		}
-		if strings.HasPrefix(line, "VERSION=") {
-			// Trim "VERSION=" prefix and quotes from the value.
-			version = strings.Trim(line[8:], `"`)
+		value = strings.Trim(value, `"'`)
+
+		switch key {
+		case "NAME":
+			name = value
+		case "VERSION":
+			version = value
+		case "VERSION_ID":
+			versionID = value
 		}
 	}
+
+	if version == "" {
+		version = versionID
+	}
 	return name, version
 }
 
+// getLinuxKernelVersion returns the release of the running kernel, as printed by 'uname -r',
+// from /proc/version, whose contents start with "Linux version <release>".
+func getLinuxKernelVersion() string {
+	data, err := os.ReadFile("/proc/version")
+	if err != nil {
+		return "unknown"
+	}
+
+	fields := strings.Fields(string(data))
+	if len(fields) < 3 || fields[1] != "version" {
+		return "unknown"
+	}
+	return fields[2]
+}
+
 // getDarwinInfo retrieves OS release and version information for macOS systems.
 // It executes the 'sw_vers' command and parses its output.
 func getDarwinInfo() (string, string) {
@@ -129,3 +185,43 @@ func getWindowsInfo() (string, string) {
 	version := strings.TrimSpace(string(output))
 	return "Windows", version
 }
+
+// getLinuxTotalRAM retrieves the total physical memory on Linux systems
+// by parsing the MemTotal entry of /proc/meminfo, which is expressed in kB.
+func getLinuxTotalRAM() uint64 {
+	f, err := os.Open("/proc/meminfo")
+	if err != nil {
+		return 0
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 2 || fields[0] != "MemTotal:" {
+			continue
+		}
+
+		kb, err := strconv.ParseUint(fields[1], 10, 64)
+		if err != nil {
+			return 0
+		}
+		return kb * 1024
+	}
+	return 0
+}
+
+// getDarwinTotalRAM retrieves the total physical memory on macOS systems.
+// It executes the 'sysctl -n hw.memsize' command, which prints the size in bytes.
+func getDarwinTotalRAM() uint64 {
+	output, err := exec.Command("sysctl", "-n", "hw.memsize").Output()
+	if err != nil {
+		return 0
+	}
+
+	size, err := strconv.ParseUint(strings.TrimSpace(string(output)), 10, 64)
+	if err != nil {
+		return 0
+	}
+	return size
+}
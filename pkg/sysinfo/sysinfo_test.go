@@ -0,0 +1,39 @@
+package sysinfo
+
+import "testing"
+
+func TestParseOSRelease(t *testing.T) {
+	tests := []struct {
+		name        string
+		data        string
+		wantName    string
+		wantVersion string
+	}{
+		{
+			name:        "ubuntu",
+			data:        "NAME=\"Ubuntu\"\nVERSION=\"22.04.3 LTS (Jammy Jellyfish)\"\nVERSION_ID=\"22.04\"\n",
+			wantName:    "Ubuntu",
+			wantVersion: "22.04.3 LTS (Jammy Jellyfish)",
+		},
+		{
+			name:        "alpine",
+			data:        "NAME=\"Alpine Linux\"\nID=alpine\nVERSION_ID=3.19.1\n",
+			wantName:    "Alpine Linux",
+			wantVersion: "3.19.1",
+		},
+		{
+			name:     "no version",
+			data:     "# rolling release\nNAME='Arch Linux'\nBUILD_ID=rolling\n",
+			wantName: "Arch Linux",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			name, version := parseOSRelease([]byte(tt.data))
+			if name != tt.wantName || version != tt.wantVersion {
+				t.Errorf("parseOSRelease() = %q, %q, want %q, %q", name, version, tt.wantName, tt.wantVersion)
+			}
+		})
+	}
+}
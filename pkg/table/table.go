@@ -19,75 +19,62 @@
 // THE SOFTWARE.
 package table
 
-const (
-	// TableSize represents the fixed size of the internal hash table.
-	// This value (2^16) is chosen to map directly to the uint16 hash space.
-	TableSize = 65536 // Or 1 << 16 for clarity of its power-of-2 nature
-)
-
 // PrefixTable is a generic data structure that stores key-value pairs
 // and allows for efficient prefix-based lookups and traversals.
-// It uses a custom hashing mechanism to map byte prefixes to an internal
-// 65536-byte (2^16) array, optimizing for small, byte-array keys.
+// It is implemented as a trie keyed on full bytes, where each node has
+// a direct-indexed array of 256 children, optimizing lookups of small,
+// byte-array keys. Lookups are exact: a value is only reported for a key
+// whose bytes are all equal to the searched ones.
 // The `T` type parameter allows it to store any kind of value.
 type PrefixTable[T any] struct {
-	// table is a 65536-byte array used to mark the presence of prefixes.
-	// Each byte in the array indicates the status of a hash collision point,
-	// where the hash is derived from a key prefix.
-	// It uses `none`, `presentMarker`, and `elemMarker` to differentiate
-	// between no prefix, a prefix that is part of a longer key, and a prefix
-	// that is itself a complete key associated with an element.
-	table [TableSize]byte
-	// elems stores the actual key-value pairs. Keys are stored as strings
-	// for efficient map lookup, and values are of the generic type T.
-	elems map[string]T
+	// root is the node associated with the empty key.
+	root node[T]
+	// size is the number of keys with an associated value.
+	size int
 }
 
-const (
-	// none indicates that no key prefix hashes to this position in the table.
-	none = iota
-	// presentMarker indicates that a key prefix hashes to this position,
-	// but it is only a prefix of a longer key, not a complete key itself.
-	presentMarker
-	// elemMarker indicates that a key prefix hashes to this position,
-	// and this prefix is also a complete key stored in the `elems` map.
-	elemMarker
-)
+// node is a trie node. The key of a node is given by the path from the root.
+type node[T any] struct {
+	// children maps the next byte of a key to the corresponding child node.
+	children *[256]*node[T]
+	// hasValue reports whether the key of the node is associated with a value,
+	// or if it is only a prefix of a longer key.
+	hasValue bool
+	value    T
+}
 
 // New creates and returns a new initialized PrefixTable.
-// It allocates the internal map for storing elements.
 func New[T any]() *PrefixTable[T] {
-	return &PrefixTable[T]{
-		elems: make(map[string]T),
-	}
+	return &PrefixTable[T]{}
 }
 
 // Insert adds a new key-value pair to the PrefixTable.
 //
 // The `key` is a byte slice, and `v` is the value of generic type T.
 //
-// During insertion, it iterates through the `key` bytes, updating the
-// internal `table` array. For each prefix of the key, it updates the
-// corresponding hash entry in `table` to at least `presentMarker`.
-// The entry corresponding to the full `key` is marked as `elemMarker`.
-// Finally, the key-value pair is stored in the `elems` map.
-//
-// The hashing mechanism `h = (h << 2) + uint16(v)` effectively uses a
-// 2-bit shift for each byte, allowing up to 8 bytes (16 bits / 2 bits_per_byte)
-// of key information to directly influence the 16-bit hash, though
-// longer keys will cause hash collisions at the 16-bit boundary.
-// This is suitable for relatively short keys or scenarios where the
-// first 8 bytes provide sufficient distinction for prefix matching.
+// During insertion, it follows the path of the `key` bytes from the root,
+// creating any missing node along the way. The node corresponding to the
+// full `key` is then associated with `v`, replacing any previous value.
 func (t *PrefixTable[T]) Insert(key []byte, v T) {
-	var h uint16 = 0
+	n := &t.root
 	for _, b := range key {
-		h = (h << 2) + uint16(b)
-		// `max` ensures that an `elemMarker` (if already set by a shorter key) isn't downgraded.
-		t.table[h] = max(t.table[h], presentMarker)
+		if n.children == nil {
+			n.children = new([256]*node[T])
+		}
+
+		child := n.children[b]
+		if child == nil {
+			child = &node[T]{}
+			n.children[b] = child
+		}
+		n = child
+	}
+
+	if !n.hasValue {
+		t.size++
 	}
-	// indicating that an element is associated with this full key.
-	t.table[h] = elemMarker
-	t.elems[string(key)] = v
+	n.hasValue = true
+	n.value = v
 }
 
 // Get retrieves the value associated with a given `key` from the PrefixTable.
@@ -95,12 +82,20 @@ func (t *PrefixTable[T]) Insert(key []byte, v T) {
 // It returns the value of type T and a boolean indicating whether the key
 // was found (`true`) or not (`false`).
 func (t *PrefixTable[T]) Get(key []byte) (T, bool) {
-	v, found := t.elems[string(key)]
-	return v, found
+	n := &t.root
+	for _, b := range key {
+		if n.children == nil || n.children[b] == nil {
+			var zero T
+			return zero, false
+		}
+		n = n.children[b]
+	}
+	return n.value, n.hasValue
 }
 
 // Walk traverses the `PrefixTable` using a given `key` and executes the `onMatch`
-// function for every complete key stored in the table that is a prefix of your `key`.
+// function for every complete key stored in the table that is a prefix of your `key`,
+// from the shortest to the longest one. The traversal stops as soon as `onMatch` returns true.
 //
 // For example, if your table contains "apple", "applet", and "apricot":
 //   - If you call `Walk("appletie", onMatch)`, `onMatch` will be called twice:
@@ -111,31 +106,26 @@ func (t *PrefixTable[T]) Get(key []byte) (T, bool) {
 // The traversal stops as soon as a part of your `key` does not match any prefix
 // in the table.
 func (t *PrefixTable[T]) Walk(key []byte, onMatch func(T) bool) {
-	var h uint16 = 0
-	// Iterate through the key to find matching prefixes.
-	for i, b := range key {
-		h = (h << 2) + uint16(b)
+	n := &t.root
+	for _, b := range key {
+		if n.children == nil {
+			// No stored key extends the current prefix.
+			return
+		}
 
-		// Check the marker for the current prefix's hash.
-		marker := t.table[h]
-		if marker == none {
-			// If `none`, no key starts with this prefix, so no longer prefixes can exist.
+		n = n.children[b]
+		if n == nil {
 			return
 		}
 
-		if marker == elemMarker {
-			// If `elemMarker`, this prefix itself is a complete key in the table.
-			// Retrieve the value associated with this prefix and call the callback.
-			// Note: string(key[:i+1]) creates a new string for the prefix.
-			v, ok := t.elems[string(key[:i+1])]
-			if ok && onMatch(v) {
-				return
-			}
+		// The prefix consumed so far is itself a complete key in the table.
+		if n.hasValue && onMatch(n.value) {
+			return
 		}
 	}
 }
 
 // Size returns the number of unique key-value pairs currently stored in the table.
 func (t *PrefixTable[T]) Size() int {
-	return len(t.elems)
+	return t.size
 }
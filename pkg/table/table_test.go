@@ -0,0 +1,92 @@
+package table
+
+import (
+	"testing"
+)
+
+func walkAll(t *PrefixTable[string], key []byte) []string {
+	var matches []string
+	t.Walk(key, func(v string) bool {
+		matches = append(matches, v)
+		return false
+	})
+	return matches
+}
+
+func TestPrefixTableWalk(t *testing.T) {
+	tab := New[string]()
+	tab.Insert([]byte("apple"), "apple")
+	tab.Insert([]byte("applet"), "applet")
+	tab.Insert([]byte("apricot"), "apricot")
+
+	tests := []struct {
+		key  string
+		want []string
+	}{
+		{"appletie", []string{"apple", "applet"}},
+		{"apricot", []string{"apricot"}},
+		{"application", nil},
+		{"app", nil},
+	}
+
+	for _, tt := range tests {
+		got := walkAll(tab, []byte(tt.key))
+		if len(got) != len(tt.want) {
+			t.Fatalf("Walk(%q) = %v, want %v", tt.key, got, tt.want)
+		}
+		for i := range got {
+			if got[i] != tt.want[i] {
+				t.Fatalf("Walk(%q) = %v, want %v", tt.key, got, tt.want)
+			}
+		}
+	}
+
+	if tab.Size() != 3 {
+		t.Fatalf("Size() = %d, want 3", tab.Size())
+	}
+}
+
+// TestPrefixTableCollisions uses keys which collided under the previous
+// (h << 2) + b hashing scheme, which only took into account the low bits of each byte.
+func TestPrefixTableCollisions(t *testing.T) {
+	tab := New[string]()
+	tab.Insert([]byte{0x01, 0x00}, "a")
+	tab.Insert([]byte{0x40, 0x04}, "b")
+	tab.Insert([]byte{0xFF, 0xD8, 0xFF}, "jpeg")
+
+	// {0x04} hashes to the same slot as {0x01, 0x00}.
+	if got := walkAll(tab, []byte{0x04, 0x00}); len(got) != 0 {
+		t.Fatalf("unexpected matches for colliding key: %v", got)
+	}
+
+	// {0x00, 0x04} hashes to the same slot as {0x40, 0x04}, since the high bits are shifted out.
+	if got := walkAll(tab, []byte{0x00, 0x04, 0x00}); len(got) != 0 {
+		t.Fatalf("unexpected matches for colliding key: %v", got)
+	}
+
+	if got := walkAll(tab, []byte{0x40, 0x04, 0x00}); len(got) != 1 || got[0] != "b" {
+		t.Fatalf("expected exactly one match, got %v", got)
+	}
+
+	if got := walkAll(tab, []byte{0xFF, 0xD8, 0xFF, 0xE0}); len(got) != 1 || got[0] != "jpeg" {
+		t.Fatalf("expected exactly one match, got %v", got)
+	}
+
+	if _, ok := tab.Get([]byte{0x04}); ok {
+		t.Fatalf("unexpected value for key not in table")
+	}
+
+	if v, ok := tab.Get([]byte{0x01, 0x00}); !ok || v != "a" {
+		t.Fatalf("Get() = %q, %v, want \"a\", true", v, ok)
+	}
+}
+
+func TestPrefixTableInsertReplace(t *testing.T) {
+	tab := New[int]()
+	tab.Insert([]byte("PK"), 1)
+	tab.Insert([]byte("PK"), 2)
+
+	if v, _ := tab.Get([]byte("PK")); v != 2 || tab.Size() != 1 {
+		t.Fatalf("Get() = %d, Size() = %d, want 2, 1", v, tab.Size())
+	}
+}
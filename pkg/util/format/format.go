@@ -21,37 +21,51 @@ package format
 
 import (
 	"fmt"
+	"math"
 	"strconv"
 	"strings"
 	"unicode"
 )
 
+// Binary (IEC) units.
 const (
-	_  = iota // ignore first value
-	KB = 1 << (10 * iota)
-	MB
-	GB
-	TB
+	_   = iota // ignore first value
+	KiB = 1 << (10 * iota)
+	MiB
+	GiB
+	TiB
 )
 
+// Decimal (SI) units.
+const (
+	KB = 1000
+	MB = 1000 * KB
+	GB = 1000 * MB
+	TB = 1000 * GB
+)
+
+// AutoSize is returned by ParseBytes for the literal "auto", meaning that the size
+// should be chosen automatically by the caller.
+const AutoSize uint64 = math.MaxUint64
+
 // Helper to format bytes into human-readable units, avoiding .00 for whole numbers
 func FormatBytes(b int64) string {
 	val := float64(b)
 	var unit string
 
 	switch {
-	case b >= TB:
-		val /= float64(TB)
-		unit = "TB"
-	case b >= GB:
-		val /= float64(GB)
-		unit = "GB"
-	case b >= MB:
-		val /= float64(MB)
-		unit = "MB"
-	case b >= KB:
-		val /= float64(KB)
-		unit = "KB"
+	case b >= TiB:
+		val /= float64(TiB)
+		unit = "TiB"
+	case b >= GiB:
+		val /= float64(GiB)
+		unit = "GiB"
+	case b >= MiB:
+		val /= float64(MiB)
+		unit = "MiB"
+	case b >= KiB:
+		val /= float64(KiB)
+		unit = "KiB"
 	default:
 		return fmt.Sprintf("%dB", b)
 	}
@@ -63,14 +77,20 @@ func FormatBytes(b int64) string {
 	return fmt.Sprintf("%.2f%s", val, unit)
 }
 
-// ParseBytes converts a human-readable byte size string (e.g., "10MB", "1.5GB", "2048")
-// into its corresponding int64 value in bytes. If no unit is specified, bytes are assumed.
+// ParseBytes converts a human-readable byte size string (e.g., "10MB", "1.5GiB", "2048")
+// into its corresponding value in bytes. If no unit is specified, bytes are assumed.
+// Decimal units (kB, MB, GB, TB) are powers of 1000, while binary units (KiB, MiB, GiB, TiB)
+// are powers of 1024. Units are case insensitive. The literal "auto" is parsed as AutoSize.
 func ParseBytes(s string) (uint64, error) {
 	s = strings.TrimSpace(s)
 	if s == "" {
 		return 0, fmt.Errorf("empty input")
 	}
 
+	if strings.EqualFold(s, "auto") {
+		return AutoSize, nil
+	}
+
 	var i int
 	for i = 0; i < len(s); i++ {
 		if !unicode.IsDigit(rune(s[i])) && s[i] != '.' {
@@ -81,12 +101,16 @@ func ParseBytes(s string) (uint64, error) {
 	numStr := s[:i]
 	unitStr := strings.ToUpper(strings.TrimSpace(s[i:]))
 
+	if numStr == "" {
+		return 0, fmt.Errorf("missing number in %q", s)
+	}
+
 	num, err := strconv.ParseFloat(numStr, 64)
 	if err != nil {
-		return 0, fmt.Errorf("invalid number: %v", err)
+		return 0, fmt.Errorf("invalid number %q", numStr)
 	}
 
-	var multiplier int64 = 1
+	var multiplier uint64 = 1
 	switch unitStr {
 	case "", "B":
 		multiplier = 1
@@ -98,8 +122,21 @@ func ParseBytes(s string) (uint64, error) {
 		multiplier = GB
 	case "TB":
 		multiplier = TB
+	case "KIB":
+		multiplier = KiB
+	case "MIB":
+		multiplier = MiB
+	case "GIB":
+		multiplier = GiB
+	case "TIB":
+		multiplier = TiB
 	default:
-		return 0, fmt.Errorf("unknown unit: %s", unitStr)
+		return 0, fmt.Errorf("unknown unit: %s", s[i:])
+	}
+
+	v := num * float64(multiplier)
+	if v >= math.MaxUint64 {
+		return 0, fmt.Errorf("value out of range: %s", s)
 	}
-	return uint64(num * float64(multiplier)), nil
+	return uint64(v), nil
 }
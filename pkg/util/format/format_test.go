@@ -0,0 +1,39 @@
+package format
+
+import "testing"
+
+func TestParseBytes(t *testing.T) {
+	tests := []struct {
+		in   string
+		want uint64
+	}{
+		{"2048", 2048},
+		{"512B", 512},
+		{"10MiB", 10 * 1024 * 1024},
+		{"10mib", 10 * 1024 * 1024},
+		{"4KiB", 4096},
+		{"1.5GB", 1500000000},
+		{"1kB", 1000},
+		{"2 TB", 2000000000000},
+		{"auto", AutoSize},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseBytes(tt.in)
+		if err != nil {
+			t.Errorf("ParseBytes(%q): unexpected error: %v", tt.in, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseBytes(%q) = %d, want %d", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestParseBytesInvalid(t *testing.T) {
+	for _, in := range []string{"", "abc", "MB", "10XB", "1.2.3KB", "-5MB", "1e30TB"} {
+		if v, err := ParseBytes(in); err == nil {
+			t.Errorf("ParseBytes(%q) = %d, expected an error", in, v)
+		}
+	}
+}
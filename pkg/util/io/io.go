@@ -26,18 +26,40 @@ import (
 	"os"
 )
 
-// CopyFile copies data from the provided reader to the file at filePath.
-// It creates or truncates the file and writes using a 32KB buffer.
+// CopyFile copies data from the provided reader to the file at filePath, using a 32KB buffer.
+// The data is written to a temporary file, named after filePath with a ".tmp" suffix, which
+// replaces the file at filePath only once fully written. This way, an interrupted copy never
+// leaves a partial file under the final name. The temporary file is removed on error.
 func CopyFile(filePath string, r io.Reader) error {
-	f, err := os.Create(filePath)
+	tmpPath := filePath + ".tmp"
+
+	f, err := os.Create(tmpPath)
 	if err != nil {
-		return fmt.Errorf("failed to create file %q: %w", filePath, err)
+		return fmt.Errorf("failed to create file %q: %w", tmpPath, err)
+	}
+
+	if err := copyAndClose(f, r); err != nil {
+		os.Remove(tmpPath)
+		return err
 	}
-	defer f.Close()
 
+	if err := os.Rename(tmpPath, filePath); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	return nil
+}
+
+func copyAndClose(f *os.File, r io.Reader) error {
 	w := bufio.NewWriterSize(f, 32*1024)
 	if _, err := io.Copy(w, r); err != nil {
+		f.Close()
+		return err
+	}
+
+	if err := w.Flush(); err != nil {
+		f.Close()
 		return err
 	}
-	return w.Flush()
+	return f.Close()
 }
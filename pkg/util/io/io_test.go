@@ -0,0 +1,52 @@
+package io
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCopyFile(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "f0.jpeg")
+
+	if err := CopyFile(filePath, strings.NewReader("data")); err != nil {
+		t.Fatalf("CopyFile() error = %v", err)
+	}
+
+	data, err := os.ReadFile(filePath)
+	if err != nil || string(data) != "data" {
+		t.Errorf("ReadFile() = %q, %v, want %q", data, err, "data")
+	}
+
+	if _, err := os.Stat(filePath + ".tmp"); !os.IsNotExist(err) {
+		t.Errorf("temporary file was not removed: %v", err)
+	}
+}
+
+func TestCopyFileError(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "f0.jpeg")
+
+	errRead := errors.New("read error")
+	r := io.MultiReader(strings.NewReader("partial"), &errReader{err: errRead})
+
+	if err := CopyFile(filePath, r); !errors.Is(err, errRead) {
+		t.Fatalf("CopyFile() error = %v, want %v", err, errRead)
+	}
+
+	for _, path := range []string{filePath, filePath + ".tmp"} {
+		if _, err := os.Stat(path); !os.IsNotExist(err) {
+			t.Errorf("file %s exists after a failed copy", filepath.Base(path))
+		}
+	}
+}
+
+type errReader struct {
+	err error
+}
+
+func (r *errReader) Read([]byte) (int, error) {
+	return 0, r.err
+}
@@ -20,6 +20,11 @@ func (c *simpleScanner) Description() string {
 	return "Simple test file format scanner"
 }
 
+// Category returns the category of the format
+func (c *simpleScanner) Category() format.Category {
+	return format.CategoryOther
+}
+
 // Signatures returns file signature byte slices to identify the file
 func (c *simpleScanner) Signatures() [][]byte {
 	return [][]byte{